@@ -0,0 +1,260 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// AddRecords adds records, given as for AddRecordByFid, in an
+// API_ImportFromCSV call for each distinct set of fields among them,
+// and each thousand records of a set, rather than a call per record,
+// returning the record IDs of the new records in order.  Records
+// which include the table's key field with the value of an existing
+// record update it, as imports do, and its record ID is returned in
+// their place; fields such a record lacks are left as they were.
+// Should one call fail, the records of those before it have already
+// been imported.
+func AddRecords(ticket Ticket, dbid string, records []map[int]string) (rids []int, err error) {
+	if len(records) == 0 {
+		return nil, nil
+	}
+	batches, err := recordsBatches(ticket, dbid, records, 0)
+	if err != nil {
+		return nil, err
+	}
+	result, err := importBatches(batches, len(records), apiCall.execute)
+	return result.Rids, err
+}
+
+// An UpsertResult reports the records UpsertRecords added and updated.
+type UpsertResult struct {
+	Added   int
+	Updated int
+	Rids    []int // the record IDs of the records, added or updated, in order
+}
+
+// UpsertRecords adds or updates records, given as for AddRecordByFid,
+// in an API_ImportFromCSV call for each distinct set of fields among
+// them, and each thousand records of a set, matching them to existing
+// records by their values of the field mergeFid, which must be unique
+// (or be Record ID#) and which each record must include.  Records
+// matching an existing record update the fields they include, leaving
+// the others as they were; others are added.  Sparse records, each
+// including a different subset of fields, thus take a call apiece;
+// giving them all the same fields, with the current values of those
+// they are not to change, lets them share calls.  Should one call
+// fail, the records of those before it have already been imported.
+func UpsertRecords(ticket Ticket, dbid string, mergeFid int, records []map[int]string) (result UpsertResult, err error) {
+	batches, err := upsertRecordsBatches(ticket, dbid, mergeFid, records)
+	if err != nil || len(records) == 0 {
+		return result, err
+	}
+	return importBatches(batches, len(records), apiCall.execute)
+}
+
+func upsertRecordsBatches(ticket Ticket, dbid string, mergeFid int, records []map[int]string) (batches []recordsBatch, err error) {
+	for i, record := range records {
+		if _, ok := record[mergeFid]; !ok {
+			return nil, fmt.Errorf("Record %d lacks merge field %d", i, mergeFid)
+		}
+	}
+	return recordsBatches(ticket, dbid, records, mergeFid)
+}
+
+// A recordsBatch is an API_ImportFromCSV call importing those records,
+// at the given indexes of a list, which have the same set of fields.
+// Were records with differing fields imported together, those lacking
+// a field would blank it in any existing record they update.
+type recordsBatch struct {
+	call    apiCall
+	indexes []int
+}
+
+// The most rows, and bytes of CSV, an API_ImportFromCSV call of
+// recordsBatches imports, keeping each request well within what
+// QuickBase accepts.  A single row longer than maxImportBytes is
+// imported alone.
+const (
+	maxImportRows  = 1000
+	maxImportBytes = 8 << 20
+)
+
+// recordsBatches returns the API_ImportFromCSV calls importing
+// records, merged by mergeFid if it is not zero, calls for each
+// distinct set of fields in the order they first appear.
+func recordsBatches(ticket Ticket, dbid string, records []map[int]string, mergeFid int) (batches []recordsBatch, err error) {
+	byFields := make(map[string]int)
+	var fidLists [][]int
+	var groups [][]int
+	for i, record := range records {
+		fids := make([]int, 0, len(record))
+		for fid := range record {
+			fids = append(fids, fid)
+		}
+		sort.Ints(fids)
+		key := fmt.Sprint(fids)
+		n, ok := byFields[key]
+		if !ok {
+			n = len(groups)
+			byFields[key] = n
+			groups = append(groups, nil)
+			fidLists = append(fidLists, fids)
+		}
+		groups[n] = append(groups[n], i)
+	}
+	for n, indexes := range groups {
+		calls, err := recordsCalls(ticket, dbid, fidLists[n], records, indexes, mergeFid)
+		if err != nil {
+			return nil, err
+		}
+		batches = append(batches, calls...)
+	}
+	return batches, nil
+}
+
+// importBatches executes batches importing n records, returning the
+// record IDs of the records in order and the totals added and updated.
+func importBatches(batches []recordsBatch, n int, execute func(apiCall, response) error) (result UpsertResult, err error) {
+	result.Rids = make([]int, n)
+	for _, batch := range batches {
+		var response importResponse
+		if err = execute(batch.call, &response); err != nil {
+			return UpsertResult{}, err
+		}
+		rids, err := response.recordIds(len(batch.indexes))
+		if err != nil {
+			return UpsertResult{}, err
+		}
+		for i, j := range batch.indexes {
+			result.Rids[j] = rids[i]
+		}
+		result.Added += response.Added
+		result.Updated += response.Updated
+	}
+	return result, nil
+}
+
+// recordsCalls returns the API_ImportFromCSV calls importing the
+// fields fids of the records at indexes, merged by mergeFid if it is
+// not zero, each of at most maxImportRows rows and maxImportBytes.
+func recordsCalls(ticket Ticket, dbid string, fids []int, records []map[int]string, indexes []int, mergeFid int) (batches []recordsBatch, err error) {
+	row := make([]string, len(fids))
+	for i, fid := range fids {
+		row[i] = strconv.Itoa(fid)
+	}
+	header, err := csvRow(row) // skipped, as skipfirst is set
+	if err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	var batch recordsBatch
+	flush := func() {
+		params := importFromCSVParams(ticket, fids, mergeFid)
+		params["records_csv"] = b.String()
+		batch.call = apiCall{ticket, ticket.url + "db/" + dbid, "API_ImportFromCSV", params}
+		batches = append(batches, batch)
+		batch = recordsBatch{}
+		b.Reset()
+	}
+	for _, j := range indexes {
+		for i, fid := range fids {
+			row[i] = records[j][fid]
+		}
+		line, err := csvRow(row)
+		if err != nil {
+			return nil, err
+		}
+		if len(batch.indexes) > 0 && (len(batch.indexes) == maxImportRows || b.Len()+len(line) > maxImportBytes) {
+			flush()
+		}
+		if b.Len() == 0 {
+			b.WriteString(header)
+		}
+		b.WriteString(line)
+		batch.indexes = append(batch.indexes, j)
+	}
+	if len(batch.indexes) > 0 {
+		flush()
+	}
+	return batches, nil
+}
+
+// csvRow returns row written as a line of CSV.
+func csvRow(row []string) (line string, err error) {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	w.Write(row)
+	w.Flush()
+	return b.String(), w.Error()
+}
+
+// recordIds returns the record IDs of the n rows imported.
+func (r *importResponse) recordIds(n int) (rids []int, err error) {
+	if len(r.Rids) != n {
+		return nil, fmt.Errorf("API_ImportFromCSV returned %d rids for %d records", len(r.Rids), n)
+	}
+	return r.Rids, nil
+}
+
+// AddRecords is as the package-level function.  A DryRun Client
+// returns no record IDs.
+func (c *Client) AddRecords(dbid string, records []map[int]string) (rids []int, err error) {
+	if len(records) == 0 {
+		return nil, nil
+	}
+	batches, err := recordsBatches(c.ticket(), dbid, records, 0)
+	if err != nil {
+		return nil, err
+	}
+	if c.DryRun {
+		return nil, c.dryRunBatches(batches)
+	}
+	result, err := importBatches(batches, len(records), c.mutate)
+	return result.Rids, err
+}
+
+// UpsertRecords is as the package-level function.  A DryRun Client
+// returns an empty result.
+func (c *Client) UpsertRecords(dbid string, mergeFid int, records []map[int]string) (result UpsertResult, err error) {
+	batches, err := upsertRecordsBatches(c.ticket(), dbid, mergeFid, records)
+	if err != nil || len(records) == 0 {
+		return result, err
+	}
+	if c.DryRun {
+		return result, c.dryRunBatches(batches)
+	}
+	return importBatches(batches, len(records), c.mutate)
+}
+
+// dryRunBatches logs the calls of batches rather than executing them.
+func (c *Client) dryRunBatches(batches []recordsBatch) (err error) {
+	for _, batch := range batches {
+		if err = c.dryRun(batch.call); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"github.com/WesTower/quickbase"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestAddRecords(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rids, err := client.AddRecords(testTableDbid, []map[int]string{
+		{6: "Mesa", 7: "4"},
+		{6: "Tempe, AZ"},
+		{7: "\"quoted\"\nvalue"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rids) != 3 || rids[0] >= rids[1] || rids[1] >= rids[2] {
+		t.Fatalf("got rids %v", rids)
+	}
+	records := server.Records(testTableDbid)
+	byRid := make(map[string]map[int]string)
+	for _, record := range records {
+		byRid[record[3]] = record
+	}
+	for i, want := range []map[int]string{{6: "Mesa", 7: "4"}, {6: "Tempe, AZ", 7: ""}, {6: "", 7: "\"quoted\"\nvalue"}} {
+		record := byRid[strconv.Itoa(rids[i])]
+		for fid, value := range want {
+			if record[fid] != value {
+				t.Errorf("record %d field %d is %q, want %q", rids[i], fid, record[fid], value)
+			}
+		}
+	}
+	if rids, err = client.AddRecords(testTableDbid, nil); rids != nil || err != nil {
+		t.Errorf("adding no records gave %v, %v", rids, err)
+	}
+
+	var logged []quickbase.DryRunRequest
+	client.DryRun = true
+	client.DryRunLog = func(req quickbase.DryRunRequest) { logged = append(logged, req) }
+	n := len(server.Records(testTableDbid))
+	if rids, err = client.AddRecords(testTableDbid, []map[int]string{{6: "Yuma"}}); rids != nil || err != nil {
+		t.Errorf("dry run gave %v, %v", rids, err)
+	}
+	if len(server.Records(testTableDbid)) != n || len(logged) != 1 || logged[0].Action != "API_ImportFromCSV" {
+		t.Errorf("dry run added records, or logged %+v", logged)
+	}
+}
+
+func TestUpsertRecords(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := client.UpsertRecords(testTableDbid, 6, []map[int]string{
+		{6: "Denver", 7: "20"},
+		{6: "Mesa", 7: "4"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Added != 1 || result.Updated != 1 || len(result.Rids) != 2 {
+		t.Errorf("got %+v", result)
+	}
+	costs := make(map[string]string)
+	for _, record := range server.Records(testTableDbid) {
+		costs[record[6]] = record[7]
+	}
+	if len(costs) != 3 || costs["Denver"] != "20" || costs["Mesa"] != "4" || costs["Boise"] != "3" {
+		t.Errorf("upsert left %v", costs)
+	}
+	err = client.ImportFromCSVWithOptions(testTableDbid, []int{6, 7}, strings.NewReader("Site,Cost\nBoise,5\n"), quickbase.ImportOptions{MergeFid: 6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if records := server.Records(testTableDbid); len(records) != 3 {
+		t.Errorf("import merged by site left %d records", len(records))
+	}
+	if _, err = client.UpsertRecords(testTableDbid, 6, []map[int]string{{7: "1"}}); err == nil {
+		t.Error("upsert of a record lacking the merge field succeeded")
+	}
+}
+
+func TestUpsertRecordsDifferingFields(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rids := make(map[string]string)
+	for _, record := range server.Records(testTableDbid) {
+		rids[record[6]] = record[3]
+	}
+	result, err := client.UpsertRecords(testTableDbid, 3, []map[int]string{
+		{3: rids["Denver"], 6: "Denver West"},
+		{3: rids["Boise"], 7: "9"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Added != 0 || result.Updated != 2 || len(result.Rids) != 2 || strconv.Itoa(result.Rids[1]) != rids["Boise"] {
+		t.Errorf("got %+v", result)
+	}
+	byRid := make(map[string]map[int]string)
+	for _, record := range server.Records(testTableDbid) {
+		byRid[record[3]] = record
+	}
+	if denver := byRid[rids["Denver"]]; denver[6] != "Denver West" || denver[7] != "12.50" {
+		t.Errorf("upsert of the site left %v", denver)
+	}
+	if boise := byRid[rids["Boise"]]; boise[6] != "Boise" || boise[7] != "9" {
+		t.Errorf("upsert of the cost left %v", boise)
+	}
+
+	var logged []quickbase.DryRunRequest
+	client.DryRun = true
+	client.DryRunLog = func(req quickbase.DryRunRequest) { logged = append(logged, req) }
+	_, err = client.UpsertRecords(testTableDbid, 6, []map[int]string{
+		{6: "Denver", 7: "1"},
+		{6: "Boise"},
+		{6: "Mesa", 7: "2"},
+		{6: "Yuma"},
+		{6: "Tempe", 7: "3"},
+	})
+	if err != nil || len(logged) != 2 {
+		t.Errorf("upsert of two sets of fields took %d calls, %v", len(logged), err)
+	}
+	logged = nil
+	_, err = client.UpsertRecords(testTableDbid, 3, []map[int]string{
+		{3: rids["Denver"], 6: "Denver"},
+		{3: rids["Boise"], 7: "1"},
+		{3: "99", 6: "Mesa", 7: "2"},
+	})
+	if err != nil || len(logged) != 3 {
+		t.Errorf("upsert of three sparse records took %d calls, %v", len(logged), err)
+	}
+}
+
+func TestAddRecordsLarge(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := make([]map[int]string, 2500)
+	for i := range records {
+		records[i] = map[int]string{6: "Site " + strconv.Itoa(i)}
+	}
+	rids, err := client.AddRecords(testTableDbid, records)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rids) != len(records) || len(server.Records(testTableDbid)) != 2+len(records) {
+		t.Fatalf("adding %d records gave %d rids", len(records), len(rids))
+	}
+	for i := 1; i < len(rids); i++ {
+		if rids[i] <= rids[i-1] {
+			t.Fatalf("rids out of order at %d: %v", i, rids[i-1:i+1])
+		}
+	}
+
+	var logged []quickbase.DryRunRequest
+	client.DryRun = true
+	client.DryRunLog = func(req quickbase.DryRunRequest) { logged = append(logged, req) }
+	if _, err = client.AddRecords(testTableDbid, records); err != nil || len(logged) != 3 {
+		t.Errorf("adding %d records took %d calls, %v", len(records), len(logged), err)
+	}
+	logged = nil
+	long := strings.Repeat("x", 5<<20)
+	if _, err = client.AddRecords(testTableDbid, []map[int]string{{6: long}, {6: long}, {6: "Mesa"}}); err != nil || len(logged) != 2 {
+		t.Errorf("adding two long records took %d calls, %v", len(logged), err)
+	}
+}
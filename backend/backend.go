@@ -0,0 +1,245 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+// Package backend provides a single high-level interface to
+// QuickBase with interchangeable implementations over the legacy XML
+// API (package quickbase) and the JSON RESTful API (package restapi),
+// so that application code can move between them by configuration.
+//
+// Records are exchanged as maps from field IDs to text, in a format
+// which is the same for both backends: checkboxes are "1" or "0",
+// dates are YYYY-MM-DD and date/times are RFC 3339 in UTC.  Other
+// values are passed through as each API represents them.
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"github.com/WesTower/quickbase/restapi"
+	"sync"
+	"time"
+)
+
+// A Record maps field IDs to values.
+type Record map[int]string
+
+// A Query selects records from a table.
+type Query struct {
+	Where  string // in QuickBase's query language, e.g. "{'7'.EX.'foo'}"
+	Select []int  // field IDs to return; if empty, the table's defaults
+	SortBy []int  // field IDs to sort by, ascending
+	Top    int    // maximum number of records to return; 0 for all
+}
+
+// Field describes a single field of a table.  Type is as reported by
+// the underlying API.
+type Field struct {
+	Id    int
+	Label string
+	Type  string
+}
+
+// Schema describes a table.
+type Schema struct {
+	Fields []Field
+}
+
+// Field returns the field with the given ID, and whether there is
+// one.
+func (s Schema) Field(fid int) (field Field, ok bool) {
+	for _, field := range s.Fields {
+		if field.Id == fid {
+			return field, true
+		}
+	}
+	return field, false
+}
+
+// Backend is the interface implemented by XML, REST and Fallback.
+type Backend interface {
+	Query(tableId string, query Query) (records []Record, err error)
+	Insert(tableId string, record Record) (rid int, err error)
+	Update(tableId string, rid int, record Record) (err error)
+	Delete(tableId string, rid int) (err error)
+	Schema(tableId string) (schema Schema, err error)
+}
+
+// ErrUnsupported is returned by a Backend for an operation which its
+// API cannot perform; Fallback then tries its secondary Backend.
+var ErrUnsupported = errors.New("Operation not supported by this backend")
+
+// Config selects and configures a Backend.  Kind and FallbackKind are
+// 'xml' or 'rest'; FallbackKind may be empty.  Ticket is used by the
+// XML backend, and RestClient by the REST backend.
+type Config struct {
+	Kind         string
+	FallbackKind string
+	Ticket       quickbase.Ticket
+	RestClient   *restapi.Client
+}
+
+// New returns the Backend described by config.
+func New(config Config) (b Backend, err error) {
+	if b, err = newKind(config.Kind, config); err != nil {
+		return nil, err
+	}
+	if config.FallbackKind == "" {
+		return b, nil
+	}
+	secondary, err := newKind(config.FallbackKind, config)
+	if err != nil {
+		return nil, err
+	}
+	return &Fallback{Primary: b, Secondary: secondary}, nil
+}
+
+func newKind(kind string, config Config) (b Backend, err error) {
+	switch kind {
+	case "xml":
+		return NewXML(config.Ticket), nil
+	case "rest":
+		if config.RestClient == nil {
+			return nil, fmt.Errorf("REST backend requires a RestClient")
+		}
+		return NewREST(config.RestClient), nil
+	}
+	return nil, fmt.Errorf("Unknown backend kind %q", kind)
+}
+
+// Fallback uses Primary for every operation, retrying with Secondary
+// those which Primary reports as ErrUnsupported.
+type Fallback struct {
+	Primary   Backend
+	Secondary Backend
+}
+
+func (f *Fallback) Query(tableId string, query Query) (records []Record, err error) {
+	if records, err = f.Primary.Query(tableId, query); err == ErrUnsupported {
+		return f.Secondary.Query(tableId, query)
+	}
+	return records, err
+}
+
+func (f *Fallback) Insert(tableId string, record Record) (rid int, err error) {
+	if rid, err = f.Primary.Insert(tableId, record); err == ErrUnsupported {
+		return f.Secondary.Insert(tableId, record)
+	}
+	return rid, err
+}
+
+func (f *Fallback) Update(tableId string, rid int, record Record) (err error) {
+	if err = f.Primary.Update(tableId, rid, record); err == ErrUnsupported {
+		return f.Secondary.Update(tableId, rid, record)
+	}
+	return err
+}
+
+func (f *Fallback) Delete(tableId string, rid int) (err error) {
+	if err = f.Primary.Delete(tableId, rid); err == ErrUnsupported {
+		return f.Secondary.Delete(tableId, rid)
+	}
+	return err
+}
+
+func (f *Fallback) Schema(tableId string) (schema Schema, err error) {
+	if schema, err = f.Primary.Schema(tableId); err == ErrUnsupported {
+		return f.Secondary.Schema(tableId)
+	}
+	return schema, err
+}
+
+// kind classifies field types whose representation differs between
+// the two APIs.
+type kind int
+
+const (
+	kindOther kind = iota
+	kindNumber
+	kindCheckbox
+	kindDate
+	kindTimestamp
+)
+
+func kindOf(fieldType string) kind {
+	switch fieldType {
+	case "float", "numeric", "currency", "percent", "rating", "duration", "recordid":
+		return kindNumber
+	case "checkbox":
+		return kindCheckbox
+	case "date":
+		return kindDate
+	case "timestamp":
+		return kindTimestamp
+	}
+	return kindOther
+}
+
+const dateLayout = "2006-01-02"
+
+// schemaCache remembers table schemas, which are needed to convert
+// values to and from the common format.
+type schemaCache struct {
+	mutex   sync.Mutex
+	schemas map[string]Schema
+}
+
+func (c *schemaCache) get(tableId string, load func(string) (Schema, error)) (schema Schema, err error) {
+	c.mutex.Lock()
+	schema, ok := c.schemas[tableId]
+	c.mutex.Unlock()
+	if ok {
+		return schema, nil
+	}
+	if schema, err = load(tableId); err != nil {
+		return schema, err
+	}
+	c.mutex.Lock()
+	if c.schemas == nil {
+		c.schemas = make(map[string]Schema)
+	}
+	c.schemas[tableId] = schema
+	c.mutex.Unlock()
+	return schema, nil
+}
+
+func (c *schemaCache) kinds(tableId string, load func(string) (Schema, error)) (kinds map[int]kind, err error) {
+	schema, err := c.get(tableId, load)
+	if err != nil {
+		return nil, err
+	}
+	kinds = make(map[int]kind, len(schema.Fields))
+	for _, field := range schema.Fields {
+		kinds[field.Id] = kindOf(field.Type)
+	}
+	return kinds, nil
+}
+
+func msecsToTime(value string) (t time.Time, err error) {
+	var msecs int64
+	if _, err = fmt.Sscan(value, &msecs); err != nil {
+		return t, err
+	}
+	return time.Unix(msecs/1000, (msecs%1000)*int64(time.Millisecond)).UTC(), nil
+}
+
+func timeToMsecs(t time.Time) string {
+	return fmt.Sprint(t.UnixNano() / int64(time.Millisecond))
+}
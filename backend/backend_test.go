@@ -0,0 +1,214 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package backend_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"github.com/WesTower/quickbase/backend"
+	"github.com/WesTower/quickbase/restapi"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Both servers hold the same single record: Site (text) 'Denver', Done
+// (checkbox) true, Due (date) 2015-04-03 and Closed (timestamp)
+// 2015-04-03 14:05 UTC.
+
+func xmlServer(t *testing.T, added *string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		fmt.Fprint(w, "<qdbapi><errcode>0</errcode><errtext>No error</errtext>")
+		switch r.Header.Get("QUICKBASE-ACTION") {
+		case "API_Authenticate":
+			fmt.Fprint(w, "<ticket>t</ticket><userid>u</userid>")
+		case "API_GetSchema":
+			fmt.Fprint(w, `<table><name>Sites</name><fields>
+				<field id="3" field_type="recordid"><label>Record ID#</label></field>
+				<field id="6" field_type="text"><label>Site</label></field>
+				<field id="7" field_type="checkbox"><label>Done</label></field>
+				<field id="8" field_type="date"><label>Due</label></field>
+				<field id="9" field_type="timestamp"><label>Closed</label></field></fields></table>`)
+		case "API_DoQuery":
+			fmt.Fprint(w, `<table><records><record><f id="3">1</f><f id="6">Denver</f><f id="7">1</f><f id="8">1428019200000</f><f id="9">1428069900000</f></record></records></table>`)
+		case "API_AddRecord":
+			*added = string(body)
+			fmt.Fprint(w, "<rid>2</rid>")
+		default:
+			t.Errorf("unexpected action %s", r.Header.Get("QUICKBASE-ACTION"))
+		}
+		fmt.Fprint(w, "</qdbapi>")
+	}))
+}
+
+func restServer(t *testing.T, added *string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		switch r.URL.Path {
+		case "/fields":
+			fmt.Fprint(w, `[{"id": 3, "label": "Record ID#", "fieldType": "recordid"},
+				{"id": 6, "label": "Site", "fieldType": "text"},
+				{"id": 7, "label": "Done", "fieldType": "checkbox"},
+				{"id": 8, "label": "Due", "fieldType": "date"},
+				{"id": 9, "label": "Closed", "fieldType": "timestamp"}]`)
+		case "/records/query":
+			fmt.Fprint(w, `{"data": [{"3": {"value": 1}, "6": {"value": "Denver"}, "7": {"value": true}, "8": {"value": "2015-04-03"}, "9": {"value": "2015-04-03T14:05:00.000Z"}}],
+				"metadata": {"numRecords": 1, "totalRecords": 1}}`)
+		case "/records":
+			*added = string(body)
+			fmt.Fprint(w, `{"data": [], "metadata": {"createdRecordIds": [2]}}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+}
+
+func TestBackendsAgree(t *testing.T) {
+	var xmlAdded, restAdded string
+	xmlSrv := xmlServer(t, &xmlAdded)
+	defer xmlSrv.Close()
+	restSrv := restServer(t, &restAdded)
+	defer restSrv.Close()
+
+	ticket, err := quickbase.Authenticate(xmlSrv.URL+"/", "user", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	restClient := restapi.NewClient("example.quickbase.com", "token")
+	restClient.BaseUrl = restSrv.URL
+	xmlBackend, err := backend.New(backend.Config{Kind: "xml", Ticket: ticket})
+	if err != nil {
+		t.Fatal(err)
+	}
+	restBackend, err := backend.New(backend.Config{Kind: "rest", RestClient: restClient})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []backend.Record{{3: "1", 6: "Denver", 7: "1", 8: "2015-04-03", 9: "2015-04-03T14:05:00Z"}}
+	for name, b := range map[string]backend.Backend{"xml": xmlBackend, "rest": restBackend} {
+		records, err := b.Query("bck7gp3q2", backend.Query{})
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		if !reflect.DeepEqual(records, expected) {
+			t.Errorf("%s: got %v", name, records)
+		}
+		rid, err := b.Insert("bck7gp3q2", backend.Record{6: "Boise", 7: "0", 8: "2015-04-03", 9: "2015-04-03T08:05:00-06:00"})
+		if err != nil || rid != 2 {
+			t.Errorf("%s: inserted %d (%v)", name, rid, err)
+		}
+	}
+	if !strings.Contains(xmlAdded, "<_fid_8>1428019200000</_fid_8>") || !strings.Contains(xmlAdded, "<_fid_9>1428069900000</_fid_9>") || !strings.Contains(xmlAdded, "<_fid_6>Boise</_fid_6>") {
+		t.Errorf("xml added %s", xmlAdded)
+	}
+	var upsert restapi.UpsertRequest
+	if err := json.Unmarshal([]byte(restAdded), &upsert); err != nil {
+		t.Fatal(err)
+	}
+	if done, _ := upsert.Data[0][7].Bool(); done || upsert.Data[0][8].String() != "2015-04-03" || upsert.Data[0][9].String() != "2015-04-03T14:05:00Z" {
+		t.Errorf("rest added %s", restAdded)
+	}
+}
+
+type unsupported struct{ backend.Backend }
+
+func (unsupported) Schema(tableId string) (backend.Schema, error) {
+	return backend.Schema{}, backend.ErrUnsupported
+}
+
+type fixedSchema struct{ backend.Backend }
+
+func (fixedSchema) Schema(tableId string) (backend.Schema, error) {
+	return backend.Schema{Fields: []backend.Field{{Id: 6, Label: "Site", Type: "text"}}}, nil
+}
+
+func TestFallback(t *testing.T) {
+	f := &backend.Fallback{Primary: unsupported{}, Secondary: fixedSchema{}}
+	schema, err := f.Schema("bck7gp3q2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if field, ok := schema.Field(6); !ok || field.Label != "Site" {
+		t.Errorf("unexpected schema %+v", schema)
+	}
+}
+
+func TestFallbackToXML(t *testing.T) {
+	var added string
+	xmlSrv := xmlServer(t, &added)
+	defer xmlSrv.Close()
+	restSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message": "Not Implemented"}`, http.StatusNotImplemented)
+	}))
+	defer restSrv.Close()
+
+	ticket, err := quickbase.Authenticate(xmlSrv.URL+"/", "user", "password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	restClient := restapi.NewClient("example.quickbase.com", "token")
+	restClient.BaseUrl = restSrv.URL
+	b, err := backend.New(backend.Config{Kind: "rest", FallbackKind: "xml", Ticket: ticket, RestClient: restClient})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = backend.NewREST(restClient).Schema("bck7gp3q2"); err != backend.ErrUnsupported {
+		t.Errorf("REST Schema gave %v", err)
+	}
+	records, err := b.Query("bck7gp3q2", backend.Query{})
+	if err != nil || len(records) != 1 || records[0][6] != "Denver" {
+		t.Errorf("Query gave %v, %v", records, err)
+	}
+	if rid, err := b.Insert("bck7gp3q2", backend.Record{6: "Boise"}); err != nil || rid != 2 {
+		t.Errorf("Insert gave %d, %v", rid, err)
+	}
+}
+
+func TestRESTUpdateMissingRecord(t *testing.T) {
+	var upserted bool
+	restSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/records/query":
+			fmt.Fprint(w, `{"data": [], "metadata": {"numRecords": 0, "totalRecords": 0}}`)
+		case "/records":
+			upserted = true
+			fmt.Fprint(w, `{"data": [], "metadata": {"createdRecordIds": [5]}}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	defer restSrv.Close()
+	restClient := restapi.NewClient("example.quickbase.com", "token")
+	restClient.BaseUrl = restSrv.URL
+	err := backend.NewREST(restClient).Update("bck7gp3q2", 5, backend.Record{6: "Boise"})
+	if qbErr, ok := err.(quickbase.QuickBaseError); !ok || qbErr.Code != 30 {
+		t.Errorf("updating a missing record gave %v", err)
+	}
+	if upserted {
+		t.Error("updating a missing record created it")
+	}
+}
@@ -0,0 +1,219 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"github.com/WesTower/quickbase/restapi"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// REST is a Backend using the JSON RESTful API.  It reports as
+// ErrUnsupported the calls a realm's API refuses as not implemented or
+// not supported, and those to endpoints it does not have, so that a
+// Fallback may make them with the XML API.
+type REST struct {
+	Client *restapi.Client
+	cache  schemaCache
+}
+
+// NewREST returns a REST Backend using client.
+func NewREST(client *restapi.Client) *REST {
+	return &REST{Client: client}
+}
+
+func (r *REST) Query(tableId string, query Query) (records []Record, err error) {
+	kinds, err := r.cache.kinds(tableId, r.Schema)
+	if err != nil {
+		return nil, err
+	}
+	restQuery := restapi.Query{From: tableId, Select: query.Select, Where: query.Where}
+	for _, fid := range query.SortBy {
+		restQuery.SortBy = append(restQuery.SortBy, restapi.SortField{FieldId: fid, Order: "ASC"})
+	}
+	if query.Top > 0 {
+		restQuery.Options = &restapi.QueryOptions{Top: query.Top}
+	}
+	result, err := r.Client.QueryRecords(restQuery)
+	if err != nil {
+		return nil, unsupported(err)
+	}
+	for _, data := range result.Data {
+		record := make(Record, len(data))
+		for fid, value := range data {
+			if record[fid], err = fromRest(kinds[fid], value); err != nil {
+				return nil, fmt.Errorf("Field %d: %s", fid, err)
+			}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func (r *REST) Insert(tableId string, record Record) (rid int, err error) {
+	result, err := r.upsert(tableId, record)
+	if err != nil {
+		return 0, err
+	}
+	if len(result.Metadata.CreatedRecordIds) != 1 {
+		return 0, fmt.Errorf("Expected one created record, got %d", len(result.Metadata.CreatedRecordIds))
+	}
+	return result.Metadata.CreatedRecordIds[0], nil
+}
+
+// Update fails, as the XML API does, if there is no record rid.  The
+// RESTful API's upsert would instead create it, so the record is
+// looked for first.
+func (r *REST) Update(tableId string, rid int, record Record) (err error) {
+	found, err := r.Client.QueryRecords(restapi.Query{From: tableId, Select: []int{3}, Where: fmt.Sprintf("{'3'.EX.'%d'}", rid)})
+	if err != nil {
+		return unsupported(err)
+	}
+	if len(found.Data) == 0 {
+		return quickbase.QuickBaseError{Message: "No such record", Code: 30}
+	}
+	withRid := make(Record, len(record)+1)
+	for fid, value := range record {
+		withRid[fid] = value
+	}
+	withRid[3] = strconv.Itoa(rid)
+	result, err := r.upsert(tableId, withRid)
+	if err != nil {
+		return err
+	}
+	if len(result.Metadata.CreatedRecordIds) > 0 {
+		return fmt.Errorf("Record %d did not exist, and was created as %d", rid, result.Metadata.CreatedRecordIds[0])
+	}
+	return nil
+}
+
+func (r *REST) Delete(tableId string, rid int) (err error) {
+	deleted, err := r.Client.DeleteRecords(tableId, fmt.Sprintf("{'3'.EX.'%d'}", rid))
+	if err != nil {
+		return unsupported(err)
+	}
+	if deleted != 1 {
+		err = fmt.Errorf("Expected to delete one record, deleted %d", deleted)
+	}
+	return err
+}
+
+func (r *REST) Schema(tableId string) (schema Schema, err error) {
+	fields, err := r.Client.GetFields(tableId)
+	if err != nil {
+		return schema, unsupported(err)
+	}
+	for _, field := range fields {
+		schema.Fields = append(schema.Fields, Field{Id: field.Id, Label: field.Label, Type: field.FieldType})
+	}
+	return schema, nil
+}
+
+func (r *REST) upsert(tableId string, record Record) (result restapi.UpsertResult, err error) {
+	kinds, err := r.cache.kinds(tableId, r.Schema)
+	if err != nil {
+		return result, err
+	}
+	data := make(restapi.Record, len(record))
+	for fid, value := range record {
+		if data[fid], err = toRest(kinds[fid], value); err != nil {
+			return result, fmt.Errorf("Field %d: %s", fid, err)
+		}
+	}
+	result, err = r.Client.UpsertRecords(restapi.UpsertRequest{To: tableId, Data: []restapi.Record{data}, MergeFieldId: 3})
+	return result, unsupported(err)
+}
+
+// unsupported returns ErrUnsupported for an error of the RESTful API
+// meaning that the call cannot be made at all: a status of 405 or
+// 501, a 404 without a message of its own, which comes of a missing
+// endpoint rather than a missing table or record, or a message saying
+// that the call is not supported.  Other errors are returned as they
+// are.
+func unsupported(err error) error {
+	qbErr, ok := err.(quickbase.QuickBaseError)
+	if !ok {
+		return err
+	}
+	switch {
+	case qbErr.HttpStatus == http.StatusMethodNotAllowed, qbErr.HttpStatus == http.StatusNotImplemented,
+		qbErr.HttpStatus == http.StatusNotFound && qbErr.Message == http.StatusText(http.StatusNotFound),
+		strings.Contains(strings.ToLower(qbErr.Message), "not supported"):
+		return ErrUnsupported
+	}
+	return err
+}
+
+func toRest(k kind, value string) (restapi.Value, error) {
+	switch {
+	case value == "":
+		return restapi.NewValue(nil)
+	case k == kindNumber:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return restapi.Value{}, err
+		}
+		return restapi.NewValue(f)
+	case k == kindCheckbox:
+		return restapi.NewValue(value == "1")
+	case k == kindDate:
+		if _, err := time.Parse(dateLayout, value); err != nil {
+			return restapi.Value{}, err
+		}
+	case k == kindTimestamp:
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return restapi.Value{}, err
+		}
+		value = t.UTC().Format(time.RFC3339)
+	}
+	return restapi.NewValue(value)
+}
+
+// fromRest converts a value of the RESTful API to the common format,
+// in which date/times, given by the API with milliseconds and
+// sometimes an offset, are RFC 3339 in UTC to the second, as the XML
+// backend gives them.
+func fromRest(k kind, value restapi.Value) (string, error) {
+	switch k {
+	case kindCheckbox:
+		if b, err := value.Bool(); err == nil {
+			if b {
+				return "1", nil
+			}
+			return "0", nil
+		}
+	case kindDate, kindTimestamp:
+		t, err := value.Time()
+		if err != nil || t.IsZero() {
+			return "", err
+		}
+		if k == kindDate {
+			return t.Format(dateLayout), nil
+		}
+		return t.UTC().Format(time.RFC3339), nil
+	}
+	return value.String(), nil
+}
@@ -0,0 +1,150 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package backend
+
+import (
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"strings"
+	"time"
+)
+
+// XML is a Backend using the legacy XML API.
+type XML struct {
+	Ticket quickbase.Ticket
+	cache  schemaCache
+}
+
+// NewXML returns an XML Backend authenticated by ticket.
+func NewXML(ticket quickbase.Ticket) *XML {
+	return &XML{Ticket: ticket}
+}
+
+func (x *XML) Query(tableId string, query Query) (records []Record, err error) {
+	kinds, err := x.cache.kinds(tableId, x.Schema)
+	if err != nil {
+		return nil, err
+	}
+	var options []string
+	if query.Top > 0 {
+		options = append(options, fmt.Sprintf("num-%d", query.Top))
+	}
+	results, err := quickbase.DoStructuredQuery(x.Ticket, tableId, query.Where, joinFids(query.Select), joinFids(query.SortBy), strings.Join(options, "."))
+	if err != nil {
+		return nil, err
+	}
+	for _, result := range results {
+		record := make(Record, len(result))
+		for fid, value := range result {
+			if record[fid], err = fromXml(kinds[fid], value); err != nil {
+				return nil, fmt.Errorf("Field %d: %s", fid, err)
+			}
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func (x *XML) Insert(tableId string, record Record) (rid int, err error) {
+	fields, err := x.toXml(tableId, record)
+	if err != nil {
+		return 0, err
+	}
+	return quickbase.AddRecordByFid(x.Ticket, tableId, fields)
+}
+
+func (x *XML) Update(tableId string, rid int, record Record) (err error) {
+	fields, err := x.toXml(tableId, record)
+	if err != nil {
+		return err
+	}
+	return quickbase.EditRecordByFid(x.Ticket, tableId, rid, fields)
+}
+
+func (x *XML) Delete(tableId string, rid int) (err error) {
+	return quickbase.DeleteRecord(x.Ticket, tableId, rid)
+}
+
+func (x *XML) Schema(tableId string) (schema Schema, err error) {
+	xmlSchema, err := quickbase.GetSchema(x.Ticket, tableId)
+	if err != nil {
+		return schema, err
+	}
+	for _, field := range xmlSchema.Fields {
+		schema.Fields = append(schema.Fields, Field{Id: field.Id, Label: field.Label, Type: field.Type})
+	}
+	return schema, nil
+}
+
+func (x *XML) toXml(tableId string, record Record) (fields map[int]string, err error) {
+	kinds, err := x.cache.kinds(tableId, x.Schema)
+	if err != nil {
+		return nil, err
+	}
+	fields = make(map[int]string, len(record))
+	for fid, value := range record {
+		switch kinds[fid] {
+		case kindDate, kindTimestamp:
+			if value == "" {
+				break
+			}
+			layout := time.RFC3339
+			if kinds[fid] == kindDate {
+				layout = dateLayout
+			}
+			t, err := time.Parse(layout, value)
+			if err != nil {
+				return nil, fmt.Errorf("Field %d: %s", fid, err)
+			}
+			value = timeToMsecs(t)
+		}
+		fields[fid] = value
+	}
+	return fields, nil
+}
+
+// fromXml converts a value from API_DoQuery's structured format,
+// which represents dates as milliseconds since the epoch.
+func fromXml(k kind, value string) (string, error) {
+	if value == "" {
+		return value, nil
+	}
+	switch k {
+	case kindDate, kindTimestamp:
+		t, err := msecsToTime(value)
+		if err != nil {
+			return "", err
+		}
+		if k == kindDate {
+			return t.Format(dateLayout), nil
+		}
+		return t.Format(time.RFC3339), nil
+	}
+	return value, nil
+}
+
+func joinFids(fids []int) string {
+	strs := make([]string, len(fids))
+	for i, fid := range fids {
+		strs[i] = fmt.Sprint(fid)
+	}
+	return strings.Join(strs, ".")
+}
@@ -0,0 +1,322 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+// Package bulk runs large jobs against QuickBase, such as
+// multi-million-record migrations, in batches whose completion is
+// checkpointed to a file.  A batch which fails, or whose worker
+// panics, is retried, and failing it for good does not stop the
+// others; a job killed part-way is resumed by running it again with
+// the same checkpoint file, skipping the batches already done.  A
+// batch failing with quickbase.ErrClosed, its Client having been shut
+// down, is not retried, and no more batches are started.
+//
+// Batches are carried out at least once: one under way when a job
+// dies is repeated when it is resumed.  Imports should therefore merge
+// on a key field (see quickbase.ImportFromCSV), so that a repeated
+// batch updates the records it added rather than duplicating them.
+//
+// Bulk, by contrast, makes a stream of single-record changes with a
+// pool of workers, reporting their results in order.
+package bulk
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Runner runs the batches of a job, recording each which completes
+// in its Checkpoint file.
+type Runner struct {
+	Checkpoint string        // path of the checkpoint file; if empty, progress is not saved
+	Workers    int           // batches run at once; if zero, 1
+	Retries    int           // further attempts at a failing batch; if negative, none
+	RetryDelay time.Duration // wait before the first retry, doubled for each after it
+
+	mutex sync.Mutex
+	file  *os.File
+	done  map[int]bool
+}
+
+// DefaultRetries is used when a Runner's Retries is zero.
+const DefaultRetries = 3
+
+// A Report describes a run of a job.
+type Report struct {
+	Completed int           // batches completed by this run
+	Skipped   int           // batches completed by an earlier run
+	Failed    map[int]error // batches which failed every attempt, with their last errors
+}
+
+// Run runs a job of n batches, numbered from 0, calling work for each
+// batch not completed by an earlier run.  It returns an error if any
+// batch failed, once the others have been run, or if the checkpoint
+// file cannot be written, at once.
+func (r *Runner) Run(n int, work func(batch int) error) (report Report, err error) {
+	return r.run(func(jobs chan<- job, quit <-chan struct{}) error {
+		for batch := 0; batch < n; batch++ {
+			batch := batch
+			if !r.send(jobs, quit, job{batch, func() error { return work(batch) }}) {
+				return nil
+			}
+		}
+		return nil
+	})
+}
+
+// ImportCSV imports rows read from a CSV reader, which must yield them
+// in the same order on every run, into the table dbid with an
+// API_ImportFromCSV call for each batch of batchSize rows.  The ith
+// column is imported into the field fids[i].
+func (r *Runner) ImportCSV(qb quickbase.QuickBase, dbid string, fids []int, rows *csv.Reader, batchSize int) (report Report, err error) {
+	if batchSize < 1 {
+		return report, fmt.Errorf("Batch size must be positive")
+	}
+	return r.run(func(jobs chan<- job, quit <-chan struct{}) error {
+		for batch := 0; ; batch++ {
+			var chunk [][]string
+			for len(chunk) < batchSize {
+				row, err := rows.Read()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					return fmt.Errorf("Batch %d: %s", batch, err)
+				}
+				chunk = append(chunk, row)
+			}
+			if len(chunk) == 0 {
+				return nil
+			}
+			if !r.send(jobs, quit, job{batch, func() error { return importBatch(qb, dbid, fids, chunk) }}) {
+				return nil
+			}
+			if len(chunk) < batchSize {
+				return nil
+			}
+		}
+	})
+}
+
+func importBatch(qb quickbase.QuickBase, dbid string, fids []int, rows [][]string) error {
+	var buf strings.Builder
+	w := csv.NewWriter(&buf)
+	header := make([]string, len(fids))
+	for i, fid := range fids {
+		header[i] = strconv.Itoa(fid)
+	}
+	w.Write(header) // skipped by ImportFromCSV
+	w.WriteAll(rows)
+	return qb.ImportFromCSV(dbid, fids, strings.NewReader(buf.String()))
+}
+
+// A job is a batch ready to be run.
+type job struct {
+	batch int
+	do    func() error
+}
+
+// send queues a job unless its batch is already done, returning false
+// if the run has been stopped.
+func (r *Runner) send(jobs chan<- job, quit <-chan struct{}, j job) bool {
+	r.mutex.Lock()
+	done := r.done[j.batch]
+	r.mutex.Unlock()
+	if done {
+		j.do = nil
+	}
+	select {
+	case jobs <- j:
+		return true
+	case <-quit:
+		return false
+	}
+}
+
+// run loads the checkpoint, runs the jobs produce sends with the
+// Runner's workers and reports on them.
+func (r *Runner) run(produce func(jobs chan<- job, quit <-chan struct{}) error) (report Report, err error) {
+	if err = r.open(); err != nil {
+		return report, err
+	}
+	defer r.close()
+	report.Failed = make(map[int]error)
+	workers := r.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	jobs := make(chan job)
+	quit := make(chan struct{})
+	var stopping sync.Once
+	stop := func() { stopping.Do(func() { close(quit) }) }
+	var mutex sync.Mutex
+	var fatal error
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				if j.do == nil {
+					mutex.Lock()
+					report.Skipped++
+					mutex.Unlock()
+					continue
+				}
+				err := r.attempt(j)
+				if err == nil {
+					err = r.markDone(j.batch)
+					mutex.Lock()
+					if err != nil && fatal == nil {
+						fatal = err
+						stop()
+					} else if err == nil {
+						report.Completed++
+					}
+					mutex.Unlock()
+					continue
+				}
+				mutex.Lock()
+				report.Failed[j.batch] = err
+				mutex.Unlock()
+				if err == quickbase.ErrClosed {
+					stop()
+				}
+			}
+		}()
+	}
+	produceErr := produce(jobs, quit)
+	close(jobs)
+	wg.Wait()
+	switch {
+	case fatal != nil:
+		return report, fmt.Errorf("Cannot write checkpoint: %s", fatal)
+	case produceErr != nil:
+		return report, produceErr
+	case len(report.Failed) > 0:
+		var batches []int
+		for batch := range report.Failed {
+			batches = append(batches, batch)
+		}
+		sort.Ints(batches)
+		return report, fmt.Errorf("%d batches failed, the first (%d) with: %s", len(batches), batches[0], report.Failed[batches[0]])
+	}
+	return report, nil
+}
+
+// attempt runs a job, retrying it as need be.
+func (r *Runner) attempt(j job) (err error) {
+	retries := r.Retries
+	if retries == 0 {
+		retries = DefaultRetries
+	}
+	delay := r.RetryDelay
+	for attempt := 0; ; attempt++ {
+		if err = protect(j); err == nil || err == quickbase.ErrClosed || attempt >= retries {
+			return err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// protect runs a job, turning a panic into an error.
+func protect(j job) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("Batch %d panicked: %v", j.batch, p)
+		}
+	}()
+	return j.do()
+}
+
+// open reads the checkpoint file, if any, and opens it for appending.
+// A final line cut short by a crash is discarded.
+func (r *Runner) open() (err error) {
+	r.done = make(map[int]bool)
+	if r.Checkpoint == "" {
+		return nil
+	}
+	size, err := r.load()
+	switch {
+	case err == nil:
+		if err = os.Truncate(r.Checkpoint, size); err != nil {
+			return err
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+	r.file, err = os.OpenFile(r.Checkpoint, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	return err
+}
+
+// load reads the batch numbers in the checkpoint file, one per line,
+// returning the size of the complete lines.
+func (r *Runner) load() (size int64, err error) {
+	file, err := os.Open(r.Checkpoint)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	for n := 1; ; n++ {
+		line, err := reader.ReadString('\n')
+		if strings.HasSuffix(line, "\n") {
+			batch, err := strconv.Atoi(strings.TrimSpace(line))
+			if err != nil {
+				return 0, fmt.Errorf("Bad checkpoint on line %d of %s", n, r.Checkpoint)
+			}
+			r.done[batch] = true
+			size += int64(len(line))
+		}
+		if err != nil {
+			return size, nil
+		}
+	}
+}
+
+// markDone records a batch as complete.
+func (r *Runner) markDone(batch int) (err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.done[batch] = true
+	if r.file == nil {
+		return nil
+	}
+	if _, err = fmt.Fprintf(r.file, "%d\n", batch); err != nil {
+		return err
+	}
+	return r.file.Sync()
+}
+
+func (r *Runner) close() {
+	if r.file != nil {
+		r.file.Close()
+		r.file = nil
+	}
+}
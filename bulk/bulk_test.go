@@ -0,0 +1,177 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package bulk_test
+
+import (
+	"encoding/csv"
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"github.com/WesTower/quickbase/bulk"
+	"github.com/WesTower/quickbase/quickbasetest"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func checkpoint(t *testing.T) (path string, cleanup func()) {
+	dir, err := ioutil.TempDir("", "bulk")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(dir, "checkpoint"), func() { os.RemoveAll(dir) }
+}
+
+func TestRunResumes(t *testing.T) {
+	path, cleanup := checkpoint(t)
+	defer cleanup()
+	var mutex sync.Mutex
+	runs := make(map[int]int)
+	work := func(batch int) error {
+		mutex.Lock()
+		runs[batch]++
+		n := runs[batch]
+		mutex.Unlock()
+		switch {
+		case batch == 5 && n == 1:
+			panic("worker crashed")
+		case batch == 7:
+			return fmt.Errorf("batch 7 is bad")
+		}
+		return nil
+	}
+	runner := &bulk.Runner{Checkpoint: path, Workers: 3, Retries: 2}
+	report, err := runner.Run(20, work)
+	if err == nil || report.Completed != 19 || len(report.Failed) != 1 || report.Failed[7] == nil {
+		t.Fatalf("first run gave %+v, %v", report, err)
+	}
+	if runs[5] != 2 || runs[7] != 3 {
+		t.Errorf("batch 5 was run %d times and batch 7 %d times", runs[5], runs[7])
+	}
+
+	// a new process resumes the job
+	runs = make(map[int]int)
+	runner = &bulk.Runner{Checkpoint: path, Workers: 2}
+	report, err = runner.Run(20, func(batch int) error {
+		runs[batch]++
+		return nil
+	})
+	if err != nil || report.Completed != 1 || report.Skipped != 19 {
+		t.Errorf("resumed run gave %+v, %v", report, err)
+	}
+	if len(runs) != 1 || runs[7] != 1 {
+		t.Errorf("resumed run ran %v", runs)
+	}
+}
+
+func TestRunStopsWhenClosed(t *testing.T) {
+	var mutex sync.Mutex
+	var ran []int
+	runner := &bulk.Runner{}
+	report, err := runner.Run(10, func(batch int) error {
+		mutex.Lock()
+		ran = append(ran, batch)
+		mutex.Unlock()
+		if batch >= 2 {
+			return quickbase.ErrClosed
+		}
+		return nil
+	})
+	if err == nil || report.Completed != 2 || report.Failed[2] != quickbase.ErrClosed {
+		t.Errorf("Run gave %+v, %v", report, err)
+	}
+	if len(ran) > 4 {
+		t.Errorf("Run went on after its Client closed: ran %v", ran)
+	}
+}
+
+func TestCheckpointTruncated(t *testing.T) {
+	path, cleanup := checkpoint(t)
+	defer cleanup()
+	if err := ioutil.WriteFile(path, []byte("0\n1\n2"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var ran []int
+	report, err := (&bulk.Runner{Checkpoint: path}).Run(3, func(batch int) error {
+		ran = append(ran, batch)
+		return nil
+	})
+	if err != nil || report.Skipped != 2 || len(ran) != 1 || ran[0] != 2 {
+		t.Errorf("run gave %+v, %v, ran %v", report, err, ran)
+	}
+	if contents, _ := ioutil.ReadFile(path); string(contents) != "0\n1\n2\n" {
+		t.Errorf("checkpoint holds %q", contents)
+	}
+}
+
+// flaky fails the nth call of ImportFromCSV.
+type flaky struct {
+	quickbase.QuickBase
+	mutex sync.Mutex
+	calls int
+	fail  int
+}
+
+func (f *flaky) ImportFromCSV(dbid string, columns []int, r io.Reader) error {
+	f.mutex.Lock()
+	f.calls++
+	call := f.calls
+	f.mutex.Unlock()
+	if call == f.fail {
+		return quickbase.QuickBaseError{Message: "Unable to process request", Code: 83}
+	}
+	return f.QuickBase.ImportFromCSV(dbid, columns, r)
+}
+
+func TestImportCSV(t *testing.T) {
+	path, cleanup := checkpoint(t)
+	defer cleanup()
+	const dbid = "bck7gp3q2"
+	fake := quickbasetest.NewFake()
+	fake.AddTable(dbid, map[int]string{6: "Site", 7: "Cost"})
+	var data strings.Builder
+	for i := 0; i < 25; i++ {
+		fmt.Fprintf(&data, "site %02d,%d\n", i, i)
+	}
+
+	runner := &bulk.Runner{Checkpoint: path, Retries: -1}
+	qb := &flaky{QuickBase: fake, fail: 2}
+	report, err := runner.ImportCSV(qb, dbid, []int{6, 7}, csv.NewReader(strings.NewReader(data.String())), 10)
+	if err == nil || report.Completed != 2 || report.Failed[1] == nil {
+		t.Fatalf("first import gave %+v, %v", report, err)
+	}
+	if records := fake.Records(dbid); len(records) != 15 {
+		t.Fatalf("first import added %d records", len(records))
+	}
+
+	qb = &flaky{QuickBase: fake}
+	report, err = runner.ImportCSV(qb, dbid, []int{6, 7}, csv.NewReader(strings.NewReader(data.String())), 10)
+	if err != nil || report.Completed != 1 || report.Skipped != 2 || qb.calls != 1 {
+		t.Fatalf("resumed import gave %+v, %v after %d calls", report, err, qb.calls)
+	}
+	records := fake.Records(dbid)
+	if len(records) != 25 || records[24][6] != "site 19" {
+		t.Errorf("after resuming the table holds %d records, the last %v", len(records), records[len(records)-1])
+	}
+}
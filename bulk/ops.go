@@ -0,0 +1,155 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package bulk
+
+import (
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"sort"
+)
+
+// An OpKind is the kind of change an Op makes.
+type OpKind int
+
+const (
+	Add OpKind = iota
+	Edit
+	Delete
+)
+
+func (k OpKind) String() string {
+	switch k {
+	case Add:
+		return "add"
+	case Edit:
+		return "edit"
+	case Delete:
+		return "delete"
+	}
+	return fmt.Sprintf("OpKind(%d)", int(k))
+}
+
+// An Op is a change to a single record, made by Bulk.
+type Op struct {
+	Kind   OpKind
+	Dbid   string
+	Rid    int            // the record edited or deleted
+	Fields map[int]string // the values added or edited, by field ID
+}
+
+// A Result is the outcome of the Op with the given Index, counting the
+// ops Bulk receives from 0.
+type Result struct {
+	Index int
+	Op    Op
+	Rid   int // the record added, edited or deleted
+	Err   error
+}
+
+// Errors holds the errors of the ops which failed, by their indexes.
+type Errors map[int]error
+
+func (e Errors) Error() string {
+	indexes := make([]int, 0, len(e))
+	for index := range e {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	if len(indexes) == 1 {
+		return fmt.Sprintf("Op %d failed: %s", indexes[0], e[indexes[0]])
+	}
+	return fmt.Sprintf("%d ops failed; the first, op %d: %s", len(indexes), indexes[0], e[indexes[0]])
+}
+
+// Bulk makes the changes received from ops, until it is closed, with
+// the given number of workers making calls at once (1 if fewer), and
+// passes the result of each to each, if it is not nil, in the order
+// of the ops.  Calls are paced by qb as it paces any others; a Client
+// sharing its Limiter with others keeps their combined rate within
+// QuickBase's limits, and one with a Concurrency limiter makes no more
+// calls at once than QuickBase sustains, however many workers are
+// given.  A failing op does not stop the others: Bulk returns their
+// errors as Errors once all are done.
+func Bulk(qb quickbase.QuickBase, workers int, ops <-chan Op, each func(Result)) error {
+	if workers < 1 {
+		workers = 1
+	}
+	// Results are queued in the order of their ops, the queue
+	// bounding how far workers may run ahead of a slow op.
+	type pending struct {
+		result Result
+		done   chan struct{}
+	}
+	queue := make(chan *pending, 2*workers)
+	work := make(chan *pending)
+	go func() {
+		index := 0
+		for op := range ops {
+			p := &pending{Result{Index: index, Op: op}, make(chan struct{})}
+			queue <- p
+			work <- p
+			index++
+		}
+		close(queue)
+		close(work)
+	}()
+	for i := 0; i < workers; i++ {
+		go func() {
+			for p := range work {
+				p.result.Rid, p.result.Err = apply(qb, p.result.Op)
+				close(p.done)
+			}
+		}()
+	}
+	errs := make(Errors)
+	for p := range queue {
+		<-p.done
+		if p.result.Err != nil {
+			errs[p.result.Index] = p.result.Err
+		}
+		if each != nil {
+			each(p.result)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// apply makes the change an op describes, turning a panic into an
+// error.
+func apply(qb quickbase.QuickBase, op Op) (rid int, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("Op panicked: %v", p)
+		}
+	}()
+	switch op.Kind {
+	case Add:
+		return qb.AddRecordByFid(op.Dbid, op.Fields)
+	case Edit:
+		return op.Rid, qb.EditRecordByFid(op.Dbid, op.Rid, op.Fields)
+	case Delete:
+		return op.Rid, qb.DeleteRecord(op.Dbid, op.Rid)
+	}
+	return 0, fmt.Errorf("Unknown op kind %s", op.Kind)
+}
@@ -0,0 +1,92 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package bulk_test
+
+import (
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"github.com/WesTower/quickbase/bulk"
+	"github.com/WesTower/quickbase/quickbasetest"
+	"testing"
+	"time"
+)
+
+// slowStart makes the first calls slowest, so that later ones finish
+// first.
+type slowStart struct {
+	quickbase.QuickBase
+	delays chan time.Duration
+}
+
+func (s slowStart) AddRecordByFid(dbid string, fields map[int]string) (int, error) {
+	time.Sleep(<-s.delays)
+	return s.QuickBase.AddRecordByFid(dbid, fields)
+}
+
+func TestBulk(t *testing.T) {
+	const dbid = "bck7gp3q2"
+	fake := quickbasetest.NewFake()
+	fake.AddTable(dbid, map[int]string{6: "Site"})
+	existing := fake.Seed(dbid, map[int]string{6: "Boise"})
+	qb := slowStart{fake, make(chan time.Duration, 10)}
+	for i := 10; i > 0; i-- {
+		qb.delays <- time.Duration(i) * time.Millisecond
+	}
+
+	ops := make(chan bulk.Op)
+	go func() {
+		for i := 0; i < 10; i++ {
+			ops <- bulk.Op{Kind: bulk.Add, Dbid: dbid, Fields: map[int]string{6: fmt.Sprintf("site %d", i)}}
+		}
+		ops <- bulk.Op{Kind: bulk.Edit, Dbid: dbid, Rid: existing, Fields: map[int]string{6: "Tulsa"}}
+		ops <- bulk.Op{Kind: bulk.Delete, Dbid: dbid, Rid: 999}
+		ops <- bulk.Op{Kind: bulk.Delete, Dbid: dbid, Rid: existing}
+		close(ops)
+	}()
+	var results []bulk.Result
+	err := bulk.Bulk(qb, 4, ops, func(result bulk.Result) {
+		results = append(results, result)
+	})
+	errs, ok := err.(bulk.Errors)
+	if !ok || len(errs) != 1 || errs[11] == nil {
+		t.Errorf("Bulk gave %v", err)
+	}
+	if len(results) != 13 {
+		t.Fatalf("Bulk gave %d results", len(results))
+	}
+	for i, result := range results {
+		if result.Index != i {
+			t.Errorf("result %d is of op %d", i, result.Index)
+		}
+	}
+	if results[12].Rid != existing || results[12].Err != nil {
+		t.Errorf("last result is %+v", results[12])
+	}
+	records := fake.Records(dbid)
+	if len(records) != 10 {
+		t.Fatalf("table holds %v", records)
+	}
+	for _, result := range results[:10] {
+		if result.Err != nil || result.Rid == 0 {
+			t.Errorf("add gave %+v", result)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GetRecord returns all the fields of record rid of table dbid, keyed
+// by fid.
+func GetRecord(ticket Ticket, dbid string, rid int) (record map[int]string, err error) {
+	return getRecord(ticket, dbid, 3, strconv.Itoa(rid))
+}
+
+// GetRecordByKey returns all the fields of the record of table dbid
+// whose field fid, typically the table's key field, is exactly value.
+// If several records match, the first is returned.
+func GetRecordByKey(ticket Ticket, dbid string, fid int, value string) (record map[int]string, err error) {
+	return getRecord(ticket, dbid, fid, value)
+}
+
+func getRecord(ticket Ticket, dbid string, fid int, value string) (record map[int]string, err error) {
+	query, err := exactly(fid, value)
+	if err != nil {
+		return nil, err
+	}
+	records, err := DoStructuredQuery(ticket, dbid, query, "a", "", "num-1")
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("No record with field %d of %q in %s", fid, value, dbid)
+	}
+	return records[0], nil
+}
+
+// A RecordCache holds records fetched by a Client's GetRecord and
+// GetRecordByKey for a time, so that services looking up the same
+// records repeatedly need not ask QuickBase each time.  Writes made
+// through the Client invalidate the records they touch; writes made
+// by anyone else are seen only once the cached copy expires, or after
+// Invalidate or InvalidateTable is called.
+//
+// A RecordCache is safe for concurrent use, and may be shared between
+// Clients using the same application.
+type RecordCache struct {
+	mutex  sync.Mutex
+	ttl    time.Duration
+	ttls   map[string]time.Duration
+	tables map[string]map[cacheKey]cacheEntry
+	// generations counts the invalidations of each table, so that a
+	// record read before one is not cached after it.
+	generations map[string]uint64
+	now         func() time.Time
+}
+
+type cacheKey struct {
+	fid   int
+	value string
+}
+
+type cacheEntry struct {
+	rid     int
+	record  map[int]string
+	expires time.Time
+}
+
+// NewRecordCache returns a RecordCache holding records for ttl, unless
+// SetTTL gives their table another.
+func NewRecordCache(ttl time.Duration) *RecordCache {
+	return &RecordCache{
+		ttl:         ttl,
+		ttls:        make(map[string]time.Duration),
+		tables:      make(map[string]map[cacheKey]cacheEntry),
+		generations: make(map[string]uint64),
+		now:         time.Now,
+	}
+}
+
+// SetTTL sets how long records of table dbid are held; a ttl of zero
+// or less stops them being cached at all.
+func (c *RecordCache) SetTTL(dbid string, ttl time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.ttls[dbid] = ttl
+	if ttl <= 0 {
+		delete(c.tables, dbid)
+		c.generations[dbid]++
+	}
+}
+
+// Invalidate discards the cached copy of record rid of table dbid,
+// however it was looked up.
+func (c *RecordCache) Invalidate(dbid string, rid int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for key, entry := range c.tables[dbid] {
+		if entry.rid == rid {
+			delete(c.tables[dbid], key)
+		}
+	}
+	c.generations[dbid]++
+}
+
+// InvalidateTable discards every cached record of table dbid.
+func (c *RecordCache) InvalidateTable(dbid string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.tables, dbid)
+	c.generations[dbid]++
+}
+
+// Len returns the number of lookups cached, including those expired
+// but not yet discarded.
+func (c *RecordCache) Len() (n int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, entries := range c.tables {
+		n += len(entries)
+	}
+	return n
+}
+
+func (c *RecordCache) tableTTL(dbid string) time.Duration {
+	if ttl, ok := c.ttls[dbid]; ok {
+		return ttl
+	}
+	return c.ttl
+}
+
+// get returns a copy of the cached record, if any, looked up by key.
+// If there is none, it returns the table's generation, to be given to
+// put with the record once read.
+func (c *RecordCache) get(dbid string, key cacheKey) (record map[int]string, generation uint64, ok bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	entry, ok := c.tables[dbid][key]
+	if !ok {
+		return nil, c.generations[dbid], false
+	}
+	if !c.now().Before(entry.expires) {
+		delete(c.tables[dbid], key)
+		return nil, c.generations[dbid], false
+	}
+	return copyRecord(entry.record), 0, true
+}
+
+// put caches a copy of record as looked up by key, discarding any
+// other expired lookups of its table, unless the table has been
+// invalidated since generation, when record was read.
+func (c *RecordCache) put(dbid string, key cacheKey, record map[int]string, generation uint64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	ttl := c.tableTTL(dbid)
+	if ttl <= 0 || c.generations[dbid] != generation {
+		return
+	}
+	rid, err := strconv.Atoi(record[3])
+	if err != nil {
+		return
+	}
+	now := c.now()
+	entries := c.tables[dbid]
+	if entries == nil {
+		entries = make(map[cacheKey]cacheEntry)
+		c.tables[dbid] = entries
+	}
+	for k, entry := range entries {
+		if !now.Before(entry.expires) {
+			delete(entries, k)
+		}
+	}
+	entries[key] = cacheEntry{rid, copyRecord(record), now.Add(ttl)}
+}
+
+func copyRecord(record map[int]string) map[int]string {
+	copied := make(map[int]string, len(record))
+	for fid, value := range record {
+		copied[fid] = value
+	}
+	return copied
+}
+
+// GetRecord is as the package-level function, reading through the
+// Client's Cache if it has one.
+func (c *Client) GetRecord(dbid string, rid int) (record map[int]string, err error) {
+	return c.getRecord(dbid, 3, strconv.Itoa(rid))
+}
+
+// GetRecordByKey is as the package-level function, reading through
+// the Client's Cache if it has one.
+func (c *Client) GetRecordByKey(dbid string, fid int, value string) (record map[int]string, err error) {
+	return c.getRecord(dbid, fid, value)
+}
+
+func (c *Client) getRecord(dbid string, fid int, value string) (record map[int]string, err error) {
+	if c.Cache == nil {
+		return getRecord(c.ticket(), dbid, fid, value)
+	}
+	key := cacheKey{fid, value}
+	record, generation, ok := c.Cache.get(dbid, key)
+	if ok {
+		return record, nil
+	}
+	if record, err = getRecord(c.ticket(), dbid, fid, value); err != nil {
+		return nil, err
+	}
+	c.Cache.put(dbid, key, record, generation)
+	return record, nil
+}
+
+// invalidate discards whatever the Client's Cache holds which call may
+// have changed: the record it names, or the whole table if it names
+// none.
+func (c *Client) invalidate(call apiCall) {
+	if c.Cache == nil {
+		return
+	}
+	i := strings.LastIndex(call.url, "db/")
+	if i < 0 {
+		return
+	}
+	dbid := call.url[i+len("db/"):]
+	if rid, err := strconv.Atoi(call.params["rid"]); err == nil {
+		c.Cache.Invalidate(dbid, rid)
+	} else if call.action != "API_AddRecord" {
+		c.Cache.InvalidateTable(dbid)
+	}
+}
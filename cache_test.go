@@ -0,0 +1,175 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/WesTower/quickbase"
+)
+
+func TestRecordCache(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	ticket, err := authenticate(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := quickbase.NewClient(ticket)
+	client.Cache = quickbase.NewRecordCache(time.Hour)
+	site := func(get func() (map[int]string, error)) string {
+		record, err := get()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return record[6]
+	}
+	byRid := func() (map[int]string, error) { return client.GetRecord(testTableDbid, 1) }
+	byKey := func() (map[int]string, error) { return client.GetRecordByKey(testTableDbid, 7, "12.50") }
+
+	if s := site(byRid); s != "Denver" {
+		t.Fatalf("GetRecord gave %q", s)
+	}
+	if s := site(byKey); s != "Denver" {
+		t.Fatalf("GetRecordByKey gave %q", s)
+	}
+	if n := client.Cache.Len(); n != 2 {
+		t.Errorf("cached %d lookups, want 2", n)
+	}
+
+	// A write by someone else goes unseen until invalidated.
+	if err = quickbase.EditRecordByFid(ticket, testTableDbid, 1, map[int]string{6: "Aurora"}); err != nil {
+		t.Fatal(err)
+	}
+	if s := site(byRid); s != "Denver" {
+		t.Errorf("cached GetRecord gave %q", s)
+	}
+	client.Cache.Invalidate(testTableDbid, 1)
+	if n := client.Cache.Len(); n != 0 {
+		t.Errorf("Invalidate left %d lookups", n)
+	}
+	if s := site(byKey); s != "Aurora" {
+		t.Errorf("invalidated GetRecordByKey gave %q", s)
+	}
+
+	// A write through the client invalidates the record, whether
+	// it was looked up by rid or by key.
+	site(byRid)
+	if err = client.EditRecordByFid(testTableDbid, 1, map[int]string{6: "Golden"}); err != nil {
+		t.Fatal(err)
+	}
+	if s := site(byRid); s != "Golden" {
+		t.Errorf("GetRecord after EditRecordByFid gave %q", s)
+	}
+	if s := site(byKey); s != "Golden" {
+		t.Errorf("GetRecordByKey after EditRecordByFid gave %q", s)
+	}
+
+	if err = client.DeleteRecord(testTableDbid, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = byRid(); err == nil {
+		t.Error("GetRecord of deleted record succeeded")
+	}
+}
+
+func TestRecordCacheTTL(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	ticket, err := authenticate(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := quickbase.NewClient(ticket)
+	client.Cache = quickbase.NewRecordCache(time.Hour)
+	client.Cache.SetTTL(testTableDbid, 10*time.Millisecond)
+	if _, err = client.GetRecord(testTableDbid, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err = quickbase.EditRecordByFid(ticket, testTableDbid, 2, map[int]string{6: "Nampa"}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	record, err := client.GetRecord(testTableDbid, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if record[6] != "Nampa" {
+		t.Errorf("expired record gave %q", record[6])
+	}
+
+	client.Cache.SetTTL(testTableDbid, 0)
+	if _, err = client.GetRecord(testTableDbid, 2); err != nil {
+		t.Fatal(err)
+	}
+	if n := client.Cache.Len(); n != 0 {
+		t.Errorf("uncached table has %d lookups", n)
+	}
+}
+
+func TestGetRecordByKeyQuote(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	ticket, err := authenticate(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := quickbase.NewClient(ticket)
+	client.Cache = quickbase.NewRecordCache(time.Hour)
+	if err = client.EditRecordByFid(testTableDbid, 1, map[int]string{6: "O'Brien"}); err != nil {
+		t.Fatal(err)
+	}
+	record, err := client.GetRecordByKey(testTableDbid, 6, "O'Brien")
+	if err != nil || record[3] != "1" {
+		t.Errorf("GetRecordByKey of O'Brien gave %v, %v", record, err)
+	}
+	for _, value := range []string{"O'", "x'}y"} {
+		if _, err = client.GetRecordByKey(testTableDbid, 6, value); err == nil {
+			t.Errorf("GetRecordByKey of %q succeeded", value)
+		}
+	}
+}
+
+func TestRecordCacheInvalidatedDuringRead(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	ticket, err := authenticate(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := quickbase.NewClient(ticket)
+	client.Cache = quickbase.NewRecordCache(time.Hour)
+	server.SetLatency(200*time.Millisecond, 0)
+	done := make(chan error)
+	go func() {
+		_, err := client.GetRecord(testTableDbid, 1)
+		done <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	client.Cache.Invalidate(testTableDbid, 1)
+	if err = <-done; err != nil {
+		t.Fatal(err)
+	}
+	if n := client.Cache.Len(); n != 0 {
+		t.Errorf("a record read before Invalidate was cached after it: %d lookups", n)
+	}
+}
@@ -0,0 +1,359 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// QuickBase is the set of operations offered by this package.  Code
+// written against QuickBase rather than the package's functions may
+// be handed a *Client in production and a mock or fake in its tests.
+type QuickBase interface {
+	GetAppDTMInfo(dbid string) (received, nextAllowed time.Time, schemaModification SchemaModification, tableModification []SchemaModification, err error)
+	GetSchema(dbid string) (schema Schema, err error)
+	GetRelationshipGraph(dbids ...string) (graph *RelationshipGraph, err error)
+	DoQueryCount(dbid, query string) (count int64, err error)
+	DoQuery(dbid, query, clist, slist, options string) (records []map[string]string, err error)
+	DoStructuredQuery(dbid, query, clist, slist, options string) (records []map[int]string, err error)
+	DoQueryByQid(dbid string, qid int, clist, slist, options string) (records []map[int]string, err error)
+	GenResultsTable(dbid, query string, columns []int) (resp *http.Response, err error)
+	AddRecord(dbid string, fields map[string]string) (rid int, err error)
+	AddRecordByFid(dbid string, fields map[int]string) (rid int, err error)
+	EditRecord(dbid string, recordId int, fields map[string]string) (err error)
+	EditRecordByFid(dbid string, recordId int, fields map[int]string) (err error)
+	DeleteRecord(dbid string, rid int) (err error)
+	ChangeRecordOwner(dbid string, rid int, owner string) (err error)
+	ImportFromCSV(dbid string, columns []int, r io.Reader) (err error)
+	UserRoles(dbid string) (users []User, err error)
+	Download(dbid string, rid, fid, vid int) (file io.ReadCloser, err error)
+	Upload(dbid string, rid, fid int, filename string, r io.Reader) (err error)
+}
+
+// A Client makes calls on behalf of an authenticated user.  Its
+// methods are those of the package-level functions of the same name,
+// with the Ticket argument supplied by the Client.
+type Client struct {
+	Ticket     Ticket
+	HttpClient *http.Client // if nil, DefaultClient is used
+	// Limiter, if set, paces calls and is told of the rate limits
+	// QuickBase reports; share it between Clients (and with the
+	// REST API) using the same credentials.
+	Limiter *RateLimiter
+	// Concurrency, if set, bounds the calls in flight at once,
+	// adapting the bound to QuickBase's latency and throttling;
+	// share it between Clients as the Limiter is shared.
+	Concurrency *ConcurrencyLimiter
+
+	// DryRun, if set, makes the methods which change data
+	// (AddRecord, EditRecord, DeleteRecord, ChangeRecordOwner,
+	// ImportFromCSV, Upload and their variants) do nothing but
+	// pass the request they would have sent to DryRunLog, and
+	// return zero values.  Other calls are still made.
+	DryRun bool
+	// DryRunLog receives the requests a DryRun Client does not
+	// send; if nil, they are written to the standard logger with
+	// their credentials redacted.
+	DryRunLog func(DryRunRequest)
+	// Journal, if set, records each call which changes data and
+	// its outcome, so that a failed bulk job may be resumed with
+	// Replay.
+	Journal *Journal
+	// WireDump, if set, receives a dump of the HTTP request and
+	// response of each call, with credentials redacted, for
+	// debugging.  Bodies are read into memory to be dumped, even
+	// those otherwise streamed.
+	WireDump io.Writer
+	// WireDumpActions, if set, limits WireDump to calls of the
+	// given actions, e.g. 'API_DoQuery'.
+	WireDumpActions []string
+	// DisableCompression, if set, stops the Client asking for
+	// gzipped responses, for proxies which mangle them.
+	DisableCompression bool
+	// Cache, if set, holds the records looked up by GetRecord and
+	// GetRecordByKey; the Client's own writes invalidate them.
+	Cache *RecordCache
+	// Hooks, if set, are told of the Client's calls.
+	Hooks *Hooks
+
+	refresher *ticketRefresher // if set, holds the ticket calls are made with
+	life      *lifecycle       // calls in flight, for Shutdown; see lifecycle()
+	ctx       context.Context  // if set, bounds each call
+}
+
+var _ QuickBase = (*Client)(nil)
+
+// NewClient returns a Client making calls with ticket.
+func NewClient(ticket Ticket) *Client {
+	return &Client{Ticket: ticket}
+}
+
+// Login authenticates a user, as Authenticate does, and returns a
+// Client making calls as that user.
+func Login(url, username, password string) (client *Client, err error) {
+	ticket, err := Authenticate(url, username, password)
+	if err != nil {
+		return nil, err
+	}
+	return NewClient(ticket), nil
+}
+
+// Authenticate authenticates a user, as the package-level
+// Authenticate does, but making the call with the Client's HttpClient,
+// Limiter and Concurrency, and sets the Client's Ticket.
+func (c *Client) Authenticate(url, username, password string) (err error) {
+	ticket, err := authenticate(c.ticket(), url, username, password)
+	if err != nil {
+		return err
+	}
+	ticket.Apptoken = c.Ticket.Apptoken
+	c.Ticket = ticket
+	return nil
+}
+
+// WithContext returns a shallow copy of the Client whose calls are
+// made with ctx, as Ticket.WithContext describes, e.g.
+//
+//	records, err := client.WithContext(ctx).DoStructuredQuery(dbid, query, clist, "", "")
+//
+// The copy shares the Client's transport, limiters, cache, journal
+// and hooks, and is shut down with it.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	c.lifecycle() // shared with the copy
+	copied := *c
+	copied.ctx = ctx
+	return &copied
+}
+
+// AuthenticateCached authenticates a user as Authenticate does, unless
+// cache holds an unexpired ticket for them, which the Client then
+// uses instead.  A ticket got by authenticating is saved in cache.
+func (c *Client) AuthenticateCached(cache *TicketCache, url, username, password string) (err error) {
+	ticket, err := cache.authenticate(c.ticket(), url, username, password)
+	if err != nil {
+		return err
+	}
+	ticket.Apptoken = c.Ticket.Apptoken
+	c.Ticket = ticket
+	return nil
+}
+
+// ticket returns the Client's Ticket, set to make calls with its
+// HttpClient, Limiter, Concurrency, WireDump, DisableCompression and
+// Hooks.
+func (c *Client) ticket() Ticket {
+	ticket := c.Ticket
+	if c.refresher != nil {
+		ticket = c.refresher.current()
+		ticket.renewer = c.refresher
+	}
+	ticket.httpClient, ticket.limiter, ticket.concurrency, ticket.uncompressed, ticket.hooks = c.HttpClient, c.Limiter, c.Concurrency, c.DisableCompression, c.Hooks
+	ticket.life = c.lifecycle()
+	ticket.ctx = c.ctx
+	if c.WireDump != nil {
+		ticket.dump = &wireDump{c.WireDump, c.WireDumpActions}
+	}
+	return ticket
+}
+
+// writeTicket returns the Client's Ticket for calls which change
+// data, which Shutdown lets finish.
+func (c *Client) writeTicket() Ticket {
+	ticket := c.ticket()
+	ticket.write = true
+	return ticket
+}
+
+// GetAppDTMInfo calls GetAppDTMInfo against the Client's instance.
+func (c *Client) GetAppDTMInfo(dbid string) (received, nextAllowed time.Time, schemaModification SchemaModification, tableModification []SchemaModification, err error) {
+	return getAppDTMInfo(c.ticket(), c.Ticket.url, dbid)
+}
+
+func (c *Client) GetSchema(dbid string) (schema Schema, err error) {
+	return GetSchema(c.ticket(), dbid)
+}
+
+func (c *Client) GetRelationshipGraph(dbids ...string) (graph *RelationshipGraph, err error) {
+	return GetRelationshipGraph(c.ticket(), dbids...)
+}
+
+func (c *Client) DoQueryCount(dbid, query string) (count int64, err error) {
+	return DoQueryCount(c.ticket(), dbid, query)
+}
+
+func (c *Client) DoQuery(dbid, query, clist, slist, options string) (records []map[string]string, err error) {
+	return DoQuery(c.ticket(), dbid, query, clist, slist, options)
+}
+
+// DoQueryChan is as the package-level function.
+//
+// Deprecated: use StreamQuery.
+func (c *Client) DoQueryChan(dbid, query, clist, slist string) (records chan map[string]string, err error) {
+	return DoQueryChan(c.ticket(), dbid, query, clist, slist)
+}
+
+func (c *Client) DoStructuredQuery(dbid, query, clist, slist, options string) (records []map[int]string, err error) {
+	return DoStructuredQuery(c.ticket(), dbid, query, clist, slist, options)
+}
+
+func (c *Client) DoQueryByQid(dbid string, qid int, clist, slist, options string) (records []map[int]string, err error) {
+	return DoQueryByQid(c.ticket(), dbid, qid, clist, slist, options)
+}
+
+func (c *Client) DoQueryByQname(dbid, qname, clist, slist, options string) (records []map[int]string, err error) {
+	return DoQueryByQname(c.ticket(), dbid, qname, clist, slist, options)
+}
+
+func (c *Client) DoReport(dbid string, qid int) (records []map[int]string, err error) {
+	return DoReport(c.ticket(), dbid, qid)
+}
+
+func (c *Client) DoReportByName(dbid, qname string) (records []map[int]string, err error) {
+	return DoReportByName(c.ticket(), dbid, qname)
+}
+
+func (c *Client) DoQueryPaged(dbid, query, clist string, options PageOptions) (records *RecordSet, err error) {
+	return DoQueryPaged(c.ticket(), dbid, query, clist, options)
+}
+
+func (c *Client) ScanQuery(dbid, query, clist, slist, options string) (scanner *Scanner, err error) {
+	return ScanQuery(c.ticket(), dbid, query, clist, slist, options)
+}
+
+func (c *Client) ForEachRecord(ctx context.Context, dbid string, options ScanOptions, each func(*Record) error) (err error) {
+	return ForEachRecord(ctx, c.ticket(), dbid, options, each)
+}
+
+func (c *Client) GenResultsTable(dbid, query string, columns []int) (resp *http.Response, err error) {
+	return GenResultsTable(c.ticket(), dbid, query, columns)
+}
+
+func (c *Client) AddRecord(dbid string, fields map[string]string) (rid int, err error) {
+	var result addRecordResponse
+	if err = c.mutate(addRecordCall(c.ticket(), dbid, fields), &result); err != nil || c.DryRun {
+		return 0, err
+	}
+	return result.recordId()
+}
+
+func (c *Client) AddRecordByFid(dbid string, fields map[int]string) (rid int, err error) {
+	var result addRecordResponse
+	if err = c.mutate(addRecordByFidCall(c.ticket(), dbid, fields), &result); err != nil || c.DryRun {
+		return 0, err
+	}
+	return result.recordId()
+}
+
+func (c *Client) EditRecord(dbid string, recordId int, fields map[string]string) (err error) {
+	return c.mutate(editRecordCall(c.ticket(), dbid, recordId, fields), nil)
+}
+
+func (c *Client) EditRecordByFid(dbid string, recordId int, fields map[int]string) (err error) {
+	return c.mutate(editRecordByFidCall(c.ticket(), dbid, recordId, fields), nil)
+}
+
+func (c *Client) DeleteRecord(dbid string, rid int) (err error) {
+	return c.mutate(deleteRecordCall(c.ticket(), dbid, rid), nil)
+}
+
+func (c *Client) ChangeRecordOwner(dbid string, rid int, owner string) (err error) {
+	return c.mutate(changeRecordOwnerCall(c.ticket(), dbid, rid, owner), nil)
+}
+
+// ImportFromCSV is as the package-level function, except that a
+// DryRun Client or one with a Journal reads the whole CSV into memory,
+// rather than streaming it, in order to log or record it.
+func (c *Client) ImportFromCSV(dbid string, columns []int, r io.Reader) (err error) {
+	return c.ImportFromCSVWithOptions(dbid, columns, r, ImportOptions{})
+}
+
+// ImportFromCSVWithOptions is as the package-level function, except
+// that a DryRun Client or one with a Journal ignores options other
+// than MergeFid, reading the whole CSV as ImportFromCSV does.
+func (c *Client) ImportFromCSVWithOptions(dbid string, columns []int, r io.Reader, options ImportOptions) (err error) {
+	if !c.DryRun && c.Journal == nil {
+		if c.Cache != nil {
+			defer c.Cache.InvalidateTable(dbid)
+		}
+		return ImportFromCSVWithOptions(c.writeTicket(), dbid, columns, r, options)
+	}
+	call, err := importFromCSVCall(c.ticket(), dbid, columns, r, options.MergeFid)
+	if err != nil {
+		return err
+	}
+	return c.mutate(call, nil)
+}
+
+func (c *Client) UserRoles(dbid string) (users []User, err error) {
+	return UserRoles(c.ticket(), dbid)
+}
+
+func (c *Client) Download(dbid string, rid, fid, vid int) (file io.ReadCloser, err error) {
+	return Download(c.ticket(), dbid, rid, fid, vid)
+}
+
+func (c *Client) Upload(dbid string, rid, fid int, filename string, r io.Reader) (err error) {
+	if c.DryRun {
+		return c.dryRunUpload(dbid, rid, fid, filename, r)
+	}
+	if c.Cache != nil {
+		defer c.Cache.Invalidate(dbid, rid)
+	}
+	if c.Journal == nil {
+		return Upload(c.writeTicket(), dbid, rid, fid, filename, r)
+	}
+	seq, err := c.Journal.begin(JournalEntry{
+		Action: "API_EditRecord",
+		Path:   "db/" + dbid,
+		Params: map[string]string{"rid": strconv.Itoa(rid), "fid": strconv.Itoa(fid), "filename": filename},
+		Upload: true,
+	})
+	if err != nil {
+		return err
+	}
+	err = Upload(c.writeTicket(), dbid, rid, fid, filename, r)
+	return c.Journal.end(seq, err)
+}
+
+// mutate makes a call which changes data, decoding its response into
+// result as execute does and recording it in the Client's Journal if
+// it has one and invalidating what its Cache holds of the records
+// changed.  If the Client is a DryRun one the call is only logged, and
+// result is left untouched.
+func (c *Client) mutate(call apiCall, result response) (err error) {
+	if c.DryRun {
+		return c.dryRun(call)
+	}
+	defer c.invalidate(call)
+	call.ticket.write = true
+	if c.Journal == nil {
+		return call.execute(result)
+	}
+	seq, err := c.Journal.begin(journalEntry(c.Ticket, call))
+	if err != nil {
+		return err
+	}
+	return c.Journal.end(seq, call.execute(result))
+}
@@ -0,0 +1,308 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// siteCount is the sort of application code which, written against
+// quickbase.QuickBase, can be tested with a mock.
+func siteCount(qb quickbase.QuickBase, site string) (int64, error) {
+	return qb.DoQueryCount(testTableDbid, "{'6'.EX.'"+site+"'}")
+}
+
+type countingMock struct {
+	quickbase.QuickBase
+	queries []string
+}
+
+func (m *countingMock) DoQueryCount(dbid, query string) (int64, error) {
+	m.queries = append(m.queries, query)
+	return 7, nil
+}
+
+func TestClient(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count, err := siteCount(client, "Boise"); err != nil || count != 1 {
+		t.Errorf("siteCount gave %d, %v", count, err)
+	}
+	rid, err := client.AddRecordByFid(testTableDbid, map[int]string{6: "Boise"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count, err := siteCount(client, "Boise"); err != nil || count != 2 {
+		t.Errorf("siteCount after AddRecordByFid gave %d, %v", count, err)
+	}
+	if err = client.DeleteRecord(testTableDbid, rid); err != nil {
+		t.Error(err)
+	}
+	if _, err = quickbase.Login(server.BaseUrl(), "jdoe", "wrong"); err == nil {
+		t.Error("Login with a bad password succeeded")
+	}
+}
+
+func TestQuickBaseMock(t *testing.T) {
+	mock := &countingMock{}
+	if count, err := siteCount(mock, "Denver"); err != nil || count != 7 {
+		t.Errorf("siteCount gave %d, %v", count, err)
+	}
+	if len(mock.queries) != 1 || mock.queries[0] != "{'6'.EX.'Denver'}" {
+		t.Errorf("mock saw %v", mock.queries)
+	}
+}
+
+func TestWireDump(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dump bytes.Buffer
+	client.WireDump = &dump
+	client.WireDumpActions = []string{"API_DoQueryCount"}
+	if _, err = client.AddRecordByFid(testTableDbid, map[int]string{6: "Tulsa"}); err != nil {
+		t.Fatal(err)
+	}
+	if count, err := client.DoQueryCount(testTableDbid, "{'6'.EX.'Tulsa'}"); err != nil || count != 1 {
+		t.Fatalf("DoQueryCount gave %d, %v", count, err)
+	}
+	got := dump.String()
+	for _, want := range []string{
+		">>> POST /db/" + testTableDbid,
+		"Quickbase-Action: API_DoQueryCount",
+		"<ticket>REDACTED</ticket>",
+		"<<< HTTP/1.1 200 OK",
+		"<numMatches>1</numMatches>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("dump lacks %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "API_AddRecord") || strings.Contains(got, "fake_ticket") {
+		t.Errorf("dump holds an unselected call or a ticket:\n%s", got)
+	}
+}
+
+func TestKeepAlive(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	var conns int32
+	front := httptest.NewUnstartedServer(server)
+	front.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt32(&conns, 1)
+		}
+	}
+	front.Start()
+	defer front.Close()
+	client, err := quickbase.Login(front.URL+"/", "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if _, err = client.DoQueryCount(testTableDbid, "{'6'.EX.'Boise'}"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := atomic.LoadInt32(&conns); n != 1 {
+		t.Errorf("11 calls opened %d connections", n)
+	}
+}
+
+// gzipWriter compresses a response written through it.
+type gzipWriter struct {
+	http.ResponseWriter
+	gz *gzip.Writer
+}
+
+func (w gzipWriter) Write(p []byte) (int, error) {
+	return w.gz.Write(p)
+}
+
+func TestGzip(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	var compressed int32
+	front := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Accept-Encoding") != "gzip" {
+			server.ServeHTTP(w, r)
+			return
+		}
+		atomic.AddInt32(&compressed, 1)
+		w.Header().Set("Content-Encoding", "gzip")
+		gz := gzip.NewWriter(w)
+		defer gz.Close()
+		server.ServeHTTP(gzipWriter{w, gz}, r)
+	}))
+	defer front.Close()
+	client, err := quickbase.Login(front.URL+"/", "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count, err := siteCount(client, "Boise"); err != nil || count != 1 {
+		t.Errorf("siteCount gave %d, %v", count, err)
+	}
+	resp, err := client.GenResultsTable(testTableDbid, "{'6'.EX.'Denver'}", []int{6, 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Encoding") != "" {
+		t.Errorf("response still encoded as %q", resp.Header.Get("Content-Encoding"))
+	}
+	rows, err := csv.NewReader(resp.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 || rows[1][0] != "Denver" {
+		t.Errorf("GenResultsTable gave %q", rows)
+	}
+	if n := atomic.LoadInt32(&compressed); n != 3 {
+		t.Errorf("%d of 3 responses were compressed", n)
+	}
+	client.DisableCompression = true
+	if count, err := siteCount(client, "Boise"); err != nil || count != 1 {
+		t.Errorf("uncompressed siteCount gave %d, %v", count, err)
+	}
+	if n := atomic.LoadInt32(&compressed); n != 3 {
+		t.Errorf("a response was compressed despite DisableCompression")
+	}
+}
+
+func TestLimitRedirects(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	moved := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, server.URL+r.URL.Path, http.StatusTemporaryRedirect)
+	}))
+	defer moved.Close()
+	ticket, err := quickbase.Authenticate(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, ticketValue, userid := ticket.Credentials()
+	client := quickbase.NewClient(quickbase.RestoreTicket(moved.URL+"/", ticketValue, userid))
+	if count, err := siteCount(client, "Boise"); err != nil || count != 1 {
+		t.Errorf("redirected siteCount gave %d, %v", count, err)
+	}
+	client.HttpClient = &http.Client{CheckRedirect: quickbase.LimitRedirects(0)}
+	if _, err = siteCount(client, "Boise"); err == nil || !strings.Contains(err.Error(), "307") {
+		t.Errorf("unfollowed redirect gave %v", err)
+	}
+}
+
+// TestConcurrentCalls checks that calls sharing pooled request buffers
+// each send their own parameters.
+func TestConcurrentCalls(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	errs := make(chan error)
+	for i := 0; i < 20; i++ {
+		go func(site string) {
+			_, err := client.AddRecordByFid(testTableDbid, map[int]string{6: site})
+			errs <- err
+		}(fmt.Sprintf("Site %d", i))
+	}
+	for i := 0; i < 20; i++ {
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	}
+	for i := 0; i < 20; i++ {
+		if count, err := siteCount(client, fmt.Sprintf("Site %d", i)); err != nil || count != 1 {
+			t.Errorf("Site %d: siteCount gave %d, %v", i, count, err)
+		}
+	}
+}
+
+func TestNewTransport(t *testing.T) {
+	transport := quickbase.NewTransport(quickbase.TransportOptions{MaxIdleConnsPerHost: 64, IdleConnTimeout: time.Minute})
+	if transport.MaxIdleConnsPerHost != 64 || transport.IdleConnTimeout != time.Minute {
+		t.Errorf("options not applied: %+v", transport)
+	}
+	if transport.MaxIdleConns != 100 || transport.TLSHandshakeTimeout != 10*time.Second || !transport.ForceAttemptHTTP2 {
+		t.Errorf("defaults not applied: %+v", transport)
+	}
+	transport = quickbase.NewTransport(quickbase.TransportOptions{DisableHTTP2: true})
+	if transport.ForceAttemptHTTP2 || transport.TLSNextProto == nil {
+		t.Errorf("HTTP/2 not disabled: %+v", transport)
+	}
+	proxy, _ := url.Parse("http://proxy.example.com:3128")
+	transport = quickbase.NewTransport(quickbase.TransportOptions{Proxy: proxy})
+	req, _ := http.NewRequest("POST", "https://example.quickbase.com/db/main", nil)
+	if got, err := transport.Proxy(req); err != nil || got.String() != proxy.String() {
+		t.Errorf("proxy gave %v, %v", got, err)
+	}
+}
+
+func TestClientAuthenticateWith(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	server.AddUserToken("jdoe", "b2fr52_xyz")
+	for _, credentials := range []quickbase.Credentials{
+		{Username: "jdoe", Password: "secret"},
+		{Usertoken: "b2fr52_xyz"},
+	} {
+		client := &quickbase.Client{}
+		provider := quickbase.CredentialsFunc(func(url string) (quickbase.Credentials, error) { return credentials, nil })
+		if err := client.AuthenticateWith(provider, server.BaseUrl()); err != nil {
+			t.Errorf("%+v: %v", credentials, err)
+			continue
+		}
+		if count, err := client.DoQueryCount(testTableDbid, ""); err != nil || count != 2 {
+			t.Errorf("%+v: query gave %d, %v", credentials, count, err)
+		}
+	}
+	client := &quickbase.Client{}
+	if err := client.AuthenticateWith(quickbase.NewUserTokenAuth("b2fr52_xyz"), server.BaseUrl()); err != nil {
+		t.Fatal(err)
+	}
+	if count, err := client.DoQueryCount(testTableDbid, ""); err != nil || count != 2 {
+		t.Errorf("user token auth: query gave %d, %v", count, err)
+	}
+	empty := quickbase.CredentialsFunc(func(url string) (quickbase.Credentials, error) { return quickbase.Credentials{}, nil })
+	if err := (&quickbase.Client{}).AuthenticateWith(empty, server.BaseUrl()); err == nil {
+		t.Error("AuthenticateWith no credentials succeeded")
+	}
+}
@@ -0,0 +1,147 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// ticketLifetime is how long a ticket from API_Authenticate lasts,
+// unless a different lifetime is requested.
+const ticketLifetime = 12 * time.Hour
+
+func init() {
+	commands = append(commands, &command{
+		name:    "auth",
+		summary: "authenticate, saving the ticket or user token in the OS keychain",
+		run:     runAuth,
+	})
+}
+
+// storedCredentials are what qbcli keeps in the keychain for a
+// profile: never the password, but the ticket it was exchanged for,
+// or a user token.
+type storedCredentials struct {
+	Url       string    `json:"url"`
+	Ticket    string    `json:"ticket,omitempty"`
+	Userid    string    `json:"userid,omitempty"`
+	Expires   time.Time `json:"expires,omitempty"`
+	Usertoken string    `json:"usertoken,omitempty"`
+	Apptoken  string    `json:"apptoken,omitempty"`
+}
+
+// The secret is base64-encoded, so that no keychain tool has to cope
+// with quotes or newlines in it.
+func loadCredentials(profile string) (creds storedCredentials, err error) {
+	secret, err := systemKeychain.Get(profile)
+	if err != nil {
+		return creds, err
+	}
+	decoded, err := base64.StdEncoding.DecodeString(secret)
+	if err == nil {
+		err = json.Unmarshal(decoded, &creds)
+	}
+	if err != nil {
+		return creds, fmt.Errorf("corrupt credentials in keychain for profile %s: %s", profile, err)
+	}
+	return creds, nil
+}
+
+func saveCredentials(profile string, creds storedCredentials) error {
+	encoded, err := json.Marshal(creds)
+	if err != nil {
+		return err
+	}
+	return systemKeychain.Set(profile, base64.StdEncoding.EncodeToString(encoded))
+}
+
+// ticket recreates the Ticket the credentials hold.
+func (creds storedCredentials) ticket() (ticket quickbase.Ticket, err error) {
+	if creds.Usertoken != "" {
+		ticket = quickbase.UserTokenTicket(creds.Url, creds.Usertoken)
+	} else {
+		if time.Now().After(creds.Expires) {
+			return ticket, fmt.Errorf("saved ticket expired at %s; run qbcli auth again", creds.Expires.Format(time.RFC1123))
+		}
+		ticket = quickbase.RestoreTicket(creds.Url, creds.Ticket, creds.Userid)
+	}
+	ticket.Apptoken = creds.Apptoken
+	return ticket, nil
+}
+
+func runAuth(args []string, stdout io.Writer) error {
+	flags, conn := newFlagSet("auth")
+	logout := flags.Bool("logout", false, "remove the profile's saved credentials")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *logout {
+		if err := systemKeychain.Delete(conn.profile); err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "removed credentials for profile %s\n", conn.profile)
+		return nil
+	}
+	url, err := conn.baseUrl()
+	if err != nil {
+		return err
+	}
+	creds := storedCredentials{Url: url, Apptoken: conn.apptoken, Usertoken: conn.usertoken}
+	if creds.Usertoken == "" {
+		if conn.username == "" {
+			return fmt.Errorf("auth: use -username or -usertoken")
+		}
+		if conn.password == "" {
+			if conn.password, err = readPassword(); err != nil {
+				return err
+			}
+		}
+		ticket, err := quickbase.Authenticate(url, conn.username, conn.password)
+		if err != nil {
+			return err
+		}
+		_, creds.Ticket, creds.Userid = ticket.Credentials()
+		creds.Expires = time.Now().Add(ticketLifetime)
+	}
+	if err = saveCredentials(conn.profile, creds); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "saved credentials for %s as profile %s\n", url, conn.profile)
+	return nil
+}
+
+// readPassword prompts for a password on standard input.
+func readPassword() (password string, err error) {
+	fmt.Fprint(os.Stderr, "Password: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", fmt.Errorf("reading password: %s", err)
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
@@ -0,0 +1,96 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// memoryKeychain is a keychain for tests.
+type memoryKeychain map[string]string
+
+func (k memoryKeychain) Get(account string) (string, error) {
+	secret, ok := k[account]
+	if !ok {
+		return "", errNotFound
+	}
+	return secret, nil
+}
+
+func (k memoryKeychain) Set(account, secret string) error {
+	k[account] = secret
+	return nil
+}
+
+func (k memoryKeychain) Delete(account string) error {
+	delete(k, account)
+	return nil
+}
+
+func useMemoryKeychain() (keys memoryKeychain, restore func()) {
+	saved := systemKeychain
+	keys = make(memoryKeychain)
+	systemKeychain = keys
+	return keys, func() { systemKeychain = saved }
+}
+
+func TestAuth(t *testing.T) {
+	keys, restore := useMemoryKeychain()
+	defer restore()
+	server, conn := newServer()
+	defer server.Close()
+	server.AddUserToken("jdoe", "b12345_token")
+	query := []string{"query", "-dbid", sitesDbid, "-clist", "6", "-format", "csv", "-query", "{'6'.EX.'Boise'}"}
+
+	if err := run(query, &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "not authenticated") {
+		t.Errorf("query before auth gave %v", err)
+	}
+	runOutput(t, append([]string{"auth"}, conn...)...)
+	if strings.Contains(keys["default"], "secret") {
+		t.Error("password saved in keychain")
+	}
+	if got := runOutput(t, query...); got != "Site\nBoise\n" {
+		t.Errorf("query after auth gave %q", got)
+	}
+
+	runOutput(t, "auth", "-profile", "token", "-url", server.BaseUrl(), "-usertoken", "b12345_token")
+	if got := runOutput(t, append(query, "-profile", "token")...); got != "Site\nBoise\n" {
+		t.Errorf("query with a saved user token gave %q", got)
+	}
+
+	creds, err := loadCredentials("default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	creds.Expires = time.Now().Add(-time.Minute)
+	saveCredentials("default", creds)
+	if err := run(query, &bytes.Buffer{}); err == nil || !strings.Contains(err.Error(), "expired") {
+		t.Errorf("query with an expired ticket gave %v", err)
+	}
+
+	runOutput(t, "auth", "-logout", "-profile", "token")
+	if _, ok := keys["token"]; ok {
+		t.Error("auth -logout left the credentials")
+	}
+}
@@ -0,0 +1,155 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"github.com/WesTower/quickbase/mask"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	commands = append(commands, &command{
+		name:    "export",
+		summary: "export a table or report as CSV or JSON lines",
+		run:     runExport,
+	})
+}
+
+func runExport(args []string, stdout io.Writer) error {
+	flags, conn := newFlagSet("export")
+	dbid := flags.String("dbid", "", "table to export (required)")
+	query := flags.String("query", "", "query selecting the records to export; default all")
+	qid := flags.Int("qid", 0, "ID of a saved query (report) to export instead of -query")
+	clist := flags.String("clist", "", "period-separated field IDs to export; default all")
+	format := flags.String("format", "csv", "output format: csv or jsonl")
+	pageSize := flags.Int("page", 1000, "records fetched per call")
+	file := flags.String("file", "", "file to write; default standard output")
+	var masks maskRules
+	flags.Var(&masks, "mask", "mask fields, as selector=method: a field ID or label regexp, and hash, redact or truncate:n; repeatable")
+	maskKey := flags.String("mask-key", os.Getenv("QUICKBASE_MASK_KEY"), "key for hashing masked fields")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *dbid == "" {
+		return fmt.Errorf("export: -dbid is required")
+	}
+	if *format != "csv" && *format != "jsonl" {
+		return fmt.Errorf("export: unknown format %q; use csv or jsonl", *format)
+	}
+	if *pageSize < 1 {
+		return fmt.Errorf("export: -page must be positive")
+	}
+	out := stdout
+	if *file != "" {
+		f, err := os.Create(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	client, err := conn.connect()
+	if err != nil {
+		return err
+	}
+	schema, err := client.GetSchema(*dbid)
+	if err != nil {
+		return err
+	}
+	fids, err := columns(schema, *clist)
+	if err != nil {
+		return err
+	}
+	list := joinFids(fids)
+	masker := (&mask.Masker{Rules: masks, Key: []byte(*maskKey)}).Table(schema)
+
+	csvWriter := csv.NewWriter(out)
+	encoder := json.NewEncoder(out)
+	header := newResultTable(schema, fids, nil)
+	if *format == "csv" {
+		csvWriter.Write(header.labels)
+	}
+	// Sorting by Record ID# keeps the pages stable.
+	for skip := 0; ; skip += *pageSize {
+		options := fmt.Sprintf("num-%d.skp-%d", *pageSize, skip)
+		var records []map[int]string
+		if *qid != 0 {
+			records, err = client.DoQueryByQid(*dbid, *qid, list, "3", options)
+		} else {
+			records, err = client.DoStructuredQuery(*dbid, *query, list, "3", options)
+		}
+		if err != nil {
+			return err
+		}
+		for i, record := range records {
+			records[i] = masker.Record(record)
+		}
+		page := newResultTable(schema, fids, records)
+		for _, row := range page.rows {
+			if *format == "csv" {
+				csvWriter.Write(row)
+			} else if err = encoder.Encode(page.object(row)); err != nil {
+				return err
+			}
+		}
+		csvWriter.Flush()
+		if err = csvWriter.Error(); err != nil {
+			return err
+		}
+		if len(records) < *pageSize {
+			return nil
+		}
+	}
+}
+
+// maskRules is the value of export's repeatable -mask flag.
+type maskRules []mask.Rule
+
+func (m *maskRules) String() string {
+	specs := make([]string, len(*m))
+	for i, rule := range *m {
+		method := string(rule.Method)
+		if rule.Method == mask.Truncate {
+			method += ":" + strconv.Itoa(rule.Keep)
+		}
+		if rule.Fid != 0 {
+			specs[i] = fmt.Sprintf("%d=%s", rule.Fid, method)
+		} else {
+			specs[i] = fmt.Sprintf("%s=%s", rule.Label, method)
+		}
+	}
+	return strings.Join(specs, ",")
+}
+
+func (m *maskRules) Set(spec string) error {
+	rule, err := mask.ParseRule(spec)
+	if err != nil {
+		return err
+	}
+	*m = append(*m, rule)
+	return nil
+}
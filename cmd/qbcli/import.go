@@ -0,0 +1,260 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	commands = append(commands, &command{
+		name:    "import",
+		summary: "import a CSV file into a table",
+		run:     runImport,
+	})
+}
+
+func runImport(args []string, stdout io.Writer) error {
+	flags, conn := newFlagSet("import")
+	dbid := flags.String("dbid", "", "table to import into (required)")
+	file := flags.String("file", "", "CSV file, with a header line, to import; default standard input")
+	mapping := flags.String("map", "", "comma-separated column=fid pairs; unmapped columns are matched to field labels")
+	merge := flags.Int("merge", 0, "ID of a field whose value identifies the existing record a row updates")
+	batchSize := flags.Int("batch", 1000, "rows per API_ImportFromCSV call")
+	dryRun := flags.Bool("dry-run", false, "report what would be imported, without importing it")
+	journal := flags.String("journal", "", "file in which to journal each batch, for qbcli replay")
+	compress := flags.Bool("compress", false, "gzip large batches, if QuickBase accepts them")
+	numbers := flags.String("numbers", "", "format of numbers in the CSV, converted for numeric fields: us, euro, swiss, french or plain; default, numbers must be plain")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *dbid == "" {
+		return fmt.Errorf("import: -dbid is required")
+	}
+	if *batchSize < 1 {
+		return fmt.Errorf("import: -batch must be positive")
+	}
+	format, ok := numberFormats[*numbers]
+	if !ok && *numbers != "" {
+		return fmt.Errorf("import: unknown -numbers %q", *numbers)
+	}
+	in := io.Reader(os.Stdin)
+	if *file != "" {
+		f, err := os.Open(*file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	rows, err := csv.NewReader(in).ReadAll()
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return fmt.Errorf("import: no header line")
+	}
+	header, rows := rows[0], rows[1:]
+
+	client, err := conn.connect()
+	if err != nil {
+		return err
+	}
+	schema, err := client.GetSchema(*dbid)
+	if err != nil {
+		return err
+	}
+	fids, err := mapColumns(schema, header, *mapping)
+	if err != nil {
+		return err
+	}
+	if err = canonicalNumbers(schema, header, fids, rows, format, ok); err != nil {
+		return err
+	}
+	updates := 0
+	if *merge != 0 {
+		if fids, rows, updates, err = mergeRows(client, *dbid, *merge, fids, rows); err != nil {
+			return err
+		}
+		header = append(header, "Record ID#")
+	}
+	if *dryRun {
+		fmt.Fprintf(stdout, "would import %d rows into %s: %d new, %d updates\n", len(rows), *dbid, len(rows)-updates, updates)
+		for i, fid := range fids {
+			fmt.Fprintf(stdout, "  %s -> %d\n", header[i], fid)
+		}
+		return nil
+	}
+	if *journal != "" {
+		if client.Journal, err = quickbase.OpenJournal(*journal); err != nil {
+			return err
+		}
+		defer client.Journal.Close()
+	}
+	for start := 0; start < len(rows); start += *batchSize {
+		end := start + *batchSize
+		if end > len(rows) {
+			end = len(rows)
+		}
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		w.Write(header) // skipped by ImportFromCSV
+		w.WriteAll(rows[start:end])
+		options := quickbase.ImportOptions{Compress: *compress}
+		if err = client.ImportFromCSVWithOptions(*dbid, fids, bytes.NewReader(buf.Bytes()), options); err != nil {
+			return fmt.Errorf("rows %d-%d: %s", start+1, end, err)
+		}
+	}
+	fmt.Fprintf(stdout, "imported %d rows into %s\n", len(rows), *dbid)
+	return nil
+}
+
+// mapColumns returns the field ID to import each column into, from
+// the explicit mapping if it names the column and else by matching
+// the column name to a field label.
+func mapColumns(schema quickbase.Schema, header []string, mapping string) (fids []int, err error) {
+	explicit := make(map[string]int)
+	if mapping != "" {
+		for _, pair := range strings.Split(mapping, ",") {
+			i := strings.LastIndex(pair, "=")
+			if i < 0 {
+				return nil, fmt.Errorf("bad mapping %q; want column=fid", pair)
+			}
+			fid, err := strconv.Atoi(pair[i+1:])
+			if err != nil {
+				return nil, fmt.Errorf("bad field ID in mapping %q", pair)
+			}
+			explicit[pair[:i]] = fid
+		}
+	}
+	var unmapped []string
+	for _, column := range header {
+		fid, ok := explicit[column]
+		if !ok {
+			for _, field := range schema.Fields {
+				if strings.EqualFold(field.Label, strings.TrimSpace(column)) {
+					fid, ok = field.Id, true
+					break
+				}
+			}
+		}
+		if !ok {
+			unmapped = append(unmapped, column)
+		}
+		fids = append(fids, fid)
+	}
+	if unmapped != nil {
+		return nil, fmt.Errorf("no field for columns %s; use -map", strings.Join(unmapped, ", "))
+	}
+	return fids, nil
+}
+
+var numberFormats = map[string]quickbase.NumberFormat{
+	"us":     quickbase.USNumbers,
+	"euro":   quickbase.EuroNumbers,
+	"swiss":  quickbase.SwissNumbers,
+	"french": quickbase.FrenchNumbers,
+	"plain":  quickbase.PlainNumbers,
+}
+
+// canonicalNumbers rewrites the values of rows imported into numeric
+// fields from format to QuickBase's canonical form, or if convert is
+// not set checks that they are already in it, so that numbers with
+// e.g. thousands separators are not misread.
+func canonicalNumbers(schema quickbase.Schema, header []string, fids []int, rows [][]string, format quickbase.NumberFormat, convert bool) error {
+	numeric := make(map[int]bool)
+	for _, field := range schema.Fields {
+		switch field.Type {
+		case "float", "currency", "percent", "rating":
+			numeric[field.Id] = true
+		}
+	}
+	for i, row := range rows {
+		for column, fid := range fids {
+			if !numeric[fid] || column >= len(row) {
+				continue
+			}
+			var err error
+			if convert {
+				row[column], err = format.Canonical(row[column])
+			} else {
+				err = quickbase.CheckNumber(row[column])
+			}
+			if err != nil {
+				return fmt.Errorf("import: row %d, column %s: %s", i+1, header[column], err)
+			}
+		}
+	}
+	return nil
+}
+
+// mergeRows appends a Record ID# column to rows whose value of the
+// merge field matches an existing record, so that the import updates
+// those records rather than adding new ones.  Blank values match
+// nothing, and existing records sharing a value are refused, as it is
+// then unclear which a row would update.
+func mergeRows(client quickbase.QuickBase, dbid string, merge int, fids []int, rows [][]string) (merged []int, mergedRows [][]string, updates int, err error) {
+	column := -1
+	for i, fid := range fids {
+		if fid == merge {
+			column = i
+		}
+		if fid == 3 {
+			return nil, nil, 0, fmt.Errorf("cannot merge when importing Record ID#")
+		}
+	}
+	if column < 0 {
+		return nil, nil, 0, fmt.Errorf("merge field %d is not imported", merge)
+	}
+	existing, err := client.DoStructuredQuery(dbid, "", "3."+strconv.Itoa(merge), "", "")
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	rids := make(map[string]string, len(existing))
+	for _, record := range existing {
+		key := record[merge]
+		if key == "" {
+			continue
+		}
+		if rid, ok := rids[key]; ok {
+			return nil, nil, 0, fmt.Errorf("records %s and %s share the merge value %q", rid, record[3], key)
+		}
+		rids[key] = record[3]
+	}
+	for _, row := range rows {
+		rid := ""
+		if column < len(row) {
+			rid = rids[row[column]]
+		}
+		if rid != "" {
+			updates++
+		}
+		mergedRows = append(mergedRows, append(append([]string(nil), row...), rid))
+	}
+	return append(fids, 3), mergedRows, updates, nil
+}
@@ -0,0 +1,180 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"github.com/WesTower/quickbase/quickbasetest"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeCsv(t *testing.T, contents string) (file string, cleanup func()) {
+	dir, err := ioutil.TempDir("", "qbcli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	file = filepath.Join(dir, "import.csv")
+	if err = ioutil.WriteFile(file, []byte(contents), os.FileMode(0644)); err != nil {
+		t.Fatal(err)
+	}
+	return file, func() { os.RemoveAll(dir) }
+}
+
+func TestImport(t *testing.T) {
+	server, conn := newServer()
+	defer server.Close()
+	file, cleanup := writeCsv(t, "site,Price\nBoise,4\nTulsa,5\nMesa,6\n")
+	defer cleanup()
+	args := append(append([]string{"import"}, conn...), "-dbid", sitesDbid, "-file", file,
+		"-map", "Price=7", "-merge", "6", "-batch", "2")
+
+	got := runOutput(t, append(args, "-dry-run")...)
+	if !strings.HasPrefix(got, "would import 3 rows into bck7gp3q2: 2 new, 1 updates\n") {
+		t.Errorf("dry run gave %q", got)
+	}
+	if records := server.Records(sitesDbid); len(records) != 2 {
+		t.Errorf("dry run changed the table: %v", records)
+	}
+
+	runOutput(t, args...)
+	records := server.Records(sitesDbid)
+	if len(records) != 4 || records[1][7] != "4" || records[3][6] != "Mesa" {
+		t.Errorf("import left %v", records)
+	}
+
+	file, cleanup = writeCsv(t, "Site,Colour\nBoise,red\n")
+	defer cleanup()
+	if err := run(append(append([]string{"import"}, conn...), "-dbid", sitesDbid, "-file", file), ioutil.Discard); err == nil {
+		t.Error("import of an unmapped column succeeded")
+	}
+}
+
+func TestImportMergeBlanks(t *testing.T) {
+	server, conn := newServer()
+	defer server.Close()
+	server.Seed(sitesDbid, map[int]string{7: "8"})
+	file, cleanup := writeCsv(t, "Site,Cost\n,9\nBoise,4\n")
+	defer cleanup()
+	args := append(append([]string{"import"}, conn...), "-dbid", sitesDbid, "-file", file, "-merge", "6")
+
+	runOutput(t, args...)
+	records := server.Records(sitesDbid)
+	if len(records) != 4 || records[1][7] != "4" || records[2][7] != "8" || records[3][7] != "9" {
+		t.Errorf("import merging blank sites left %v", records)
+	}
+
+	server.Seed(sitesDbid, map[int]string{6: "Boise", 7: "5"})
+	if err := run(args, ioutil.Discard); err == nil || !strings.Contains(err.Error(), `"Boise"`) {
+		t.Errorf("import merging duplicate sites gave %v", err)
+	}
+}
+
+func TestImportNumbers(t *testing.T) {
+	server := quickbasetest.NewServer()
+	defer server.Close()
+	server.AddUser("jdoe", "secret")
+	server.AddTable(sitesDbid, map[int]string{6: "Site", 7: "Cost"}).Types[7] = "currency"
+	conn := []string{"-url", server.BaseUrl(), "-username", "jdoe", "-password", "secret"}
+	file, cleanup := writeCsv(t, "Site,Cost\nTulsa,\"1.234,50\"\nMesa,\"7,25 €\"\n")
+	defer cleanup()
+	args := append(append([]string{"import"}, conn...), "-dbid", sitesDbid, "-file", file)
+	if err := run(args, ioutil.Discard); err == nil || !strings.Contains(err.Error(), "row 1, column Cost") {
+		t.Errorf("import of unconverted numbers gave %v", err)
+	}
+	if err := run(append(args, "-numbers", "klingon"), ioutil.Discard); err == nil {
+		t.Error("import with an unknown number format succeeded")
+	}
+	runOutput(t, append(args, "-numbers", "euro")...)
+	records := server.Records(sitesDbid)
+	if len(records) != 2 || records[0][7] != "1234.50" || records[1][7] != "7.25" {
+		t.Errorf("import left %v", records)
+	}
+}
+
+func TestExport(t *testing.T) {
+	server, conn := newServer()
+	defer server.Close()
+	server.Seed(sitesDbid, map[int]string{6: "Tulsa", 7: "5"})
+	args := append(append([]string{"export"}, conn...), "-dbid", sitesDbid, "-clist", "6.7", "-page", "2")
+
+	got := runOutput(t, args...)
+	if want := "Site,Cost\nDenver,12.50\nBoise,3\nTulsa,5\n"; got != want {
+		t.Errorf("csv export %q, want %q", got, want)
+	}
+	got = runOutput(t, append(args, "-format", "jsonl", "-qid", "1")...)
+	if want := `{"Cost":"12.50","Site":"Denver"}` + "\n"; got != want {
+		t.Errorf("jsonl export %q, want %q", got, want)
+	}
+	got = runOutput(t, append(args, "-mask", "(?i)^site$=truncate:2", "-mask", "7=redact")...)
+	if want := "Site,Cost\nDe,[redacted]\nBo,[redacted]\nTu,[redacted]\n"; got != want {
+		t.Errorf("masked export %q, want %q", got, want)
+	}
+	if err := run(append(args, "-mask", "7=scramble"), ioutil.Discard); err == nil {
+		t.Error("export with a bad -mask succeeded")
+	}
+}
+
+func TestSeed(t *testing.T) {
+	server, conn := newServer()
+	defer server.Close()
+	args := append(append([]string{"seed"}, conn...), "-dbid", sitesDbid, "-n", "5", "-batch", "2", "-seed", "3",
+		"-values", "7=1|2")
+
+	if got := runOutput(t, args...); got != "added 5 records to bck7gp3q2 (seed 3)\n" {
+		t.Errorf("seed gave %q", got)
+	}
+	records := server.Records(sitesDbid)
+	if len(records) != 7 {
+		t.Fatalf("seed left %d records", len(records))
+	}
+	for _, record := range records[2:] {
+		if cost := record[7]; cost != "" && cost != "1" && cost != "2" {
+			t.Errorf("seeded Cost %q", cost)
+		}
+	}
+}
+
+func TestImportJournalReplay(t *testing.T) {
+	server, conn := newServer()
+	defer server.Close()
+	file, cleanup := writeCsv(t, "Site\nBoise\nTulsa\nMesa\n")
+	defer cleanup()
+	journal := file + ".journal"
+	server.FailNext("API_ImportFromCSV", 24, "The application is locked")
+	args := append(append([]string{"import"}, conn...), "-dbid", sitesDbid, "-file", file, "-batch", "2", "-journal", journal)
+	if err := run(args, ioutil.Discard); err == nil {
+		t.Fatal("import succeeded despite the injected error")
+	}
+
+	replay := append(append([]string{"replay"}, conn...), "-journal", journal)
+	if got := runOutput(t, append(replay, "-dry-run")...); !strings.HasPrefix(got, "would replay 1 operations\n") {
+		t.Errorf("replay -dry-run gave %q", got)
+	}
+	if got := runOutput(t, replay...); got != "replayed 1 operations\n" {
+		t.Errorf("replay gave %q", got)
+	}
+	if records := server.Records(sitesDbid); len(records) != 4 || records[3][6] != "Tulsa" {
+		t.Errorf("after replay the table holds %v", records)
+	}
+}
@@ -0,0 +1,37 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"github.com/WesTower/quickbase/keyring"
+)
+
+// keychainService is the service name under which qbcli stores
+// credentials in the OS keychain.
+const keychainService = "qbcli"
+
+// errNotFound is returned by a keychain's Get for an unknown account.
+var errNotFound = keyring.ErrNotFound
+
+// systemKeychain is the keychain of the operating system qbcli runs
+// on: the macOS Keychain, the Windows Credential Manager, or the
+// freedesktop.org Secret Service (GNOME Keyring, KWallet).
+var systemKeychain keyring.Keyring = keyring.System(keychainService)
@@ -0,0 +1,158 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+// Command qbcli inspects and manipulates QuickBase data from the
+// command line or from scripts, without writing any Go.
+//
+// Usage:
+//
+//	qbcli <command> [flags]
+//
+// Every command accepts -url, -username, -password, -usertoken,
+// -apptoken and -profile, which default to the environment variables
+// QUICKBASE_URL, QUICKBASE_USERNAME, QUICKBASE_PASSWORD,
+// QUICKBASE_USERTOKEN, QUICKBASE_APPTOKEN and QUICKBASE_PROFILE.  Run
+// 'qbcli <command> -h' for a command's own flags.
+//
+// 'qbcli auth' exchanges a username and password for a ticket, and
+// saves it (or a user token) in the OS keychain under the profile's
+// name.  Commands run without a password or user token then use the
+// profile's saved credentials.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"io"
+	"os"
+	"strings"
+)
+
+// A command is a subcommand of qbcli.
+type command struct {
+	name    string
+	summary string
+	run     func(args []string, stdout io.Writer) error
+}
+
+var commands []*command
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		if err != flag.ErrHelp {
+			fmt.Fprintln(os.Stderr, "qbcli:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout io.Writer) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "-help" {
+		usage(os.Stderr)
+		return flag.ErrHelp
+	}
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:], stdout)
+		}
+	}
+	usage(os.Stderr)
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: qbcli <command> [flags]\n\ncommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+}
+
+// newFlagSet returns the flag set for a command, with the connection
+// flags every command shares.
+func newFlagSet(name string) (flags *flag.FlagSet, conn *connection) {
+	flags = flag.NewFlagSet("qbcli "+name, flag.ContinueOnError)
+	conn = &connection{}
+	flags.StringVar(&conn.url, "url", os.Getenv("QUICKBASE_URL"), "QuickBase instance URL, e.g. https://example.quickbase.com/")
+	flags.StringVar(&conn.username, "username", os.Getenv("QUICKBASE_USERNAME"), "QuickBase username")
+	flags.StringVar(&conn.password, "password", os.Getenv("QUICKBASE_PASSWORD"), "QuickBase password")
+	flags.StringVar(&conn.usertoken, "usertoken", os.Getenv("QUICKBASE_USERTOKEN"), "user token, to use instead of a password")
+	flags.StringVar(&conn.apptoken, "apptoken", os.Getenv("QUICKBASE_APPTOKEN"), "application token")
+	profile := os.Getenv("QUICKBASE_PROFILE")
+	if profile == "" {
+		profile = "default"
+	}
+	flags.StringVar(&conn.profile, "profile", profile, "name of the credentials saved by qbcli auth")
+	return flags, conn
+}
+
+// A connection holds the settings needed to connect to QuickBase.
+type connection struct {
+	url       string
+	username  string
+	password  string
+	usertoken string
+	apptoken  string
+	profile   string
+}
+
+// baseUrl returns the instance URL, with the trailing slash
+// quickbase.Authenticate requires.
+func (c *connection) baseUrl() (url string, err error) {
+	if c.url == "" {
+		return "", fmt.Errorf("no QuickBase URL; use -url or QUICKBASE_URL")
+	}
+	if !strings.HasSuffix(c.url, "/") {
+		return c.url + "/", nil
+	}
+	return c.url, nil
+}
+
+// connect returns a Client authenticated with the password or user
+// token given, or else with the profile's saved credentials.
+func (c *connection) connect() (client *quickbase.Client, err error) {
+	var ticket quickbase.Ticket
+	if c.password == "" && c.usertoken == "" {
+		creds, err := loadCredentials(c.profile)
+		if err == errNotFound {
+			return nil, fmt.Errorf("not authenticated; run qbcli auth, or use -password or -usertoken")
+		}
+		if err != nil {
+			return nil, err
+		}
+		if ticket, err = creds.ticket(); err != nil {
+			return nil, err
+		}
+	} else {
+		url, err := c.baseUrl()
+		if err != nil {
+			return nil, err
+		}
+		if c.usertoken != "" {
+			ticket = quickbase.UserTokenTicket(url, c.usertoken)
+		} else if ticket, err = quickbase.Authenticate(url, c.username, c.password); err != nil {
+			return nil, err
+		}
+	}
+	if c.apptoken != "" {
+		ticket.Apptoken = c.apptoken
+	}
+	return quickbase.NewClient(ticket), nil
+}
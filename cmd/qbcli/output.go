@@ -0,0 +1,79 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// A resultTable is a set of records with labelled columns.
+type resultTable struct {
+	labels []string
+	rows   [][]string
+}
+
+// formats are the output formats resultTable.write accepts.
+var formats = []string{"table", "csv", "json"}
+
+func (t resultTable) write(w io.Writer, format string) error {
+	switch format {
+	case "table":
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(t.labels, "\t"))
+		for _, row := range t.rows {
+			cells := make([]string, len(row))
+			for i, value := range row {
+				// keep each record on a single line
+				cells[i] = strings.NewReplacer("\r", " ", "\n", " ", "\t", " ").Replace(value)
+			}
+			fmt.Fprintln(tw, strings.Join(cells, "\t"))
+		}
+		return tw.Flush()
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write(t.labels)
+		cw.WriteAll(t.rows)
+		return cw.Error()
+	case "json":
+		objects := make([]map[string]string, len(t.rows))
+		for i, row := range t.rows {
+			objects[i] = t.object(row)
+		}
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(objects)
+	}
+	return fmt.Errorf("unknown format %q; use one of %s", format, strings.Join(formats, ", "))
+}
+
+// object returns a row as a map from column labels to values.
+func (t resultTable) object(row []string) map[string]string {
+	object := make(map[string]string, len(row))
+	for i, value := range row {
+		object[t.labels[i]] = value
+	}
+	return object
+}
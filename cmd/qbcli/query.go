@@ -0,0 +1,126 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"io"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	commands = append(commands, &command{
+		name:    "query",
+		summary: "query the records of a table",
+		run:     runQuery,
+	})
+}
+
+func runQuery(args []string, stdout io.Writer) error {
+	flags, conn := newFlagSet("query")
+	dbid := flags.String("dbid", "", "table to query (required)")
+	query := flags.String("query", "", "query, e.g. {'6'.EX.'Denver'}")
+	qid := flags.Int("qid", 0, "ID of a saved query to run instead of -query")
+	clist := flags.String("clist", "", "period-separated field IDs to output; default all")
+	slist := flags.String("slist", "", "period-separated field IDs to sort by")
+	options := flags.String("options", "", "API_DoQuery options, e.g. num-10.sortorder-D")
+	format := flags.String("format", "table", "output format: "+strings.Join(formats, ", "))
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *dbid == "" {
+		return fmt.Errorf("query: -dbid is required")
+	}
+	client, err := conn.connect()
+	if err != nil {
+		return err
+	}
+	schema, err := client.GetSchema(*dbid)
+	if err != nil {
+		return err
+	}
+	fids, err := columns(schema, *clist)
+	if err != nil {
+		return err
+	}
+	list := joinFids(fids)
+	var records []map[int]string
+	if *qid != 0 {
+		records, err = client.DoQueryByQid(*dbid, *qid, list, *slist, *options)
+	} else {
+		records, err = client.DoStructuredQuery(*dbid, *query, list, *slist, *options)
+	}
+	if err != nil {
+		return err
+	}
+	return newResultTable(schema, fids, records).write(stdout, *format)
+}
+
+// columns returns the field IDs of a clist, or all the table's fields
+// if it is empty or 'a'.
+func columns(schema quickbase.Schema, clist string) (fids []int, err error) {
+	if clist == "" || clist == "a" {
+		for _, field := range schema.Fields {
+			fids = append(fids, field.Id)
+		}
+		return fids, nil
+	}
+	for _, s := range strings.Split(clist, ".") {
+		fid, err := strconv.Atoi(s)
+		if err != nil {
+			return nil, fmt.Errorf("bad field ID %q in clist", s)
+		}
+		fids = append(fids, fid)
+	}
+	return fids, nil
+}
+
+func joinFids(fids []int) string {
+	list := make([]string, len(fids))
+	for i, fid := range fids {
+		list[i] = strconv.Itoa(fid)
+	}
+	return strings.Join(list, ".")
+}
+
+// newResultTable labels the given fields of records from schema.
+func newResultTable(schema quickbase.Schema, fids []int, records []map[int]string) resultTable {
+	labels := make(map[int]string)
+	for _, field := range schema.Fields {
+		labels[field.Id] = field.Label
+	}
+	t := resultTable{labels: make([]string, len(fids))}
+	for i, fid := range fids {
+		if t.labels[i] = labels[fid]; t.labels[i] == "" {
+			t.labels[i] = strconv.Itoa(fid)
+		}
+	}
+	for _, record := range records {
+		row := make([]string, len(fids))
+		for i, fid := range fids {
+			row[i] = record[fid]
+		}
+		t.rows = append(t.rows, row)
+	}
+	return t
+}
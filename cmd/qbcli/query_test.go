@@ -0,0 +1,76 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"github.com/WesTower/quickbase/quickbasetest"
+	"strings"
+	"testing"
+)
+
+const sitesDbid = "bck7gp3q2"
+
+// newServer starts a fake QuickBase with a table of sites, and
+// returns the connection flags for it.
+func newServer() (server *quickbasetest.Server, connFlags []string) {
+	server = quickbasetest.NewServer()
+	server.AddUser("jdoe", "secret")
+	table := server.AddTable(sitesDbid, map[int]string{6: "Site", 7: "Cost"})
+	table.Queries[1] = "{'7'.GT.'10'}"
+	server.Seed(sitesDbid, map[int]string{6: "Denver", 7: "12.50"})
+	server.Seed(sitesDbid, map[int]string{6: "Boise", 7: "3"})
+	return server, []string{"-url", server.BaseUrl(), "-username", "jdoe", "-password", "secret"}
+}
+
+func runOutput(t *testing.T, args ...string) string {
+	var stdout bytes.Buffer
+	if err := run(args, &stdout); err != nil {
+		t.Fatalf("qbcli %s: %s", strings.Join(args, " "), err)
+	}
+	return stdout.String()
+}
+
+func TestQuery(t *testing.T) {
+	server, conn := newServer()
+	defer server.Close()
+	args := append([]string{"query"}, conn...)
+	args = append(args, "-dbid", sitesDbid, "-clist", "6.7", "-slist", "6")
+
+	got := runOutput(t, append(args, "-format", "csv")...)
+	if want := "Site,Cost\nBoise,3\nDenver,12.50\n"; got != want {
+		t.Errorf("csv output %q, want %q", got, want)
+	}
+	got = runOutput(t, append(args, "-query", "{'6'.EX.'Boise'}")...)
+	if want := "Site   Cost\nBoise  3\n"; got != want {
+		t.Errorf("table output %q, want %q", got, want)
+	}
+	got = runOutput(t, append(args, "-qid", "1", "-format", "json")...)
+	if !strings.Contains(got, `"Site": "Denver"`) || strings.Contains(got, "Boise") {
+		t.Errorf("json output %q", got)
+	}
+	if err := run(append(args, "-format", "xml"), &bytes.Buffer{}); err == nil {
+		t.Error("unknown format accepted")
+	}
+	if err := run([]string{"query", "-url", server.BaseUrl()}, &bytes.Buffer{}); err == nil {
+		t.Error("query without -dbid accepted")
+	}
+}
@@ -0,0 +1,105 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"io"
+	"os"
+	"strconv"
+)
+
+func init() {
+	commands = append(commands, &command{
+		name:    "relabel",
+		summary: "relabel fields across an application, checking what breaks",
+		run:     runRelabel,
+	})
+}
+
+func runRelabel(args []string, stdout io.Writer) error {
+	flags, conn := newFlagSet("relabel")
+	app := flags.String("app", "", "application whose fields to relabel (required)")
+	mapping := flags.String("map", "", "CSV file of table dbid, field ID and new label (required)")
+	check := flags.Bool("check", false, "list the uses of the fields, without relabelling them")
+	force := flags.Bool("force", false, "relabel even if uses of the fields would break")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *app == "" || *mapping == "" {
+		return fmt.Errorf("relabel: -app and -map are required")
+	}
+	relabels, err := readRelabels(*mapping)
+	if err != nil {
+		return err
+	}
+	client, err := conn.connect()
+	if err != nil {
+		return err
+	}
+	if *check {
+		dump, err := getSchemaDump(client, *app)
+		if err != nil {
+			return err
+		}
+		references, err := quickbase.PlanRelabels(dump.Tables, relabels)
+		if err != nil {
+			return fmt.Errorf("relabel: %s", err)
+		}
+		for _, reference := range references {
+			fmt.Fprintln(stdout, reference)
+		}
+		return nil
+	}
+	references, err := client.RelabelFields(*app, relabels, *force)
+	for _, reference := range references {
+		fmt.Fprintln(stdout, reference)
+	}
+	if err != nil {
+		return fmt.Errorf("relabel: %s; use -force to relabel anyway", err)
+	}
+	fmt.Fprintf(stdout, "relabelled %d fields\n", len(relabels))
+	return nil
+}
+
+func readRelabels(path string) (relabels []quickbase.Relabel, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = 3
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("relabel: %s", err)
+	}
+	for i, row := range rows {
+		fid, err := strconv.Atoi(row[1])
+		if err != nil {
+			return nil, fmt.Errorf("relabel: row %d: bad field ID %q", i+1, row[1])
+		}
+		relabels = append(relabels, quickbase.Relabel{Dbid: row[0], Fid: fid, Label: row[2]})
+	}
+	return relabels, nil
+}
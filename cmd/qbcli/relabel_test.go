@@ -0,0 +1,54 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRelabel(t *testing.T) {
+	server, conn := newServer()
+	defer server.Close()
+	server.AddApp("bck7gp3q1", sitesDbid)
+	mapping, cleanup := writeCsv(t, sitesDbid+",7,Price\n")
+	defer cleanup()
+	args := append([]string{"relabel"}, conn...)
+	args = append(args, "-app", "bck7gp3q1", "-map", mapping)
+
+	want := "bck7gp3q2 field 7: report 1 (Query 1) uses it by ID (unaffected)\n" +
+		`bck7gp3q2 field 7: DoQuery's records will key it by "price" rather than "cost" (BREAKS)` + "\n"
+	if got := runOutput(t, append(args, "-check")...); got != want {
+		t.Errorf("relabel -check gave %q", got)
+	}
+	var stdout bytes.Buffer
+	if err := run(args, &stdout); err == nil || !strings.Contains(err.Error(), "use -force") {
+		t.Errorf("unforced relabel gave %v", err)
+	}
+	if got := runOutput(t, append(args, "-force")...); got != want+"relabelled 1 fields\n" {
+		t.Errorf("relabel -force gave %q", got)
+	}
+	schema := runOutput(t, append(append([]string{"schema"}, conn...), "-dbid", sitesDbid)...)
+	if !strings.Contains(schema, "Price") || strings.Contains(schema, "Cost") {
+		t.Errorf("schema after relabel is\n%s", schema)
+	}
+}
@@ -0,0 +1,73 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"io"
+)
+
+func init() {
+	commands = append(commands, &command{
+		name:    "replay",
+		summary: "resume a failed job from its journal",
+		run:     runReplay,
+	})
+}
+
+func runReplay(args []string, stdout io.Writer) error {
+	flags, conn := newFlagSet("replay")
+	path := flags.String("journal", "", "journal of the failed job (required)")
+	dryRun := flags.Bool("dry-run", false, "list the operations which would be replayed, without replaying them")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *path == "" {
+		return fmt.Errorf("replay: -journal is required")
+	}
+	journal, err := quickbase.OpenJournal(*path)
+	if err != nil {
+		return err
+	}
+	defer journal.Close()
+	if *dryRun {
+		pending, err := journal.Pending()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "would replay %d operations\n", len(pending))
+		for _, entry := range pending {
+			fmt.Fprintf(stdout, "  %d %s %s %s\n", entry.Seq, entry.Time.Format("2006-01-02 15:04:05"), entry.Action, entry.Path)
+		}
+		return nil
+	}
+	client, err := conn.connect()
+	if err != nil {
+		return err
+	}
+	replayed, err := client.Replay(journal)
+	fmt.Fprintf(stdout, "replayed %d operations\n", replayed)
+	if err != nil {
+		return fmt.Errorf("replay stopped: %s", err)
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+func init() {
+	commands = append(commands, &command{
+		name:    "schema",
+		summary: "describe an application or table",
+		run:     runSchema,
+	})
+}
+
+// schemaDump is the schema of an application, or of a single table,
+// as output by the schema command.
+type schemaDump struct {
+	App           *quickbase.Schema        `json:",omitempty"`
+	Tables        []quickbase.Schema       `json:"Tables"`
+	Relationships []quickbase.Relationship `json:"Relationships"`
+}
+
+func runSchema(args []string, stdout io.Writer) error {
+	flags, conn := newFlagSet("schema")
+	dbid := flags.String("dbid", "", "application or table to describe (required)")
+	format := flags.String("format", "table", "output format: table or json")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *dbid == "" {
+		return fmt.Errorf("schema: -dbid is required")
+	}
+	if *format != "table" && *format != "json" {
+		return fmt.Errorf("schema: unknown format %q; use table or json", *format)
+	}
+	client, err := conn.connect()
+	if err != nil {
+		return err
+	}
+	dump, err := getSchemaDump(client, *dbid)
+	if err != nil {
+		return err
+	}
+	if *format == "json" {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(dump)
+	}
+	return dump.write(stdout)
+}
+
+// getSchemaDump fetches the schema of dbid and, if it is an
+// application, of each of its tables.
+func getSchemaDump(client quickbase.QuickBase, dbid string) (dump schemaDump, err error) {
+	schema, err := client.GetSchema(dbid)
+	if err != nil {
+		return dump, err
+	}
+	dbids := []string{dbid}
+	if len(schema.Tables) > 0 {
+		dump.App = &schema
+		dbids = schema.Tables
+		for _, table := range schema.Tables {
+			tableSchema, err := client.GetSchema(table)
+			if err != nil {
+				return dump, err
+			}
+			dump.Tables = append(dump.Tables, tableSchema)
+		}
+	} else {
+		dump.Tables = []quickbase.Schema{schema}
+	}
+	graph, err := client.GetRelationshipGraph(dbids...)
+	if err != nil {
+		return dump, err
+	}
+	dump.Relationships = graph.Relationships
+	return dump, nil
+}
+
+func (d schemaDump) write(w io.Writer) error {
+	if d.App != nil {
+		fmt.Fprintf(w, "Application %s (%s)\n\n", d.App.Name, d.App.Dbid)
+	}
+	for _, table := range d.Tables {
+		fmt.Fprintf(w, "Table %s (%s)\n", table.Name, table.Dbid)
+		tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(tw, "  FID\tLABEL\tTYPE\tMODE\tRELATION")
+		for _, field := range table.Fields {
+			relation := ""
+			switch {
+			case field.ParentDbid != "":
+				relation = "references " + field.ParentDbid
+			case field.ReferenceFid != 0:
+				relation = fmt.Sprintf("looks up through %d", field.ReferenceFid)
+			}
+			fmt.Fprintf(tw, "  %d\t%s\t%s\t%s\t%s\n", field.Id, field.Label, field.Type, field.Mode, relation)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		if len(table.Queries) > 0 {
+			fmt.Fprintln(w, "  Reports:")
+			tw = tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+			for _, query := range table.Queries {
+				fmt.Fprintf(tw, "    %d\t%s\t%s\t%s\n", query.Id, query.Name, query.Type, query.Criteria)
+			}
+			if err := tw.Flush(); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintln(w)
+	}
+	if len(d.Relationships) > 0 {
+		fmt.Fprintln(w, "Relationships")
+		for _, r := range d.Relationships {
+			fmt.Fprintf(w, "  %s -> %s through field %d", r.ParentDbid, r.ChildDbid, r.ReferenceFid)
+			if len(r.LookupFids) > 0 {
+				fids := make([]string, len(r.LookupFids))
+				for i, fid := range r.LookupFids {
+					fids[i] = fmt.Sprint(fid)
+				}
+				fmt.Fprintf(w, ", looking up %s", strings.Join(fids, ", "))
+			}
+			fmt.Fprintln(w)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,51 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestSchema(t *testing.T) {
+	server, conn := newServer()
+	defer server.Close()
+	server.AddTable("bck7gp3q3", map[int]string{6: "Summary"})
+	server.AddApp("bck7gp3q1", sitesDbid, "bck7gp3q3")
+	args := append([]string{"schema"}, conn...)
+
+	got := runOutput(t, append(args, "-dbid", sitesDbid)...)
+	for _, want := range []string{"Table bck7gp3q2 (bck7gp3q2)\n", "  7    Cost", "Reports:\n    1  Query 1  table  {'7'.GT.'10'}\n"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("table schema %q lacks %q", got, want)
+		}
+	}
+
+	var dump schemaDump
+	got = runOutput(t, append(args, "-dbid", "bck7gp3q1", "-format", "json")...)
+	if err := json.Unmarshal([]byte(got), &dump); err != nil {
+		t.Fatalf("%s: %s", err, got)
+	}
+	if dump.App == nil || len(dump.Tables) != 2 || dump.Tables[1].Fields[5].Label != "Summary" {
+		t.Errorf("app schema %+v", dump)
+	}
+}
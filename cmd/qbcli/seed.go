@@ -0,0 +1,83 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"github.com/WesTower/quickbase/quickbasetest"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	commands = append(commands, &command{
+		name:    "seed",
+		summary: "add random test records to a table",
+		run:     runSeed,
+	})
+}
+
+func runSeed(args []string, stdout io.Writer) error {
+	flags, conn := newFlagSet("seed")
+	dbid := flags.String("dbid", "", "table to add records to (required)")
+	n := flags.Int("n", 100, "number of records to add")
+	batchSize := flags.Int("batch", 1000, "records per API_ImportFromCSV call")
+	seed := flags.Int64("seed", 0, "random seed, for repeatable records; default the time")
+	values := flags.String("values", "", "semicolon-separated fid=value|value... lists to choose fields' values from, e.g. for reference fields")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if *dbid == "" {
+		return fmt.Errorf("seed: -dbid is required")
+	}
+	client, err := conn.connect()
+	if err != nil {
+		return err
+	}
+	schema, err := client.GetSchema(*dbid)
+	if err != nil {
+		return err
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	g := quickbasetest.NewGenerator(schema, *seed)
+	if *values != "" {
+		for _, list := range strings.Split(*values, ";") {
+			i := strings.Index(list, "=")
+			if i < 0 {
+				return fmt.Errorf("bad values %q; want fid=value|value...", list)
+			}
+			fid, err := strconv.Atoi(list[:i])
+			if err != nil {
+				return fmt.Errorf("bad field ID in values %q", list)
+			}
+			g.Values[fid] = strings.Split(list[i+1:], "|")
+		}
+	}
+	if err = g.Populate(client, *n, *batchSize); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "added %d records to %s (seed %d)\n", *n, *dbid, *seed)
+	return nil
+}
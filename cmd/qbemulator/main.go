@@ -0,0 +1,103 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+// Command qbemulator runs a local QuickBase emulator, so that
+// integration environments need not depend on a shared QuickBase
+// sandbox.  It speaks the qdbapi XML protocol understood by package
+// quickbase; see package quickbasetest for the actions it supports.
+//
+// Usage:
+//
+//	qbemulator [-addr localhost:8080] [-state realm.json] [-user name:password]
+//	           [-latency 100ms] [-jitter 50ms] [-failure-rate 0.01]
+//
+// The realm's users and tables are read from the -state file, if it
+// exists, and every change is written back to it.  The file is JSON,
+// and may be written by hand to describe a fresh realm:
+//
+//	{
+//	  "users": {"jdoe": {"id": "56789.abcd", "password": "secret"}},
+//	  "tables": [{"dbid": "bck7gp3q2", "name": "Sites",
+//	              "fields": {"6": "Site", "7": "Cost"}, "key_fid": 6}]
+//	}
+//
+// Point clients at the URL printed on startup, e.g.
+// quickbase.Authenticate("http://localhost:8080/", "jdoe", "secret").
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/WesTower/quickbase/quickbasetest"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+)
+
+// users collects repeated -user flags.
+type users []string
+
+func (u *users) String() string {
+	return strings.Join(*u, ",")
+}
+
+func (u *users) Set(value string) error {
+	if !strings.Contains(value, ":") {
+		return fmt.Errorf("user %q is not of the form name:password", value)
+	}
+	*u = append(*u, value)
+	return nil
+}
+
+func main() {
+	var extraUsers users
+	addr := flag.String("addr", "localhost:8080", "address on which to listen")
+	state := flag.String("state", "", "file in which to keep the realm's data")
+	latency := flag.Duration("latency", 0, "delay before every response")
+	jitter := flag.Duration("jitter", 0, "maximum random delay added to -latency")
+	failureRate := flag.Float64("failure-rate", 0, "fraction of requests to fail with 503 Service Unavailable")
+	flag.Var(&extraUsers, "user", "add a user, as name:password (repeatable)")
+	flag.Parse()
+
+	server, err := quickbasetest.NewServerAt(*addr)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "qbemulator:", err)
+		os.Exit(1)
+	}
+	defer server.Close()
+	if *state != "" {
+		if err = server.Persist(*state); err != nil {
+			fmt.Fprintln(os.Stderr, "qbemulator:", err)
+			os.Exit(1)
+		}
+	}
+	for _, u := range extraUsers {
+		i := strings.Index(u, ":")
+		server.AddUser(u[:i], u[i+1:])
+	}
+	server.SetLatency(*latency, *jitter)
+	server.SetFailureRate(*failureRate)
+
+	fmt.Println("qbemulator: serving", server.BaseUrl())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	<-signals
+}
@@ -0,0 +1,138 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// slowFactor is how many times slower than the fastest seen a call
+// must be for a ConcurrencyLimiter to count it as slow.
+const slowFactor = 2
+
+// A ConcurrencyLimiter bounds the number of calls to QuickBase in
+// flight at once, adjusting the bound to what QuickBase sustains.  The
+// bound grows by one for each bound's worth of calls answered
+// promptly, and shrinks by a quarter when calls are slow, or by half
+// when QuickBase throttles them (with a 429 or 503 status or a
+// Retry-After header), so that bulk jobs find their realm's
+// sustainable throughput without hand-tuned worker counts.  Like a
+// RateLimiter, it may be shared between goroutines and Clients.
+type ConcurrencyLimiter struct {
+	mutex    sync.Mutex
+	cond     *sync.Cond
+	min, max float64
+	limit    float64       // calls allowed in flight
+	inFlight int           // calls in flight
+	fastest  time.Duration // baseline latency, against which slowness is judged
+	cut      time.Time     // when the limit was last cut
+}
+
+// NewConcurrencyLimiter returns a ConcurrencyLimiter allowing between
+// min (at least 1) and max calls in flight, starting with min.
+func NewConcurrencyLimiter(min, max int) *ConcurrencyLimiter {
+	if min < 1 {
+		min = 1
+	}
+	if max < min {
+		max = min
+	}
+	l := &ConcurrencyLimiter{min: float64(min), max: float64(max), limit: float64(min)}
+	l.cond = sync.NewCond(&l.mutex)
+	return l
+}
+
+// Limit returns the number of calls currently allowed in flight.
+func (l *ConcurrencyLimiter) Limit() int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return int(l.limit)
+}
+
+// acquire blocks until a call may be made, and returns its start,
+// unless ctx is done first, when it returns ctx.Err().
+func (l *ConcurrencyLimiter) acquire(ctx context.Context) (start time.Time, err error) {
+	// wake the waiters once ctx is done, so that this one notices
+	stop := context.AfterFunc(ctx, func() {
+		l.mutex.Lock()
+		l.cond.Broadcast()
+		l.mutex.Unlock()
+	})
+	defer stop()
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	for l.inFlight >= int(l.limit) {
+		if err = ctx.Err(); err != nil {
+			return start, err
+		}
+		l.cond.Wait()
+	}
+	l.inFlight++
+	return time.Now(), nil
+}
+
+// release ends a call begun at start, adjusting the limit by its
+// response, which is nil if the call failed without one.
+func (l *ConcurrencyLimiter) release(start time.Time, resp *http.Response) {
+	latency := time.Since(start)
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.inFlight--
+	defer l.cond.Broadcast()
+	if resp == nil {
+		return
+	}
+	throttled := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable || resp.Header.Get("Retry-After") != ""
+	slow := l.fastest > 0 && latency > slowFactor*l.fastest
+	if !throttled {
+		if l.fastest == 0 || latency < l.fastest {
+			l.fastest = latency
+		} else {
+			// drift toward the latencies seen, lest one lucky
+			// call make every other seem slow forever
+			l.fastest += (latency - l.fastest) / 64
+		}
+	}
+	switch {
+	case throttled || slow:
+		// calls started before the last cut were made under the
+		// old limit, and do not call for another
+		if start.Before(l.cut) {
+			return
+		}
+		if throttled {
+			l.limit /= 2
+		} else {
+			l.limit *= 0.75
+		}
+		if l.limit < l.min {
+			l.limit = l.min
+		}
+		l.cut = time.Now()
+	default:
+		if l.limit += 1 / l.limit; l.limit > l.max {
+			l.limit = l.max
+		}
+	}
+}
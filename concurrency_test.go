@@ -0,0 +1,102 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"github.com/WesTower/quickbase"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// concurrencyFront serves through a fake QuickBase, recording the most
+// calls it sees at once and throttling those beyond capacity.
+func concurrencyFront(t *testing.T, capacity int32) (client *quickbase.Client, peak *int32, done func()) {
+	server := newServer()
+	var inFlight int32
+	peak = new(int32)
+	front := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for p := atomic.LoadInt32(peak); n > p && !atomic.CompareAndSwapInt32(peak, p, n); p = atomic.LoadInt32(peak) {
+		}
+		time.Sleep(2 * time.Millisecond)
+		if n > capacity {
+			http.Error(w, "Too Many Requests", http.StatusTooManyRequests)
+			return
+		}
+		server.ServeHTTP(w, r)
+	}))
+	ticket, err := quickbase.Authenticate(front.URL+"/", "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	atomic.StoreInt32(peak, 0)
+	return &quickbase.Client{Ticket: ticket}, peak, func() {
+		front.Close()
+		server.Close()
+	}
+}
+
+// hammer makes calls calls from workers goroutines at once.
+func hammer(client *quickbase.Client, workers, calls int) {
+	var wg sync.WaitGroup
+	var made int32
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for atomic.AddInt32(&made, 1) <= int32(calls) {
+				client.DoQueryCount(testTableDbid, "")
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestConcurrencyLimiterGrows(t *testing.T) {
+	client, peak, done := concurrencyFront(t, 100)
+	defer done()
+	client.Concurrency = quickbase.NewConcurrencyLimiter(1, 6)
+	hammer(client, 16, 300)
+	if n := atomic.LoadInt32(peak); n > 6 {
+		t.Errorf("%d calls were in flight at once", n)
+	}
+	if limit := client.Concurrency.Limit(); limit < 2 {
+		t.Errorf("limit stayed at %d", limit)
+	}
+}
+
+func TestConcurrencyLimiterThrottled(t *testing.T) {
+	client, peak, done := concurrencyFront(t, 3)
+	defer done()
+	client.Concurrency = quickbase.NewConcurrencyLimiter(2, 16)
+	hammer(client, 16, 300)
+	if n := atomic.LoadInt32(peak); n > 16 {
+		t.Errorf("%d calls were in flight at once", n)
+	}
+	if limit := client.Concurrency.Limit(); limit > 6 {
+		t.Errorf("limit grew to %d despite throttling", limit)
+	}
+}
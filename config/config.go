@@ -0,0 +1,421 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+// Package config builds a quickbase.Client from environment
+// variables, a configuration file, or both, so that programs need not
+// each read their own settings.
+//
+// A configuration file may be JSON:
+//
+//	{
+//	  "url": "https://example.quickbase.com/",
+//	  "usertoken": "b2fr52_xyz_0_abcdefghijklmnopqrstuvwxyz",
+//	  "apptoken": "cmzaaz3dgdmmwwksdb7zcd7a9wg",
+//	  "timeout": "30s",
+//	  "rate_interval": "200ms"
+//	}
+//
+// or YAML, of which the flat mappings of scalars used here are
+// understood:
+//
+//	url: https://example.quickbase.com/
+//	username: jdoe
+//	password: "s3cret: really"   # quote values containing ': ' or ' #'
+//	timeout: 30s
+//
+// Each setting may also be given by an environment variable, which
+// overrides the file: QUICKBASE_URL, QUICKBASE_AUTH,
+// QUICKBASE_USERNAME, QUICKBASE_PASSWORD, QUICKBASE_USERTOKEN,
+// QUICKBASE_APPTOKEN, QUICKBASE_TIMEOUT, QUICKBASE_RATE_INTERVAL,
+// QUICKBASE_MAX_CONCURRENCY, QUICKBASE_MAX_IDLE_CONNS,
+// QUICKBASE_MAX_IDLE_CONNS_PER_HOST, QUICKBASE_IDLE_CONN_TIMEOUT,
+// QUICKBASE_TLS_HANDSHAKE_TIMEOUT, QUICKBASE_EXPECT_CONTINUE_TIMEOUT,
+// QUICKBASE_DISABLE_HTTP2, QUICKBASE_DISABLE_COMPRESSION,
+// QUICKBASE_MAX_REDIRECTS, QUICKBASE_PROXY, QUICKBASE_TICKET_CACHE,
+// QUICKBASE_TICKET_CACHE_KEY and QUICKBASE_KEYRING.
+// QUICKBASE_CONFIG names the file Load reads if given none.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"github.com/WesTower/quickbase/keyring"
+	"io/ioutil"
+	neturl "net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Config holds the settings from which a Client is built.
+type Config struct {
+	Url       string `json:"url"`  // realm URL, e.g. 'https://example.quickbase.com/'
+	Auth      string `json:"auth"` // 'password' or 'usertoken'; if empty, whichever credentials are set
+	Username  string `json:"username"`
+	Password  string `json:"password"`
+	Usertoken string `json:"usertoken"`
+	Apptoken  string `json:"apptoken"`
+	// Timeout limits each HTTP request, including reading the
+	// response; zero means no limit.
+	Timeout Duration `json:"timeout"`
+	// RateInterval is the least time between the starts of calls;
+	// zero means calls are paced only as QuickBase asks.
+	RateInterval Duration `json:"rate_interval"`
+	// MaxConcurrency, if set, bounds the calls in flight at once
+	// with a quickbase.ConcurrencyLimiter adapting between one and
+	// this many.
+	MaxConcurrency int `json:"max_concurrency"`
+	// The remaining settings tune the client's connections, as
+	// quickbase.TransportOptions; zero values take the defaults of
+	// quickbase.DefaultTransport.
+	MaxIdleConns          int      `json:"max_idle_conns"`
+	MaxIdleConnsPerHost   int      `json:"max_idle_conns_per_host"`
+	IdleConnTimeout       Duration `json:"idle_conn_timeout"`
+	TLSHandshakeTimeout   Duration `json:"tls_handshake_timeout"`
+	ExpectContinueTimeout Duration `json:"expect_continue_timeout"`
+	DisableHTTP2          bool     `json:"disable_http2"`
+	// DisableCompression stops the client asking for gzipped
+	// responses.
+	DisableCompression bool `json:"disable_compression"`
+	// MaxRedirects limits the redirects followed by a call; zero
+	// means Go's default of 10, and a negative number none.
+	MaxRedirects int `json:"max_redirects"`
+	// Proxy is the URL of the proxy through which calls are sent;
+	// if empty, that named by $HTTPS_PROXY, if any.
+	Proxy string `json:"proxy"`
+	// TicketCache, if set, names a file in which the ticket got by
+	// password authentication is kept, encrypted with TicketCacheKey,
+	// for later runs to reuse; see quickbase.TicketCache.
+	TicketCache    string `json:"ticket_cache"`
+	TicketCacheKey string `json:"ticket_cache_key"`
+	// Keyring, if set, names the service under which the OS keyring
+	// holds the credentials for Url, stored by a keyring.Provider;
+	// they are used unless others are configured.
+	Keyring string `json:"keyring"`
+}
+
+// A Duration is a time.Duration which reads from JSON either as a
+// string understood by time.ParseDuration, e.g. '1m30s', or as a
+// number of seconds.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) (err error) {
+	var secs float64
+	if err = json.Unmarshal(data, &secs); err == nil {
+		*d = Duration(secs * float64(time.Second))
+		return nil
+	}
+	var s string
+	if err = json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("Bad duration %s", data)
+	}
+	return d.set(s)
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+func (d *Duration) set(s string) error {
+	if secs, err := strconv.ParseFloat(s, 64); err == nil {
+		*d = Duration(secs * float64(time.Second))
+		return nil
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return fmt.Errorf("Bad duration %q", s)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Load reads the configuration file at path, or at $QUICKBASE_CONFIG
+// if path is empty, and overrides its settings with those of the
+// environment.  With neither a path nor $QUICKBASE_CONFIG, only the
+// environment is read.
+func Load(path string) (config Config, err error) {
+	if path == "" {
+		path = os.Getenv("QUICKBASE_CONFIG")
+	}
+	if path != "" {
+		if config, err = ReadFile(path); err != nil {
+			return config, err
+		}
+	}
+	return config, config.readEnv()
+}
+
+// FromEnv returns the configuration given by environment variables.
+func FromEnv() (config Config, err error) {
+	return config, config.readEnv()
+}
+
+// ReadFile reads a JSON or YAML configuration file; it is JSON if its
+// name ends in '.json' or its contents begin with '{'.  Unknown
+// settings are refused in either, lest a misspelt one be ignored.
+func ReadFile(path string) (config Config, err error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return config, err
+	}
+	if strings.HasSuffix(path, ".json") || strings.HasPrefix(strings.TrimSpace(string(data)), "{") {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err = decoder.Decode(&config); err != nil {
+			return config, fmt.Errorf("Bad configuration file %s: %s", path, err)
+		}
+		return config, nil
+	}
+	values, err := parseYaml(string(data))
+	if err != nil {
+		return config, fmt.Errorf("Bad configuration file %s: %s", path, err)
+	}
+	for key, value := range values {
+		if err = config.set(key, value); err != nil {
+			return config, fmt.Errorf("Bad configuration file %s: %s", path, err)
+		}
+	}
+	return config, nil
+}
+
+// set sets the setting with the given JSON name.
+func (c *Config) set(key, value string) error {
+	switch key {
+	case "url":
+		c.Url = value
+	case "auth":
+		c.Auth = value
+	case "username":
+		c.Username = value
+	case "password":
+		c.Password = value
+	case "usertoken":
+		c.Usertoken = value
+	case "apptoken":
+		c.Apptoken = value
+	case "timeout":
+		return c.Timeout.set(value)
+	case "rate_interval":
+		return c.RateInterval.set(value)
+	case "max_concurrency":
+		return setInt(&c.MaxConcurrency, value)
+	case "max_idle_conns":
+		return setInt(&c.MaxIdleConns, value)
+	case "max_idle_conns_per_host":
+		return setInt(&c.MaxIdleConnsPerHost, value)
+	case "idle_conn_timeout":
+		return c.IdleConnTimeout.set(value)
+	case "tls_handshake_timeout":
+		return c.TLSHandshakeTimeout.set(value)
+	case "expect_continue_timeout":
+		return c.ExpectContinueTimeout.set(value)
+	case "disable_http2":
+		return setBool(&c.DisableHTTP2, value)
+	case "disable_compression":
+		return setBool(&c.DisableCompression, value)
+	case "max_redirects":
+		return setInt(&c.MaxRedirects, value)
+	case "proxy":
+		c.Proxy = value
+	case "ticket_cache":
+		c.TicketCache = value
+	case "ticket_cache_key":
+		c.TicketCacheKey = value
+	case "keyring":
+		c.Keyring = value
+	default:
+		return fmt.Errorf("Unknown setting %q", key)
+	}
+	return nil
+}
+
+func setInt(n *int, value string) (err error) {
+	if *n, err = strconv.Atoi(value); err != nil {
+		return fmt.Errorf("Bad number %q", value)
+	}
+	return nil
+}
+
+func setBool(b *bool, value string) (err error) {
+	if *b, err = strconv.ParseBool(value); err != nil {
+		return fmt.Errorf("Bad boolean %q", value)
+	}
+	return nil
+}
+
+var envSettings = []string{"url", "auth", "username", "password", "usertoken", "apptoken", "timeout", "rate_interval",
+	"max_concurrency", "max_idle_conns", "max_idle_conns_per_host", "idle_conn_timeout", "tls_handshake_timeout", "expect_continue_timeout",
+	"disable_http2", "disable_compression", "max_redirects", "proxy", "ticket_cache", "ticket_cache_key", "keyring"}
+
+func (c *Config) readEnv() error {
+	for _, key := range envSettings {
+		name := "QUICKBASE_" + strings.ToUpper(key)
+		if value := os.Getenv(name); value != "" {
+			if err := c.set(key, value); err != nil {
+				return fmt.Errorf("Bad %s: %s", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// parseYaml parses a flat YAML mapping of scalars.
+func parseYaml(data string) (values map[string]string, err error) {
+	values = make(map[string]string)
+	for n, line := range strings.Split(data, "\n") {
+		line = strings.TrimRight(line, " \t\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || trimmed == "---" {
+			continue
+		}
+		if trimmed != line {
+			return nil, fmt.Errorf("line %d: nested values are not supported", n+1)
+		}
+		i := strings.Index(line, ":")
+		if i < 0 {
+			return nil, fmt.Errorf("line %d: expected key: value", n+1)
+		}
+		key, value := strings.TrimSpace(line[:i]), strings.TrimSpace(line[i+1:])
+		if value, err = yamlScalar(value); err != nil {
+			return nil, fmt.Errorf("line %d: %s", n+1, err)
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// yamlScalar returns the value of a plain, single- or double-quoted
+// scalar, less any trailing comment.
+func yamlScalar(s string) (string, error) {
+	switch {
+	case strings.HasPrefix(s, `"`):
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '\\':
+				i++
+			case '"':
+				return strconv.Unquote(s[:i+1])
+			}
+		}
+		return "", fmt.Errorf("unterminated string")
+	case strings.HasPrefix(s, "'"):
+		for i := 1; i < len(s); i++ {
+			if s[i] == '\'' {
+				if i+1 < len(s) && s[i+1] == '\'' {
+					i++
+					continue
+				}
+				return strings.Replace(s[1:i], "''", "'", -1), nil
+			}
+		}
+		return "", fmt.Errorf("unterminated string")
+	}
+	if i := strings.Index(s, " #"); i >= 0 {
+		s = strings.TrimSpace(s[:i])
+	}
+	return s, nil
+}
+
+// Client returns a Client built from the configuration, authenticated
+// with a password or a user token.
+func (c Config) Client() (client *quickbase.Client, err error) {
+	if c.Url == "" {
+		return nil, fmt.Errorf("No QuickBase URL configured")
+	}
+	url := c.Url
+	if !strings.HasSuffix(url, "/") {
+		url += "/"
+	}
+	client = &quickbase.Client{}
+	options := quickbase.TransportOptions{
+		MaxIdleConns:          c.MaxIdleConns,
+		MaxIdleConnsPerHost:   c.MaxIdleConnsPerHost,
+		IdleConnTimeout:       time.Duration(c.IdleConnTimeout),
+		TLSHandshakeTimeout:   time.Duration(c.TLSHandshakeTimeout),
+		ExpectContinueTimeout: time.Duration(c.ExpectContinueTimeout),
+		DisableHTTP2:          c.DisableHTTP2,
+	}
+	if c.Proxy != "" {
+		if options.Proxy, err = neturl.Parse(c.Proxy); err != nil {
+			return nil, fmt.Errorf("Bad proxy URL %q", c.Proxy)
+		}
+	}
+	if c.Timeout != 0 || c.MaxRedirects != 0 || options != (quickbase.TransportOptions{}) {
+		httpClient := *quickbase.DefaultClient
+		httpClient.Timeout = time.Duration(c.Timeout)
+		if options != (quickbase.TransportOptions{}) {
+			httpClient.Transport = quickbase.NewTransport(options)
+		}
+		if c.MaxRedirects < 0 {
+			httpClient.CheckRedirect = quickbase.LimitRedirects(0)
+		} else if c.MaxRedirects > 0 {
+			httpClient.CheckRedirect = quickbase.LimitRedirects(c.MaxRedirects)
+		}
+		client.HttpClient = &httpClient
+	}
+	client.DisableCompression = c.DisableCompression
+	client.Limiter = quickbase.NewRateLimiter(time.Duration(c.RateInterval))
+	if c.MaxConcurrency > 0 {
+		client.Concurrency = quickbase.NewConcurrencyLimiter(1, c.MaxConcurrency)
+	}
+	if c.Keyring != "" && c.Usertoken == "" && (c.Username == "" || c.Password == "") {
+		credentials, err := keyring.Provider{Keyring: keyring.System(c.Keyring)}.Credentials(url)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot read credentials from keyring %s: %s", c.Keyring, err)
+		}
+		c.Username, c.Password, c.Usertoken = credentials.Username, credentials.Password, credentials.Usertoken
+	}
+	auth := c.Auth
+	if auth == "" {
+		auth = "password"
+		if c.Usertoken != "" {
+			auth = "usertoken"
+		}
+	}
+	switch auth {
+	case "usertoken":
+		if c.Usertoken == "" {
+			return nil, fmt.Errorf("No user token configured")
+		}
+		client.Ticket = quickbase.UserTokenTicket(url, c.Usertoken)
+	case "password":
+		if c.Username == "" || c.Password == "" {
+			return nil, fmt.Errorf("No username and password, or user token, configured")
+		}
+		if c.TicketCache == "" {
+			err = client.Authenticate(url, c.Username, c.Password)
+		} else {
+			var cache *quickbase.TicketCache
+			if cache, err = quickbase.NewTicketCache(c.TicketCache, []byte(c.TicketCacheKey)); err == nil {
+				err = client.AuthenticateCached(cache, url, c.Username, c.Password)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("Unknown auth mode %q; want password or usertoken", c.Auth)
+	}
+	client.Ticket.Apptoken = c.Apptoken
+	return client, nil
+}
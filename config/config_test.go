@@ -0,0 +1,195 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package config_test
+
+import (
+	"github.com/WesTower/quickbase/config"
+	"github.com/WesTower/quickbase/quickbasetest"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, name, contents string) (path string, cleanup func()) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path = filepath.Join(dir, name)
+	if err = ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path, func() { os.RemoveAll(dir) }
+}
+
+func setenv(t *testing.T, name, value string) (restore func()) {
+	old, had := os.LookupEnv(name)
+	os.Setenv(name, value)
+	return func() {
+		if had {
+			os.Setenv(name, old)
+		} else {
+			os.Unsetenv(name)
+		}
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	want := config.Config{
+		Url:          "https://example.quickbase.com/",
+		Username:     "jdoe",
+		Password:     "s3cret: #1",
+		Apptoken:     "it's",
+		Timeout:      config.Duration(30 * time.Second),
+		RateInterval: config.Duration(250 * time.Millisecond),
+
+		MaxConcurrency:      8,
+		MaxIdleConnsPerHost: 64,
+		IdleConnTimeout:     config.Duration(2 * time.Minute),
+		DisableHTTP2:        true,
+		MaxRedirects:        -1,
+	}
+	files := map[string]string{
+		"qb.json": `{"url": "https://example.quickbase.com/", "username": "jdoe", "password": "s3cret: #1",
+			"apptoken": "it's", "timeout": 30, "rate_interval": "250ms", "max_concurrency": 8, "max_idle_conns_per_host": 64,
+			"idle_conn_timeout": "2m", "disable_http2": true, "max_redirects": -1}`,
+		"qb.yaml": "---\n# QuickBase settings\nurl: https://example.quickbase.com/  # the realm\nusername: jdoe\n" +
+			"password: \"s3cret: #1\"\napptoken: 'it''s'\ntimeout: 30s\nrate_interval: 0.25\n" +
+			"max_concurrency: 8\nmax_idle_conns_per_host: 64\nidle_conn_timeout: 120\ndisable_http2: true\nmax_redirects: -1\n",
+	}
+	for name, contents := range files {
+		path, cleanup := writeConfig(t, name, contents)
+		defer cleanup()
+		got, err := config.ReadFile(path)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+		} else if got != want {
+			t.Errorf("%s gave %+v, want %+v", name, got, want)
+		}
+	}
+	for name, contents := range map[string]string{
+		"nested.yaml":  "url:\n  host: example\n",
+		"unknown.yaml": "colour: red\n",
+		"unknown.json": `{"url": "https://example.quickbase.com/", "usertokn": "b2fr52_xyz"}`,
+		"bad.json":     `{"timeout": "soon"}`,
+		"bad.yaml":     "max_idle_conns: many\n",
+	} {
+		path, cleanup := writeConfig(t, name, contents)
+		defer cleanup()
+		if _, err := config.ReadFile(path); err == nil {
+			t.Errorf("%s was read without error", name)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path, cleanup := writeConfig(t, "qb.yaml", "url: https://example.quickbase.com/\nusertoken: abc\ntimeout: 5s\n")
+	defer cleanup()
+	defer setenv(t, "QUICKBASE_CONFIG", path)()
+	defer setenv(t, "QUICKBASE_USERTOKEN", "def")()
+	got, err := config.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Url != "https://example.quickbase.com/" || got.Usertoken != "def" || got.Timeout != config.Duration(5*time.Second) {
+		t.Errorf("Load gave %+v", got)
+	}
+	defer setenv(t, "QUICKBASE_TIMEOUT", "later")()
+	if _, err = config.Load(""); err == nil {
+		t.Error("a bad QUICKBASE_TIMEOUT was accepted")
+	}
+}
+
+func TestClient(t *testing.T) {
+	server := quickbasetest.NewServer()
+	defer server.Close()
+	server.AddUser("jdoe", "secret")
+	server.AddUserToken("jdoe", "b2fr52_xyz")
+	server.AddTable("bck7gp3q2", map[int]string{6: "Site"})
+	url := server.BaseUrl()
+
+	for _, c := range []config.Config{
+		{Url: url, Username: "jdoe", Password: "secret", Timeout: config.Duration(time.Second)},
+		{Url: url[:len(url)-1], Usertoken: "b2fr52_xyz", RateInterval: config.Duration(time.Millisecond), MaxConcurrency: 4},
+		{Url: url, Auth: "usertoken", Username: "jdoe", Password: "wrong", Usertoken: "b2fr52_xyz"},
+		{Url: url, Usertoken: "b2fr52_xyz", MaxIdleConnsPerHost: 64, TLSHandshakeTimeout: config.Duration(time.Second)},
+		{Url: url, Usertoken: "b2fr52_xyz", DisableHTTP2: true, DisableCompression: true, MaxRedirects: -1},
+	} {
+		client, err := c.Client()
+		if err != nil {
+			t.Errorf("%+v: %v", c, err)
+			continue
+		}
+		if _, err = client.DoQueryCount("bck7gp3q2", ""); err != nil {
+			t.Errorf("%+v: %v", c, err)
+		}
+	}
+	for _, c := range []config.Config{
+		{Username: "jdoe", Password: "secret"},
+		{Url: url, Username: "jdoe", Password: "wrong"},
+		{Url: url, Auth: "usertoken", Username: "jdoe", Password: "secret"},
+		{Url: url, Auth: "oauth"},
+		{Url: url, Usertoken: "b2fr52_xyz", Proxy: "http://%zz"},
+	} {
+		if _, err := c.Client(); err == nil {
+			t.Errorf("%+v gave a Client", c)
+		}
+	}
+}
+
+func TestClientTicketCache(t *testing.T) {
+	server := quickbasetest.NewServer()
+	defer server.Close()
+	server.AddUser("jdoe", "secret")
+	server.AddTable("bck7gp3q2", map[int]string{6: "Site"})
+	path, cleanup := writeConfig(t, "tickets", "")
+	defer cleanup()
+	os.Remove(path)
+	c := config.Config{Url: server.BaseUrl(), Username: "jdoe", Password: "secret", TicketCache: path, TicketCacheKey: "k3y"}
+	if _, err := c.Client(); err != nil {
+		t.Fatal(err)
+	}
+	c.Password = "wrong"
+	client, err := c.Client()
+	if err != nil {
+		t.Fatalf("cached ticket was not reused: %v", err)
+	}
+	if _, err = client.DoQueryCount("bck7gp3q2", ""); err != nil {
+		t.Error(err)
+	}
+	c.TicketCacheKey = ""
+	if _, err = c.Client(); err == nil {
+		t.Error("ticket cache without a key gave a Client")
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	server := quickbasetest.NewServer()
+	defer server.Close()
+	server.AddUser("jdoe", "secret")
+	server.SetLatency(200*time.Millisecond, 0)
+	c := config.Config{Url: server.BaseUrl(), Username: "jdoe", Password: "secret", Timeout: config.Duration(20 * time.Millisecond)}
+	if _, err := c.Client(); err == nil {
+		t.Error("authentication did not time out")
+	}
+}
@@ -0,0 +1,79 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"context"
+	"github.com/WesTower/quickbase"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithContext(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.SetLatency(200*time.Millisecond, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	bounded := client.WithContext(ctx)
+	if _, err = bounded.DoStructuredQuery(testTableDbid, "", "6", "", ""); err != context.DeadlineExceeded {
+		t.Errorf("DoStructuredQuery past its deadline gave %v", err)
+	}
+	if err = bounded.Upload(testTableDbid, 1, 7, "a.txt", strings.NewReader("hello")); err != context.DeadlineExceeded {
+		t.Errorf("Upload past its deadline gave %v", err)
+	}
+	if _, err = quickbase.DoQueryCount(client.Ticket.WithContext(ctx), testTableDbid, ""); err != context.DeadlineExceeded {
+		t.Errorf("DoQueryCount past its deadline gave %v", err)
+	}
+	if count, err := client.DoQueryCount(testTableDbid, ""); err != nil || count != 2 {
+		t.Errorf("Client without a context gave %d, %v", count, err)
+	}
+
+	client.Close()
+	if _, err = bounded.DoQueryCount(testTableDbid, ""); err != quickbase.ErrClosed {
+		t.Errorf("copy of a closed Client gave %v", err)
+	}
+}
+
+func TestWithContextBackOff(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Limiter = quickbase.NewRateLimiter(0)
+	client.Limiter.BackOff(time.Now().Add(time.Hour))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err = client.WithContext(ctx).DoQueryCount(testTableDbid, ""); err != context.DeadlineExceeded {
+		t.Errorf("DoQueryCount backed off past its deadline gave %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("DoQueryCount waited %s for the back-off", elapsed)
+	}
+}
@@ -0,0 +1,56 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"github.com/WesTower/quickbase/config"
+	"github.com/WesTower/quickbase/quickbasetest"
+	"os"
+	"strconv"
+	"testing"
+)
+
+// TestSandboxContract runs the contract tests against a real sandbox
+// table, if QUICKBASE_CONTRACT_DBID and QUICKBASE_CONTRACT_TEXT_FID
+// name one.  The connection is configured as for package config;
+// QUICKBASE_CONTRACT_NUMBER_FID, QUICKBASE_CONTRACT_FILE_FID and
+// QUICKBASE_CONTRACT_APP_DBID enable further checks.
+func TestSandboxContract(t *testing.T) {
+	cfg := quickbasetest.ContractConfig{
+		Dbid:    os.Getenv("QUICKBASE_CONTRACT_DBID"),
+		AppDbid: os.Getenv("QUICKBASE_CONTRACT_APP_DBID"),
+	}
+	cfg.TextFid, _ = strconv.Atoi(os.Getenv("QUICKBASE_CONTRACT_TEXT_FID"))
+	cfg.NumberFid, _ = strconv.Atoi(os.Getenv("QUICKBASE_CONTRACT_NUMBER_FID"))
+	cfg.FileFid, _ = strconv.Atoi(os.Getenv("QUICKBASE_CONTRACT_FILE_FID"))
+	if cfg.Dbid == "" || cfg.TextFid == 0 {
+		t.Skip("QUICKBASE_CONTRACT_DBID and QUICKBASE_CONTRACT_TEXT_FID are not set")
+	}
+	settings, err := config.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, err := settings.Client()
+	if err != nil {
+		t.Fatal(err)
+	}
+	quickbasetest.RunContractTests(t, client, cfg)
+}
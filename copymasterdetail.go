@@ -0,0 +1,93 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// CopyMasterDetailOptions control what CopyMasterDetail copies.
+type CopyMasterDetailOptions struct {
+	// DestRid is an existing master record to copy the details to.
+	// If zero, the master record is itself copied.
+	DestRid int
+	// CopyFid is the field of the master table, typically its name,
+	// whose value in the copy is prefixed with 'Copy of'.  It is
+	// required when DestRid is zero.
+	CopyFid int
+	// Recurse copies the details of the details, and so on.
+	Recurse bool
+	// RelFids are the reference fields, in the detail tables, of the
+	// relationships whose details are copied; if empty, all are.
+	RelFids []int
+}
+
+// CopyMasterDetail copies the record sourceRid of the master table
+// dbid, with its detail records, in one call, returning the record ID
+// of the new master record, or options.DestRid.
+func CopyMasterDetail(ticket Ticket, dbid string, sourceRid int, options CopyMasterDetailOptions) (parentRid int, err error) {
+	call, err := copyMasterDetailCall(ticket, dbid, sourceRid, options)
+	if err != nil {
+		return 0, err
+	}
+	var result copyMasterDetailResponse
+	if err = call.execute(&result); err != nil {
+		return 0, err
+	}
+	return result.parentRid()
+}
+
+func copyMasterDetailCall(ticket Ticket, dbid string, sourceRid int, options CopyMasterDetailOptions) (call apiCall, err error) {
+	if options.DestRid == 0 && options.CopyFid == 0 {
+		return call, fmt.Errorf("CopyFid is required to copy master record %d", sourceRid)
+	}
+	params := ticket.params()
+	params["sourcerid"] = strconv.Itoa(sourceRid)
+	params["destrid"] = strconv.Itoa(options.DestRid)
+	if options.CopyFid != 0 {
+		params["copyfid"] = strconv.Itoa(options.CopyFid)
+	}
+	params["recurse"] = strconv.FormatBool(options.Recurse)
+	if len(options.RelFids) == 0 {
+		params["relfids"] = "all"
+	} else {
+		fids := make([]string, len(options.RelFids))
+		for i, fid := range options.RelFids {
+			fids[i] = strconv.Itoa(fid)
+		}
+		params["relfids"] = strings.Join(fids, ",")
+	}
+	return apiCall{ticket, ticket.url + "db/" + dbid, "API_CopyMasterDetail", params}, nil
+}
+
+func (c *Client) CopyMasterDetail(dbid string, sourceRid int, options CopyMasterDetailOptions) (parentRid int, err error) {
+	call, err := copyMasterDetailCall(c.ticket(), dbid, sourceRid, options)
+	if err != nil {
+		return 0, err
+	}
+	var result copyMasterDetailResponse
+	if err = c.mutate(call, &result); err != nil || c.DryRun {
+		return 0, err
+	}
+	return result.parentRid()
+}
@@ -0,0 +1,66 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"encoding/xml"
+	"github.com/WesTower/quickbase"
+	"github.com/WesTower/quickbase/quickbasetest"
+	"testing"
+)
+
+func TestCopyMasterDetail(t *testing.T) {
+	fixture := quickbasetest.NewFixtureServer(map[string][]byte{"API_CopyMasterDetail": []byte(`<?xml version="1.0" ?>
+<qdbapi><action>API_CopyMasterDetail</action><errcode>0</errcode><errtext>No error</errtext>
+<parentrid>12</parentrid><numCreated>5</numCreated></qdbapi>`)})
+	defer fixture.Close()
+	client, err := quickbase.Login(fixture.BaseUrl(), "fixture", "fixture")
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := quickbase.CopyMasterDetailOptions{CopyFid: 6, Recurse: true, RelFids: []int{8, 9}}
+	if rid, err := client.CopyMasterDetail(testTableDbid, 3, options); err != nil || rid != 12 {
+		t.Errorf("CopyMasterDetail gave %d, %v", rid, err)
+	}
+	if _, err = client.CopyMasterDetail(testTableDbid, 3, quickbase.CopyMasterDetailOptions{}); err == nil {
+		t.Error("CopyMasterDetail succeeded without CopyFid or DestRid")
+	}
+
+	var body []byte
+	client.DryRun = true
+	client.DryRunLog = func(req quickbase.DryRunRequest) { body = req.Body }
+	if _, err = client.CopyMasterDetail(testTableDbid, 3, options); err != nil {
+		t.Fatal(err)
+	}
+	var request struct {
+		SourceRid string `xml:"sourcerid"`
+		DestRid   string `xml:"destrid"`
+		CopyFid   string `xml:"copyfid"`
+		Recurse   string `xml:"recurse"`
+		RelFids   string `xml:"relfids"`
+	}
+	if err = xml.Unmarshal(body, &request); err != nil {
+		t.Fatalf("%s: %v", body, err)
+	}
+	if request.SourceRid != "3" || request.DestRid != "0" || request.CopyFid != "6" || request.Recurse != "true" || request.RelFids != "8,9" {
+		t.Errorf("CopyMasterDetail would have sent %s", body)
+	}
+}
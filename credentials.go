@@ -0,0 +1,81 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"fmt"
+)
+
+// Credentials are what a user authenticates with: a username and
+// password, or a user token.
+type Credentials struct {
+	Username  string `json:"username,omitempty"`
+	Password  string `json:"password,omitempty"`
+	Usertoken string `json:"usertoken,omitempty"`
+}
+
+// A CredentialsProvider supplies the credentials for a QuickBase realm,
+// given its URL, e.g. from a secrets store, so that programs need not
+// keep them in their environment or configuration.
+type CredentialsProvider interface {
+	Credentials(url string) (Credentials, error)
+}
+
+// CredentialsFunc adapts a function to a CredentialsProvider.
+type CredentialsFunc func(url string) (Credentials, error)
+
+func (f CredentialsFunc) Credentials(url string) (Credentials, error) {
+	return f(url)
+}
+
+// AuthenticateWith authenticates with the credentials provider gives
+// for url: by user token if they include one, and otherwise by
+// username and password, as Authenticate does.
+func (c *Client) AuthenticateWith(provider CredentialsProvider, url string) (err error) {
+	credentials, err := provider.Credentials(url)
+	if err != nil {
+		return err
+	}
+	switch {
+	case credentials.Usertoken != "":
+		ticket := UserTokenTicket(url, credentials.Usertoken)
+		ticket.Apptoken = c.Ticket.Apptoken
+		c.Ticket = ticket
+		return nil
+	case credentials.Username != "" && credentials.Password != "":
+		return c.Authenticate(url, credentials.Username, credentials.Password)
+	}
+	return fmt.Errorf("No username and password, or user token, provided for %s", url)
+}
+
+// NewUserTokenAuth returns a CredentialsProvider which gives the user
+// token for every realm, so that a token may be passed wherever a
+// provider is accepted, e.g. to AuthenticateWith or to
+// restapi.NewClientWith.
+func NewUserTokenAuth(token string) CredentialsProvider {
+	return userTokenAuth(token)
+}
+
+type userTokenAuth string
+
+func (a userTokenAuth) Credentials(url string) (Credentials, error) {
+	return Credentials{Usertoken: string(a)}, nil
+}
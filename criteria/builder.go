@@ -0,0 +1,176 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package criteria
+
+import (
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"strconv"
+	"strings"
+)
+
+// A FieldRef names a field, whose methods give the criteria comparing
+// it with a value.  Values are written as quickbase.EncodeValue writes
+// them, e.g. a bool as "1" or "0" and a time.Time as milliseconds since
+// the epoch; a string is written as it is, so "today" or "03-01-2015"
+// may be given for a date.  A value EncodeValue cannot encode makes a
+// criterion which Check and Validate refuse.
+type FieldRef int
+
+// Field returns a reference to the field with the given ID.
+func Field(fid int) FieldRef {
+	return FieldRef(fid)
+}
+
+func (f FieldRef) criterion(op string, value interface{}) Criterion {
+	s, err := quickbase.EncodeValue(value)
+	if err != nil {
+		s = fmt.Sprint(value)
+	}
+	return Criterion{Fid: int(f), Op: op, Value: s, err: err}
+}
+
+func (f FieldRef) Eq(value interface{}) Criterion          { return f.criterion("EX", value) }
+func (f FieldRef) Ne(value interface{}) Criterion          { return f.criterion("XEX", value) }
+func (f FieldRef) Contains(value interface{}) Criterion    { return f.criterion("CT", value) }
+func (f FieldRef) NotContains(value interface{}) Criterion { return f.criterion("XCT", value) }
+func (f FieldRef) Has(value interface{}) Criterion         { return f.criterion("HAS", value) }
+func (f FieldRef) StartsWith(value interface{}) Criterion  { return f.criterion("SW", value) }
+func (f FieldRef) Gt(value interface{}) Criterion          { return f.criterion("GT", value) }
+func (f FieldRef) Gte(value interface{}) Criterion         { return f.criterion("GTE", value) }
+func (f FieldRef) Lt(value interface{}) Criterion          { return f.criterion("LT", value) }
+func (f FieldRef) Lte(value interface{}) Criterion         { return f.criterion("LTE", value) }
+func (f FieldRef) Before(value interface{}) Criterion      { return f.criterion("BF", value) }
+func (f FieldRef) OnOrBefore(value interface{}) Criterion  { return f.criterion("OBF", value) }
+func (f FieldRef) After(value interface{}) Criterion       { return f.criterion("AF", value) }
+func (f FieldRef) OnOrAfter(value interface{}) Criterion   { return f.criterion("OAF", value) }
+func (f FieldRef) During(value interface{}) Criterion      { return f.criterion("IR", value) }
+
+// A Query builds the query, clist, slist and options arguments of
+// DoQuery and its kin.  Its methods change and return it, so that
+// calls may be chained.
+type Query struct {
+	where Expr
+	clist []int
+	slist []int
+	order []byte // 'A' or 'D' for each field of slist
+	num   int
+	skip  int
+}
+
+// Select returns a Query for the given fields, or for the default
+// columns if none are given.
+func Select(fids ...int) *Query {
+	return &Query{clist: fids}
+}
+
+// Where conjoins expr with the Query's criteria.
+func (q *Query) Where(expr Expr) *Query {
+	q.where = Conjoin(q.where, expr)
+	return q
+}
+
+// SortBy sorts the results by the field, in ascending order, after any
+// fields sorted by already.
+func (q *Query) SortBy(fid int) *Query {
+	q.slist, q.order = append(q.slist, fid), append(q.order, 'A')
+	return q
+}
+
+// SortByDesc is like SortBy, in descending order.
+func (q *Query) SortByDesc(fid int) *Query {
+	q.slist, q.order = append(q.slist, fid), append(q.order, 'D')
+	return q
+}
+
+// Limit returns at most n records.
+func (q *Query) Limit(n int) *Query {
+	q.num = n
+	return q
+}
+
+// Skip skips the first n records.
+func (q *Query) Skip(n int) *Query {
+	q.skip = n
+	return q
+}
+
+// Query returns the query string, which is empty if the Query has no
+// criteria.
+func (q *Query) Query() string {
+	if q.where == nil {
+		return ""
+	}
+	return q.where.String()
+}
+
+// Clist returns the clist.
+func (q *Query) Clist() string {
+	return joinFids(q.clist)
+}
+
+// Slist returns the slist.
+func (q *Query) Slist() string {
+	return joinFids(q.slist)
+}
+
+// Options returns the options, giving the limit, the records skipped
+// and the sort order.
+func (q *Query) Options() string {
+	var options []string
+	if q.num > 0 {
+		options = append(options, "num-"+strconv.Itoa(q.num))
+	}
+	if q.skip > 0 {
+		options = append(options, "skp-"+strconv.Itoa(q.skip))
+	}
+	if strings.IndexByte(string(q.order), 'D') >= 0 {
+		options = append(options, "sortorder-"+string(q.order))
+	}
+	return strings.Join(options, ".")
+}
+
+// Check returns an error if a value of the Query's criteria cannot be
+// encoded or written in a query string.
+func (q *Query) Check() error {
+	for _, c := range Criteria(q.where) {
+		if err := checkValue(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Run checks the Query and runs it with qb.DoStructuredQuery.
+func (q *Query) Run(qb quickbase.QuickBase, dbid string) (records []map[int]string, err error) {
+	if err = q.Check(); err != nil {
+		return nil, err
+	}
+	return qb.DoStructuredQuery(dbid, q.Query(), q.Clist(), q.Slist(), q.Options())
+}
+
+func joinFids(fids []int) string {
+	parts := make([]string, len(fids))
+	for i, fid := range fids {
+		parts[i] = strconv.Itoa(fid)
+	}
+	return strings.Join(parts, ".")
+}
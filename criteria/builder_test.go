@@ -0,0 +1,93 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package criteria_test
+
+import (
+	"github.com/WesTower/quickbase"
+	"github.com/WesTower/quickbase/criteria"
+	"github.com/WesTower/quickbase/quickbasetest"
+	"testing"
+	"time"
+)
+
+func TestBuilder(t *testing.T) {
+	due := time.Date(2015, 3, 1, 0, 0, 0, 0, time.UTC)
+	q := criteria.Select(6, 7).
+		Where(criteria.Field(6).Eq("O'Brien").Or(criteria.Field(6).StartsWith("Den"))).
+		Where(criteria.Field(7).Gt(5).And(criteria.Field(8).OnOrAfter(due), criteria.Field(9).Eq(true))).
+		SortBy(6).SortByDesc(7).Limit(10).Skip(20)
+	for _, test := range []struct{ got, want string }{
+		{q.Query(), "({'6'.EX.'O'Brien'}OR{'6'.SW.'Den'})AND{'7'.GT.'5'}AND{'8'.OAF.'1425168000000'}AND{'9'.EX.'1'}"},
+		{q.Clist(), "6.7"},
+		{q.Slist(), "6.7"},
+		{q.Options(), "num-10.skp-20.sortorder-AD"},
+		{criteria.Select().SortBy(6).Options(), ""},
+		{criteria.Select().Query(), ""},
+	} {
+		if test.got != test.want {
+			t.Errorf("got %s, want %s", test.got, test.want)
+		}
+	}
+	if err := q.Check(); err != nil {
+		t.Error(err)
+	}
+	if err := criteria.Select().Where(criteria.Field(6).Eq("a'}b")).Check(); err == nil {
+		t.Error("Check of an unwritable value succeeded")
+	}
+	if err := criteria.Select().Where(criteria.Field(6).Eq([]string{"a"})).Check(); err == nil {
+		t.Error("Check of an unencodable value succeeded")
+	}
+}
+
+func TestBuilderRun(t *testing.T) {
+	server := quickbasetest.NewServer()
+	defer server.Close()
+	server.AddUser("jdoe", "secret")
+	server.AddTable("bck7gp3q2", map[int]string{6: "Site", 7: "Cost"})
+	server.Seed("bck7gp3q2", map[int]string{6: "O'Brien", 7: "12"})
+	server.Seed("bck7gp3q2", map[int]string{6: "Denver", 7: "3"})
+	server.Seed("bck7gp3q2", map[int]string{6: "Boise", 7: "8"})
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := criteria.Select(6).Where(criteria.Field(6).Eq("O'Brien").Or(criteria.Field(7).Lt(5))).SortByDesc(6).Run(client, "bck7gp3q2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0][6] != "O'Brien" || records[1][6] != "Denver" {
+		t.Errorf("got %v", records)
+	}
+}
+
+func TestBuilderRunFake(t *testing.T) {
+	fake := quickbasetest.NewFake()
+	fake.AddTable("bck7gp3q2", map[int]string{6: "Site", 7: "Cost"})
+	fake.Seed("bck7gp3q2", map[int]string{6: "Denver", 7: "3"})
+	fake.Seed("bck7gp3q2", map[int]string{6: "Boise", 7: "8"})
+	records, err := criteria.Select(6).Where(criteria.Field(7).Gt(5)).Run(fake, "bck7gp3q2")
+	if err != nil || len(records) != 1 || records[0][6] != "Boise" {
+		t.Errorf("got %v, %v", records, err)
+	}
+	if _, err = criteria.Select(6).Where(criteria.Field(7).Gt(struct{}{})).Run(fake, "bck7gp3q2"); err == nil {
+		t.Error("Run of an unencodable value succeeded")
+	}
+}
@@ -0,0 +1,434 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+// Package criteria parses the query strings of API_DoQuery, such as
+// {'6'.EX.'Denver'}AND({'7'.GT.'10'}OR{'7'.LT.'2'}), into trees which
+// may be validated against a table's schema, rewritten, and printed
+// again.  For example, to confine any query to a tenant's records:
+//
+//	expr, err := criteria.Parse(query)
+//	...
+//	query = criteria.Conjoin(expr, criteria.Criterion{Fid: 12, Op: "EX", Value: tenant}).String()
+//
+// AND binds more tightly than OR.
+//
+// Queries may also be built, with their values quoted, by Field and
+// Select:
+//
+//	q := criteria.Select(6, 7).Where(criteria.Field(6).Eq("O'Brien").And(criteria.Field(7).Gt(5))).SortBy(6)
+//	records, err := q.Run(qb, dbid)
+package criteria
+
+import (
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"strconv"
+	"strings"
+)
+
+// An Expr is a parsed query: a Criterion, an And or an Or.  The empty
+// query, which matches every record, is the nil Expr.
+type Expr interface {
+	// String returns the expression as a query string, with
+	// parentheses only where they are needed.
+	String() string
+	// And returns the expression conjoined with exprs, as Conjoin
+	// does.
+	And(exprs ...Expr) Expr
+	// Or returns the expression disjoined with exprs, as Disjoin
+	// does.
+	Or(exprs ...Expr) Expr
+	expr()
+}
+
+// A Criterion compares a field with a value.
+type Criterion struct {
+	Fid   int
+	Op    string // e.g. 'EX', 'CT' or 'OBF'
+	Value string
+
+	err error // from encoding the value given to a FieldRef
+}
+
+// An And matches the records all its expressions match.
+type And []Expr
+
+// An Or matches the records any of its expressions match.
+type Or []Expr
+
+func (Criterion) expr() {}
+func (And) expr()       {}
+func (Or) expr()        {}
+
+func (c Criterion) And(exprs ...Expr) Expr { return Conjoin(append([]Expr{c}, exprs...)...) }
+func (a And) And(exprs ...Expr) Expr       { return Conjoin(append([]Expr{a}, exprs...)...) }
+func (o Or) And(exprs ...Expr) Expr        { return Conjoin(append([]Expr{o}, exprs...)...) }
+
+func (c Criterion) Or(exprs ...Expr) Expr { return Disjoin(append([]Expr{c}, exprs...)...) }
+func (a And) Or(exprs ...Expr) Expr       { return Disjoin(append([]Expr{a}, exprs...)...) }
+func (o Or) Or(exprs ...Expr) Expr        { return Disjoin(append([]Expr{o}, exprs...)...) }
+
+func (c Criterion) String() string {
+	return fmt.Sprintf("{'%d'.%s.'%s'}", c.Fid, c.Op, c.Value)
+}
+
+func (a And) String() string {
+	parts := make([]string, len(a))
+	for i, e := range a {
+		parts[i] = e.String()
+		if _, ok := e.(Or); ok {
+			parts[i] = "(" + parts[i] + ")"
+		}
+	}
+	return strings.Join(parts, "AND")
+}
+
+func (o Or) String() string {
+	parts := make([]string, len(o))
+	for i, e := range o {
+		parts[i] = e.String()
+	}
+	return strings.Join(parts, "OR")
+}
+
+// Ops maps the operators QuickBase's queries support to a description
+// of each.
+var Ops = map[string]string{
+	"CT":   "contains",
+	"XCT":  "does not contain",
+	"HAS":  "has, of a list field",
+	"XHAS": "does not have, of a list field",
+	"EX":   "is",
+	"TV":   "is, of a user field by the value shown",
+	"XEX":  "is not",
+	"SW":   "starts with",
+	"XSW":  "does not start with",
+	"BF":   "is before",
+	"OBF":  "is on or before",
+	"AF":   "is after",
+	"OAF":  "is on or after",
+	"IR":   "is during",
+	"XIR":  "is not during",
+	"LT":   "is less than",
+	"LTE":  "is less than or equal to",
+	"GT":   "is greater than",
+	"GTE":  "is greater than or equal to",
+}
+
+// dateOps are those operators meaningful only of dates.
+var dateOps = map[string]bool{"BF": true, "OBF": true, "AF": true, "OAF": true, "IR": true, "XIR": true}
+
+// Parse parses a query string.  Operators are accepted in any case
+// and field IDs quoted or not, as QuickBase accepts them; the Expr
+// returned is normalized as Normalize does.
+func Parse(query string) (expr Expr, err error) {
+	p := &parser{s: query}
+	p.space()
+	if p.i == len(p.s) {
+		return nil, nil
+	}
+	if expr, err = p.disjunction(); err != nil {
+		return nil, err
+	}
+	if p.i < len(p.s) {
+		return nil, p.errorf("Expected AND or OR")
+	}
+	return Normalize(expr), nil
+}
+
+type parser struct {
+	s string
+	i int
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	found := "end of query"
+	if p.i < len(p.s) {
+		found = strconv.Quote(p.s[p.i:min(p.i+10, len(p.s))])
+	}
+	return fmt.Errorf("%s but found %s at offset %d", fmt.Sprintf(format, args...), found, p.i)
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func (p *parser) space() {
+	for p.i < len(p.s) && strings.IndexByte(" \t\r\n", p.s[p.i]) >= 0 {
+		p.i++
+	}
+}
+
+// accept consumes word, in any case, and any space following it.
+func (p *parser) accept(word string) bool {
+	if len(p.s)-p.i >= len(word) && strings.EqualFold(p.s[p.i:p.i+len(word)], word) {
+		p.i += len(word)
+		p.space()
+		return true
+	}
+	return false
+}
+
+func (p *parser) disjunction() (expr Expr, err error) {
+	var or Or
+	for {
+		if expr, err = p.conjunction(); err != nil {
+			return nil, err
+		}
+		or = append(or, expr)
+		if !p.accept("OR") {
+			return or, nil
+		}
+	}
+}
+
+func (p *parser) conjunction() (expr Expr, err error) {
+	var and And
+	for {
+		if expr, err = p.primary(); err != nil {
+			return nil, err
+		}
+		and = append(and, expr)
+		if !p.accept("AND") {
+			return and, nil
+		}
+	}
+}
+
+func (p *parser) primary() (expr Expr, err error) {
+	if p.accept("(") {
+		if expr, err = p.disjunction(); err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, p.errorf("Expected )")
+		}
+		return expr, nil
+	}
+	if !p.accept("{") {
+		return nil, p.errorf("Expected { or (")
+	}
+	var c Criterion
+	start := p.i
+	fid := p.word("'")
+	if c.Fid, err = strconv.Atoi(fid); err != nil || c.Fid <= 0 {
+		p.i = start
+		return nil, p.errorf("Expected a field ID")
+	}
+	if !p.accept(".") {
+		return nil, p.errorf("Expected .")
+	}
+	start = p.i
+	c.Op = strings.ToUpper(p.word(""))
+	if _, ok := Ops[c.Op]; !ok {
+		p.i = start
+		return nil, p.errorf("Expected an operator")
+	}
+	if !p.accept(".") {
+		return nil, p.errorf("Expected .")
+	}
+	if p.i < len(p.s) && p.s[p.i] == '\'' {
+		// As QuickBase does, end the value at the quote closing
+		// the criterion, so that it may contain quotes.
+		end := strings.Index(p.s[p.i+1:], "'}")
+		if end < 0 {
+			end = strings.IndexByte(p.s[p.i+1:], '\'')
+		}
+		if end < 0 {
+			return nil, p.errorf("Expected a closing quote")
+		}
+		c.Value = p.s[p.i+1 : p.i+1+end]
+		p.i += end + 2
+	} else {
+		end := strings.IndexByte(p.s[p.i:], '}')
+		if end < 0 {
+			end = len(p.s) - p.i
+		}
+		c.Value = p.s[p.i : p.i+end]
+		p.i += end
+	}
+	if !p.accept("}") {
+		return nil, p.errorf("Expected }")
+	}
+	return c, nil
+}
+
+// word consumes the letters and digits at the parser's offset,
+// optionally enclosed in quote, returning them.
+func (p *parser) word(quote string) string {
+	quoted := quote != "" && strings.HasPrefix(p.s[p.i:], quote)
+	if quoted {
+		p.i++
+	}
+	start := p.i
+	for p.i < len(p.s) && (p.s[p.i] >= '0' && p.s[p.i] <= '9' || p.s[p.i] >= 'a' && p.s[p.i] <= 'z' || p.s[p.i] >= 'A' && p.s[p.i] <= 'Z') {
+		p.i++
+	}
+	word := p.s[start:p.i]
+	if quoted {
+		if !strings.HasPrefix(p.s[p.i:], quote) {
+			p.i = start - 1
+			return ""
+		}
+		p.i++
+	}
+	return word
+}
+
+// Normalize returns expr with each And or Or of a single expression
+// replaced by that expression, those of none removed, and those
+// nested in another of the same kind merged into it.
+func Normalize(expr Expr) Expr {
+	switch e := expr.(type) {
+	case And:
+		var and And
+		for _, sub := range e {
+			switch sub := Normalize(sub).(type) {
+			case nil:
+			case And:
+				and = append(and, sub...)
+			default:
+				and = append(and, sub)
+			}
+		}
+		switch len(and) {
+		case 0:
+			return nil
+		case 1:
+			return and[0]
+		}
+		return and
+	case Or:
+		var or Or
+		for _, sub := range e {
+			switch sub := Normalize(sub).(type) {
+			case nil:
+			case Or:
+				or = append(or, sub...)
+			default:
+				or = append(or, sub)
+			}
+		}
+		switch len(or) {
+		case 0:
+			return nil
+		case 1:
+			return or[0]
+		}
+		return or
+	}
+	return expr
+}
+
+// Conjoin returns the normalized And of exprs, ignoring any nil ones.
+func Conjoin(exprs ...Expr) Expr {
+	return Normalize(And(exprs))
+}
+
+// Disjoin returns the normalized Or of exprs, ignoring any nil ones.
+func Disjoin(exprs ...Expr) Expr {
+	return Normalize(Or(exprs))
+}
+
+// Criteria returns the criteria of expr, in order.
+func Criteria(expr Expr) (criteria []Criterion) {
+	switch e := expr.(type) {
+	case Criterion:
+		criteria = append(criteria, e)
+	case And:
+		for _, sub := range e {
+			criteria = append(criteria, Criteria(sub)...)
+		}
+	case Or:
+		for _, sub := range e {
+			criteria = append(criteria, Criteria(sub)...)
+		}
+	}
+	return criteria
+}
+
+// Validate checks that the criteria of expr name fields of the table
+// described by schema, with operators meaningful of those fields'
+// types, and values QuickBase can match.
+func Validate(expr Expr, schema quickbase.Schema) error {
+	types := make(map[int]string)
+	for _, field := range schema.Fields {
+		types[field.Id] = field.Type
+	}
+	for _, c := range Criteria(expr) {
+		typ, ok := types[c.Fid]
+		switch {
+		case !ok:
+			return fmt.Errorf("No field %d in %s, in %s", c.Fid, schema.Dbid, c)
+		case Ops[c.Op] == "":
+			return fmt.Errorf("Unknown operator %s, in %s", c.Op, c)
+		case dateOps[c.Op] && typ != "" && typ != "date" && typ != "timestamp":
+			return fmt.Errorf("Operator %s applies only to dates, not field %d's type %s, in %s", c.Op, c.Fid, typ, c)
+		}
+		if err := checkValue(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkValue returns an error if c's value cannot be written in a
+// query: one containing '} or ending in a quote, which would end the
+// criterion early, or one a FieldRef could not encode.
+func checkValue(c Criterion) error {
+	if c.err != nil {
+		return fmt.Errorf("Cannot encode the value of field %d: %s", c.Fid, c.err)
+	}
+	if strings.Contains(c.Value, "'}") || strings.HasSuffix(c.Value, "'") {
+		return fmt.Errorf("Cannot match a value containing '} or ending in ', in %s", c)
+	}
+	return nil
+}
+
+// Pretty returns expr formatted for people, with one criterion to a
+// line and the criteria of each And or Or indented beneath it.
+func Pretty(expr Expr) string {
+	var b strings.Builder
+	pretty(&b, expr, "")
+	return b.String()
+}
+
+func pretty(b *strings.Builder, expr Expr, indent string) {
+	var op string
+	var exprs []Expr
+	switch e := expr.(type) {
+	case nil:
+		return
+	case Criterion:
+		fmt.Fprintf(b, "%s%s\n", indent, e)
+		return
+	case And:
+		op, exprs = "AND", e
+	case Or:
+		op, exprs = "OR", e
+	}
+	fmt.Fprintf(b, "%s%s\n", indent, op)
+	for _, sub := range exprs {
+		pretty(b, sub, indent+"  ")
+	}
+}
@@ -0,0 +1,145 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package criteria_test
+
+import (
+	"github.com/WesTower/quickbase"
+	"github.com/WesTower/quickbase/criteria"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		query string
+		want  criteria.Expr
+		norm  string
+	}{
+		{"", nil, ""},
+		{"{'6'.EX.'Denver'}", criteria.Criterion{Fid: 6, Op: "EX", Value: "Denver"}, "{'6'.EX.'Denver'}"},
+		{" {6.ex.Denver} and {'7'.gt.'10'} ", criteria.And{
+			criteria.Criterion{Fid: 6, Op: "EX", Value: "Denver"},
+			criteria.Criterion{Fid: 7, Op: "GT", Value: "10"},
+		}, "{'6'.EX.'Denver'}AND{'7'.GT.'10'}"},
+		{"{'6'.CT.'a.b}'}OR{'6'.EX.''}AND{'7'.LT.'2'}", criteria.Or{
+			criteria.Criterion{Fid: 6, Op: "CT", Value: "a.b}"},
+			criteria.And{criteria.Criterion{Fid: 6, Op: "EX", Value: ""}, criteria.Criterion{Fid: 7, Op: "LT", Value: "2"}},
+		}, "{'6'.CT.'a.b}'}OR{'6'.EX.''}AND{'7'.LT.'2'}"},
+		{"{'6'.EX.'O'Brien'}", criteria.Criterion{Fid: 6, Op: "EX", Value: "O'Brien"}, "{'6'.EX.'O'Brien'}"},
+		{"(({'6'.EX.'a'}))AND({'7'.GT.'1'}OR{'7'.LT.'0'})AND({'8'.AF.'today'}AND{'8'.BF.'tomorrow'})", criteria.And{
+			criteria.Criterion{Fid: 6, Op: "EX", Value: "a"},
+			criteria.Or{criteria.Criterion{Fid: 7, Op: "GT", Value: "1"}, criteria.Criterion{Fid: 7, Op: "LT", Value: "0"}},
+			criteria.Criterion{Fid: 8, Op: "AF", Value: "today"},
+			criteria.Criterion{Fid: 8, Op: "BF", Value: "tomorrow"},
+		}, "{'6'.EX.'a'}AND({'7'.GT.'1'}OR{'7'.LT.'0'})AND{'8'.AF.'today'}AND{'8'.BF.'tomorrow'}"},
+	}
+	for _, test := range tests {
+		expr, err := criteria.Parse(test.query)
+		if err != nil {
+			t.Errorf("%s: %v", test.query, err)
+			continue
+		}
+		if !reflect.DeepEqual(expr, test.want) {
+			t.Errorf("%s: got %#v", test.query, expr)
+		}
+		if expr != nil && expr.String() != test.norm {
+			t.Errorf("%s: printed as %s", test.query, expr)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct{ query, want string }{
+		{"{'6'.EX.'Denver'", `Expected } but found end of query at offset 16`},
+		{"{'x'.EX.'a'}", `Expected a field ID but found "'x'.EX.'a'" at offset 1`},
+		{"{'6'.EQ.'a'}", `Expected an operator but found "EQ.'a'}" at offset 5`},
+		{"{'6'.EX.'a}", `Expected a closing quote`},
+		{"{'6'.EX.'a'}{'7'.EX.'b'}", `Expected AND or OR but found "{'7'.EX.'b" at offset 12`},
+		{"({'6'.EX.'a'}", `Expected ) but found end of query`},
+		{"{'6'.EX.'a'}AND", `Expected { or ( but found end of query at offset 15`},
+	}
+	for _, test := range tests {
+		_, err := criteria.Parse(test.query)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: got error %v, want %q", test.query, err, test.want)
+		}
+	}
+}
+
+func TestConjoin(t *testing.T) {
+	tenant := criteria.Criterion{Fid: 12, Op: "EX", Value: "acme"}
+	for query, want := range map[string]string{
+		"":                            "{'12'.EX.'acme'}",
+		"{'6'.EX.'a'}":                "{'6'.EX.'a'}AND{'12'.EX.'acme'}",
+		"{'6'.EX.'a'}AND{'7'.EX.'b'}": "{'6'.EX.'a'}AND{'7'.EX.'b'}AND{'12'.EX.'acme'}",
+		"{'6'.EX.'a'}OR{'7'.EX.'b'}":  "({'6'.EX.'a'}OR{'7'.EX.'b'})AND{'12'.EX.'acme'}",
+	} {
+		expr, err := criteria.Parse(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := criteria.Conjoin(expr, tenant).String(); got != want {
+			t.Errorf("%s: got %s, want %s", query, got, want)
+		}
+	}
+	if got := criteria.Disjoin(nil, tenant, criteria.Or{tenant}); !reflect.DeepEqual(got, criteria.Or{tenant, tenant}) {
+		t.Errorf("Disjoin gave %#v", got)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	schema := quickbase.Schema{Dbid: "bck7gp3q2", Fields: []quickbase.Field{
+		{Id: 6, Label: "Site", Type: "text"},
+		{Id: 8, Label: "Due", Type: "date"},
+	}}
+	for query, want := range map[string]string{
+		"{'6'.EX.'a'}AND{'8'.OAF.'today'}": "",
+		"{'6'.EX.'a'}OR{'9'.EX.'b'}":       "No field 9 in bck7gp3q2, in {'9'.EX.'b'}",
+		"{'6'.BF.'today'}":                 "Operator BF applies only to dates, not field 6's type text",
+	} {
+		expr, err := criteria.Parse(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = criteria.Validate(expr, schema)
+		if want == "" && err != nil || want != "" && (err == nil || !strings.Contains(err.Error(), want)) {
+			t.Errorf("%s: got %v, want %q", query, err, want)
+		}
+	}
+	if err := criteria.Validate(criteria.Criterion{Fid: 6, Op: "EX", Value: "O'Brien"}, schema); err != nil {
+		t.Errorf("value with a quote: %v", err)
+	}
+	if err := criteria.Validate(criteria.Criterion{Fid: 6, Op: "EX", Value: "a'}b"}, schema); err == nil {
+		t.Error("value with a closing quote and brace validated")
+	}
+}
+
+func TestPretty(t *testing.T) {
+	expr, err := criteria.Parse("{'6'.EX.'a'}AND({'7'.GT.'1'}OR{'7'.LT.'0'})")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "AND\n  {'6'.EX.'a'}\n  OR\n    {'7'.GT.'1'}\n    {'7'.LT.'0'}\n"
+	if got := criteria.Pretty(expr); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,107 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package criteria
+
+import (
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"strings"
+)
+
+// QuickBase offers no API call to create or change a table's saved
+// queries (reports): neither API_GetSchema's counterparts nor the
+// RESTful API's report endpoints write them.  What can be automated is
+// checking that each copy of an application has the reports it should,
+// so that those missing or changed may be made or repaired in the
+// QuickBase UI, or the application recopied.
+
+// A ReportDrift is a difference between a report a table should have
+// and the table's saved queries.
+type ReportDrift struct {
+	Spec        quickbase.Query  // the report the table should have
+	Actual      *quickbase.Query // the saved query of the same name, or nil if there is none
+	Differences []string         // how Actual differs from Spec
+}
+
+func (d ReportDrift) String() string {
+	if d.Actual == nil {
+		return fmt.Sprintf("report %q is missing", d.Spec.Name)
+	}
+	return fmt.Sprintf("report %q (qid %d): %s", d.Spec.Name, d.Actual.Id, strings.Join(d.Differences, "; "))
+}
+
+// CheckReports compares the saved queries of a table, as described by
+// its schema, with the reports it should have, matching them by name.
+// Of each spec, only the Type, Criteria, Clist and Slist given are
+// compared; field lists compare equal regardless of a trailing period,
+// and criteria as Parse reads them, so regardless of the case of
+// operators and of spacing, but not of values.  Criteria which do not
+// parse are compared verbatim.  It returns the reports which are
+// missing or differ, in the order of specs.
+func CheckReports(schema quickbase.Schema, specs []quickbase.Query) (drift []ReportDrift) {
+	byName := make(map[string]*quickbase.Query)
+	for i := range schema.Queries {
+		byName[schema.Queries[i].Name] = &schema.Queries[i]
+	}
+	for _, spec := range specs {
+		actual := byName[spec.Name]
+		if actual == nil {
+			drift = append(drift, ReportDrift{Spec: spec})
+			continue
+		}
+		var differences []string
+		compare := func(what, want, got string, normalize func(string) string) {
+			if want != "" && normalize(want) != normalize(got) {
+				differences = append(differences, fmt.Sprintf("%s is %q, not %q", what, got, want))
+			}
+		}
+		compare("type", spec.Type, actual.Type, strings.ToLower)
+		compare("criteria", spec.Criteria, actual.Criteria, normalizeQuery)
+		compare("clist", spec.Clist, actual.Clist, normalizeFidList)
+		compare("slist", spec.Slist, actual.Slist, normalizeFidList)
+		if differences != nil {
+			drift = append(drift, ReportDrift{spec, actual, differences})
+		}
+	}
+	return drift
+}
+
+// CheckTableReports fetches the schema of table dbid with qb and
+// compares its saved queries with specs, as CheckReports does.
+func CheckTableReports(qb quickbase.QuickBase, dbid string, specs []quickbase.Query) (drift []ReportDrift, err error) {
+	schema, err := qb.GetSchema(dbid)
+	if err != nil {
+		return nil, err
+	}
+	return CheckReports(schema, specs), nil
+}
+
+func normalizeQuery(query string) string {
+	expr, err := Parse(query)
+	if err != nil || expr == nil {
+		return query
+	}
+	return expr.String()
+}
+
+func normalizeFidList(list string) string {
+	return strings.Trim(list, ".")
+}
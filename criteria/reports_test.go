@@ -0,0 +1,75 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package criteria_test
+
+import (
+	"github.com/WesTower/quickbase"
+	"github.com/WesTower/quickbase/criteria"
+	"github.com/WesTower/quickbase/quickbasetest"
+	"testing"
+)
+
+const testTableDbid = "bck7gp3q2"
+
+func TestCheckReports(t *testing.T) {
+	schema := quickbase.Schema{Dbid: testTableDbid, Queries: []quickbase.Query{
+		{Id: 1, Name: "List All", Type: "table", Clist: "6.7.", Slist: "6"},
+		{Id: 5, Name: "Denver", Type: "table", Criteria: "{'6'.EX.'Denver'}", Clist: "6.7", Slist: "7"},
+	}}
+	drift := criteria.CheckReports(schema, []quickbase.Query{
+		{Name: "List All", Clist: "6.7"},
+		{Name: "Denver", Type: "Table", Criteria: "{'6'.ex.'denver'} AND {'7'.GT.'0'}", Slist: "7"},
+		{Name: "Boise", Criteria: "{'6'.EX.'Boise'}"},
+	})
+	if len(drift) != 2 {
+		t.Fatalf("got drift %v", drift)
+	}
+	if got := drift[0].String(); got != `report "Denver" (qid 5): criteria is "{'6'.EX.'Denver'}", not "{'6'.ex.'denver'} AND {'7'.GT.'0'}"` {
+		t.Errorf("got %s", got)
+	}
+	if got := drift[1].String(); drift[1].Actual != nil || got != `report "Boise" is missing` {
+		t.Errorf("got %s", got)
+	}
+
+	schema.Queries[1].Criteria = "{'6'.EX.'New York'}OR{'7'.GT.'0'}"
+	for query, drifts := range map[string]bool{
+		"{ 6.ex. 'New York'} or {'7'.gt.'0'}": false,
+		"{'6'.EX.'new york'}OR{'7'.GT.'0'}":   true,
+		"{'6'.EX.'NewYork'}OR{'7'.GT.'0'}":    true,
+	} {
+		drift = criteria.CheckReports(schema, []quickbase.Query{{Name: "Denver", Criteria: query}})
+		if (len(drift) != 0) != drifts {
+			t.Errorf("criteria %s gave drift %v", query, drift)
+		}
+	}
+}
+
+func TestCheckTableReports(t *testing.T) {
+	fake := quickbasetest.NewFake()
+	fake.AddTable(testTableDbid, map[int]string{6: "Site"})
+	drift, err := criteria.CheckTableReports(fake, testTableDbid, []quickbase.Query{{Name: "Boise", Criteria: "{'6'.EX.'Boise'}"}})
+	if err != nil || len(drift) != 1 || drift[0].Actual != nil {
+		t.Errorf("got drift %v, %v", drift, err)
+	}
+	if _, err = criteria.CheckTableReports(fake, "bck7gp3q9", nil); err == nil {
+		t.Error("check of a missing table succeeded")
+	}
+}
@@ -0,0 +1,132 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"fmt"
+)
+
+// An Application is an application created by CreateDatabase.
+type Application struct {
+	Dbid     string // the application's dbid
+	Apptoken string // an application token with which to call it
+}
+
+// CreateDatabase creates an application, with an application token.
+func CreateDatabase(ticket Ticket, name, description string) (app Application, err error) {
+	var result createDatabaseResponse
+	if err = createDatabaseCall(ticket, name, description).execute(&result); err != nil {
+		return app, err
+	}
+	return result.application()
+}
+
+func createDatabaseCall(ticket Ticket, name, description string) apiCall {
+	params := ticket.params()
+	params["dbname"] = name
+	params["dbdesc"] = description
+	params["createapptoken"] = "1"
+	return apiCall{ticket, ticket.url + "db/main", "API_CreateDatabase", params}
+}
+
+func (r *createDatabaseResponse) application() (app Application, err error) {
+	app = Application{string(r.AppDbid), string(r.Apptoken)}
+	if app.Dbid == "" {
+		// Older realms report only the dbid, which is then the
+		// application's.
+		app.Dbid = string(r.Dbid)
+	}
+	if app.Dbid == "" {
+		return app, fmt.Errorf("No dbid returned from API_CreateDatabase")
+	}
+	return app, nil
+}
+
+// CloneOptions control what CloneDatabase copies besides the
+// application's structure.
+type CloneOptions struct {
+	KeepData      bool // copy the records of the tables
+	ExcludeFiles  bool // but not their file attachments
+	UsersAndRoles bool // copy the users and their roles
+}
+
+// CloneDatabase copies the application dbid as a new application
+// named name, returning the new application's dbid.
+func CloneDatabase(ticket Ticket, dbid, name, description string, options CloneOptions) (newDbid string, err error) {
+	var result cloneDatabaseResponse
+	if err = cloneDatabaseCall(ticket, dbid, name, description, options).execute(&result); err != nil {
+		return "", err
+	}
+	return result.dbid()
+}
+
+func cloneDatabaseCall(ticket Ticket, dbid, name, description string, options CloneOptions) apiCall {
+	params := ticket.params()
+	params["newdbname"] = name
+	params["newdbdesc"] = description
+	if options.KeepData {
+		params["keepData"] = "1"
+	}
+	if options.ExcludeFiles {
+		params["excludefiles"] = "1"
+	}
+	if options.UsersAndRoles {
+		params["usersandroles"] = "1"
+	}
+	return apiCall{ticket, ticket.url + "db/" + dbid, "API_CloneDatabase", params}
+}
+
+func (r *cloneDatabaseResponse) dbid() (dbid string, err error) {
+	if r.NewDbid == "" {
+		return "", fmt.Errorf("No newdbid returned from API_CloneDatabase")
+	}
+	return string(r.NewDbid), nil
+}
+
+// DeleteDatabase deletes an application, with all its tables, or a
+// single table.  There is no undoing it.
+func DeleteDatabase(ticket Ticket, dbid string) (err error) {
+	return deleteDatabaseCall(ticket, dbid).execute(nil)
+}
+
+func deleteDatabaseCall(ticket Ticket, dbid string) apiCall {
+	return apiCall{ticket, ticket.url + "db/" + dbid, "API_DeleteDatabase", ticket.params()}
+}
+
+func (c *Client) CreateDatabase(name, description string) (app Application, err error) {
+	var result createDatabaseResponse
+	if err = c.mutate(createDatabaseCall(c.ticket(), name, description), &result); err != nil || c.DryRun {
+		return app, err
+	}
+	return result.application()
+}
+
+func (c *Client) CloneDatabase(dbid, name, description string, options CloneOptions) (newDbid string, err error) {
+	var result cloneDatabaseResponse
+	if err = c.mutate(cloneDatabaseCall(c.ticket(), dbid, name, description, options), &result); err != nil || c.DryRun {
+		return "", err
+	}
+	return result.dbid()
+}
+
+func (c *Client) DeleteDatabase(dbid string) (err error) {
+	return c.mutate(deleteDatabaseCall(c.ticket(), dbid), nil)
+}
@@ -0,0 +1,71 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"github.com/WesTower/quickbase"
+	"testing"
+)
+
+func TestCloneDatabase(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	app, err := client.CreateDatabase("Customer Template", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if app.Dbid == "" || app.Apptoken == "" {
+		t.Errorf("CreateDatabase gave %+v", app)
+	}
+	if _, err = client.CreateTable(app.Dbid, "Sites", "Site"); err != nil {
+		t.Fatal(err)
+	}
+	for _, keepData := range []bool{false, true} {
+		clone, err := client.CloneDatabase(testAppDbid, "Acme", "Acme's towers", quickbase.CloneOptions{KeepData: keepData})
+		if err != nil {
+			t.Fatal(err)
+		}
+		schema, err := client.GetSchema(clone)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(schema.Tables) != 1 || schema.Tables[0] == testTableDbid {
+			t.Fatalf("clone has tables %v", schema.Tables)
+		}
+		count, err := client.DoQueryCount(schema.Tables[0], "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := map[bool]int64{false: 0, true: 2}[keepData]; count != want {
+			t.Errorf("keepData %v: clone has %d records, not %d", keepData, count, want)
+		}
+		if err = client.DeleteDatabase(clone); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = client.DoQueryCount(schema.Tables[0], ""); err == nil {
+			t.Error("table of deleted application survives")
+		}
+	}
+}
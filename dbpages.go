@@ -0,0 +1,186 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// The types of DB pages.
+const (
+	PageHTML      = 1 // an HTML page or XSL stylesheet, e.g. a code page
+	PageExactForm = 3 // an Exact Forms template
+)
+
+// A DBPage is a page stored in an application, as listed by
+// ListDBPages.
+type DBPage struct {
+	Id   int
+	Name string
+	Type int // PageHTML or PageExactForm
+}
+
+// ListDBPages returns the pages of the application appDbid.
+func ListDBPages(ticket Ticket, appDbid string) (pages []DBPage, err error) {
+	var result listDBPagesResponse
+	if err = listDBPagesCall(ticket, appDbid).execute(&result); err != nil {
+		return nil, err
+	}
+	for _, page := range result.Pages {
+		pages = append(pages, DBPage{page.Id, strings.TrimSpace(page.Name), page.Type})
+	}
+	return pages, nil
+}
+
+func listDBPagesCall(ticket Ticket, appDbid string) apiCall {
+	return apiCall{ticket, ticket.url + "db/" + appDbid, "API_ListDBPages", ticket.params()}
+}
+
+// GetDBPage returns the body of a page of the application appDbid;
+// page is either the page's ID or its name.
+func GetDBPage(ticket Ticket, appDbid, page string) (body []byte, err error) {
+	params := ticket.params()
+	params["pageID"] = page
+	resp, err := executeRawApiCall(ticket, ticket.url+"db/"+appDbid, "API_GetDBPage", params)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp.Body)
+	if body, err = ioutil.ReadAll(resp.Body); err != nil {
+		return nil, err
+	}
+	// QuickBase answers with the page itself, or with a qdbapi
+	// response if the call failed.
+	if trimmed := bytes.TrimSpace(body); bytes.HasPrefix(trimmed, []byte("<?xml")) || bytes.HasPrefix(trimmed, []byte("<qdbapi")) {
+		var result qdbapiResponse
+		if xml.Unmarshal(body, &result) == nil && result.Errcode != nil {
+			if err = responseError(string(*result.Errcode), string(result.Errtext), string(result.Errdetail), resp.StatusCode); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("Failed to get page %s (HTTP status %d)", page, resp.StatusCode)
+	}
+	return body, nil
+}
+
+// AddDBPage adds a page named name to the application appDbid,
+// returning its page ID.  The body is escaped within the request, so
+// it need not be wrapped in CDATA or otherwise encoded.
+func AddDBPage(ticket Ticket, appDbid, name string, pageType int, body string) (pageId int, err error) {
+	var result addReplaceDBPageResponse
+	if err = addDBPageCall(ticket, appDbid, name, pageType, body).execute(&result); err != nil {
+		return 0, err
+	}
+	return result.pageId()
+}
+
+func addDBPageCall(ticket Ticket, appDbid, name string, pageType int, body string) apiCall {
+	params := ticket.params()
+	params["pagename"] = name
+	params["pagetype"] = strconv.Itoa(pageType)
+	params["pagebody"] = body
+	return apiCall{ticket, ticket.url + "db/" + appDbid, "API_AddReplaceDBPage", params}
+}
+
+// ReplaceDBPage replaces the body of the page pageId of the
+// application appDbid.
+func ReplaceDBPage(ticket Ticket, appDbid string, pageId, pageType int, body string) (err error) {
+	return replaceDBPageCall(ticket, appDbid, pageId, pageType, body).execute(nil)
+}
+
+func replaceDBPageCall(ticket Ticket, appDbid string, pageId, pageType int, body string) apiCall {
+	params := ticket.params()
+	params["pageid"] = strconv.Itoa(pageId)
+	params["pagetype"] = strconv.Itoa(pageType)
+	params["pagebody"] = body
+	return apiCall{ticket, ticket.url + "db/" + appDbid, "API_AddReplaceDBPage", params}
+}
+
+// PutDBPage replaces the body of the page named name of the
+// application appDbid, adding the page if there is none, and returns
+// its page ID.  It suits deploying code pages, whose IDs differ from
+// one application to another.
+func PutDBPage(ticket Ticket, appDbid, name string, pageType int, body string) (pageId int, err error) {
+	pages, err := ListDBPages(ticket, appDbid)
+	if err != nil {
+		return 0, err
+	}
+	if page, ok := findDBPage(pages, name); ok {
+		return page.Id, ReplaceDBPage(ticket, appDbid, page.Id, pageType, body)
+	}
+	return AddDBPage(ticket, appDbid, name, pageType, body)
+}
+
+// findDBPage returns the page named name; QuickBase compares page names
+// without regard to case.
+func findDBPage(pages []DBPage, name string) (page DBPage, ok bool) {
+	for _, page = range pages {
+		if strings.EqualFold(page.Name, name) {
+			return page, true
+		}
+	}
+	return DBPage{}, false
+}
+
+func (r *addReplaceDBPageResponse) pageId() (pageId int, err error) {
+	if r.PageId == nil {
+		return 0, fmt.Errorf("No pageID returned from API_AddReplaceDBPage")
+	}
+	return strconv.Atoi(string(*r.PageId))
+}
+
+func (c *Client) ListDBPages(appDbid string) (pages []DBPage, err error) {
+	return ListDBPages(c.ticket(), appDbid)
+}
+
+func (c *Client) GetDBPage(appDbid, page string) (body []byte, err error) {
+	return GetDBPage(c.ticket(), appDbid, page)
+}
+
+func (c *Client) AddDBPage(appDbid, name string, pageType int, body string) (pageId int, err error) {
+	var result addReplaceDBPageResponse
+	if err = c.mutate(addDBPageCall(c.ticket(), appDbid, name, pageType, body), &result); err != nil || c.DryRun {
+		return 0, err
+	}
+	return result.pageId()
+}
+
+func (c *Client) ReplaceDBPage(appDbid string, pageId, pageType int, body string) (err error) {
+	return c.mutate(replaceDBPageCall(c.ticket(), appDbid, pageId, pageType, body), nil)
+}
+
+func (c *Client) PutDBPage(appDbid, name string, pageType int, body string) (pageId int, err error) {
+	pages, err := c.ListDBPages(appDbid)
+	if err != nil {
+		return 0, err
+	}
+	if page, ok := findDBPage(pages, name); ok {
+		return page.Id, c.ReplaceDBPage(appDbid, page.Id, pageType, body)
+	}
+	return c.AddDBPage(appDbid, name, pageType, body)
+}
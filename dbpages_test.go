@@ -0,0 +1,66 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"github.com/WesTower/quickbase"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestDBPages(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// A body which would break a naive CDATA section
+	body := "<script>if (a < b && c) { x = ']]>'; }</script>"
+	id, err := client.PutDBPage(testAppDbid, "deploy.js", quickbase.PageHTML, "old")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := client.AddDBPage(testAppDbid, "help.html", quickbase.PageHTML, "<p>Help</p>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replaced, err := client.PutDBPage(testAppDbid, "Deploy.js", quickbase.PageHTML, body); err != nil || replaced != id {
+		t.Errorf("PutDBPage replaced page %d, %v, not %d", replaced, err, id)
+	}
+	for _, page := range []string{"deploy.js", strconv.Itoa(id)} {
+		if got, err := client.GetDBPage(testAppDbid, page); err != nil || string(got) != body {
+			t.Errorf("GetDBPage(%s) gave %q, %v", page, got, err)
+		}
+	}
+	pages, err := client.ListDBPages(testAppDbid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []quickbase.DBPage{{id, "deploy.js", quickbase.PageHTML}, {other, "help.html", quickbase.PageHTML}}
+	if !reflect.DeepEqual(pages, want) {
+		t.Errorf("ListDBPages gave %v, not %v", pages, want)
+	}
+	if _, err = client.GetDBPage(testAppDbid, "missing.html"); err == nil {
+		t.Error("GetDBPage succeeded for a missing page")
+	}
+}
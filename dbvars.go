@@ -0,0 +1,112 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// GetDBVar returns the value of the variable name of the application
+// appDbid.
+func GetDBVar(ticket Ticket, appDbid, name string) (value string, err error) {
+	var result dbvarResponse
+	if err = getDBVarCall(ticket, appDbid, name).execute(&result); err != nil {
+		return "", err
+	}
+	return result.value(name)
+}
+
+// GetDBVarInt is like GetDBVar, but parses the variable as an integer.
+func GetDBVarInt(ticket Ticket, appDbid, name string) (value int, err error) {
+	s, err := GetDBVar(ticket, appDbid, name)
+	if err != nil {
+		return 0, err
+	}
+	if value, err = strconv.Atoi(s); err != nil {
+		return 0, fmt.Errorf("Variable %s is not an integer: %q", name, s)
+	}
+	return value, nil
+}
+
+func getDBVarCall(ticket Ticket, appDbid, name string) apiCall {
+	params := ticket.params()
+	params["varname"] = name
+	return apiCall{ticket, ticket.url + "db/" + appDbid, "API_GetDBvar", params}
+}
+
+func (r *dbvarResponse) value(name string) (value string, err error) {
+	if r.Value == nil {
+		return "", fmt.Errorf("No value of %s returned from API_GetDBvar", name)
+	}
+	return string(*r.Value), nil
+}
+
+// SetDBVar sets the variable name of the application appDbid,
+// creating it if need be.
+func SetDBVar(ticket Ticket, appDbid, name, value string) (err error) {
+	return setDBVarCall(ticket, appDbid, name, value).execute(nil)
+}
+
+// SetDBVarInt is like SetDBVar, but sets the variable to an integer.
+func SetDBVarInt(ticket Ticket, appDbid, name string, value int) (err error) {
+	return SetDBVar(ticket, appDbid, name, strconv.Itoa(value))
+}
+
+func setDBVarCall(ticket Ticket, appDbid, name, value string) apiCall {
+	params := ticket.params()
+	params["varname"] = name
+	params["value"] = value
+	return apiCall{ticket, ticket.url + "db/" + appDbid, "API_SetDBvar", params}
+}
+
+// DBVars returns all the variables of the application appDbid, by
+// name, from its schema.
+func DBVars(ticket Ticket, appDbid string) (vars map[string]string, err error) {
+	schema, err := GetSchema(ticket, appDbid)
+	if err != nil {
+		return nil, err
+	}
+	if schema.Variables == nil {
+		return make(map[string]string), nil
+	}
+	return schema.Variables, nil
+}
+
+func (c *Client) GetDBVar(appDbid, name string) (value string, err error) {
+	return GetDBVar(c.ticket(), appDbid, name)
+}
+
+func (c *Client) GetDBVarInt(appDbid, name string) (value int, err error) {
+	return GetDBVarInt(c.ticket(), appDbid, name)
+}
+
+func (c *Client) SetDBVar(appDbid, name, value string) (err error) {
+	return c.mutate(setDBVarCall(c.ticket(), appDbid, name, value), nil)
+}
+
+func (c *Client) SetDBVarInt(appDbid, name string, value int) (err error) {
+	return c.SetDBVar(appDbid, name, strconv.Itoa(value))
+}
+
+func (c *Client) DBVars(appDbid string) (vars map[string]string, err error) {
+	return DBVars(c.ticket(), appDbid)
+}
@@ -0,0 +1,64 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"github.com/WesTower/quickbase"
+	"reflect"
+	"testing"
+)
+
+func TestDBVars(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vars, err := client.DBVars(testAppDbid); err != nil || len(vars) != 0 {
+		t.Errorf("DBVars gave %v, %v before any were set", vars, err)
+	}
+	if _, err = client.GetDBVar(testAppDbid, "Region"); err == nil {
+		t.Error("GetDBVar succeeded for an unset variable")
+	}
+	if err = client.SetDBVar(testAppDbid, "Region", "West & North"); err != nil {
+		t.Fatal(err)
+	}
+	if err = client.SetDBVarInt(testAppDbid, "Threshold", 10); err != nil {
+		t.Fatal(err)
+	}
+	if value, err := client.GetDBVar(testAppDbid, "Region"); err != nil || value != "West & North" {
+		t.Errorf("GetDBVar gave %q, %v", value, err)
+	}
+	if value, err := client.GetDBVarInt(testAppDbid, "Threshold"); err != nil || value != 10 {
+		t.Errorf("GetDBVarInt gave %d, %v", value, err)
+	}
+	if _, err = client.GetDBVarInt(testAppDbid, "Region"); err == nil {
+		t.Error("GetDBVarInt parsed a non-integer")
+	}
+	vars, err := client.DBVars(testAppDbid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]string{"Region": "West & North", "Threshold": "10"}; !reflect.DeepEqual(vars, want) {
+		t.Errorf("DBVars gave %v, not %v", vars, want)
+	}
+}
@@ -0,0 +1,67 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"bytes"
+	"io"
+	"log"
+)
+
+// A DryRunRequest is a request which a Client in dry-run mode did not
+// send.
+type DryRunRequest struct {
+	Url    string
+	Action string // the QUICKBASE-ACTION, e.g. 'API_AddRecord'
+	Body   []byte // the qdbapi XML which would have been sent
+}
+
+// String returns the request with its credentials redacted.
+func (r DryRunRequest) String() string {
+	return r.Action + " " + r.Url + " " + redact(string(r.Body))
+}
+
+// dryRun reports a call which the Client will not make.
+func (c *Client) dryRun(call apiCall) error {
+	body, err := requestBody(call.params)
+	if err != nil {
+		return err
+	}
+	c.logDryRun(DryRunRequest{Url: call.url, Action: call.action, Body: body})
+	return nil
+}
+
+func (c *Client) dryRunUpload(dbid string, rid, fid int, filename string, r io.Reader) error {
+	var body bytes.Buffer
+	if err := writeUploadBody(&body, c.Ticket, rid, fid, filename, r); err != nil {
+		return err
+	}
+	c.logDryRun(DryRunRequest{Url: c.Ticket.url + "db/" + dbid, Action: "API_EditRecord", Body: body.Bytes()})
+	return nil
+}
+
+func (c *Client) logDryRun(req DryRunRequest) {
+	if c.DryRunLog != nil {
+		c.DryRunLog(req)
+	} else {
+		log.Print("quickbase: dry run: ", req)
+	}
+}
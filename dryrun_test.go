@@ -0,0 +1,83 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"github.com/WesTower/quickbase"
+	"strings"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var requests []quickbase.DryRunRequest
+	client.DryRun = true
+	client.DryRunLog = func(req quickbase.DryRunRequest) {
+		requests = append(requests, req)
+	}
+
+	if rid, err := client.AddRecordByFid(testTableDbid, map[int]string{6: "Tulsa"}); err != nil || rid != 0 {
+		t.Errorf("AddRecordByFid gave %d, %v", rid, err)
+	}
+	if err = client.EditRecord(testTableDbid, 1, map[string]string{"Site": "Aurora"}); err != nil {
+		t.Error(err)
+	}
+	if err = client.DeleteRecord(testTableDbid, 2); err != nil {
+		t.Error(err)
+	}
+	if err = client.ImportFromCSV(testTableDbid, []int{6}, strings.NewReader("Site\nMesa\n")); err != nil {
+		t.Error(err)
+	}
+	if err = client.Upload(testTableDbid, 1, 7, "a.txt", strings.NewReader("hello")); err != nil {
+		t.Error(err)
+	}
+	records := server.Records(testTableDbid)
+	if len(records) != 2 || records[0][6] != "Denver" {
+		t.Errorf("dry run changed the table: %v", records)
+	}
+	if count, err := client.DoQueryCount(testTableDbid, ""); err != nil || count != 2 {
+		t.Errorf("DoQueryCount in dry run gave %d, %v", count, err)
+	}
+
+	actions := []string{"API_AddRecord", "API_EditRecord", "API_DeleteRecord", "API_ImportFromCSV", "API_EditRecord"}
+	if len(requests) != len(actions) {
+		t.Fatalf("dry run logged %v", requests)
+	}
+	for i, action := range actions {
+		if requests[i].Action != action {
+			t.Errorf("request %d is %s, want %s", i, requests[i].Action, action)
+		}
+	}
+	if body := string(requests[0].Body); !strings.Contains(body, "<_fid_6>Tulsa</_fid_6>") || !strings.Contains(body, "<ticket>") {
+		t.Errorf("AddRecordByFid would have sent %s", body)
+	}
+	if s := requests[0].String(); strings.Contains(s, "fake_ticket") || !strings.Contains(s, "<ticket>REDACTED</ticket>") {
+		t.Errorf("String gave %s", s)
+	}
+	if body := string(requests[4].Body); !strings.Contains(body, "aGVsbG8=") {
+		t.Errorf("Upload would have sent %s", body)
+	}
+}
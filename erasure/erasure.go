@@ -0,0 +1,190 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+// Package erasure carries out requests by the subjects of personal
+// data to have it erased: given a key identifying the subject, such as
+// an email address, it finds the records matching it in each of a set
+// of tables, deletes or anonymizes them, and reports what was done, for
+// the record of the request.
+package erasure
+
+import (
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"github.com/WesTower/quickbase/criteria"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Table is a table which may hold a subject's data.
+type Table struct {
+	Dbid   string
+	KeyFid int // the field holding the subject's key
+	// Anonymize, if set, gives the values to overwrite the fields
+	// of matching records with, rather than deleting the records,
+	// e.g. where other data depend on them.  Its values may be
+	// blank.  It should overwrite KeyFid, lest the records be
+	// found again by later erasures.
+	Anonymize map[int]string
+	// FileFids are file attachment fields blanked when a record is
+	// anonymized, removing the files; deleting a record removes its
+	// files along with it.
+	FileFids []int
+}
+
+// An Eraser erases subjects' data from its Tables.
+type Eraser struct {
+	QB     quickbase.QuickBase
+	Tables []Table
+	// DryRun, if set, makes Erase only find the matching records,
+	// changing nothing.
+	DryRun bool
+}
+
+// A Report records an erasure.
+type Report struct {
+	Key      string        `json:"key"`
+	Started  time.Time     `json:"started"`
+	Finished time.Time     `json:"finished"`
+	DryRun   bool          `json:"dry_run,omitempty"`
+	Tables   []TableReport `json:"tables"`
+}
+
+// A TableReport records the erasure of a subject's data from a table.
+type TableReport struct {
+	Dbid       string `json:"dbid"`
+	Found      []int  `json:"found"`      // the Record IDs of matching records
+	Deleted    []int  `json:"deleted"`    // those deleted
+	Anonymized []int  `json:"anonymized"` // those anonymized
+	Files      int    `json:"files"`      // file attachments removed, of FileFids
+	Error      string `json:"error,omitempty"`
+}
+
+// Done reports whether every matching record was erased, or, for a
+// dry run, found.
+func (r Report) Done() bool {
+	for _, table := range r.Tables {
+		if table.Error != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func (r Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "erasure of %q at %s", r.Key, r.Started.Format(time.RFC3339))
+	if r.DryRun {
+		b.WriteString(" (dry run)")
+	}
+	b.WriteString("\n")
+	for _, table := range r.Tables {
+		fmt.Fprintf(&b, "  %s: %d found, %d deleted, %d anonymized, %d files removed", table.Dbid,
+			len(table.Found), len(table.Deleted), len(table.Anonymized), table.Files)
+		if table.Error != "" {
+			fmt.Fprintf(&b, ": %s", table.Error)
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// Erase erases the data of the subject with the given key from each of
+// the Eraser's tables.  A failure in one table does not stop the
+// others; it is recorded in the report, and the first such is
+// returned.  Run Erase again to finish a failed erasure.
+func (e *Eraser) Erase(key string) (report Report, err error) {
+	report = Report{Key: key, Started: time.Now(), DryRun: e.DryRun}
+	if key == "" {
+		return report, fmt.Errorf("Cannot query for the blank key")
+	}
+	if err = keyQuery(Table{}, key).Check(); err != nil {
+		return report, err
+	}
+	for _, table := range e.Tables {
+		tableReport, tableErr := e.erase(table, key)
+		if tableErr != nil {
+			tableReport.Error = tableErr.Error()
+			if err == nil {
+				err = fmt.Errorf("Erasing from %s: %s", table.Dbid, tableErr)
+			}
+		}
+		report.Tables = append(report.Tables, tableReport)
+	}
+	report.Finished = time.Now()
+	return report, err
+}
+
+// keyQuery returns the query for the Record IDs and files of table's
+// records holding key.
+func keyQuery(table Table, key string) *criteria.Query {
+	return criteria.Select(append([]int{3}, table.FileFids...)...).Where(criteria.Field(table.KeyFid).Eq(key)).SortBy(3)
+}
+
+func (e *Eraser) erase(table Table, key string) (report TableReport, err error) {
+	report = TableReport{Dbid: table.Dbid}
+	query := keyQuery(table, key)
+	records, err := e.QB.DoStructuredQuery(table.Dbid, query.Query(), query.Clist(), query.Slist(), query.Options())
+	if err != nil {
+		return report, err
+	}
+	files := make(map[int]int)
+	for _, record := range records {
+		rid, err := strconv.Atoi(record[3])
+		if err != nil {
+			return report, fmt.Errorf("Bad Record ID# %q", record[3])
+		}
+		report.Found = append(report.Found, rid)
+		for _, fid := range table.FileFids {
+			if record[fid] != "" {
+				files[rid]++
+			}
+		}
+	}
+	sort.Ints(report.Found)
+	if e.DryRun {
+		return report, nil
+	}
+	for _, rid := range report.Found {
+		if table.Anonymize == nil {
+			if err = e.QB.DeleteRecord(table.Dbid, rid); err != nil {
+				return report, err
+			}
+			report.Deleted = append(report.Deleted, rid)
+			report.Files += files[rid]
+			continue
+		}
+		fields := make(map[int]string)
+		for fid, value := range table.Anonymize {
+			fields[fid] = value
+		}
+		for _, fid := range table.FileFids {
+			fields[fid] = ""
+		}
+		if err = e.QB.EditRecordByFid(table.Dbid, rid, fields); err != nil {
+			return report, err
+		}
+		report.Anonymized = append(report.Anonymized, rid)
+		report.Files += files[rid]
+	}
+	return report, nil
+}
@@ -0,0 +1,98 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package erasure_test
+
+import (
+	"github.com/WesTower/quickbase/erasure"
+	"github.com/WesTower/quickbase/quickbasetest"
+	"strings"
+	"testing"
+)
+
+const (
+	contacts = "bck7gp3q2"
+	orders   = "bck7gp3q3"
+)
+
+func TestErase(t *testing.T) {
+	fake := quickbasetest.NewFake()
+	fake.AddTable(contacts, map[int]string{6: "Email", 7: "Name"})
+	fake.AddTable(orders, map[int]string{6: "Customer Email", 7: "Total", 8: "Receipt"})
+	fake.Seed(contacts, map[int]string{6: "jdoe@example.com", 7: "Jane Doe"})
+	fake.Seed(contacts, map[int]string{6: "rroe@example.com", 7: "Richard Roe"})
+	fake.Seed(orders, map[int]string{6: "JDoe@example.com", 7: "12.50", 8: "receipt.pdf"})
+	fake.Seed(orders, map[int]string{6: "jdoe@example.com", 7: "3"})
+	fake.Seed(orders, map[int]string{6: "rroe@example.com", 7: "7"})
+	eraser := &erasure.Eraser{QB: fake, DryRun: true, Tables: []erasure.Table{
+		{Dbid: contacts, KeyFid: 6},
+		{Dbid: orders, KeyFid: 6, Anonymize: map[int]string{6: "erased"}, FileFids: []int{8}},
+	}}
+
+	report, err := eraser.Erase("jdoe@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Tables) != 2 || len(report.Tables[0].Found) != 1 || len(report.Tables[1].Found) != 2 || report.Tables[1].Deleted != nil {
+		t.Errorf("dry run reported %+v", report)
+	}
+	if records := fake.Records(contacts); len(records) != 2 {
+		t.Errorf("dry run changed the table: %v", records)
+	}
+
+	eraser.DryRun = false
+	if report, err = eraser.Erase("jdoe@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if !report.Done() {
+		t.Errorf("erasure not done: %v", report)
+	}
+	want := "  bck7gp3q2: 1 found, 1 deleted, 0 anonymized, 0 files removed\n" +
+		"  bck7gp3q3: 2 found, 0 deleted, 2 anonymized, 1 files removed\n"
+	if got := report.String(); !strings.HasSuffix(got, want) {
+		t.Errorf("report is\n%s", got)
+	}
+	if records := fake.Records(contacts); len(records) != 1 || records[0][7] != "Richard Roe" {
+		t.Errorf("contacts left %v", records)
+	}
+	for _, record := range fake.Records(orders)[:2] {
+		if record[6] != "erased" || record[8] != "" || record[7] == "" {
+			t.Errorf("order left %v", record)
+		}
+	}
+
+	eraser.Tables = append(eraser.Tables, erasure.Table{Dbid: "bck7gp3q9", KeyFid: 6})
+	report, err = eraser.Erase("rroe@example.com")
+	if err == nil || report.Done() || report.Tables[2].Error == "" || len(report.Tables[1].Anonymized) != 1 {
+		t.Errorf("erasure with a missing table gave %v, %v", report, err)
+	}
+
+	fake.Seed(contacts, map[int]string{6: "o'brien@example.com", 7: "Pat O'Brien"})
+	eraser.Tables = eraser.Tables[:1]
+	if report, err = eraser.Erase("o'brien@example.com"); err != nil || len(report.Tables[0].Deleted) != 1 {
+		t.Errorf("erasure of a key with a quote gave %v, %v", report, err)
+	}
+	if _, err = eraser.Erase("o'brien'"); err == nil {
+		t.Error("erasure of a key ending in a quote succeeded")
+	}
+	if _, err = eraser.Erase(""); err == nil {
+		t.Error("erasure of a blank key succeeded")
+	}
+}
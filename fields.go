@@ -0,0 +1,158 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"strconv"
+)
+
+// SetFieldProperties changes the properties of a field, such as its
+// 'label' or 'required', with properties as documented at
+// <http://www.quickbase.com/api-guide/index.html#setfieldproperties.html>.
+func SetFieldProperties(ticket Ticket, dbid string, fid int, properties map[string]string) (err error) {
+	return setFieldPropertiesCall(ticket, dbid, fid, properties).execute(nil)
+}
+
+func setFieldPropertiesCall(ticket Ticket, dbid string, fid int, properties map[string]string) apiCall {
+	params := ticket.params()
+	for name, value := range properties {
+		params[name] = value
+	}
+	params["fid"] = strconv.Itoa(fid)
+	return apiCall{ticket, ticket.url + "db/" + dbid, "API_SetFieldProperties", params}
+}
+
+func (c *Client) SetFieldProperties(dbid string, fid int, properties map[string]string) (err error) {
+	return c.mutate(setFieldPropertiesCall(c.ticket(), dbid, fid, properties), nil)
+}
+
+// FieldProperties describes a field for AddField and UpdateField.
+// Empty and nil members are left as they are.
+type FieldProperties struct {
+	Label    string
+	Type     string // e.g. 'text' or 'float'; only AddField sets it
+	Required *bool
+	Unique   *bool
+	Choices  []string // added to a multiple-choice field's choices
+	Default  string   // the default value of new records
+}
+
+// params returns the parameters of API_SetFieldProperties for the
+// properties other than Type and Choices.
+func (p FieldProperties) params() map[string]string {
+	params := make(map[string]string)
+	if p.Label != "" {
+		params["label"] = p.Label
+	}
+	if p.Required != nil {
+		params["required"] = CheckboxValue(*p.Required)
+	}
+	if p.Unique != nil {
+		params["unique"] = CheckboxValue(*p.Unique)
+	}
+	if p.Default != "" {
+		params["default_value"] = p.Default
+	}
+	return params
+}
+
+// AddField adds a field to a table, returning its field ID.  The
+// field's Label and Type are required; its other properties are set
+// after it is added, as UpdateField does.
+func AddField(ticket Ticket, dbid string, properties FieldProperties) (fid int, err error) {
+	var result addFieldResponse
+	if err = addFieldCall(ticket, dbid, properties).execute(&result); err != nil {
+		return 0, err
+	}
+	if fid, err = result.fieldId(); err != nil {
+		return 0, err
+	}
+	properties.Label = ""
+	return fid, UpdateField(ticket, dbid, fid, properties)
+}
+
+func addFieldCall(ticket Ticket, dbid string, properties FieldProperties) apiCall {
+	params := ticket.params()
+	params["label"] = properties.Label
+	params["type"] = properties.Type
+	return apiCall{ticket, ticket.url + "db/" + dbid, "API_AddField", params}
+}
+
+// UpdateField changes the properties of a field.  The Type of a field
+// cannot be changed, and Choices are added to those it has, each with
+// a call to API_FieldAddChoices.
+func UpdateField(ticket Ticket, dbid string, fid int, properties FieldProperties) (err error) {
+	for _, call := range updateFieldCalls(ticket, dbid, fid, properties) {
+		if err = call.execute(nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func updateFieldCalls(ticket Ticket, dbid string, fid int, properties FieldProperties) (calls []apiCall) {
+	if params := properties.params(); len(params) > 0 {
+		calls = append(calls, setFieldPropertiesCall(ticket, dbid, fid, params))
+	}
+	for _, choice := range properties.Choices {
+		params := ticket.params()
+		params["fid"] = strconv.Itoa(fid)
+		params["choice"] = choice
+		calls = append(calls, apiCall{ticket, ticket.url + "db/" + dbid, "API_FieldAddChoices", params})
+	}
+	return calls
+}
+
+// DeleteField deletes a field from a table, with all its values.
+func DeleteField(ticket Ticket, dbid string, fid int) (err error) {
+	return deleteFieldCall(ticket, dbid, fid).execute(nil)
+}
+
+func deleteFieldCall(ticket Ticket, dbid string, fid int) apiCall {
+	params := ticket.params()
+	params["fid"] = strconv.Itoa(fid)
+	return apiCall{ticket, ticket.url + "db/" + dbid, "API_DeleteField", params}
+}
+
+func (c *Client) AddField(dbid string, properties FieldProperties) (fid int, err error) {
+	var result addFieldResponse
+	if err = c.mutate(addFieldCall(c.ticket(), dbid, properties), &result); err != nil || c.DryRun {
+		return 0, err
+	}
+	if fid, err = result.fieldId(); err != nil {
+		return 0, err
+	}
+	properties.Label = ""
+	return fid, c.UpdateField(dbid, fid, properties)
+}
+
+func (c *Client) UpdateField(dbid string, fid int, properties FieldProperties) (err error) {
+	for _, call := range updateFieldCalls(c.ticket(), dbid, fid, properties) {
+		if err = c.mutate(call, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) DeleteField(dbid string, fid int) (err error) {
+	return c.mutate(deleteFieldCall(c.ticket(), dbid, fid), nil)
+}
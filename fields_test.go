@@ -0,0 +1,76 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"github.com/WesTower/quickbase"
+	"reflect"
+	"testing"
+)
+
+func TestFieldManagement(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	required := true
+	fid, err := client.AddField(testTableDbid, quickbase.FieldProperties{Label: "Status", Type: "text", Required: &required, Choices: []string{"Open", "Closed"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fid != 8 {
+		t.Errorf("new field has fid %d, not 8", fid)
+	}
+	if err = client.UpdateField(testTableDbid, fid, quickbase.FieldProperties{Label: "State", Choices: []string{"Closed", "Billed"}}); err != nil {
+		t.Fatal(err)
+	}
+	field := findField(t, client, fid)
+	if field == nil || field.Label != "State" || !reflect.DeepEqual(field.Choices, []string{"Open", "Closed", "Billed"}) {
+		t.Errorf("field %d is %+v", fid, field)
+	}
+	if _, err = client.AddField(testTableDbid, quickbase.FieldProperties{Label: "site", Type: "text"}); err == nil {
+		t.Error("AddField succeeded with a duplicate label")
+	}
+	if err = client.DeleteField(testTableDbid, fid); err != nil {
+		t.Fatal(err)
+	}
+	if field = findField(t, client, fid); field != nil {
+		t.Errorf("field %d survived DeleteField", fid)
+	}
+	if err = client.DeleteField(testTableDbid, 3); err == nil {
+		t.Error("DeleteField deleted Record ID#")
+	}
+}
+
+func findField(t *testing.T, client *quickbase.Client, fid int) *quickbase.Field {
+	schema, err := client.GetSchema(testTableDbid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, field := range schema.Fields {
+		if field.Id == fid {
+			return &field
+		}
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Value is a field value of a type suited to the field's type, as
+// DecodeValue and DoQueryValues return.  Encode gives it in the form
+// QuickBase accepts when writing to such a field; EncodeValue and
+// EncodeFields accept Values.
+type Value interface {
+	Encode() (value string, err error)
+}
+
+// Text is the value of a text field, or of any field whose type has no
+// Value of its own.
+type Text string
+
+// Number is the value of a numeric, currency, percent, rating or
+// Record ID# field.
+type Number float64
+
+// Date is the value of a date field: a calendar date, at midnight UTC.
+type Date struct {
+	time.Time
+}
+
+// DateTime is the value of a date/time field.
+type DateTime struct {
+	time.Time
+}
+
+// Checkbox is the value of a checkbox field.
+type Checkbox bool
+
+// UserValue is the value of a user field: the user's ID, and their
+// name if the query reported it.
+type UserValue struct {
+	Id   string
+	Name string
+}
+
+// FileAttachment is the value of a file attachment field: the name of
+// the file, and the URL from which it may be downloaded if the query
+// reported it.
+type FileAttachment struct {
+	Name string
+	Url  string
+}
+
+func (v Text) Encode() (string, error) {
+	return string(v), nil
+}
+
+func (v Number) Encode() (string, error) {
+	return NumberValue(float64(v))
+}
+
+func (v Date) Encode() (string, error) {
+	return DateValue(v.Time), nil
+}
+
+func (v DateTime) Encode() (string, error) {
+	return TimestampValue(v.Time), nil
+}
+
+func (v Checkbox) Encode() (string, error) {
+	return CheckboxValue(bool(v)), nil
+}
+
+func (v UserValue) Encode() (string, error) {
+	return v.Id, nil
+}
+
+// Encode returns an error: a file is written with Upload.
+func (v FileAttachment) Encode() (string, error) {
+	return "", fmt.Errorf("Cannot write file %s as a field value; use Upload", v.Name)
+}
+
+// DecodeValue decodes a field value as API_DoQuery returns it, given
+// the field's type as API_GetSchema and structured queries report it,
+// e.g. "float" or "timestamp".  A blank value of any type but text
+// decodes as nil.
+func DecodeValue(fieldType, value string) (v Value, err error) {
+	if value == "" {
+		if valueIsText(fieldType) {
+			return Text(""), nil
+		}
+		return nil, nil
+	}
+	switch fieldType {
+	case "float", "currency", "percent", "rating", "recordid":
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Bad %s value %q", fieldType, value)
+		}
+		return Number(f), nil
+	case "date":
+		msecs, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Bad date value %q", value)
+		}
+		return Date{time.Unix(msecs/1000, 0).UTC()}, nil
+	case "timestamp":
+		msecs, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Bad date/time value %q", value)
+		}
+		return DateTime{time.Unix(msecs/1000, (msecs%1000)*int64(time.Millisecond))}, nil
+	case "checkbox":
+		return Checkbox(value == "1" || strings.EqualFold(value, "true")), nil
+	case "userid":
+		return UserValue{Id: value}, nil
+	case "file":
+		return FileAttachment{Name: value}, nil
+	}
+	return Text(value), nil
+}
+
+// valueIsText reports whether DecodeValue gives values of a field type
+// as Text.
+func valueIsText(fieldType string) bool {
+	switch fieldType {
+	case "float", "currency", "percent", "rating", "recordid", "date", "timestamp", "checkbox", "userid", "file":
+		return false
+	}
+	return true
+}
+
+// DoQueryValues is like DoStructuredQuery, but decodes each field's
+// value with DecodeValue, by the field types the structured response
+// reports.  User fields carry the names of their users, and file
+// attachments their URLs.
+func DoQueryValues(ticket Ticket, dbid, query, clist, slist, options string) (records []map[int]Value, err error) {
+	params := ticket.params()
+	params["fmt"] = "structured"
+	if query != "" {
+		params["query"] = query
+	}
+	result, err := structuredQuery(ticket, dbid, params, clist, slist, options)
+	if err != nil {
+		return nil, err
+	}
+	types := make(map[int]string, len(result.StructuredFields))
+	for _, field := range result.StructuredFields {
+		types[field.Id] = field.FieldType
+	}
+	users := make(map[string]string, len(result.Lusers))
+	for _, user := range result.Lusers {
+		users[user.Id] = user.Name
+	}
+	for _, record := range result.StructuredRecords {
+		values := make(map[int]Value)
+		for _, field := range record.Fields {
+			if field.Name != "f" {
+				continue
+			}
+			v, err := DecodeValue(types[field.Id], field.Value)
+			if err != nil {
+				return nil, fmt.Errorf("Field %d: %s", field.Id, err)
+			}
+			switch value := v.(type) {
+			case UserValue:
+				value.Name = users[value.Id]
+				v = value
+			case FileAttachment:
+				value.Url = field.Url
+				v = value
+			}
+			values[field.Id] = v
+		}
+		records = append(records, values)
+	}
+	return records, nil
+}
+
+// DoQueryValues calls DoQueryValues against the Client's instance.
+func (c *Client) DoQueryValues(dbid, query, clist, slist, options string) (records []map[int]Value, err error) {
+	return DoQueryValues(c.ticket(), dbid, query, clist, slist, options)
+}
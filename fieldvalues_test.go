@@ -0,0 +1,108 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"github.com/WesTower/quickbase"
+	"github.com/WesTower/quickbase/quickbasetest"
+	"testing"
+	"time"
+)
+
+func TestDecodeValue(t *testing.T) {
+	for _, test := range []struct {
+		fieldType, value string
+		want             quickbase.Value
+	}{
+		{"text", "Denver", quickbase.Text("Denver")},
+		{"text", "", quickbase.Text("")},
+		{"phone", "(303) 555-1234", quickbase.Text("(303) 555-1234")},
+		{"currency", "12.5", quickbase.Number(12.5)},
+		{"float", "", nil},
+		{"recordid", "7", quickbase.Number(7)},
+		{"date", "1425168000000", quickbase.Date{time.Date(2015, 3, 1, 0, 0, 0, 0, time.UTC)}},
+		{"timestamp", "1425277800999", quickbase.DateTime{time.Unix(1425277800, 999000000)}},
+		{"checkbox", "1", quickbase.Checkbox(true)},
+		{"checkbox", "0", quickbase.Checkbox(false)},
+		{"userid", "56760415.bkxs", quickbase.UserValue{Id: "56760415.bkxs"}},
+		{"file", "plan.pdf", quickbase.FileAttachment{Name: "plan.pdf"}},
+	} {
+		got, err := quickbase.DecodeValue(test.fieldType, test.value)
+		if err != nil {
+			t.Errorf("%s %q: %v", test.fieldType, test.value, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s %q gave %#v, want %#v", test.fieldType, test.value, got, test.want)
+		}
+		if got == nil {
+			continue
+		}
+		if encoded, err := quickbase.EncodeValue(got); test.fieldType != "file" && (err != nil || encoded != test.value) {
+			t.Errorf("%s %q encoded as %q, %v", test.fieldType, test.value, encoded, err)
+		}
+	}
+	if _, err := quickbase.DecodeValue("float", "12,5"); err == nil {
+		t.Error("DecodeValue of a bad number succeeded")
+	}
+	if _, err := quickbase.EncodeValue(quickbase.FileAttachment{Name: "plan.pdf"}); err == nil {
+		t.Error("EncodeValue of a file attachment succeeded")
+	}
+}
+
+func TestDoQueryValues(t *testing.T) {
+	server := quickbasetest.NewServer()
+	defer server.Close()
+	server.AddUser("jdoe", "secret")
+	table := server.AddTable(testTableDbid, map[int]string{6: "Site", 7: "Cost", 8: "Active"})
+	table.Types[7], table.Types[8] = "currency", "checkbox"
+	server.Seed(testTableDbid, map[int]string{6: "Denver", 7: "12.50", 8: "1"})
+	ticket, err := authenticate(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := quickbase.DoQueryValues(ticket, testTableDbid, "", "6.7.8", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0][6] != quickbase.Text("Denver") || records[0][7] != quickbase.Number(12.5) || records[0][8] != quickbase.Checkbox(true) {
+		t.Errorf("unexpected records %v", records)
+	}
+
+	fixture := quickbasetest.NewFixtureServer(map[string][]byte{"API_DoQuery": []byte(`<?xml version="1.0" ?>
+<qdbapi><errcode>0</errcode><errtext>No error</errtext><table>
+<fields><field id="4" field_type="userid" base_type="text"/><field id="9" field_type="file" base_type="text"/></fields>
+<lusers><luser id="56760415.bkxs">Jane Doe</luser></lusers>
+<records><record rid="1" update_id="1"><f id="4">56760415.bkxs</f><f id="9">plan.pdf<url>https://example.quickbase.com/up/bck7gp3q2/a/r1/e9/v0</url></f></record></records>
+</table></qdbapi>`)})
+	defer fixture.Close()
+	ticket, err = quickbase.Authenticate(fixture.BaseUrl(), "fixture", "fixture")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if records, err = quickbase.DoQueryValues(ticket, testTableDbid, "", "4.9", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0][4] != (quickbase.UserValue{"56760415.bkxs", "Jane Doe"}) ||
+		records[0][9] != (quickbase.FileAttachment{"plan.pdf", "https://example.quickbase.com/up/bck7gp3q2/a/r1/e9/v0"}) {
+		t.Errorf("unexpected records %v", records)
+	}
+}
@@ -0,0 +1,110 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"github.com/WesTower/quickbase"
+	"github.com/WesTower/quickbase/quickbasetest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fixtureTicket authenticates against a FixtureServer answering
+// action with the named file of the testdata corpus.
+func fixtureTicket(t *testing.T, action, name string) (*quickbasetest.FixtureServer, quickbase.Ticket) {
+	server, err := quickbasetest.NewFixtureFileServer(map[string]string{action: filepath.Join("testdata", name)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ticket, err := quickbase.Authenticate(server.BaseUrl(), "fixture", "fixture")
+	if err != nil {
+		server.Close()
+		t.Fatal(err)
+	}
+	return server, ticket
+}
+
+func golden(name string) string {
+	return filepath.Join("testdata", strings.TrimSuffix(name, ".xml")+".golden")
+}
+
+func TestDoQueryCorpus(t *testing.T) {
+	for _, name := range []string{"doquery_br.xml", "doquery_entities.xml", "doquery_empty.xml", "doquery_none.xml"} {
+		server, ticket := fixtureTicket(t, "API_DoQuery", name)
+		records, err := quickbase.DoQuery(ticket, "bck7gp3q2", "", "", "", "")
+		server.Close()
+		if err != nil {
+			t.Errorf("%s: %s", name, err)
+			continue
+		}
+		quickbasetest.AssertGolden(t, golden(name), records)
+	}
+}
+
+func TestStructuredQueryCorpus(t *testing.T) {
+	server, ticket := fixtureTicket(t, "API_DoQuery", "structured_query.xml")
+	defer server.Close()
+	records, err := quickbase.DoStructuredQuery(ticket, "bck7gp3q2", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	quickbasetest.AssertGolden(t, golden("structured_query.xml"), records)
+}
+
+func TestGetSchemaCorpus(t *testing.T) {
+	server, ticket := fixtureTicket(t, "API_GetSchema", "getschema_lookups.xml")
+	defer server.Close()
+	schema, err := quickbase.GetSchema(ticket, "bck7gp3q3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	quickbasetest.AssertGolden(t, golden("getschema_lookups.xml"), schema)
+}
+
+func TestGetAppSchemaCorpus(t *testing.T) {
+	server, ticket := fixtureTicket(t, "API_GetSchema", "getschema_app.xml")
+	defer server.Close()
+	schema, err := quickbase.GetSchema(ticket, "bck7gp3q1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	quickbasetest.AssertGolden(t, golden("getschema_app.xml"), schema)
+}
+
+func TestDoQueryCountCorpus(t *testing.T) {
+	server, ticket := fixtureTicket(t, "API_DoQueryCount", "doquerycount.xml")
+	defer server.Close()
+	if count, err := quickbase.DoQueryCount(ticket, "bck7gp3q2", ""); err != nil || count != 42 {
+		t.Errorf("DoQueryCount gave %d, %v", count, err)
+	}
+}
+
+func TestErrorCorpus(t *testing.T) {
+	server, ticket := fixtureTicket(t, "API_DoQuery", "error_detail.xml")
+	defer server.Close()
+	_, err := quickbase.DoQuery(ticket, "bck7gp3q2", "", "", "", "")
+	qbErr, ok := err.(quickbase.QuickBaseError)
+	if !ok {
+		t.Fatalf("DoQuery gave %v", err)
+	}
+	quickbasetest.AssertGolden(t, golden("error_detail.xml"), qbErr)
+}
@@ -0,0 +1,3 @@
+module github.com/WesTower/quickbase
+
+go 1.21
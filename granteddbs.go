@@ -0,0 +1,127 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+// A GrantedDB is an application or table the user may access, as
+// listed by GrantedDBs.
+type GrantedDB struct {
+	Dbid string
+	// Name is an application's name or, for a table, the
+	// application's name and the table's, e.g. 'Towers: Sites'.
+	Name string
+	// Parent is, for a table, the dbid of its application, if that
+	// is listed too; it is empty for an application.
+	// API_GrantedDBs does not report it, so it is only set if
+	// GrantedDBsOptions.FindParents is.
+	Parent string
+	// SchemaErr is, for an application, why FindParents could not
+	// get its schema; its tables are then left without a Parent.
+	SchemaErr error
+}
+
+// GrantedDBsOptions select what GrantedDBsWithOptions lists.
+type GrantedDBsOptions struct {
+	AdminOnly      bool // only those the user administers
+	ExcludeParents bool // tables only, not their applications
+	ExcludeTables  bool // applications only, not their tables
+	RealmAppsOnly  bool // only those of the ticket's realm
+	// FindParents sets the Parent of each table, from the Tables of
+	// the Schema of each application, at the cost of another
+	// API_GrantedDBs call and a GetSchema call per application.  It
+	// does nothing if either ExcludeParents or ExcludeTables is set.
+	FindParents bool
+}
+
+// GrantedDBs returns the applications the user may access, each
+// followed by its tables, so that programs may discover dbids rather
+// than have them configured.  The tables' Parents are not set;
+// GrantedDBsWithOptions finds them if told to.
+func GrantedDBs(ticket Ticket) (dbs []GrantedDB, err error) {
+	return GrantedDBsWithOptions(ticket, GrantedDBsOptions{})
+}
+
+// GrantedDBsWithOptions is like GrantedDBs, but lists those selected
+// by options.
+func GrantedDBsWithOptions(ticket Ticket, options GrantedDBsOptions) (dbs []GrantedDB, err error) {
+	if dbs, err = grantedDBs(ticket, options); err != nil {
+		return nil, err
+	}
+	if options.FindParents && !options.ExcludeParents && !options.ExcludeTables {
+		if err = findParents(ticket, options, dbs); err != nil {
+			return nil, err
+		}
+	}
+	return dbs, nil
+}
+
+func grantedDBs(ticket Ticket, options GrantedDBsOptions) (dbs []GrantedDB, err error) {
+	params := ticket.params()
+	params["adminOnly"] = CheckboxValue(options.AdminOnly)
+	params["excludeparents"] = CheckboxValue(options.ExcludeParents)
+	params["withembeddedtables"] = CheckboxValue(!options.ExcludeTables)
+	params["realmAppsOnly"] = CheckboxValue(options.RealmAppsOnly)
+	var result grantedDBsResponse
+	if err = executeApiCall(ticket, ticket.url+"db/main", "API_GrantedDBs", params, &result); err != nil {
+		return nil, err
+	}
+	for _, db := range result.Databases {
+		dbs = append(dbs, GrantedDB{Dbid: string(db.Dbid), Name: string(db.Name)})
+	}
+	return dbs, nil
+}
+
+// findParents sets the Parent of each table of dbs to the application
+// whose Schema lists it among its Tables.  The applications are those
+// API_GrantedDBs lists without tables, so that tables are not asked
+// for their schemas; an application whose schema cannot be had is
+// given its SchemaErr, and the others are still asked.
+func findParents(ticket Ticket, options GrantedDBsOptions, dbs []GrantedDB) (err error) {
+	options.ExcludeTables = true
+	apps, err := grantedDBs(ticket, options)
+	if err != nil {
+		return err
+	}
+	parents := make(map[string]string)
+	failures := make(map[string]error)
+	for _, app := range apps {
+		schema, err := GetSchema(ticket, app.Dbid)
+		if err != nil {
+			failures[app.Dbid] = err
+			continue
+		}
+		for _, table := range schema.Tables {
+			parents[table] = app.Dbid
+		}
+	}
+	for i := range dbs {
+		dbs[i].Parent = parents[dbs[i].Dbid]
+		dbs[i].SchemaErr = failures[dbs[i].Dbid]
+	}
+	return nil
+}
+
+func (c *Client) GrantedDBs() (dbs []GrantedDB, err error) {
+	return GrantedDBs(c.ticket())
+}
+
+func (c *Client) GrantedDBsWithOptions(options GrantedDBsOptions) (dbs []GrantedDB, err error) {
+	return GrantedDBsWithOptions(c.ticket(), options)
+}
@@ -0,0 +1,99 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"github.com/WesTower/quickbase"
+	"reflect"
+	"testing"
+)
+
+func TestGrantedDBs(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	app, err := client.CreateDatabase("Towers", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sites, err := client.CreateTable(app.Dbid, "Sites", "Site")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dbs, err := client.GrantedDBs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []quickbase.GrantedDB{
+		{testAppDbid, testAppDbid, "", nil},
+		{testTableDbid, testAppDbid + ": " + testTableDbid, "", nil},
+		{app.Dbid, "Towers", "", nil},
+		{sites, "Towers: Sites", "", nil},
+	}
+	if !reflect.DeepEqual(dbs, want) {
+		t.Errorf("GrantedDBs gave %v, not %v", dbs, want)
+	}
+	want[1].Parent, want[3].Parent = testAppDbid, app.Dbid
+	if dbs, err = client.GrantedDBsWithOptions(quickbase.GrantedDBsOptions{FindParents: true}); err != nil || !reflect.DeepEqual(dbs, want) {
+		t.Errorf("GrantedDBs finding parents gave %v, %v, not %v", dbs, err, want)
+	}
+	if dbs, err = client.GrantedDBsWithOptions(quickbase.GrantedDBsOptions{ExcludeTables: true}); err != nil || !reflect.DeepEqual(dbs, []quickbase.GrantedDB{want[0], want[2]}) {
+		t.Errorf("GrantedDBs without tables gave %v, %v", dbs, err)
+	}
+	tables := []quickbase.GrantedDB{{testTableDbid, testAppDbid + ": " + testTableDbid, "", nil}, {sites, "Towers: Sites", "", nil}}
+	if dbs, err = client.GrantedDBsWithOptions(quickbase.GrantedDBsOptions{ExcludeParents: true, FindParents: true}); err != nil || !reflect.DeepEqual(dbs, tables) {
+		t.Errorf("GrantedDBs without applications gave %v, %v", dbs, err)
+	}
+}
+
+func TestGrantedDBsSchemaFailure(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	app, err := client.CreateDatabase("Towers", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sites, err := client.CreateTable(app.Dbid, "Sites", "Site")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.FailNext("API_GetSchema", 4, "User not authorized")
+	dbs, err := client.GrantedDBsWithOptions(quickbase.GrantedDBsOptions{FindParents: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dbs) != 4 {
+		t.Fatalf("GrantedDBs gave %v", dbs)
+	}
+	if dbs[0].SchemaErr == nil || dbs[1].Parent != "" {
+		t.Errorf("GrantedDBs did not record the failure of %s: %v", testAppDbid, dbs[:2])
+	}
+	if dbs[2].SchemaErr != nil || dbs[3].Dbid != sites || dbs[3].Parent != app.Dbid {
+		t.Errorf("GrantedDBs gave up on %s after %s failed: %v", app.Dbid, testAppDbid, dbs[2:])
+	}
+}
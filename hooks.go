@@ -0,0 +1,132 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"net/http"
+	"time"
+)
+
+// Hooks are functions a Client calls at points in the lives of its
+// calls, so that programs may keep metrics, raise alerts or keep
+// their own records without wrapping every call.  Any may be nil.
+// They are called synchronously, perhaps from several goroutines at
+// once, so should be quick and safe for concurrent use.
+type Hooks struct {
+	// OnRequest is called as each HTTP request is sent.
+	OnRequest func(RequestEvent)
+	// OnResponse is called as each HTTP request completes,
+	// successfully or not.
+	OnResponse func(ResponseEvent)
+	// OnRetry is called before a call is made again: an import
+	// refused compression, a Modify whose record changed under it,
+	// or a page of DoQueryPaged too large.
+	OnRetry func(RetryEvent)
+	// OnRateLimited is called when QuickBase throttles a call, or
+	// asks that calls be held off.
+	OnRateLimited func(RateLimitEvent)
+	// OnAuthRefresh is called when a Client authenticates again to
+	// replace its ticket, by StartTicketRefresh, AuthenticateRenewing
+	// or a SessionPool.
+	OnAuthRefresh func(AuthRefreshEvent)
+}
+
+// A RequestEvent describes an HTTP request being sent.
+type RequestEvent struct {
+	Action string // e.g. 'API_DoQuery'; empty for file downloads
+	Method string
+	Url    string
+}
+
+// A ResponseEvent describes an HTTP request completed.  Err is an
+// error in sending the request or receiving its response; QuickBase's
+// own errors, returned with status 200, are left to the call.
+type ResponseEvent struct {
+	Action     string
+	Method     string
+	Url        string
+	StatusCode int // zero if Err is set
+	Duration   time.Duration
+	Err        error
+}
+
+// A RetryEvent describes a call about to be made again.
+type RetryEvent struct {
+	Action  string
+	Attempt int // the attempt about to be made, from 2
+	Err     error
+}
+
+// A RateLimitEvent describes QuickBase's throttling of a call.
+type RateLimitEvent struct {
+	Action     string
+	StatusCode int
+	Until      time.Time // when calls may resume; zero if not said
+}
+
+// An AuthRefreshEvent describes a Client's authenticating again.
+type AuthRefreshEvent struct {
+	Url    string
+	Userid string // the user's ID, once authenticated
+	Err    error
+}
+
+func (h *Hooks) request(req *http.Request) {
+	if h != nil && h.OnRequest != nil {
+		h.OnRequest(RequestEvent{req.Header.Get("QUICKBASE-ACTION"), req.Method, req.URL.String()})
+	}
+}
+
+func (h *Hooks) response(req *http.Request, resp *http.Response, start time.Time, err error) {
+	if h == nil || h.OnResponse == nil {
+		return
+	}
+	event := ResponseEvent{Action: req.Header.Get("QUICKBASE-ACTION"), Method: req.Method, Url: req.URL.String(),
+		Duration: time.Since(start), Err: err}
+	if resp != nil {
+		event.StatusCode = resp.StatusCode
+	}
+	h.OnResponse(event)
+}
+
+// rateLimited calls OnRateLimited if resp is throttled or asks that
+// calls be held off until until.
+func (h *Hooks) rateLimited(req *http.Request, resp *http.Response, until time.Time) {
+	if h == nil || h.OnRateLimited == nil {
+		return
+	}
+	throttled := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable
+	if throttled || !until.IsZero() {
+		h.OnRateLimited(RateLimitEvent{req.Header.Get("QUICKBASE-ACTION"), resp.StatusCode, until})
+	}
+}
+
+func (h *Hooks) retry(action string, attempt int, err error) {
+	if h != nil && h.OnRetry != nil {
+		h.OnRetry(RetryEvent{action, attempt, err})
+	}
+}
+
+func (h *Hooks) authRefresh(url, userid string, err error) {
+	if h != nil && h.OnAuthRefresh != nil {
+		h.OnAuthRefresh(AuthRefreshEvent{url, userid, err})
+	}
+}
@@ -0,0 +1,112 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"github.com/WesTower/quickbase"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// hookLog records the events given to Hooks.
+type hookLog struct {
+	mu        sync.Mutex
+	requests  []quickbase.RequestEvent
+	responses []quickbase.ResponseEvent
+	retries   []quickbase.RetryEvent
+	limits    []quickbase.RateLimitEvent
+	refreshes []quickbase.AuthRefreshEvent
+}
+
+func (l *hookLog) hooks() *quickbase.Hooks {
+	return &quickbase.Hooks{
+		OnRequest:     func(e quickbase.RequestEvent) { l.mu.Lock(); l.requests = append(l.requests, e); l.mu.Unlock() },
+		OnResponse:    func(e quickbase.ResponseEvent) { l.mu.Lock(); l.responses = append(l.responses, e); l.mu.Unlock() },
+		OnRetry:       func(e quickbase.RetryEvent) { l.mu.Lock(); l.retries = append(l.retries, e); l.mu.Unlock() },
+		OnRateLimited: func(e quickbase.RateLimitEvent) { l.mu.Lock(); l.limits = append(l.limits, e); l.mu.Unlock() },
+		OnAuthRefresh: func(e quickbase.AuthRefreshEvent) { l.mu.Lock(); l.refreshes = append(l.refreshes, e); l.mu.Unlock() },
+	}
+}
+
+func TestHooks(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	throttle := false
+	front := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if throttle {
+			throttle = false
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		server.ServeHTTP(w, r)
+	}))
+	defer front.Close()
+	log := &hookLog{}
+	client := &quickbase.Client{Hooks: log.hooks()}
+	if err := client.Authenticate(front.URL+"/", "jdoe", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.DoQueryCount(testTableDbid, ""); err != nil {
+		t.Fatal(err)
+	}
+	if len(log.requests) != 2 || log.requests[1].Action != "API_DoQueryCount" || log.requests[1].Method != "POST" {
+		t.Errorf("requests %+v", log.requests)
+	}
+	if len(log.responses) != 2 || log.responses[1].StatusCode != 200 || log.responses[1].Duration <= 0 {
+		t.Errorf("responses %+v", log.responses)
+	}
+
+	throttle = true
+	client.DoQueryCount(testTableDbid, "")
+	if len(log.limits) != 1 || log.limits[0].StatusCode != 429 || log.limits[0].Until.IsZero() {
+		t.Errorf("rate limits %+v", log.limits)
+	}
+
+	server.FailNext("API_EditRecord", 60, "Update conflict")
+	err := client.Modify(testTableDbid, 1, func(edit *quickbase.RecordEdit) error {
+		edit.Set(7, "13")
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(log.retries) != 1 || log.retries[0].Attempt != 2 || !quickbase.IsUpdateConflict(log.retries[0].Err) {
+		t.Errorf("retries %+v", log.retries)
+	}
+
+	password := quickbase.CredentialsFunc(func(url string) (quickbase.Credentials, error) {
+		return quickbase.Credentials{Username: "jdoe", Password: "secret"}, nil
+	})
+	if err = client.StartTicketRefresh(password, front.URL+"/", 12*time.Hour-10*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	client.StopTicketRefresh()
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	if len(log.refreshes) == 0 || log.refreshes[0].Err != nil || log.refreshes[0].Userid == "" {
+		t.Errorf("auth refreshes %+v", log.refreshes)
+	}
+}
@@ -0,0 +1,227 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// DefaultJoinBatch is the number of master records Join fetches in a
+// single query if JoinOptions.BatchSize is zero.
+const DefaultJoinBatch = 100
+
+// JoinOptions are the options of Join.
+type JoinOptions struct {
+	// Query, Clist, Slist and Options select the detail records,
+	// as for DoStructuredQuery, except that an empty Clist
+	// returns all fields; the relationship's reference field is
+	// added to Clist if missing.
+	Query, Clist, Slist, Options string
+	MasterKeyFid                 int    // the master's key field, referenced; if zero, Record ID# (3)
+	MasterClist                  string // the master fields to return; if empty or "a", all
+	BatchSize                    int    // the master records to fetch in each query
+}
+
+// A JoinedRecord is a detail record and the master record it
+// references, which is nil if its reference field is blank or names
+// no record.
+type JoinedRecord struct {
+	Detail map[int]string
+	Master map[int]string
+}
+
+// Join queries the detail (child) table of a relationship and returns
+// each record found along with the master (parent) record it
+// references, fetching the masters in batches of queries matching
+// several keys at once, rather than one query for each detail.  Each
+// master record is fetched once, however many details reference it.
+func Join(ticket Ticket, relationship Relationship, options JoinOptions) (records []JoinedRecord, err error) {
+	return join(ticket, relationship, options, func(keys []string) (map[string]map[int]string, error) {
+		return fetchMasters(ticket, relationship.ParentDbid, options, keys)
+	})
+}
+
+func join(ticket Ticket, relationship Relationship, options JoinOptions, masters func(keys []string) (map[string]map[int]string, error)) (records []JoinedRecord, err error) {
+	ref := strconv.Itoa(relationship.ReferenceFid)
+	clist := withFid(options.Clist, ref)
+	details, err := DoStructuredQuery(ticket, relationship.ChildDbid, options.Query, clist, options.Slist, options.Options)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	seen := make(map[string]bool)
+	for _, detail := range details {
+		key := detail[relationship.ReferenceFid]
+		if key != "" && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	found, err := masters(keys)
+	if err != nil {
+		return nil, err
+	}
+	for _, detail := range details {
+		records = append(records, JoinedRecord{detail, found[detail[relationship.ReferenceFid]]})
+	}
+	return records, nil
+}
+
+// withFid returns clist with fid appended if missing, or "a" if clist
+// is empty, since DoStructuredQuery would otherwise return only the
+// default columns.
+func withFid(clist, fid string) string {
+	if clist == "" || clist == "a" {
+		return "a"
+	}
+	if !containsFid(clist, fid) {
+		clist += "." + fid
+	}
+	return clist
+}
+
+func containsFid(clist, fid string) bool {
+	for _, f := range strings.Split(clist, ".") {
+		if f == fid {
+			return true
+		}
+	}
+	return false
+}
+
+// fetchMasters fetches the master records with the given keys, in
+// batches, returning them by key.
+func fetchMasters(ticket Ticket, dbid string, options JoinOptions, keys []string) (masters map[string]map[int]string, err error) {
+	keyFid := options.MasterKeyFid
+	if keyFid == 0 {
+		keyFid = 3
+	}
+	batch := options.BatchSize
+	if batch <= 0 {
+		batch = DefaultJoinBatch
+	}
+	clist := withFid(options.MasterClist, strconv.Itoa(keyFid))
+	masters = make(map[string]map[int]string)
+	for len(keys) > 0 {
+		n := batch
+		if n > len(keys) {
+			n = len(keys)
+		}
+		query, err := anyOf(keyFid, keys[:n])
+		if err != nil {
+			return nil, err
+		}
+		records, err := DoStructuredQuery(ticket, dbid, query, clist, "", "")
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range records {
+			masters[record[keyFid]] = record
+		}
+		keys = keys[n:]
+	}
+	return masters, nil
+}
+
+// anyOf returns a query matching the records whose field fid is any
+// of values.
+func anyOf(fid int, values []string) (query string, err error) {
+	criteria := make([]string, len(values))
+	for i, value := range values {
+		if criteria[i], err = exactly(fid, value); err != nil {
+			return "", err
+		}
+	}
+	return strings.Join(criteria, "OR"), nil
+}
+
+// exactly returns the criterion matching the records whose field fid
+// is exactly value.  As the criteria package's Validate does, it
+// refuses the values which would end a criterion early: those
+// containing '} or ending in a quote.  Other quotes, as in O'Brien,
+// are matched as they are.
+func exactly(fid int, value string) (criterion string, err error) {
+	if strings.Contains(value, "'}") || strings.HasSuffix(value, "'") {
+		return "", fmt.Errorf("Cannot query for a value containing '} or ending in ': %q", value)
+	}
+	return fmt.Sprintf("{'%d'.EX.'%s'}", fid, value), nil
+}
+
+// Join is as the package-level function, except that a Client with a
+// Cache looks for the master records there before fetching those it
+// lacks, which it then caches.  Masters are fetched whole to be
+// cached, and cut down to options.MasterClist when returned.
+func (c *Client) Join(relationship Relationship, options JoinOptions) (records []JoinedRecord, err error) {
+	if c.Cache == nil {
+		return Join(c.ticket(), relationship, options)
+	}
+	keyFid := options.MasterKeyFid
+	if keyFid == 0 {
+		keyFid = 3
+	}
+	dbid := relationship.ParentDbid
+	return join(c.ticket(), relationship, options, func(keys []string) (masters map[string]map[int]string, err error) {
+		masters = make(map[string]map[int]string)
+		var missing []string
+		var generation uint64
+		for _, key := range keys {
+			record, g, ok := c.Cache.get(dbid, cacheKey{keyFid, key})
+			if ok {
+				masters[key] = record
+				continue
+			}
+			if len(missing) == 0 {
+				generation = g
+			}
+			missing = append(missing, key)
+		}
+		whole := options
+		whole.MasterClist = "a"
+		fetched, err := fetchMasters(c.ticket(), dbid, whole, missing)
+		if err != nil {
+			return nil, err
+		}
+		for key, record := range fetched {
+			c.Cache.put(dbid, cacheKey{keyFid, key}, record, generation)
+			masters[key] = record
+		}
+		if options.MasterClist != "" && options.MasterClist != "a" {
+			for key, record := range masters {
+				masters[key] = onlyFids(record, options.MasterClist, keyFid)
+			}
+		}
+		return masters, nil
+	})
+}
+
+func onlyFids(record map[int]string, clist string, keyFid int) map[int]string {
+	only := map[int]string{keyFid: record[keyFid]}
+	for _, s := range strings.Split(clist, ".") {
+		if fid, err := strconv.Atoi(s); err == nil {
+			if value, ok := record[fid]; ok {
+				only[fid] = value
+			}
+		}
+	}
+	return only
+}
@@ -0,0 +1,113 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WesTower/quickbase"
+	"github.com/WesTower/quickbase/quickbasetest"
+)
+
+const testDetailDbid = "bck7gp3q4"
+
+func TestJoin(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	server.AddTable(testDetailDbid, map[int]string{6: "Site", 7: "Amount"})
+	for _, detail := range []map[int]string{{6: "2", 7: "1"}, {6: "1", 7: "2"}, {6: "2", 7: "3"}, {6: "", 7: "4"}, {6: "9", 7: "5"}} {
+		server.Seed(testDetailDbid, detail)
+	}
+	ticket, err := authenticate(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	relationship := quickbase.Relationship{ParentDbid: testTableDbid, ChildDbid: testDetailDbid, ReferenceFid: 6}
+	options := quickbase.JoinOptions{Clist: "7", Slist: "7", MasterClist: "6", BatchSize: 1}
+	check := func(records []quickbase.JoinedRecord, err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{"Boise", "Denver", "Boise", "", ""}
+		if len(records) != len(want) {
+			t.Fatalf("joined %d records", len(records))
+		}
+		for i, record := range records {
+			if record.Detail[7] != strconv.Itoa(i+1) || record.Master[6] != want[i] || (want[i] == "") != (record.Master == nil) {
+				t.Errorf("record %d is %v", i, record)
+			}
+			if _, ok := record.Master[7]; ok {
+				t.Errorf("master %d has unselected field: %v", i, record.Master)
+			}
+		}
+	}
+	check(quickbase.Join(ticket, relationship, options))
+
+	client := quickbase.NewClient(ticket)
+	client.Cache = quickbase.NewRecordCache(time.Hour)
+	check(client.Join(relationship, options))
+	if n := client.Cache.Len(); n != 2 {
+		t.Errorf("cached %d masters, want 2", n)
+	}
+	master, err := client.GetRecord(testTableDbid, 2)
+	if err != nil || master[7] != "3" {
+		t.Errorf("cached master is %v, %v", master, err)
+	}
+	check(client.Join(relationship, options))
+}
+
+func TestJoinAllFields(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	server.AddTable(testDetailDbid, map[int]string{6: "Site", 7: "Amount"})
+	server.Seed(testTableDbid, map[int]string{6: "O'Brien", 7: "8"})
+	server.Seed(testDetailDbid, map[int]string{6: "O'Brien", 7: "1"})
+	recorder := quickbasetest.NewRecorder("", nil)
+	quickbase.Transport = recorder
+	defer func() { quickbase.Transport = nil }()
+	ticket, err := authenticate(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	relationship := quickbase.Relationship{ParentDbid: testTableDbid, ChildDbid: testDetailDbid, ReferenceFid: 6}
+	records, err := quickbase.Join(ticket, relationship, quickbase.JoinOptions{MasterKeyFid: 6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Detail[7] != "1" || records[0].Master[7] != "8" {
+		t.Errorf("joined %v", records)
+	}
+	for _, interaction := range recorder.Interactions() {
+		if interaction.Request.Header.Get("QUICKBASE-ACTION") == "API_DoQuery" && !strings.Contains(interaction.Request.Body, "<clist>a</clist>") {
+			t.Errorf("queried without clist a: %s", interaction.Request.Body)
+		}
+	}
+
+	server.Seed(testDetailDbid, map[int]string{6: "Smith'}", 7: "2"})
+	if _, err = quickbase.Join(ticket, relationship, quickbase.JoinOptions{MasterKeyFid: 6}); err == nil {
+		t.Error("Join matched a key containing '}")
+	}
+}
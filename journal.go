@@ -0,0 +1,239 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Journal records the calls a Client makes which change data, and
+// their outcomes, in a file of JSON objects, one per line.  If a bulk
+// job dies part-way through, the Journal shows which operations were
+// never acknowledged, and Client.Replay carries them out.
+//
+// Credentials are not journaled, but everything else sent is,
+// including imported CSV data; the file should be protected
+// accordingly.
+type Journal struct {
+	mutex sync.Mutex
+	path  string
+	file  *os.File
+	next  int
+}
+
+// A JournalEntry is a line of a Journal: either an operation, or the
+// outcome of the earlier operation with the same Seq.
+type JournalEntry struct {
+	Seq    int               `json:"seq"`
+	Time   time.Time         `json:"time"`
+	Action string            `json:"action,omitempty"`
+	Path   string            `json:"path,omitempty"`   // relative to the realm URL, e.g. 'db/bck7gp3q2'
+	Params map[string]string `json:"params,omitempty"` // without credentials
+	Upload bool              `json:"upload,omitempty"` // a file upload, whose contents are not journaled
+	Done   bool              `json:"done,omitempty"`   // the entry is an outcome
+	Error  string            `json:"error,omitempty"`  // for an outcome, if the operation failed
+}
+
+// OpenJournal opens the journal at path for appending, creating it if
+// need be.  A final line cut short by a crash is discarded.
+func OpenJournal(path string) (j *Journal, err error) {
+	entries, size, err := readJournal(path)
+	switch {
+	case err == nil:
+		if err = os.Truncate(path, size); err != nil {
+			return nil, err
+		}
+	case !os.IsNotExist(err):
+		return nil, err
+	}
+	j = &Journal{path: path, next: 1}
+	for _, entry := range entries {
+		if entry.Seq >= j.next {
+			j.next = entry.Seq + 1
+		}
+	}
+	if j.file, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Close closes the journal's file.
+func (j *Journal) Close() error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	return j.file.Close()
+}
+
+// Entries returns every line of the journal, in order.
+func (j *Journal) Entries() (entries []JournalEntry, err error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	entries, _, err = readJournal(j.path)
+	return entries, err
+}
+
+// Pending returns the operations of the journal which have not been
+// acknowledged as successful, in order: those which failed, and any
+// under way when the job died.
+func (j *Journal) Pending() (pending []JournalEntry, err error) {
+	entries, err := j.Entries()
+	if err != nil {
+		return nil, err
+	}
+	succeeded := make(map[int]bool)
+	for _, entry := range entries {
+		if entry.Done && entry.Error == "" {
+			succeeded[entry.Seq] = true
+		}
+	}
+	for _, entry := range entries {
+		if !entry.Done && !succeeded[entry.Seq] {
+			pending = append(pending, entry)
+		}
+	}
+	return pending, nil
+}
+
+// readJournal returns the entries of the journal at path, and the
+// size of the complete lines holding them.  A final line without a
+// newline was cut short as it was written, and is ignored.
+func readJournal(path string) (entries []JournalEntry, size int64, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+	reader := bufio.NewReader(file)
+	for n := 1; ; n++ {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 && line[len(line)-1] == '\n' {
+			var entry JournalEntry
+			if err := json.Unmarshal(line, &entry); err != nil {
+				return nil, 0, fmt.Errorf("Bad journal entry on line %d of %s: %s", n, path, err)
+			}
+			entries = append(entries, entry)
+			size += int64(len(line))
+		}
+		if err != nil {
+			return entries, size, nil
+		}
+	}
+}
+
+// journalEntry returns the entry recording call, without the
+// ticket's credentials.
+func journalEntry(ticket Ticket, call apiCall) JournalEntry {
+	params := make(map[string]string)
+	for key, value := range call.params {
+		switch key {
+		case "ticket", "usertoken", "apptoken":
+		default:
+			params[key] = value
+		}
+	}
+	return JournalEntry{Action: call.action, Path: strings.TrimPrefix(call.url, ticket.url), Params: params}
+}
+
+// begin records an operation about to be attempted, returning its
+// sequence number.
+func (j *Journal) begin(entry JournalEntry) (seq int, err error) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	entry.Seq, entry.Time = j.next, time.Now()
+	j.next++
+	return entry.Seq, j.write(entry)
+}
+
+// end records the outcome of an operation, returning err or, if the
+// outcome cannot be recorded, the error doing so.
+func (j *Journal) end(seq int, err error) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	entry := JournalEntry{Seq: seq, Time: time.Now(), Done: true}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if writeErr := j.write(entry); err == nil {
+		err = writeErr
+	}
+	return err
+}
+
+// write appends an entry to the file, syncing it so that the entry
+// survives a crash; the caller must hold the mutex.
+func (j *Journal) write(entry JournalEntry) (err error) {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err = j.file.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return j.file.Sync()
+}
+
+// Replay carries out, in order, the operations of a journal which
+// were never acknowledged as successful, recording their outcomes in
+// it, and returns the number which succeeded.  It stops at the first
+// which fails.  An operation under way when a job died may in fact
+// have been carried out, and will be again.  Uploads cannot be
+// replayed, as the files' contents are not journaled.  As for any
+// other write, the records replayed are dropped from the Client's
+// Cache.  If the Client is a DryRun one, the operations are logged but
+// neither made nor recorded.
+func (c *Client) Replay(j *Journal) (replayed int, err error) {
+	pending, err := j.Pending()
+	if err != nil {
+		return 0, err
+	}
+	for _, entry := range pending {
+		if entry.Upload {
+			return replayed, fmt.Errorf("Cannot replay upload %d of %s: file contents are not journaled", entry.Seq, entry.Params["filename"])
+		}
+		ticket := c.ticket()
+		params := ticket.params()
+		for key, value := range entry.Params {
+			params[key] = value
+		}
+		call := apiCall{ticket, ticket.url + entry.Path, entry.Action, params}
+		if c.DryRun {
+			if err = c.dryRun(call); err != nil {
+				return replayed, err
+			}
+			continue
+		}
+		call.ticket.write = true
+		err = call.execute(nil)
+		c.invalidate(call)
+		if err = j.end(entry.Seq, err); err != nil {
+			return replayed, fmt.Errorf("Replaying operation %d: %s", entry.Seq, err)
+		}
+		replayed++
+	}
+	return replayed, nil
+}
@@ -0,0 +1,143 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"github.com/WesTower/quickbase"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestJournalReplay(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "quickbase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "journal")
+
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Journal, err = quickbase.OpenJournal(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.AddRecordByFid(testTableDbid, map[int]string{6: "Tulsa"}); err != nil {
+		t.Fatal(err)
+	}
+	server.FailNext("API_ImportFromCSV", 24, "The application is locked")
+	if err = client.ImportFromCSV(testTableDbid, []int{6}, strings.NewReader("Site\nMesa\nAurora\n")); err == nil {
+		t.Fatal("injected error was not returned")
+	}
+	if _, err = client.DoQueryCount(testTableDbid, ""); err != nil {
+		t.Fatal(err)
+	}
+	client.Journal.Close()
+
+	entries, err := readEntries(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("journal holds %v", entries)
+	}
+	if add := entries[0]; add.Action != "API_AddRecord" || add.Path != "db/"+testTableDbid || add.Params["_fid_6"] != "Tulsa" || add.Params["ticket"] != "" {
+		t.Errorf("AddRecordByFid journaled as %+v", add)
+	}
+	if outcome := entries[3]; !outcome.Done || outcome.Seq != 2 || !strings.Contains(outcome.Error, "locked") {
+		t.Errorf("failed import journaled as %+v", outcome)
+	}
+
+	// a new session resumes the job
+	client, err = quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	journal, err := quickbase.OpenJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+	pending, err := journal.Pending()
+	if err != nil || len(pending) != 1 || pending[0].Action != "API_ImportFromCSV" {
+		t.Fatalf("Pending gave %v, %v", pending, err)
+	}
+	client.Cache = quickbase.NewRecordCache(time.Hour)
+	if _, err = client.GetRecord(testTableDbid, 1); err != nil || client.Cache.Len() != 1 {
+		t.Fatalf("GetRecord gave %v, cached %d", err, client.Cache.Len())
+	}
+	if replayed, err := client.Replay(journal); err != nil || replayed != 1 {
+		t.Fatalf("Replay gave %d, %v", replayed, err)
+	}
+	if n := client.Cache.Len(); n != 0 {
+		t.Errorf("cache holds %d records of the table replayed into", n)
+	}
+	if records := server.Records(testTableDbid); len(records) != 5 || records[4][6] != "Aurora" {
+		t.Errorf("after replay the table holds %v", records)
+	}
+	if pending, err = journal.Pending(); err != nil || len(pending) != 0 {
+		t.Errorf("Pending after replay gave %v, %v", pending, err)
+	}
+	if replayed, err := client.Replay(journal); err != nil || replayed != 0 {
+		t.Errorf("second Replay gave %d, %v", replayed, err)
+	}
+}
+
+func readEntries(path string) (entries []quickbase.JournalEntry, err error) {
+	journal, err := quickbase.OpenJournal(path)
+	if err != nil {
+		return nil, err
+	}
+	defer journal.Close()
+	return journal.Entries()
+}
+
+func TestJournalTruncated(t *testing.T) {
+	dir, err := ioutil.TempDir("", "quickbase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "journal")
+	contents := `{"seq":1,"time":"2015-03-01T10:00:00Z","action":"API_DeleteRecord","path":"db/bck7gp3q2","params":{"rid":"1"}}` + "\n" +
+		`{"seq":1,"time":"2015-03-01T10:00:01Z","do`
+	if err = ioutil.WriteFile(path, []byte(contents), 0600); err != nil {
+		t.Fatal(err)
+	}
+	journal, err := quickbase.OpenJournal(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+	if pending, err := journal.Pending(); err != nil || len(pending) != 1 || pending[0].Params["rid"] != "1" {
+		t.Errorf("Pending gave %v, %v", pending, err)
+	}
+	if contents, err := ioutil.ReadFile(path); err != nil || !strings.HasSuffix(string(contents), "}}\n") {
+		t.Errorf("the cut-short line was not discarded: %q, %v", contents, err)
+	}
+}
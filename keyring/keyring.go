@@ -0,0 +1,123 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+// Package keyring keeps QuickBase credentials in the operating
+// system's credential store: the macOS Keychain, the Windows
+// Credential Manager, or the freedesktop.org Secret Service (GNOME
+// Keyring, KWallet).  A Provider reads them back as a
+// quickbase.CredentialsProvider, so that neither passwords nor user
+// tokens need be kept in environment variables or configuration
+// files.
+package keyring
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"os/exec"
+	"strings"
+)
+
+// A Keyring stores secrets, by account name, in a credential store.
+type Keyring interface {
+	Get(account string) (secret string, err error)
+	Set(account, secret string) error
+	Delete(account string) error
+}
+
+// ErrNotFound is returned by a Keyring's Get for an unknown account.
+var ErrNotFound = fmt.Errorf("not found in keyring")
+
+// System returns the keyring of the operating system, keeping secrets
+// under the given service name.  On systems other than macOS and
+// Windows, it needs secret-tool(1) installed.
+func System(service string) Keyring {
+	return system(service)
+}
+
+// A Provider is a quickbase.CredentialsProvider reading credentials
+// from a Keyring, in which they are kept by realm URL.
+type Provider struct {
+	Keyring Keyring
+}
+
+var _ quickbase.CredentialsProvider = Provider{}
+
+// Credentials returns the credentials stored for url.
+func (p Provider) Credentials(url string) (credentials quickbase.Credentials, err error) {
+	secret, err := p.Keyring.Get(url)
+	if err != nil {
+		return credentials, err
+	}
+	encoded, err := base64.StdEncoding.DecodeString(secret)
+	if err == nil {
+		err = json.Unmarshal(encoded, &credentials)
+	}
+	if err != nil {
+		return credentials, fmt.Errorf("corrupt credentials in keyring for %s: %s", url, err)
+	}
+	return credentials, nil
+}
+
+// Store stores credentials for url, replacing any stored before.  The
+// secret is base64-encoded JSON, so that no keyring tool has to cope
+// with quotes or newlines.
+func (p Provider) Store(url string, credentials quickbase.Credentials) error {
+	encoded, err := json.Marshal(credentials)
+	if err != nil {
+		return err
+	}
+	return p.Keyring.Set(url, base64.StdEncoding.EncodeToString(encoded))
+}
+
+// Remove removes the credentials stored for url.
+func (p Provider) Remove(url string) error {
+	return p.Keyring.Delete(url)
+}
+
+// A toolError reports a keyring tool exiting unsuccessfully.
+type toolError struct {
+	name     string
+	stderr   string
+	exitCode int
+}
+
+func (e *toolError) Error() string {
+	return fmt.Sprintf("%s: %s", e.name, e.stderr)
+}
+
+// runTool runs a command, feeding it stdin, and returns its output.
+// Should the command exit unsuccessfully, err is a *toolError, and
+// stdout is whatever the command printed before it did.
+func runTool(stdin string, name string, args ...string) (stdout string, err error) {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var out, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &out, &stderr
+	if err = cmd.Run(); err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			return out.String(), &toolError{name, strings.TrimSpace(stderr.String()), exitErr.ExitCode()}
+		}
+		return "", err
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,106 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package keyring_test
+
+import (
+	"github.com/WesTower/quickbase"
+	"github.com/WesTower/quickbase/keyring"
+	"github.com/WesTower/quickbase/quickbasetest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// memoryKeyring is a Keyring for tests.
+type memoryKeyring map[string]string
+
+func (k memoryKeyring) Get(account string) (string, error) {
+	secret, ok := k[account]
+	if !ok {
+		return "", keyring.ErrNotFound
+	}
+	return secret, nil
+}
+
+func (k memoryKeyring) Set(account, secret string) error {
+	k[account] = secret
+	return nil
+}
+
+func (k memoryKeyring) Delete(account string) error {
+	delete(k, account)
+	return nil
+}
+
+func TestProvider(t *testing.T) {
+	server := quickbasetest.NewServer()
+	defer server.Close()
+	server.AddUser("jdoe", "s3cret \"quoted\"\n")
+	server.AddTable("bck7gp3q2", map[int]string{6: "Site"})
+	keys := make(memoryKeyring)
+	provider := keyring.Provider{Keyring: keys}
+
+	if _, err := provider.Credentials(server.BaseUrl()); err != keyring.ErrNotFound {
+		t.Errorf("Credentials before Store gave %v", err)
+	}
+	if err := provider.Store(server.BaseUrl(), quickbase.Credentials{Username: "jdoe", Password: "s3cret \"quoted\"\n"}); err != nil {
+		t.Fatal(err)
+	}
+	client := &quickbase.Client{}
+	if err := client.AuthenticateWith(provider, server.BaseUrl()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.DoQueryCount("bck7gp3q2", ""); err != nil {
+		t.Error(err)
+	}
+
+	keys[server.BaseUrl()] = "not base64"
+	if _, err := provider.Credentials(server.BaseUrl()); err == nil {
+		t.Error("Credentials of a corrupt entry succeeded")
+	}
+	if err := provider.Remove(server.BaseUrl()); err != nil || len(keys) != 0 {
+		t.Errorf("Remove gave %v, left %v", err, keys)
+	}
+}
+
+func TestSecretToolNotFound(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		t.Skip("the system keyring does not use secret-tool")
+	}
+	dir := t.TempDir()
+	tool := filepath.Join(dir, "secret-tool")
+	t.Setenv("PATH", dir)
+
+	if err := os.WriteFile(tool, []byte("#!/bin/sh\nexit 1\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keyring.System("quickbase").Get("https://example.quickbase.com"); err != keyring.ErrNotFound {
+		t.Errorf("Get of a missing entry gave %v", err)
+	}
+
+	if err := os.WriteFile(tool, []byte("#!/bin/sh\necho locked >&2\nexit 2\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keyring.System("quickbase").Get("https://example.quickbase.com"); err == nil || err == keyring.ErrNotFound {
+		t.Errorf("Get with a failing secret-tool gave %v", err)
+	}
+}
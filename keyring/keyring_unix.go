@@ -0,0 +1,99 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+//go:build !windows
+// +build !windows
+
+package keyring
+
+import (
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+func system(service string) Keyring {
+	switch runtime.GOOS {
+	case "darwin":
+		return macKeyring{service}
+	}
+	return secretServiceKeyring{service}
+}
+
+// macKeyring uses the macOS Keychain through security(1).
+type macKeyring struct {
+	service string
+}
+
+func (k macKeyring) Get(account string) (secret string, err error) {
+	out, err := runTool("", "security", "find-generic-password", "-s", k.service, "-a", account, "-w")
+	if err != nil {
+		if strings.Contains(err.Error(), "could not be found") {
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+	return strings.TrimSuffix(out, "\n"), nil
+}
+
+// Set passes the secret to security(1) on its standard input, in
+// interactive mode, so that it never appears in a process listing.
+func (k macKeyring) Set(account, secret string) error {
+	_, err := runTool("add-generic-password -U -s "+strconv.Quote(k.service)+" -a "+strconv.Quote(account)+
+		" -w "+strconv.Quote(secret)+"\n", "security", "-i")
+	return err
+}
+
+func (k macKeyring) Delete(account string) error {
+	_, err := runTool("", "security", "delete-generic-password", "-s", k.service, "-a", account)
+	return err
+}
+
+// secretServiceKeyring uses the Secret Service through secret-tool(1).
+type secretServiceKeyring struct {
+	service string
+}
+
+// Get treats secret-tool(1) exiting 1 without output as a failed
+// lookup, which is how it reports that nothing matched.
+func (k secretServiceKeyring) Get(account string) (secret string, err error) {
+	out, err := runTool("", "secret-tool", "lookup", "service", k.service, "account", account)
+	if err != nil {
+		if toolErr, ok := err.(*toolError); ok && toolErr.exitCode == 1 && out == "" {
+			return "", ErrNotFound
+		}
+		return "", err
+	}
+	if out == "" {
+		return "", ErrNotFound
+	}
+	return strings.TrimSuffix(out, "\n"), nil
+}
+
+func (k secretServiceKeyring) Set(account, secret string) error {
+	_, err := runTool(secret, "secret-tool", "store", "--label", k.service+" "+account,
+		"service", k.service, "account", account)
+	return err
+}
+
+func (k secretServiceKeyring) Delete(account string) error {
+	_, err := runTool("", "secret-tool", "clear", "service", k.service, "account", account)
+	return err
+}
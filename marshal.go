@@ -0,0 +1,202 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Unmarshal copies query results into the slice to which dst points,
+// whose elements are structs or pointers to structs tagged as for
+// Scanner.Scan.  records is either the []map[int]string of
+// DoStructuredQuery or DoQueryByQid, in which fields are found by
+// `qb:"fid=N"` tags, or the []map[string]string of DoQuery, in which
+// they are found by `qb:"label=L"` tags.  Each value is converted as
+// by Scan.
+func Unmarshal(records interface{}, dst interface{}) (err error) {
+	slice := reflect.ValueOf(dst)
+	if slice.Kind() != reflect.Ptr || slice.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("Cannot unmarshal into %T; a pointer to a slice is required", dst)
+	}
+	slice = slice.Elem()
+	elemType := slice.Type().Elem()
+	structType := elemType
+	if elemType.Kind() == reflect.Ptr {
+		structType = elemType.Elem()
+	}
+	if structType.Kind() != reflect.Struct {
+		return fmt.Errorf("Cannot unmarshal into %T; its elements must be structs", dst)
+	}
+	fields, err := scanFields(structType)
+	if err != nil {
+		return err
+	}
+	var lookup func(i int, field scanField) (value string, present bool, err error)
+	var n int
+	switch records := records.(type) {
+	case []map[int]string:
+		n = len(records)
+		lookup = func(i int, field scanField) (string, bool, error) {
+			if field.fid == 0 {
+				return "", false, fmt.Errorf("Field %s is tagged by label, but records are keyed by field ID", field.label)
+			}
+			value, ok := records[i][field.fid]
+			return value, ok, nil
+		}
+	case []map[string]string:
+		n = len(records)
+		lookup = func(i int, field scanField) (string, bool, error) {
+			if field.fid != 0 {
+				return "", false, fmt.Errorf("Field %d is tagged by ID, but records are keyed by label", field.fid)
+			}
+			value, ok := records[i][field.label]
+			return value, ok, nil
+		}
+	default:
+		return fmt.Errorf("Cannot unmarshal records of type %T", records)
+	}
+	result := reflect.MakeSlice(slice.Type(), n, n)
+	for i := 0; i < n; i++ {
+		elem := result.Index(i)
+		if elemType.Kind() == reflect.Ptr {
+			elem.Set(reflect.New(structType))
+			elem = elem.Elem()
+		}
+		for _, field := range fields {
+			value, present, err := lookup(i, field)
+			if err != nil {
+				return err
+			}
+			if err = decodeField(elem.Field(field.index), field, []byte(value), present); err != nil {
+				return fmt.Errorf("Record %d: field %s: %s", i, fieldName(field), err)
+			}
+		}
+	}
+	slice.Set(result)
+	return nil
+}
+
+// Marshal encodes the fields of a struct, or of a pointer to one,
+// tagged `qb:"fid=N"`, for AddRecordByFid and EditRecordByFid.  Values
+// are encoded as by EncodeValue, except that a time.Time tagged with
+// the date option is encoded as a date.  Null types not Present, and
+// zero values tagged with the omitempty option, are left out.  Fields
+// tagged by label are an error; see MarshalLabels.
+func Marshal(src interface{}) (fields map[int]string, err error) {
+	fields = make(map[int]string)
+	err = marshal(src, func(field scanField, value string) error {
+		if field.fid == 0 {
+			return fmt.Errorf("Cannot marshal field %s by label; use MarshalLabels", field.label)
+		}
+		fields[field.fid] = value
+		return nil
+	})
+	return fields, err
+}
+
+// MarshalLabels is like Marshal, but encodes the fields tagged
+// `qb:"label=L"`, keyed as AddRecord and EditRecord expect.
+func MarshalLabels(src interface{}) (fields map[string]string, err error) {
+	fields = make(map[string]string)
+	err = marshal(src, func(field scanField, value string) error {
+		if field.fid != 0 {
+			return fmt.Errorf("Cannot marshal field %d by label; use Marshal", field.fid)
+		}
+		fields[field.label] = value
+		return nil
+	})
+	return fields, err
+}
+
+func marshal(src interface{}, set func(field scanField, value string) error) (err error) {
+	v := reflect.ValueOf(src)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("Cannot marshal %T; a struct is required", src)
+	}
+	fields, err := scanFields(v.Type())
+	if err != nil {
+		return err
+	}
+	for _, field := range fields {
+		value, present, err := encodeField(v.Field(field.index), field)
+		if err != nil {
+			return fmt.Errorf("Field %s: %s", fieldName(field), err)
+		}
+		if !present {
+			continue
+		}
+		if err = set(field, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeField encodes a struct field, reporting whether it is to be
+// written.
+func encodeField(v reflect.Value, field scanField) (value string, present bool, err error) {
+	if field.omitempty && v.IsZero() {
+		return "", false, nil
+	}
+	switch x := v.Interface().(type) {
+	case nullEncoder:
+		if t, ok := x.(NullTime); ok && field.date && t.Valid {
+			return DateValue(t.Time), t.Present, nil
+		}
+		return x.encode()
+	case time.Time:
+		if field.date {
+			return DateValue(x), true, nil
+		}
+		return TimestampValue(x), true, nil
+	}
+	if value, err = EncodeValue(v.Interface()); err == nil {
+		return value, true, nil
+	}
+	// Types defined on the basic types, e.g. type Status string.
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), true, nil
+	case reflect.Bool:
+		return CheckboxValue(v.Bool()), true, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), true, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), true, nil
+	case reflect.Float32, reflect.Float64:
+		value, err = numberValue(v.Float(), v.Type().Bits())
+		return value, true, err
+	}
+	return "", false, err
+}
+
+func fieldName(field scanField) string {
+	if field.fid == 0 {
+		return field.label
+	}
+	return strconv.Itoa(field.fid)
+}
@@ -0,0 +1,116 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"github.com/WesTower/quickbase"
+	"testing"
+	"time"
+)
+
+type workOrder struct {
+	Rid     int                  `qb:"fid=3"`
+	Site    string               `qb:"fid=6"`
+	Cost    float64              `qb:"fid=7"`
+	Due     time.Time            `qb:"fid=8,date"`
+	Closed  quickbase.NullTime   `qb:"fid=9"`
+	Crew    int                  `qb:"fid=10,omitempty"`
+	Notes   quickbase.NullString `qb:"fid=11"`
+	private string               `qb:"fid=12"`
+}
+
+func TestMarshal(t *testing.T) {
+	order := workOrder{
+		Site:   "Denver",
+		Cost:   12.5,
+		Due:    time.Date(2015, 3, 1, 23, 30, 0, 0, time.FixedZone("MST", -7*60*60)),
+		Closed: quickbase.NullTime{Present: true},
+	}
+	fields, err := quickbase.Marshal(&order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[int]string{3: "0", 6: "Denver", 7: "12.5", 8: "1425168000000", 9: ""}
+	if len(fields) != len(want) {
+		t.Errorf("Marshal gave %v, want %v", fields, want)
+	}
+	for fid, value := range want {
+		if fields[fid] != value {
+			t.Errorf("Marshal gave %q for field %d, want %q", fields[fid], fid, value)
+		}
+	}
+
+	var orders []workOrder
+	if err = quickbase.Unmarshal([]map[int]string{fields}, &orders); err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 1 || orders[0].Site != "Denver" || orders[0].Cost != 12.5 || !orders[0].Due.Equal(time.Date(2015, 3, 1, 0, 0, 0, 0, time.UTC)) ||
+		orders[0].Closed.Valid || !orders[0].Closed.Present || orders[0].Notes.Present {
+		t.Errorf("Unmarshal gave %+v", orders)
+	}
+
+	type labelled struct {
+		Site string  `qb:"label=Site"`
+		Cost float64 `qb:"label=Cost"`
+	}
+	labels, err := quickbase.MarshalLabels(labelled{"Boise", 3})
+	if err != nil || labels["site"] != "Boise" || labels["cost"] != "3" {
+		t.Errorf("MarshalLabels gave %v, %v", labels, err)
+	}
+	if _, err = quickbase.Marshal(labelled{}); err == nil {
+		t.Error("Marshal of a field tagged by label succeeded")
+	}
+	if _, err = quickbase.MarshalLabels(order); err == nil {
+		t.Error("MarshalLabels of a field tagged by ID succeeded")
+	}
+	if err = quickbase.Unmarshal([]map[int]string{{6: "Boise"}}, &[]labelled{}); err == nil {
+		t.Error("Unmarshal of fields tagged by label from records keyed by ID succeeded")
+	}
+	if err = quickbase.Unmarshal([]map[int]string{{7: "a lot"}}, &[]workOrder{}); err == nil {
+		t.Error("Unmarshal of a bad number succeeded")
+	}
+	if err = quickbase.Unmarshal([]map[int]string{}, []workOrder{}); err == nil {
+		t.Error("Unmarshal into a slice, not a pointer to one, succeeded")
+	}
+}
+
+func TestUnmarshalQuery(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := client.DoQuery(testTableDbid, "", "6.7", "6", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sites []*struct {
+		Name string  `qb:"label=Site"`
+		Cost float64 `qb:"label=Cost"`
+	}
+	if err = quickbase.Unmarshal(records, &sites); err != nil {
+		t.Fatal(err)
+	}
+	if len(sites) != 2 || sites[0].Name != "Boise" || sites[0].Cost != 3 || sites[1].Cost != 12.5 {
+		t.Errorf("Unmarshal gave %+v", sites)
+	}
+}
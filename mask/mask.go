@@ -0,0 +1,179 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+// Package mask hides personal data in records before they are
+// exported, for extracts given to analysts.  Fields are chosen by ID
+// or by a pattern matching their labels, and their values hashed,
+// truncated or redacted:
+//
+//	masker := &mask.Masker{Key: key}
+//	for _, spec := range []string{"7=redact", "(?i)e-?mail|phone=hash", "Name=truncate:1"} {
+//		rule, err := mask.ParseRule(spec)
+//		...
+//		masker.Rules = append(masker.Rules, rule)
+//	}
+//	table := masker.Table(schema)
+//	for _, record := range records {
+//		record = table.Record(record)
+//		...
+//	}
+package mask
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// A Method is a way of masking a value.
+type Method string
+
+const (
+	// Hash replaces a value with the hex of its HMAC-SHA256 under
+	// the Masker's Key, so that equal values may still be counted
+	// and joined on without being revealed.
+	Hash Method = "hash"
+	// Truncate keeps the first Keep characters of a value.
+	Truncate Method = "truncate"
+	// Redact replaces a value with Redacted.
+	Redact Method = "redact"
+)
+
+// Redacted replaces values masked by Redact.
+const Redacted = "[redacted]"
+
+// A Rule chooses fields to mask, by Fid or, if that is zero, by
+// Label, and how to mask them.
+type Rule struct {
+	Fid    int
+	Label  *regexp.Regexp
+	Method Method
+	Keep   int // for Truncate, the characters kept
+}
+
+// ParseRule parses a rule written as selector=method, where selector
+// is a field ID or a regular expression matching labels, and method is
+// 'hash', 'redact' or 'truncate:n', e.g. '7=redact' or
+// '(?i)phone=truncate:3'.
+func ParseRule(s string) (rule Rule, err error) {
+	i := strings.LastIndex(s, "=")
+	if i <= 0 {
+		return rule, fmt.Errorf("Bad masking rule %q: want selector=method", s)
+	}
+	selector, method := s[:i], s[i+1:]
+	if rule.Fid, err = strconv.Atoi(selector); err != nil {
+		rule.Fid = 0
+		if rule.Label, err = regexp.Compile(selector); err != nil {
+			return rule, fmt.Errorf("Bad masking rule %q: %s", s, err)
+		}
+	}
+	switch {
+	case method == string(Hash) || method == string(Redact):
+		rule.Method = Method(method)
+	case strings.HasPrefix(method, string(Truncate)+":"):
+		rule.Method = Truncate
+		rule.Keep, err = strconv.Atoi(strings.TrimPrefix(method, string(Truncate)+":"))
+		if err != nil || rule.Keep < 0 {
+			return rule, fmt.Errorf("Bad masking rule %q: bad length to truncate to", s)
+		}
+	default:
+		return rule, fmt.Errorf("Bad masking rule %q: unknown method %q; use hash, redact or truncate:n", s, method)
+	}
+	return rule, nil
+}
+
+func (r Rule) matches(field quickbase.Field) bool {
+	if r.Fid != 0 {
+		return r.Fid == field.Id
+	}
+	return r.Label != nil && r.Label.MatchString(field.Label)
+}
+
+// A Masker masks the fields chosen by its Rules.  Where several rules
+// choose a field, the first applies.
+type Masker struct {
+	Rules []Rule
+	// Key keys the HMAC of Hash; without one, common values such
+	// as names may be recovered by hashing guesses.
+	Key []byte
+}
+
+// A Table masks the records of a single table.
+type Table struct {
+	masker *Masker
+	rules  map[int]Rule
+}
+
+// Table returns the masking of the table described by schema.
+func (m *Masker) Table(schema quickbase.Schema) Table {
+	table := Table{m, make(map[int]Rule)}
+	for _, field := range schema.Fields {
+		for _, rule := range m.Rules {
+			if rule.matches(field) {
+				table.rules[field.Id] = rule
+				break
+			}
+		}
+	}
+	return table
+}
+
+// Masked reports whether field fid is masked.
+func (t Table) Masked(fid int) bool {
+	_, ok := t.rules[fid]
+	return ok
+}
+
+// Record returns a copy of record, by field ID, with its masked
+// fields' values masked.
+func (t Table) Record(record map[int]string) map[int]string {
+	masked := make(map[int]string, len(record))
+	for fid, value := range record {
+		masked[fid] = t.Value(fid, value)
+	}
+	return masked
+}
+
+// Value returns value, masked if field fid is.  Blank values are
+// left blank.
+func (t Table) Value(fid int, value string) string {
+	rule, ok := t.rules[fid]
+	if !ok || value == "" {
+		return value
+	}
+	switch rule.Method {
+	case Hash:
+		mac := hmac.New(sha256.New, t.masker.Key)
+		mac.Write([]byte(value))
+		return hex.EncodeToString(mac.Sum(nil))
+	case Truncate:
+		runes := []rune(value)
+		if len(runes) > rule.Keep {
+			return string(runes[:rule.Keep])
+		}
+		return value
+	}
+	return Redacted
+}
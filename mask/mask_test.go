@@ -0,0 +1,72 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package mask_test
+
+import (
+	"github.com/WesTower/quickbase"
+	"github.com/WesTower/quickbase/mask"
+	"testing"
+)
+
+func TestMasker(t *testing.T) {
+	schema := quickbase.Schema{Fields: []quickbase.Field{
+		{Id: 6, Label: "Name"},
+		{Id: 7, Label: "Home Phone"},
+		{Id: 8, Label: "E-mail"},
+		{Id: 9, Label: "SSN"},
+		{Id: 10, Label: "Cost"},
+	}}
+	masker := &mask.Masker{Key: []byte("secret")}
+	for _, spec := range []string{"9=redact", "(?i)e-?mail|phone=hash", "Name=truncate:1", "(?i)phone=redact"} {
+		rule, err := mask.ParseRule(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		masker.Rules = append(masker.Rules, rule)
+	}
+	table := masker.Table(schema)
+	record := map[int]string{6: "Jane Doe", 7: "555-1234", 8: "", 9: "123-45-6789", 10: "12.50"}
+	masked := table.Record(record)
+	if record[6] != "Jane Doe" {
+		t.Error("Record changed its argument")
+	}
+	if masked[6] != "J" || masked[8] != "" || masked[9] != mask.Redacted || masked[10] != "12.50" {
+		t.Errorf("masked record is %v", masked)
+	}
+	if len(masked[7]) != 64 || masked[7] != table.Value(7, "555-1234") || masked[7] == table.Value(7, "555-1235") {
+		t.Errorf("hashed phone is %q", masked[7])
+	}
+	unkeyed := (&mask.Masker{Rules: masker.Rules}).Table(schema)
+	if unkeyed.Value(7, "555-1234") == masked[7] {
+		t.Error("hash ignored key")
+	}
+	if !table.Masked(8) || table.Masked(10) {
+		t.Error("Masked is wrong")
+	}
+}
+
+func TestParseRuleErrors(t *testing.T) {
+	for _, spec := range []string{"redact", "=hash", "7=scramble", "7=truncate:x", "7=truncate", "(=hash"} {
+		if _, err := mask.ParseRule(spec); err == nil {
+			t.Errorf("%q parsed", spec)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ModifyAttempts is the number of times Modify tries to change a
+// record before giving up because others keep changing it.
+var ModifyAttempts = 5
+
+// errcodeUpdateConflict is the errcode of a write conditional on an
+// out-of-date update ID.
+const errcodeUpdateConflict = 60
+
+// IsUpdateConflict reports whether err is QuickBase's refusal of a
+// write made with an update ID which is out of date, the record having
+// been changed since it was read.
+func IsUpdateConflict(err error) bool {
+	qbErr, ok := err.(QuickBaseError)
+	return ok && qbErr.Code == errcodeUpdateConflict
+}
+
+// A RecordEdit is a change to a record being made by Modify.
+type RecordEdit struct {
+	Rid      int
+	UpdateId int            // the record's update ID when read
+	Fields   map[int]string // the record's values when read, by field ID
+	Changes  map[int]string // the values to write, by field ID
+}
+
+// Value returns the value of a field as changed, or else as read.
+func (e *RecordEdit) Value(fid int) string {
+	if value, ok := e.Changes[fid]; ok {
+		return value
+	}
+	return e.Fields[fid]
+}
+
+// Set changes the value of a field.
+func (e *RecordEdit) Set(fid int, value string) {
+	if e.Changes == nil {
+		e.Changes = make(map[int]string)
+	}
+	e.Changes[fid] = value
+}
+
+// EditRecordIfUnchanged is like EditRecordByFid, but makes the change
+// only if the record's update ID is still updateId, returning its new
+// update ID.  If the record has been changed since, it returns an
+// error for which IsUpdateConflict is true.
+func EditRecordIfUnchanged(ticket Ticket, dbid string, rid, updateId int, fields map[int]string) (newUpdateId int, err error) {
+	var result editRecordResponse
+	if err = editRecordIfUnchangedCall(ticket, dbid, rid, updateId, fields).execute(&result); err != nil {
+		return 0, err
+	}
+	return result.updateId()
+}
+
+func editRecordIfUnchangedCall(ticket Ticket, dbid string, rid, updateId int, fields map[int]string) apiCall {
+	call := editRecordByFidCall(ticket, dbid, rid, fields)
+	call.params["update_id"] = strconv.Itoa(updateId)
+	return call
+}
+
+// Modify reads a record, passes it to modify to make its changes with
+// Set, and writes them on condition that the record has not been
+// changed meanwhile.  If it has, Modify starts again, up to
+// ModifyAttempts times in all, so modify may be called more than
+// once and should do nothing but decide the changes.  An error from
+// modify is returned at once, and if it makes no changes nothing is
+// written.
+func Modify(ticket Ticket, dbid string, rid int, modify func(*RecordEdit) error) (err error) {
+	return modifyRecord(ticket, dbid, rid, modify, func(edit *RecordEdit) error {
+		_, err := EditRecordIfUnchanged(ticket, dbid, rid, edit.UpdateId, edit.Changes)
+		return err
+	})
+}
+
+// modifyRecord carries out Modify, writing changes with write.
+func modifyRecord(ticket Ticket, dbid string, rid int, modify, write func(*RecordEdit) error) (err error) {
+	for attempt := 1; ; attempt++ {
+		edit, err := readForEdit(ticket, dbid, rid)
+		if err != nil {
+			return err
+		}
+		if err = modify(edit); err != nil || len(edit.Changes) == 0 {
+			return err
+		}
+		if err = write(edit); !IsUpdateConflict(err) || attempt >= ModifyAttempts {
+			return err
+		}
+		ticket.hooks.retry("API_EditRecord", attempt+1, err)
+	}
+}
+
+// readForEdit reads all the fields of a record with its update ID.
+func readForEdit(ticket Ticket, dbid string, rid int) (edit *RecordEdit, err error) {
+	params := ticket.params()
+	params["fmt"] = "structured"
+	params["query"] = fmt.Sprintf("{'3'.EX.'%d'}", rid)
+	params["clist"] = "a"
+	var result queryResponse
+	if err = executeApiCall(ticket, ticket.url+"db/"+dbid, "API_DoQuery", params, &result); err != nil {
+		return nil, err
+	}
+	if len(result.StructuredRecords) == 0 {
+		return nil, fmt.Errorf("No record %d in %s", rid, dbid)
+	}
+	record := result.StructuredRecords[0]
+	if record.UpdateId == 0 {
+		return nil, fmt.Errorf("No update_id returned for record %d", rid)
+	}
+	edit = &RecordEdit{Rid: rid, UpdateId: record.UpdateId, Fields: make(map[int]string)}
+	for _, field := range record.Fields {
+		if field.Name == "f" {
+			edit.Fields[field.Id] = field.Value
+		}
+	}
+	return edit, nil
+}
+
+// EditRecordIfUnchanged is as the package-level function, recorded or
+// logged as other changes are by a Client with a Journal or DryRun.
+func (c *Client) EditRecordIfUnchanged(dbid string, rid, updateId int, fields map[int]string) (newUpdateId int, err error) {
+	var result editRecordResponse
+	if err = c.mutate(editRecordIfUnchangedCall(c.ticket(), dbid, rid, updateId, fields), &result); err != nil || c.DryRun {
+		return 0, err
+	}
+	return result.updateId()
+}
+
+// Modify is as the package-level function, writing its changes as
+// EditRecordIfUnchanged does.
+func (c *Client) Modify(dbid string, rid int, modify func(*RecordEdit) error) (err error) {
+	return modifyRecord(c.ticket(), dbid, rid, modify, func(edit *RecordEdit) error {
+		_, err := c.EditRecordIfUnchanged(dbid, rid, edit.UpdateId, edit.Changes)
+		return err
+	})
+}
@@ -0,0 +1,120 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"errors"
+	"github.com/WesTower/quickbase"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestModify(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	double := func(edit *quickbase.RecordEdit) error {
+		cost, err := strconv.ParseFloat(edit.Value(7), 64)
+		if err != nil {
+			return err
+		}
+		edit.Set(7, strconv.FormatFloat(2*cost, 'f', -1, 64))
+		return nil
+	}
+	if err = client.Modify(testTableDbid, 1, double); err != nil {
+		t.Fatal(err)
+	}
+	if records := server.Records(testTableDbid); records[0][7] != "25" {
+		t.Errorf("cost is %s", records[0][7])
+	}
+
+	// a change made between the read and the write is not lost
+	calls := 0
+	err = client.Modify(testTableDbid, 2, func(edit *quickbase.RecordEdit) error {
+		if calls++; calls == 1 {
+			if err := client.EditRecordByFid(testTableDbid, 2, map[int]string{7: "10"}); err != nil {
+				return err
+			}
+		}
+		return double(edit)
+	})
+	if err != nil || calls != 2 {
+		t.Errorf("Modify made %d calls: %v", calls, err)
+	}
+	if records := server.Records(testTableDbid); records[1][7] != "20" {
+		t.Errorf("cost is %s", records[1][7])
+	}
+
+	calls = 0
+	err = client.Modify(testTableDbid, 2, func(edit *quickbase.RecordEdit) error {
+		calls++
+		edit.Set(6, "Lost")
+		return client.EditRecordByFid(testTableDbid, 2, map[int]string{7: strconv.Itoa(calls)})
+	})
+	if !quickbase.IsUpdateConflict(err) || calls != quickbase.ModifyAttempts {
+		t.Errorf("Modify made %d calls: %v", calls, err)
+	}
+
+	stop := errors.New("stop")
+	if err = client.Modify(testTableDbid, 2, func(*quickbase.RecordEdit) error { return stop }); err != stop {
+		t.Errorf("Modify gave %v", err)
+	}
+	if err = client.Modify(testTableDbid, 99, double); err == nil {
+		t.Error("Modify of a missing record succeeded")
+	}
+	if _, err = client.EditRecordIfUnchanged(testTableDbid, 1, 1, map[int]string{6: "Stale"}); !quickbase.IsUpdateConflict(err) {
+		t.Errorf("stale edit gave %v", err)
+	}
+}
+
+func TestModifyConcurrently(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	ticket, err := authenticate(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(attempts int) { quickbase.ModifyAttempts = attempts }(quickbase.ModifyAttempts)
+	quickbase.ModifyAttempts = 100
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := quickbase.Modify(ticket, testTableDbid, 2, func(edit *quickbase.RecordEdit) error {
+				n, err := strconv.Atoi(edit.Value(7))
+				edit.Set(7, strconv.Itoa(n+1))
+				return err
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if records := server.Records(testTableDbid); records[1][7] != "13" {
+		t.Errorf("ten increments of 3 gave %s", records[1][7])
+	}
+}
@@ -0,0 +1,159 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The Null types below hold field values which may be blank, telling
+// apart three states which plain Go values cannot: a field which is
+// not Present was not read (e.g. not in the clist) or is not to be
+// written; one which is Present but not Valid is blank; and one which
+// is Valid has a value, which may be the zero value.
+//
+// Scanner.Scan sets them from the fields of a record, and EncodeFields
+// writes them, omitting any not Present and clearing those not Valid.
+
+// NullString is a text value which may be blank.
+type NullString struct {
+	String  string
+	Valid   bool // the field is not blank
+	Present bool // the field was read, or is to be written
+}
+
+// NullInt is an integer value which may be blank.
+type NullInt struct {
+	Int     int64
+	Valid   bool
+	Present bool
+}
+
+// NullFloat is a numeric value which may be blank.
+type NullFloat struct {
+	Float   float64
+	Valid   bool
+	Present bool
+}
+
+// NullBool is a checkbox value which may be blank.  QuickBase reports
+// an unchecked checkbox as "0", so only fields outside the clist or of
+// other types are ever blank.
+type NullBool struct {
+	Bool    bool
+	Valid   bool
+	Present bool
+}
+
+// NullTime is a date or date/time value which may be blank, written as
+// a timestamp (see TimestampValue).
+type NullTime struct {
+	Time    time.Time
+	Valid   bool
+	Present bool
+}
+
+// A nullEncoder is a Null type, encoded for a write.
+type nullEncoder interface {
+	encode() (value string, present bool, err error)
+}
+
+// A nullDecoder is a pointer to a Null type, decoded from a record.
+type nullDecoder interface {
+	decode(value []byte, present bool) error
+}
+
+func (n NullString) encode() (string, bool, error) {
+	if !n.Valid {
+		return "", n.Present, nil
+	}
+	return n.String, n.Present, nil
+}
+
+func (n *NullString) decode(value []byte, present bool) error {
+	*n = NullString{String: string(value), Valid: len(value) > 0, Present: present}
+	return nil
+}
+
+func (n NullInt) encode() (string, bool, error) {
+	if !n.Valid {
+		return "", n.Present, nil
+	}
+	return strconv.FormatInt(n.Int, 10), n.Present, nil
+}
+
+func (n *NullInt) decode(value []byte, present bool) (err error) {
+	*n = NullInt{Valid: len(value) > 0, Present: present}
+	if n.Valid {
+		n.Int, err = strconv.ParseInt(string(value), 10, 64)
+	}
+	return err
+}
+
+func (n NullFloat) encode() (string, bool, error) {
+	if !n.Valid {
+		return "", n.Present, nil
+	}
+	value, err := NumberValue(n.Float)
+	return value, n.Present, err
+}
+
+func (n *NullFloat) decode(value []byte, present bool) (err error) {
+	*n = NullFloat{Valid: len(value) > 0, Present: present}
+	if n.Valid {
+		n.Float, err = strconv.ParseFloat(string(value), 64)
+	}
+	return err
+}
+
+func (n NullBool) encode() (string, bool, error) {
+	if !n.Valid {
+		return "", n.Present, nil
+	}
+	return CheckboxValue(n.Bool), n.Present, nil
+}
+
+func (n *NullBool) decode(value []byte, present bool) error {
+	*n = NullBool{Valid: len(value) > 0, Present: present}
+	n.Bool = string(value) == "1" || strings.EqualFold(string(value), "true")
+	return nil
+}
+
+func (n NullTime) encode() (string, bool, error) {
+	if !n.Valid {
+		return "", n.Present, nil
+	}
+	return TimestampValue(n.Time), n.Present, nil
+}
+
+func (n *NullTime) decode(value []byte, present bool) error {
+	*n = NullTime{Valid: len(value) > 0, Present: present}
+	if n.Valid {
+		msecs, err := strconv.ParseInt(string(value), 10, 64)
+		if err != nil {
+			return err
+		}
+		n.Time = time.Unix(msecs/1000, (msecs%1000)*int64(time.Millisecond))
+	}
+	return nil
+}
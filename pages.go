@@ -0,0 +1,202 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"bufio"
+	"encoding/gob"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+)
+
+// DefaultPageSize is the number of records fetched by each call of
+// DoQueryPaged, unless its options give another.
+const DefaultPageSize = 1000
+
+// PageOptions control DoQueryPaged.
+type PageOptions struct {
+	PageSize int // records fetched per call; if zero, DefaultPageSize
+	// Spill, if set, writes each page to a temporary file as it
+	// arrives, so that only one page is held in memory at a time.
+	Spill    bool
+	SpillDir string // directory of the temporary file; if empty, the system's
+}
+
+// errcodeTooLarge is the error code with which QuickBase refuses a
+// query whose response would be too large.
+const errcodeTooLarge = 75
+
+// DoQueryPaged runs a query as DoStructuredQuery does, but fetches its
+// records a page at a time, sorted by Record ID# so that the pages are
+// stable, and returns them as a RecordSet.  This pulls tables far
+// larger than QuickBase will return from a single call.  If QuickBase
+// finds a page too large (error 75), e.g. because its records have
+// long text fields, the page size is halved and the page fetched
+// again.
+func DoQueryPaged(ticket Ticket, dbid, query, clist string, options PageOptions) (records *RecordSet, err error) {
+	pageSize := options.PageSize
+	if pageSize == 0 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize < 0 {
+		return nil, fmt.Errorf("Page size must be positive")
+	}
+	records = &RecordSet{}
+	if options.Spill {
+		if err = records.spill(options.SpillDir); err != nil {
+			return nil, err
+		}
+	}
+	attempt := 1
+	for skip := 0; ; skip += pageSize {
+		page, err := DoStructuredQuery(ticket, dbid, query, clist, "3", fmt.Sprintf("num-%d.skp-%d", pageSize, skip))
+		if qbErr, ok := err.(QuickBaseError); ok && qbErr.Code == errcodeTooLarge && pageSize > 1 {
+			attempt++
+			ticket.hooks.retry("API_DoQuery", attempt, err)
+			pageSize /= 2
+			skip -= pageSize
+			continue
+		}
+		attempt = 1
+		if err == nil {
+			err = records.add(page)
+		}
+		if err != nil {
+			records.Close()
+			return nil, err
+		}
+		if len(page) < pageSize {
+			break
+		}
+	}
+	return records, records.rewind()
+}
+
+// A RecordSet holds the records of a query, in memory or spilled to a
+// temporary file, and iterates over them:
+//
+//	for records.Next() {
+//		record := records.Record()
+//		...
+//	}
+//	if err := records.Err(); ...
+//
+// The caller must Close it to remove any temporary file.
+type RecordSet struct {
+	records []map[int]string
+	n       int
+	next    int
+	record  map[int]string
+	err     error
+
+	file    *os.File // holding the records if they are spilled
+	writer  *bufio.Writer
+	encoder *gob.Encoder
+	decoder *gob.Decoder
+}
+
+// spill makes the set hold its records in a temporary file in dir.
+func (s *RecordSet) spill(dir string) (err error) {
+	if s.file, err = ioutil.TempFile(dir, "quickbase-records-"); err != nil {
+		return err
+	}
+	s.writer = bufio.NewWriter(s.file)
+	s.encoder = gob.NewEncoder(s.writer)
+	return nil
+}
+
+func (s *RecordSet) add(records []map[int]string) error {
+	s.n += len(records)
+	if s.file == nil {
+		s.records = append(s.records, records...)
+		return nil
+	}
+	for _, record := range records {
+		if err := s.encoder.Encode(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rewind readies a spilled set to be read back.
+func (s *RecordSet) rewind() (err error) {
+	if s.file == nil {
+		return nil
+	}
+	if err = s.writer.Flush(); err == nil {
+		_, err = s.file.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		s.Close()
+		return err
+	}
+	s.decoder = gob.NewDecoder(bufio.NewReader(s.file))
+	return nil
+}
+
+// Len returns the number of records in the set.
+func (s *RecordSet) Len() int {
+	return s.n
+}
+
+// Next advances to the next record, returning false at the end of the
+// set or on an error reading it back, which Err then returns.
+func (s *RecordSet) Next() bool {
+	if s.err != nil || s.next >= s.n {
+		return false
+	}
+	if s.file == nil {
+		s.record = s.records[s.next]
+	} else {
+		s.record = nil
+		if s.err = s.decoder.Decode(&s.record); s.err != nil {
+			return false
+		}
+	}
+	s.next++
+	return true
+}
+
+// Record returns the record Next advanced to, a map from field IDs to
+// values as DoStructuredQuery returns.
+func (s *RecordSet) Record() map[int]string {
+	return s.record
+}
+
+// Err returns the error which stopped Next, if any.
+func (s *RecordSet) Err() error {
+	return s.err
+}
+
+// Close releases the set, removing its temporary file if it has one.
+func (s *RecordSet) Close() error {
+	s.records, s.n = nil, s.next
+	if s.file == nil {
+		return nil
+	}
+	s.file.Close()
+	err := os.Remove(s.file.Name())
+	s.file = nil
+	return err
+}
@@ -0,0 +1,111 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestDoQueryPaged(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		server.Seed(testTableDbid, map[int]string{6: fmt.Sprintf("Site %d", i)})
+	}
+	dir, err := ioutil.TempDir("", "pages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, options := range []quickbase.PageOptions{
+		{PageSize: 2},
+		{PageSize: 3, Spill: true, SpillDir: dir},
+	} {
+		records, err := client.DoQueryPaged(testTableDbid, "", "3.6", options)
+		if err != nil {
+			t.Fatalf("%+v: %v", options, err)
+		}
+		var sites []string
+		for records.Next() {
+			sites = append(sites, records.Record()[6])
+		}
+		if err = records.Err(); err != nil {
+			t.Errorf("%+v: %v", options, err)
+		}
+		if records.Len() != 7 || fmt.Sprint(sites) != "[Denver Boise Site 0 Site 1 Site 2 Site 3 Site 4]" {
+			t.Errorf("%+v: %d records: %v", options, records.Len(), sites)
+		}
+		if files, _ := ioutil.ReadDir(dir); len(files) != map[bool]int{false: 0, true: 1}[options.Spill] {
+			t.Errorf("%+v: spill directory holds %d files", options, len(files))
+		}
+		if err = records.Close(); err != nil {
+			t.Error(err)
+		}
+		if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+			t.Errorf("%+v: Close left %d files", options, len(files))
+		}
+	}
+	if _, err = client.DoQueryPaged(testTableDbid, "{'99'.EX.'x'}", "", quickbase.PageOptions{Spill: true, SpillDir: dir}); err == nil {
+		t.Error("DoQueryPaged of a missing field succeeded")
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Errorf("failed query left %d files", len(files))
+	}
+}
+
+func TestDoQueryPagedTooLarge(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		server.Seed(testTableDbid, map[int]string{6: fmt.Sprintf("Site %d", i)})
+	}
+	var retries []quickbase.RetryEvent
+	client.Hooks = &quickbase.Hooks{OnRetry: func(e quickbase.RetryEvent) { retries = append(retries, e) }}
+	server.FailNext("API_DoQuery", 75, "Report too large")
+	records, err := client.DoQueryPaged(testTableDbid, "", "3.6", quickbase.PageOptions{PageSize: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer records.Close()
+	if records.Len() != 7 {
+		t.Errorf("got %d records, want 7", records.Len())
+	}
+	if len(retries) != 1 || retries[0].Action != "API_DoQuery" || retries[0].Attempt != 2 {
+		t.Errorf("got retries %+v", retries)
+	}
+	server.FailNext("API_DoQuery", 75, "Report too large")
+	if _, err = client.DoQueryPaged(testTableDbid, "", "3.6", quickbase.PageOptions{PageSize: 1}); err == nil {
+		t.Error("DoQueryPaged of a single record too large succeeded")
+	}
+}
@@ -0,0 +1,128 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"github.com/WesTower/quickbase"
+	"github.com/WesTower/quickbase/quickbasetest"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+var malformedResponses = map[string]string{
+	"empty":         ``,
+	"html":          `<html><body><h1>502 Bad Gateway</h1></body></html>`,
+	"no errcode":    `<?xml version="1.0" ?><qdbapi><action>API_DoQuery</action></qdbapi>`,
+	"bad errcode":   `<?xml version="1.0" ?><qdbapi><errcode>zero</errcode></qdbapi>`,
+	"truncated":     `<?xml version="1.0" ?><qdbapi><errcode>0</errcode><record><site>Den`,
+	"no rid":        `<?xml version="1.0" ?><qdbapi><errcode>0</errcode><errtext>No error</errtext></qdbapi>`,
+	"bad numbers":   `<?xml version="1.0" ?><qdbapi><errcode>0</errcode><numMatches>many</numMatches><rid>x</rid><RequestTime>now</RequestTime></qdbapi>`,
+	"error no text": `<?xml version="1.0" ?><qdbapi><errcode>4</errcode></qdbapi>`,
+}
+
+// callAll makes every call whose response the server's fixture
+// answers, returning the errors which resulted.
+func callAll(server *quickbasetest.FixtureServer, response []byte) (errs map[string]error) {
+	errs = make(map[string]error)
+	for _, action := range []string{"API_DoQuery", "API_DoQueryCount", "API_AddRecord", "API_EditRecord",
+		"API_GetSchema", "API_GetAppDTMInfo", "API_UserRoles", "API_ImportFromCSV"} {
+		server.SetResponse(action, response)
+	}
+	ticket, err := quickbase.Authenticate(server.BaseUrl(), "fixture", "fixture")
+	if err != nil {
+		errs["Authenticate"] = err
+		return errs
+	}
+	_, errs["DoQuery"] = quickbase.DoQuery(ticket, "bck7gp3q2", "", "", "", "")
+	_, errs["DoStructuredQuery"] = quickbase.DoStructuredQuery(ticket, "bck7gp3q2", "", "", "", "")
+	_, errs["DoQueryCount"] = quickbase.DoQueryCount(ticket, "bck7gp3q2", "")
+	_, errs["AddRecord"] = quickbase.AddRecord(ticket, "bck7gp3q2", nil)
+	errs["EditRecord"] = quickbase.EditRecord(ticket, "bck7gp3q2", 1, nil)
+	_, errs["GetSchema"] = quickbase.GetSchema(ticket, "bck7gp3q2")
+	_, _, _, _, errs["GetAppDTMInfo"] = quickbase.GetAppDTMInfo(server.BaseUrl(), "bck7gp3q1")
+	_, errs["UserRoles"] = quickbase.UserRoles(ticket, "bck7gp3q1")
+	records, err := quickbase.DoQueryChan(ticket, "bck7gp3q2", "", "", "")
+	if errs["DoQueryChan"] = err; err == nil {
+		for range records {
+		}
+	}
+	return errs
+}
+
+func TestMalformedResponses(t *testing.T) {
+	server := quickbasetest.NewFixtureServer(nil)
+	defer server.Close()
+	for name, response := range malformedResponses {
+		errs := callAll(server, []byte(response))
+		switch name {
+		case "no rid":
+			if errs["AddRecord"] == nil {
+				t.Errorf("%s: AddRecord succeeded", name)
+			}
+		case "bad numbers":
+			for _, call := range []string{"DoQueryCount", "AddRecord", "GetAppDTMInfo"} {
+				if errs[call] == nil {
+					t.Errorf("%s: %s succeeded", name, call)
+				}
+			}
+		case "truncated":
+			if errs["DoQuery"] == nil {
+				t.Errorf("%s: DoQuery succeeded", name)
+			}
+		default:
+			for call, err := range errs {
+				if err == nil {
+					t.Errorf("%s: %s succeeded", name, call)
+				}
+			}
+		}
+	}
+}
+
+func TestMalformedAuthenticate(t *testing.T) {
+	server := quickbasetest.NewFixtureServer(map[string][]byte{
+		"API_Authenticate": []byte(`<?xml version="1.0" ?><qdbapi><errcode>0</errcode></qdbapi>`),
+	})
+	defer server.Close()
+	if _, err := quickbase.Authenticate(server.BaseUrl(), "fixture", "fixture"); err == nil {
+		t.Error("Authenticate without a ticket succeeded")
+	}
+}
+
+// FuzzResponses checks that no response, however malformed, makes a
+// call panic.
+func FuzzResponses(f *testing.F) {
+	corpus, _ := filepath.Glob(filepath.Join("testdata", "*.xml"))
+	for _, file := range corpus {
+		if response, err := ioutil.ReadFile(file); err == nil {
+			f.Add(response)
+		}
+	}
+	for _, response := range malformedResponses {
+		f.Add([]byte(response))
+	}
+	server := quickbasetest.NewFixtureServer(nil)
+	defer server.Close()
+	f.Fuzz(func(t *testing.T, response []byte) {
+		callAll(server, response)
+	})
+}
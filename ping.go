@@ -0,0 +1,60 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Ping makes about the least authenticated call there is, asking
+// API_GetUserInfo of db/main about the ticket's own user, to check
+// that the realm URL is right and the ticket or user token still
+// good, e.g. in a readiness probe or as a service starts.  It returns
+// the call's round-trip time.
+func Ping(ctx context.Context, ticket Ticket) (latency time.Duration, err error) {
+	req, release, err := newApiRequest(ticket.url+"db/main", "API_GetUserInfo", ticket.params())
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+	var result userInfoResponse
+	start := time.Now()
+	err = executeRequest(ticket, req.WithContext(ctx), &result)
+	latency = time.Since(start)
+	if err != nil {
+		if ctx.Err() != nil {
+			return latency, ctx.Err()
+		}
+		return latency, err
+	}
+	if result.User == nil || result.User.Id == "" {
+		return latency, fmt.Errorf("No user returned from API_GetUserInfo")
+	}
+	return latency, nil
+}
+
+// Ping checks the Client's realm URL and credentials, as the
+// package-level Ping does.
+func (c *Client) Ping(ctx context.Context) (latency time.Duration, err error) {
+	return Ping(ctx, c.ticket())
+}
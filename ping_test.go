@@ -0,0 +1,61 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"context"
+	"github.com/WesTower/quickbase"
+	"testing"
+	"time"
+)
+
+func TestPing(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	server.AddUserToken("jdoe", "b2fr52_xyz")
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latency, err := client.Ping(context.Background()); err != nil || latency <= 0 {
+		t.Errorf("Ping gave %s, %v", latency, err)
+	}
+	token := quickbase.NewClient(quickbase.UserTokenTicket(server.BaseUrl(), "b2fr52_xyz"))
+	if _, err = token.Ping(context.Background()); err != nil {
+		t.Errorf("Ping with a user token gave %v", err)
+	}
+
+	server.ExpireTickets()
+	if _, err = client.Ping(context.Background()); !quickbase.IsTicketError(err) {
+		t.Errorf("Ping with an expired ticket gave %v", err)
+	}
+	wrong := quickbase.NewClient(quickbase.UserTokenTicket("http://127.0.0.1:1/", "b2fr52_xyz"))
+	if _, err = wrong.Ping(context.Background()); err == nil {
+		t.Error("Ping of the wrong URL succeeded")
+	}
+
+	server.SetLatency(200*time.Millisecond, 0)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err = token.Ping(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Ping past its deadline gave %v", err)
+	}
+}
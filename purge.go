@@ -0,0 +1,83 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"fmt"
+)
+
+// PurgeRecords deletes every record of a table matching query, in a
+// single call, returning the number deleted.  As QuickBase purges the
+// whole table when given no query, an empty query is refused; use
+// PurgeAll for that.
+func PurgeRecords(ticket Ticket, dbid, query string) (count int, err error) {
+	if query == "" {
+		return 0, fmt.Errorf("Refusing to purge %s without a query; use PurgeAll", dbid)
+	}
+	var result purgeRecordsResponse
+	if err = purgeRecordsCall(ticket, dbid, query).execute(&result); err != nil {
+		return 0, err
+	}
+	return result.deleted()
+}
+
+// PurgeAll deletes every record of a table, returning the number
+// deleted.  It does nothing unless confirm is set.
+func PurgeAll(ticket Ticket, dbid string, confirm bool) (count int, err error) {
+	if !confirm {
+		return 0, fmt.Errorf("Refusing to purge all of %s without confirmation", dbid)
+	}
+	var result purgeRecordsResponse
+	if err = purgeRecordsCall(ticket, dbid, "").execute(&result); err != nil {
+		return 0, err
+	}
+	return result.deleted()
+}
+
+func purgeRecordsCall(ticket Ticket, dbid, query string) apiCall {
+	params := ticket.params()
+	if query != "" {
+		params["query"] = query
+	}
+	return apiCall{ticket, ticket.url + "db/" + dbid, "API_PurgeRecords", params}
+}
+
+func (c *Client) PurgeRecords(dbid, query string) (count int, err error) {
+	if query == "" {
+		return 0, fmt.Errorf("Refusing to purge %s without a query; use PurgeAll", dbid)
+	}
+	return c.purge(dbid, query)
+}
+
+func (c *Client) PurgeAll(dbid string, confirm bool) (count int, err error) {
+	if !confirm {
+		return 0, fmt.Errorf("Refusing to purge all of %s without confirmation", dbid)
+	}
+	return c.purge(dbid, "")
+}
+
+func (c *Client) purge(dbid, query string) (count int, err error) {
+	var result purgeRecordsResponse
+	if err = c.mutate(purgeRecordsCall(c.ticket(), dbid, query), &result); err != nil || c.DryRun {
+		return 0, err
+	}
+	return result.deleted()
+}
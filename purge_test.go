@@ -0,0 +1,56 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"github.com/WesTower/quickbase"
+	"testing"
+)
+
+func TestPurgeRecords(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = client.AddRecordByFid(testTableDbid, map[int]string{6: "Denver", 7: "8"}); err != nil {
+		t.Fatal(err)
+	}
+	if count, err := client.PurgeRecords(testTableDbid, "{'6'.EX.'Denver'}"); err != nil || count != 2 {
+		t.Errorf("PurgeRecords deleted %d, %v, not 2", count, err)
+	}
+	if records := server.Records(testTableDbid); len(records) != 1 || records[0][6] != "Boise" {
+		t.Errorf("after purge the table holds %v", records)
+	}
+	if _, err = client.PurgeRecords(testTableDbid, ""); err == nil {
+		t.Error("PurgeRecords purged without a query")
+	}
+	if _, err = client.PurgeAll(testTableDbid, false); err == nil {
+		t.Error("PurgeAll purged without confirmation")
+	}
+	if len(server.Records(testTableDbid)) != 1 {
+		t.Error("refused purges deleted records")
+	}
+	if count, err := client.PurgeAll(testTableDbid, true); err != nil || count != 1 {
+		t.Errorf("PurgeAll deleted %d, %v, not 1", count, err)
+	}
+}
@@ -27,39 +27,327 @@
 package quickbase
 
 import (
+	"bufio"
 	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
 	"encoding/base64"
 	"encoding/xml"
 	"fmt"
-	xmlx "github.com/jteeuwen/go-pkg-xmlx"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 )
 
 // QuickBaseError represents an error returned by the QuickBase API,
 // as documented at
 // <http://www.quickbase.com/api-guide/index.html#errorcodes.html>.
+//
+// Errors from the RESTful API (package restapi) are also represented
+// as QuickBaseErrors: Message and Detail hold the message and
+// description of the JSON error body, HttpStatus holds the HTTP
+// status and Code is zero.
 type QuickBaseError struct {
-	Message string // human-readable message; corresponds to errtext in a response
-	Code    int    // corresponds to errcode in a response
+	Message    string // human-readable message; corresponds to errtext in a response
+	Code       int    // corresponds to errcode in a response
+	Detail     string // further detail, if any; corresponds to errdetail in a response
+	HttpStatus int    // HTTP status of a RESTful API response
 }
 
 func (e QuickBaseError) Error() string {
+	if e.Detail != "" {
+		return e.Message + ": " + e.Detail
+	}
 	return e.Message
 }
 
-// A Ticket represents a QuickBase authentication ticket.
+// Transport, if set, is used to make every HTTP request; if nil,
+// DefaultTransport is used.  Tests may set it to a
+// quickbasetest.Recorder to record or replay API interactions.
+var Transport http.RoundTripper
+
+// DefaultTransport is the transport used unless Transport is set.
+// It keeps connections to QuickBase alive, and pools more of them
+// than http.DefaultTransport, to suit concurrent batch jobs.
+var DefaultTransport http.RoundTripper = NewTransport(TransportOptions{})
+
+// TransportOptions tune the connections a transport keeps to
+// QuickBase.  Zero values take the defaults of DefaultTransport, given
+// in brackets.
+type TransportOptions struct {
+	MaxIdleConns          int           // idle connections kept to all hosts [100]
+	MaxIdleConnsPerHost   int           // idle connections kept to each host [16]
+	IdleConnTimeout       time.Duration // how long an idle connection is kept [90s]
+	TLSHandshakeTimeout   time.Duration // how long a TLS handshake may take [10s]
+	ExpectContinueTimeout time.Duration // how long to await '100 Continue' before sending a body [1s]
+	// DisableHTTP2 makes the transport speak only HTTP/1.1, for
+	// proxies which mishandle HTTP/2.
+	DisableHTTP2 bool
+	// Proxy, if set, is the proxy through which requests are sent;
+	// otherwise that named by $HTTPS_PROXY, if any, is used.
+	Proxy *url.URL
+}
+
+// NewTransport returns a transport tuned by the given options, e.g.
+// to let many parallel workers keep their connections open:
+//
+//	client.HttpClient = &http.Client{Transport: quickbase.NewTransport(quickbase.TransportOptions{MaxIdleConnsPerHost: 64})}
+func NewTransport(options TransportOptions) *http.Transport {
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   16,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+		ForceAttemptHTTP2:     !options.DisableHTTP2,
+	}
+	if options.DisableHTTP2 {
+		// a non-nil, empty TLSNextProto turns off HTTP/2
+		transport.TLSNextProto = make(map[string]func(string, *tls.Conn) http.RoundTripper)
+	}
+	if options.MaxIdleConns != 0 {
+		transport.MaxIdleConns = options.MaxIdleConns
+	}
+	if options.MaxIdleConnsPerHost != 0 {
+		transport.MaxIdleConnsPerHost = options.MaxIdleConnsPerHost
+	}
+	if options.IdleConnTimeout != 0 {
+		transport.IdleConnTimeout = options.IdleConnTimeout
+	}
+	if options.TLSHandshakeTimeout != 0 {
+		transport.TLSHandshakeTimeout = options.TLSHandshakeTimeout
+	}
+	if options.ExpectContinueTimeout != 0 {
+		transport.ExpectContinueTimeout = options.ExpectContinueTimeout
+	}
+	if options.Proxy != nil {
+		transport.Proxy = http.ProxyURL(options.Proxy)
+	}
+	return transport
+}
+
+// DefaultClient is the HTTP client shared by every call not made by a
+// Client with its own HttpClient, so that connections and TLS sessions
+// are reused from call to call.  It sends requests with Transport, or
+// DefaultTransport if that is unset.  Set its Timeout to limit calls.
+var DefaultClient = &http.Client{Transport: sharedTransport{}}
+
+// sharedTransport sends requests with Transport or DefaultTransport,
+// whichever is in effect when each is sent.
+type sharedTransport struct{}
+
+func (sharedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if Transport != nil {
+		return Transport.RoundTrip(req)
+	}
+	return DefaultTransport.RoundTrip(req)
+}
+
+// decompress replaces the body of a gzipped response with its
+// decompressed content, as http.Transport does when it asks for gzip
+// itself.
+func decompress(resp *http.Response) {
+	if !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		return
+	}
+	resp.Body = &gzipBody{body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+}
+
+// gzipBody decompresses a response body, reading its gzip header on
+// first use so that an empty body is only an error if it is read.
+type gzipBody struct {
+	body   io.ReadCloser
+	reader *gzip.Reader
+	err    error
+}
+
+func (b *gzipBody) Read(p []byte) (n int, err error) {
+	if b.reader == nil && b.err == nil {
+		b.reader, b.err = gzip.NewReader(b.body)
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	return b.reader.Read(p)
+}
+
+func (b *gzipBody) Close() error {
+	return b.body.Close()
+}
+
+// LimitRedirects returns a policy for an http.Client's CheckRedirect
+// which follows at most n redirects, returning the response to the
+// last rather than an error; with n zero, no redirect is followed.
+func LimitRedirects(n int) func(req *http.Request, via []*http.Request) error {
+	return func(req *http.Request, via []*http.Request) error {
+		if len(via) > n {
+			return http.ErrUseLastResponse
+		}
+		return nil
+	}
+}
+
+// closeBody reads the rest of a response body and closes it, so that
+// its connection may be reused.
+func closeBody(body io.ReadCloser) {
+	io.Copy(ioutil.Discard, io.LimitReader(body, 64<<10))
+	body.Close()
+}
+
+// A Ticket represents a QuickBase authentication ticket, or a user
+// token used in its place.
 type Ticket struct {
-	ticket   string
-	userid   string
-	url      string
-	Apptoken string // if set, then each call using this Ticket
+	ticket    string
+	userid    string
+	url       string
+	usertoken string
+	Apptoken  string // if set, then each call using this Ticket
 	// will include this Apptoken
+
+	httpClient   *http.Client        // if nil, DefaultClient
+	limiter      *RateLimiter        // if set, paces calls
+	concurrency  *ConcurrencyLimiter // if set, bounds calls in flight
+	dump         *wireDump           // if set, receives HTTP exchanges
+	uncompressed bool                // if set, responses are not asked to be gzipped
+	hooks        *Hooks              // if set, told of each call
+	life         *lifecycle          // if set, tracks calls for Shutdown
+	write        bool                // if set, calls change data
+	ctx          context.Context     // if set, bounds each call
+	renewer      *ticketRefresher    // if set, renews the ticket when rejected
+}
+
+// RestoreTicket recreates a Ticket from the values returned by its
+// Credentials method, e.g. to reuse a ticket saved by an earlier run
+// of a program rather than authenticating again.
+func RestoreTicket(url, ticket, userid string) Ticket {
+	return Ticket{ticket: ticket, userid: userid, url: url}
+}
+
+// UserTokenTicket returns a Ticket which authenticates each call with
+// a user token rather than a ticket from Authenticate.  The URL is as
+// for Authenticate.
+func UserTokenTicket(url, usertoken string) Ticket {
+	return Ticket{url: url, usertoken: usertoken}
+}
+
+// WithContext returns a copy of the Ticket whose calls are made with
+// ctx, so that they are abandoned, returning ctx.Err(), once ctx is
+// done.  A call given a context of its own, e.g. by ForEachRecord or
+// Ping, uses that instead.
+func (t Ticket) WithContext(ctx context.Context) Ticket {
+	t.ctx = ctx
+	return t
+}
+
+// Credentials returns the URL, ticket and user ID of a Ticket, which
+// RestoreTicket accepts.  The ticket grants the user's access until it
+// expires, so treat it as a secret.
+func (t Ticket) Credentials() (url, ticket, userid string) {
+	return t.url, t.ticket, t.userid
+}
+
+// params returns the parameters authenticating a call.
+func (t Ticket) params() map[string]string {
+	params := make(map[string]string)
+	if t.usertoken != "" {
+		params["usertoken"] = t.usertoken
+	} else {
+		params["ticket"] = t.ticket
+	}
+	if t.Apptoken != "" {
+		params["apptoken"] = t.Apptoken
+	}
+	return params
+}
+
+// do sends an HTTP request on behalf of the ticket, paced by its
+// rate and concurrency limiters if it has them.  It asks for a gzipped response unless
+// the request names its own encodings or the ticket is set not to,
+// and decompresses one if given.
+func (t Ticket) do(req *http.Request) (resp *http.Response, err error) {
+	client := t.httpClient
+	if client == nil {
+		client = DefaultClient
+	}
+	if t.ctx != nil && req.Context() == context.Background() {
+		req = req.WithContext(t.ctx)
+	}
+	release, err := t.life.begin(req, t.write)
+	if err != nil {
+		return nil, err
+	}
+	if t.limiter != nil {
+		if err = t.limiter.Wait(req.Context()); err != nil {
+			release()
+			return nil, err
+		}
+	}
+	if t.concurrency != nil {
+		start, err := t.concurrency.acquire(req.Context())
+		if err != nil {
+			release()
+			return nil, err
+		}
+		defer func() { t.concurrency.release(start, resp) }()
+	}
+	if req.Header.Get("Accept-Encoding") == "" {
+		if t.uncompressed {
+			req.Header.Set("Accept-Encoding", "identity")
+		} else {
+			req.Header.Set("Accept-Encoding", "gzip")
+		}
+	}
+	dumping := t.dump.selects(req)
+	if dumping {
+		t.dump.request(req)
+	}
+	t.hooks.request(req)
+	start := time.Now()
+	resp, err = client.Do(req)
+	t.hooks.response(req, resp, start, err)
+	if err == nil {
+		var until time.Time
+		if t.limiter != nil {
+			until = t.limiter.BackOffFromHeaders(resp.Header)
+		} else if t.hooks != nil {
+			until = headerBackOff(resp.Header)
+		}
+		t.hooks.rateLimited(req, resp, until)
+	}
+	if err == nil {
+		decompress(resp)
+	}
+	if err == nil && dumping {
+		t.dump.response(resp)
+	}
+	if err != nil {
+		if ctxErr := req.Context().Err(); ctxErr != nil {
+			err = ctxErr
+		}
+		release()
+		return resp, err
+	}
+	resp.Body = &releasingBody{resp.Body, release}
+	return resp, nil
 }
 
 // Authenticate authenticates a user to QuickBase; it's required
@@ -71,11 +359,29 @@ type Ticket struct {
 // to include the trailing slash.  It'd be nice to fix this someday to
 // use a decent URL library to Do the Right Thing.
 func Authenticate(url, username, password string) (ticket Ticket, err error) {
-	doc, err := executeApiCall(url+"db/main", "API_Authenticate", map[string]string{"username": username, "password": password})
+	return authenticate(Ticket{}, url, username, password)
+}
+
+// authenticate authenticates a user, making the call and those of the
+// returned Ticket as session does.
+func authenticate(session Ticket, url, username, password string) (ticket Ticket, err error) {
+	var result authenticateResponse
+	err = executeApiCall(session, url+"db/main", "API_Authenticate", map[string]string{"username": username, "password": password}, &result)
 	if err != nil {
 		return ticket, err
 	}
-	return Ticket{doc.SelectNode("", "ticket").GetValue(), doc.SelectNode("", "userid").GetValue(), url, ""}, nil
+	if result.Ticket == nil {
+		return ticket, fmt.Errorf("No ticket returned from API_Authenticate")
+	}
+	ticket = RestoreTicket(url, string(*result.Ticket), string(result.Userid))
+	ticket.transportFrom(session)
+	return ticket, nil
+}
+
+// transportFrom sets t to make calls as session does.
+func (t *Ticket) transportFrom(session Ticket) {
+	t.httpClient, t.limiter, t.concurrency, t.uncompressed, t.hooks = session.httpClient, session.limiter, session.concurrency, session.uncompressed, session.hooks
+	t.life = session.life
 }
 
 type apiParam struct {
@@ -88,78 +394,169 @@ type quickBaseRequest struct {
 	Params  []apiParam
 }
 
-func executeApiCall(url, api_call string, parameters map[string]string) (doc *xmlx.Document, err error) {
-	count := 0
-	for _, _ = range parameters {
-		count++
-	}
-	api_params := make([]apiParam, count)
-	i := 0
-	for key, _ := range parameters {
-		api_params[i] = apiParam{xml.Name{"", key}, parameters[key]}
-		i++
+// apiParams converts parameters to request elements, in name order
+// so that identical calls send identical requests.
+func apiParams(parameters map[string]string) []apiParam {
+	keys := make([]string, 0, len(parameters))
+	for key := range parameters {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	params := make([]apiParam, len(keys))
+	for i, key := range keys {
+		params[i] = apiParam{xml.Name{Local: key}, parameters[key]}
+	}
+	return params
+}
+
+// An apiCall is a call ready to be executed.
+type apiCall struct {
+	ticket Ticket
+	url    string
+	action string
+	params map[string]string
+}
+
+// execute makes the call, decoding its response into result if that
+// is not nil.
+func (c apiCall) execute(result response) (err error) {
+	return executeApiCall(c.ticket, c.url, c.action, c.params, result)
+}
+
+// requestBody returns the qdbapi XML sent for a call.
+func requestBody(parameters map[string]string) ([]byte, error) {
+	var body bytes.Buffer
+	err := writeRequestBody(&body, parameters)
+	return body.Bytes(), err
+}
+
+// writeRequestBody writes the qdbapi XML sent for a call.
+func writeRequestBody(w io.Writer, parameters map[string]string) error {
+	return xml.NewEncoder(w).Encode(quickBaseRequest{Params: apiParams(parameters)})
+}
+
+// bufferPool holds the buffers calls are marshalled into, so that busy
+// programs reuse them rather than allocating a fresh one per call.
+var bufferPool = sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}
+
+// maxPooledBuffer is the capacity beyond which a buffer is left to the
+// garbage collector, lest one huge import pin its memory in the pool.
+const maxPooledBuffer = 1 << 20
+
+// A pooledBuffer is a buffer from bufferPool, returned to it once
+// all of its references are released.
+type pooledBuffer struct {
+	buf  *bytes.Buffer
+	refs int32
+}
+
+func (b *pooledBuffer) acquire() {
+	atomic.AddInt32(&b.refs, 1)
+}
+
+func (b *pooledBuffer) release() {
+	if atomic.AddInt32(&b.refs, -1) == 0 && b.buf.Cap() <= maxPooledBuffer {
+		bufferPool.Put(b.buf)
 	}
-	req := quickBaseRequest{Params: api_params}
-	xml_req, err := xml.Marshal(req)
+}
+
+// pooledBody is a request body reading a pooledBuffer, which it
+// releases once the HTTP transport closes it.
+type pooledBody struct {
+	*bytes.Reader
+	buf  *pooledBuffer
+	once sync.Once
+}
+
+func (b *pooledBody) Close() error {
+	b.once.Do(b.buf.release)
+	return nil
+}
+
+// newBody returns a new body reading the buffer.
+func (b *pooledBuffer) newBody() *pooledBody {
+	b.acquire()
+	return &pooledBody{Reader: bytes.NewReader(b.buf.Bytes()), buf: b}
+}
+
+// newApiRequest returns the HTTP request for a call, with its body in
+// a pooled buffer.  The caller must call release once the request has
+// been sent; the transport holds the buffer while it needs it, and
+// each redirect the request follows reads the buffer anew.
+func newApiRequest(url, api_call string, parameters map[string]string) (http_req *http.Request, release func(), err error) {
+	buf := &pooledBuffer{buf: bufferPool.Get().(*bytes.Buffer), refs: 1}
+	buf.buf.Reset()
+	if err = writeRequestBody(buf.buf, parameters); err != nil {
+		buf.release()
+		return nil, nil, err
+	}
+	body := buf.newBody()
+	http_req, err = http.NewRequest("POST", url, body)
 	if err != nil {
-		return
+		body.Close()
+		buf.release()
+		return nil, nil, err
 	}
-	client := &http.Client{}
-	http_req, err := http.NewRequest("POST", url, bytes.NewReader(xml_req))
-	if err != nil {
-		return nil, err
+	http_req.ContentLength = int64(body.Len())
+	http_req.GetBody = func() (io.ReadCloser, error) {
+		return buf.newBody(), nil
 	}
 	http_req.Header.Add("QUICKBASE-ACTION", api_call)
 	http_req.Header.Add("Content-Type", "application/xml")
-	resp, err := client.Do(http_req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+	return http_req, buf.release, nil
+}
 
-	//tee := io.TeeReader(resp.Body, os.Stderr)
-	doc = xmlx.New()
-	err = doc.LoadStream(resp.Body, nil)
-	//err = doc.LoadStream(tee, nil)
-	if err != nil {
-		return nil, err
+// executeApiCall makes a call, decoding its response into result if
+// that is not nil.  If the call's ticket is rejected and the Ticket has
+// a renewer, the call is retried once with a new ticket.
+func executeApiCall(ticket Ticket, url, api_call string, parameters map[string]string, result response) (err error) {
+	err = executeApiCallOnce(ticket, url, api_call, parameters, result)
+	if ticket.renewer == nil || ticket.ticket == "" || parameters["ticket"] != ticket.ticket || !IsTicketError(err) {
+		return err
 	}
-	if errcode := doc.SelectNode("", "errcode").GetValue(); errcode != "0" {
-		//err = fmt.Errorf(doc.SelectNode("", "errtext").GetValue())
-		code, err := strconv.Atoi(errcode)
-		if err != nil {
-			return nil, err
-		}
-		return nil, QuickBaseError{Message: doc.SelectNode("", "errtext").GetValue(), Code: code}
+	renewed, renewErr := ticket.renewer.renew(ticket, ticket.ticket)
+	if renewErr != nil {
+		return err
 	}
-
-	return doc, nil
+	retried := make(map[string]string, len(parameters))
+	for k, v := range parameters {
+		retried[k] = v
+	}
+	retried["ticket"] = renewed.ticket
+	ticket.ticket, ticket.userid = renewed.ticket, renewed.userid
+	return executeApiCallOnce(ticket, url, api_call, retried, result)
 }
 
-func executeRawApiCall(url, api_call string, parameters map[string]string) (resp *http.Response, err error) {
-	count := 0
-	for _, _ = range parameters {
-		count++
-	}
-	api_params := make([]apiParam, count)
-	i := 0
-	for key, _ := range parameters {
-		api_params[i] = apiParam{xml.Name{"", key}, parameters[key]}
-		i++
+func executeApiCallOnce(ticket Ticket, url, api_call string, parameters map[string]string, result response) (err error) {
+	http_req, release, err := newApiRequest(url, api_call, parameters)
+	if err != nil {
+		return err
 	}
-	req := quickBaseRequest{Params: api_params}
-	xml_req, err := xml.Marshal(req)
+	defer release()
+	return executeRequest(ticket, http_req, result)
+}
+
+// executeRequest sends the HTTP request for a call and decodes its
+// response into result, if that is not nil.
+func executeRequest(ticket Ticket, http_req *http.Request, result response) (err error) {
+	resp, err := ticket.do(http_req)
 	if err != nil {
-		return
+		return err
+	}
+	defer closeBody(resp.Body)
+	if result == nil {
+		result = &qdbapiResponse{}
 	}
-	client := &http.Client{}
-	http_req, err := http.NewRequest("POST", url, bytes.NewReader(xml_req))
+	return decodeResponse(resp.Body, resp.StatusCode, result)
+}
+
+func executeRawApiCall(ticket Ticket, url, api_call string, parameters map[string]string) (resp *http.Response, err error) {
+	http_req, release, err := newApiRequest(url, api_call, parameters)
 	if err != nil {
 		return nil, err
 	}
-	http_req.Header.Add("QUICKBASE-ACTION", api_call)
-	http_req.Header.Add("Content-Type", "application/xml")
-	return client.Do(http_req)
+	defer release()
+	return ticket.do(http_req)
 }
 
 // SchemaModification represents the modification informatiom from
@@ -174,6 +571,10 @@ type SchemaModification struct {
 // time the server will allow another request, the app schema
 // modification date and table modification dates
 func GetAppDTMInfo(baseUrl, dbid string) (received, nextAllowed time.Time, schemaModification SchemaModification, tableModification []SchemaModification, err error) {
+	return getAppDTMInfo(Ticket{}, baseUrl, dbid)
+}
+
+func getAppDTMInfo(session Ticket, baseUrl, dbid string) (received, nextAllowed time.Time, schemaModification SchemaModification, tableModification []SchemaModification, err error) {
 	params := map[string]string{"dbid": dbid}
 	parsedUrl, err := url.Parse(baseUrl)
 	if err != nil {
@@ -181,128 +582,98 @@ func GetAppDTMInfo(baseUrl, dbid string) (received, nextAllowed time.Time, schem
 	}
 	parsedUrl.Path = "/db/main"
 	reqUrl := parsedUrl.String()
-	doc, err := executeApiCall(reqUrl, "API_GetAppDTMInfo", params)
-	if err != nil {
+	var result appDTMInfoResponse
+	if err = executeApiCall(session, reqUrl, "API_GetAppDTMInfo", params, &result); err != nil {
 		return
 	}
-	received, err = selectNodeToTime(doc, "RequestTime")
-	if err != nil {
+	if received, err = msecTime(result.RequestTime, "RequestTime"); err != nil {
 		return
 	}
-	nextAllowed, err = selectNodeToTime(doc, "RequestNextAllowedTime")
-	if err != nil {
+	if nextAllowed, err = msecTime(result.RequestNextAllowedTime, "RequestNextAllowedTime"); err != nil {
 		return
 	}
-	app := doc.SelectNode("", "app")
-	if app == nil {
+	if result.App == nil {
 		err = fmt.Errorf("No app returned")
 		return
 	}
-	dbid = ""
-	for _, attr := range app.Attributes {
-		if attr.Name.Space == "" && attr.Name.Local == "id" {
-			dbid = attr.Value
-			break
-		}
-	}
-	if dbid == "" {
+	if result.App.Id == "" {
 		err = fmt.Errorf("Missing table dbid in app")
-		return received, nextAllowed, schemaModification, tableModification, err
+		return
 	}
-	schemaModification.Dbid = dbid
-	schemaModification.SchemaModified, err = selectNodeToTime(app, "lastModifiedTime")
-	if err != nil {
+	schemaModification.Dbid = result.App.Id
+	if schemaModification.SchemaModified, err = msecTime(result.App.LastModifiedTime, "lastModifiedTime"); err != nil {
 		return
 	}
-	schemaModification.RecordModified, err = selectNodeToTime(app, "lastRecModTime")
-	if err != nil {
+	if schemaModification.RecordModified, err = msecTime(result.App.LastRecModTime, "lastRecModTime"); err != nil {
 		return
 	}
-	tablesNode := doc.SelectNode("", "tables")
-	if tablesNode == nil {
+	if result.Tables == nil {
 		err = fmt.Errorf("No tables returned")
 		return
 	}
-	tables := tablesNode.SelectNodes("", "table")
-	for _, table := range tables {
-		var dbid string
-		for _, attr := range table.Attributes {
-			if attr.Name.Space == "" && attr.Name.Local == "id" {
-				dbid = attr.Value
-				break
-			}
-		}
-		if dbid == "" {
+	for _, table := range *result.Tables {
+		if table.Id == "" {
 			err = fmt.Errorf("Missing table dbid in table")
-			return received, nextAllowed, schemaModification, tableModification, err
+			return
 		}
-		schemaMod, err := selectNodeToTime(table, "lastModifiedTime")
-		if err != nil {
-			return received, nextAllowed, schemaModification, tableModification, err
+		modification := SchemaModification{Dbid: table.Id}
+		if modification.SchemaModified, err = msecTime(table.LastModifiedTime, "lastModifiedTime"); err != nil {
+			return
 		}
-		lastRecMod, err := selectNodeToTime(table, "lastRecModTime")
-		if err != nil {
-			return received, nextAllowed, schemaModification, tableModification, err
+		if modification.RecordModified, err = msecTime(table.LastRecModTime, "lastRecModTime"); err != nil {
+			return
 		}
-		tableModification = append(tableModification, SchemaModification{dbid, schemaMod, lastRecMod})
+		tableModification = append(tableModification, modification)
 	}
 	return
 }
 
-type nodeSelector interface {
-	SelectNode(space, local string) *xmlx.Node
-}
-
-func selectNodeToTime(root nodeSelector, name string) (t time.Time, err error) {
-	if root == nil {
-		return t, fmt.Errorf("Nil root passed in")
-	}
-	node := root.SelectNode("", name)
-	if node == nil {
-		return t, fmt.Errorf("Tag named %s not found", name)
-	}
-	if msecs, err := strconv.ParseInt(node.GetValue(), 10, 64); err != nil {
-		return t, err
-	} else {
-		return time.Unix(msecs/1000, (msecs%1000)*1000), nil
-	}
-	panic("can't get here, silly Go 1.0")
-}
-
 // EditRecord edits a QuickBase record.  The fields argument is a map
 // from field labels to the desired values.
 func EditRecord(ticket Ticket, dbid string, recordId int, fields map[string]string) (err error) {
-	params := map[string]string{"ticket": ticket.ticket}
-	if ticket.Apptoken != "" {
-		params["apptoken"] = ticket.Apptoken
-	}
+	return editRecordCall(ticket, dbid, recordId, fields).execute(nil)
+}
+
+func editRecordCall(ticket Ticket, dbid string, recordId int, fields map[string]string) apiCall {
+	params := ticket.params()
 	params["rid"] = fmt.Sprintf("%d", recordId)
 	for field, value := range fields {
 		params["_fnm_"+field] = value
 	}
-	_, err = executeApiCall(ticket.url+"db/"+dbid, "API_EditRecord", params)
-	return err
+	return apiCall{ticket, ticket.url + "db/" + dbid, "API_EditRecord", params}
+}
+
+// EditRecordByFid is like EditRecord, but the fields argument is a
+// map from field IDs rather than labels, which avoids the label
+// confusion described at DoQuery.
+func EditRecordByFid(ticket Ticket, dbid string, recordId int, fields map[int]string) (err error) {
+	return editRecordByFidCall(ticket, dbid, recordId, fields).execute(nil)
+}
+
+func editRecordByFidCall(ticket Ticket, dbid string, recordId int, fields map[int]string) apiCall {
+	params := ticket.params()
+	params["rid"] = strconv.Itoa(recordId)
+	for fid, value := range fields {
+		params["_fid_"+strconv.Itoa(fid)] = value
+	}
+	return apiCall{ticket, ticket.url + "db/" + dbid, "API_EditRecord", params}
 }
 
 // DoQueryCount returns the number of rows which would have been
 // returned by DoQuery for the same query, or an error.
 func DoQueryCount(ticket Ticket, dbid, query string) (count int64, err error) {
-	params := map[string]string{"ticket": ticket.ticket}
-	if ticket.Apptoken != "" {
-		params["apptoken"] = ticket.Apptoken
-	}
+	params := ticket.params()
 	if query != "" {
 		params["query"] = query
 	}
-	doc, err := executeApiCall(ticket.url+"db/"+dbid, "API_DoQueryCount", params)
-	if err != nil {
+	var result doQueryCountResponse
+	if err = executeApiCall(ticket, ticket.url+"db/"+dbid, "API_DoQueryCount", params, &result); err != nil {
 		return count, err
 	}
-	countNode := doc.SelectNode("", "numMatches")
-	if countNode == nil {
+	if result.NumMatches == nil {
 		return 0, fmt.Errorf("Invalid replay from QuickBase")
 	}
-	return strconv.ParseInt(countNode.GetValue(), 10, 64)
+	return strconv.ParseInt(string(*result.NumMatches), 10, 64)
 }
 
 // DoStructuredQuery queries QuickBase, returning a map from field IDs
@@ -311,13 +682,63 @@ func DoQueryCount(ticket Ticket, dbid, query string) (count int64, err error) {
 // not being prone to the field name/label confusion which hampers
 // DoQuery.  All arguments are as in DoQuery.
 func DoStructuredQuery(ticket Ticket, dbid, query, clist, slist, options string) (records []map[int]string, err error) {
-	params := map[string]string{"ticket": ticket.ticket, "fmt": "structured"}
-	if ticket.Apptoken != "" {
-		params["apptoken"] = ticket.Apptoken
-	}
+	params := ticket.params()
+	params["fmt"] = "structured"
 	if query != "" {
 		params["query"] = query
 	}
+	return doStructuredQuery(ticket, dbid, params, clist, slist, options)
+}
+
+// DoQueryByQid is like DoStructuredQuery, but runs the saved query
+// (report) with the given ID.  Its clist, slist and options are
+// overridden by any given here.
+func DoQueryByQid(ticket Ticket, dbid string, qid int, clist, slist, options string) (records []map[int]string, err error) {
+	params := ticket.params()
+	params["fmt"] = "structured"
+	params["qid"] = strconv.Itoa(qid)
+	return doStructuredQuery(ticket, dbid, params, clist, slist, options)
+}
+
+// DoQueryByQname is like DoQueryByQid, but names the saved query.
+func DoQueryByQname(ticket Ticket, dbid, qname, clist, slist, options string) (records []map[int]string, err error) {
+	params := ticket.params()
+	params["fmt"] = "structured"
+	params["qname"] = qname
+	return doStructuredQuery(ticket, dbid, params, clist, slist, options)
+}
+
+// DoReport runs the saved query (report) qid with its own columns and
+// sort order.  To override them, use DoQueryByQid.
+func DoReport(ticket Ticket, dbid string, qid int) (records []map[int]string, err error) {
+	return DoQueryByQid(ticket, dbid, qid, "", "", "")
+}
+
+// DoReportByName is like DoReport, but names the saved query.
+func DoReportByName(ticket Ticket, dbid, qname string) (records []map[int]string, err error) {
+	return DoQueryByQname(ticket, dbid, qname, "", "", "")
+}
+
+func doStructuredQuery(ticket Ticket, dbid string, params map[string]string, clist, slist, options string) (records []map[int]string, err error) {
+	result, err := structuredQuery(ticket, dbid, params, clist, slist, options)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range result.StructuredRecords {
+		record_map := make(map[int]string)
+		for _, field := range record.Fields {
+			if field.Name == "f" {
+				record_map[field.Id] = field.Value
+			}
+		}
+		records = append(records, record_map)
+	}
+	return
+}
+
+// structuredQuery makes a structured API_DoQuery call, returning its
+// response.
+func structuredQuery(ticket Ticket, dbid string, params map[string]string, clist, slist, options string) (result queryResponse, err error) {
 	if clist != "" {
 		params["clist"] = clist
 	}
@@ -327,19 +748,8 @@ func DoStructuredQuery(ticket Ticket, dbid, query, clist, slist, options string)
 	if options != "" {
 		params["options"] = options
 	}
-	doc, err := executeApiCall(ticket.url+"db/"+dbid, "API_DoQuery", params)
-	if err != nil {
-		return nil, err
-	}
-	for _, record := range doc.SelectNodes("", "record") {
-		record_map := make(map[int]string)
-		for _, child := range record.Children {
-
-			record_map[child.Ai("", "id")] = child.GetValue()
-		}
-		records = append(records, record_map)
-	}
-	return
+	err = executeApiCall(ticket, ticket.url+"db/"+dbid, "API_DoQuery", params, &result)
+	return result, err
 }
 
 // DoQuery queries QuickBase, returning a map from field labels to
@@ -349,12 +759,10 @@ func DoStructuredQuery(ticket Ticket, dbid, query, clist, slist, options string)
 //
 // Warning: DoQuery can 'lose' fields if two fields have different
 // names but the same label, e.g. 'foo ' and 'foo*' will have the same
-// label 'foo_'.
+// label 'foo_'.  FieldTag gives the label of a field's name, and
+// Schema.TagCollisions finds the fields of a table which are at risk.
 func DoQuery(ticket Ticket, dbid, query, clist, slist, options string) (records []map[string]string, err error) {
-	params := map[string]string{"ticket": ticket.ticket}
-	if ticket.Apptoken != "" {
-		params["apptoken"] = ticket.Apptoken
-	}
+	params := ticket.params()
 	if query != "" {
 		params["query"] = query
 	}
@@ -367,52 +775,30 @@ func DoQuery(ticket Ticket, dbid, query, clist, slist, options string) (records
 	if options != "" {
 		params["options"] = options
 	}
-	doc, err := executeApiCall(ticket.url+"db/"+dbid, "API_DoQuery", params)
-	if err != nil {
+	var result queryResponse
+	if err = executeApiCall(ticket, ticket.url+"db/"+dbid, "API_DoQuery", params, &result); err != nil {
 		return nil, err
 	}
-	for _, record := range doc.SelectNodes("", "record") {
+	for _, record := range result.Records {
 		record_map := make(map[string]string)
-		for _, child := range record.Children {
-			// Each child is a particular field.  A
-			// multi-line field may have multiple text
-			// nodes, separated by "<br/>" nodes.  This
-			// means that we need to collect up the values
-			// of all text children, and interpolate
-			// newlines where necessary.
-			//record_map[child.Name.Local] = child.GetValue()
-			for _, grandchild := range child.Children {
-				switch grandchild.Type {
-				case xmlx.NT_TEXT:
-					record_map[child.Name.Local] += grandchild.Value
-				case xmlx.NT_ELEMENT:
-					if grandchild.Name.Local == "BR" {
-						// apparently, QuickBase internally uses carriage returns to separate lines
-						record_map[child.Name.Local] += "\r"
-					} else {
-						return nil, fmt.Errorf("Cannot handle tag %s within value for field %s", grandchild.Name.Local, child.Name.Local)
-					}
-				default:
-					return nil, fmt.Errorf("Cannot handle non-text, non-element within value for field %s", child.Name.Local)
-				}
-			}
+		for _, field := range record.Fields {
+			record_map[field.Name] = field.Value
 		}
 		records = append(records, record_map)
 	}
 	return
 }
 
-// Warning: experimental
+// DoQueryChan returns a channel yielding the records of a query one
+// at a time, as DoQuery would return them.  The channel is closed at
+// the end of the records, or on an error, which is lost; and the
+// caller must read the channel to its end, lest the goroutine filling
+// it wait forever.
 //
-// DoQueryChan is intended to return a channel which will yield one
-// result at a time, enabling streamed handling of large result sets.
-// It has not been heavily tested, and may or may not currently work.
+// Deprecated: use StreamQuery, which reports errors, may be abandoned
+// or cancelled, and reads the structured format.
 func DoQueryChan(ticket Ticket, dbid, query, clist, slist string) (records chan map[string]string, err error) {
-	records = make(chan map[string]string)
-	params := map[string]string{"ticket": ticket.ticket}
-	if ticket.Apptoken != "" {
-		params["apptoken"] = ticket.Apptoken
-	}
+	params := ticket.params()
 	if query != "" {
 		params["query"] = query
 	}
@@ -422,117 +808,155 @@ func DoQueryChan(ticket Ticket, dbid, query, clist, slist string) (records chan
 	if slist != "" {
 		params["slist"] = slist
 	}
-	api_params := make([]apiParam, len(params))
-	i := 0
-	for key, val := range params {
-		api_params[i] = apiParam{xml.Name{"", key}, val}
-		i++
+	http_req, release, err := newApiRequest(ticket.url+"db/"+dbid, "API_DoQuery", params)
+	if err != nil {
+		return nil, err
 	}
-	req := quickBaseRequest{Params: api_params}
-	pipe_reader, pipe_writer := io.Pipe()
-	http_req, err := http.NewRequest("POST", ticket.url+"db/"+dbid, pipe_reader)
+	defer release()
+	resp, err := ticket.do(http_req)
 	if err != nil {
 		return nil, err
 	}
-	client := &http.Client{}
-	http_req.Header.Add("QUICKBASE-ACTION", "API_DoQuery")
-	http_req.Header.Add("Content-Type", "application/xml")
+	records = make(chan map[string]string)
+	decoder := xml.NewDecoder(resp.Body)
+	if _, err = readQueryHeader(decoder, resp.StatusCode); err != nil {
+		closeBody(resp.Body)
+		return nil, err
+	}
 	go func() {
-		encoder := xml.NewEncoder(pipe_writer)
-		encoder.Encode(req)
-		pipe_writer.Close()
+		defer closeBody(resp.Body)
+		defer close(records)
+		streamRecords(decoder, records)
 	}()
-	resp, err := client.Do(http_req)
+	return records, nil
+}
+
+// readQueryHeader reads a DoQuery response up to the start of its
+// first record, or its end if it has none, returning the error it
+// reports, if any.
+func readQueryHeader(decoder *xml.Decoder, status int) (atRecord bool, err error) {
+	errcode, errtext, errdetail := "", "", ""
+	inQdbapi := false
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return false, io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return false, err
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			if !inQdbapi {
+				if token.Name.Local != "qdbapi" {
+					return false, fmt.Errorf("qdbapi expected; %s found", token.Name.Local)
+				}
+				inQdbapi = true
+				continue
+			}
+			switch token.Name.Local {
+			case "errcode":
+				errcode, err = elementText(decoder)
+			case "errtext":
+				errtext, err = elementText(decoder)
+			case "errdetail":
+				errdetail, err = elementText(decoder)
+			case "record":
+				return true, responseError(errcode, errtext, errdetail, status)
+			}
+			if err != nil {
+				return false, err
+			}
+		case xml.EndElement:
+			if token.Name.Local == "qdbapi" {
+				return false, responseError(errcode, errtext, errdetail, status)
+			}
+		}
+	}
+}
+
+// responseError returns the error reported by a response's errcode,
+// errtext and errdetail, if any.
+func responseError(errcode, errtext, errdetail string, status int) error {
+	if errcode == "" {
+		return fmt.Errorf("Malformed response from QuickBase (HTTP status %d): no errcode", status)
+	}
+	if errcode == "0" {
+		return nil
+	}
+	code, err := strconv.Atoi(errcode)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("Malformed errcode %q from QuickBase", errcode)
 	}
+	return QuickBaseError{Message: errtext, Code: code, Detail: errdetail}
+}
 
-	decoder := xml.NewDecoder(resp.Body)
-	for token, err := decoder.Token(); err != io.EOF; token, err = decoder.Token() {
+// elementText returns the text content of the element whose start
+// the decoder has just read, consuming its end.
+func elementText(decoder *xml.Decoder) (text string, err error) {
+	depth := 1
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return "", io.ErrUnexpectedEOF
+		}
 		if err != nil {
-			return nil, err
+			return "", err
 		}
 		switch token := token.(type) {
-		case xml.ProcInst:
-			// skip
+		case xml.CharData:
+			text += string(token)
 		case xml.StartElement:
-			if token.Name.Local != "qdbapi" {
-				return nil, fmt.Errorf("qdbapi expected; %s found", token.Name.Local)
+			depth++
+		case xml.EndElement:
+			if depth--; depth == 0 {
+				return strings.TrimSpace(text), nil
 			}
-			qb_errcode := false
-			qb_errtext := ""
-			last_record_len := 1
-			for token, err := decoder.Token(); err != io.EOF; token, err = decoder.Token() {
-				switch token := token.(type) {
-				case xml.StartElement:
-					switch token.Name.Local {
-					case "errcode":
-						token, err := decoder.Token()
-						if err != nil {
-							return nil, err
-						}
-						if string(token.(xml.CharData)) != "0" {
-							qb_errcode = true
-							if qb_errtext != "" {
-								return nil, fmt.Errorf(qb_errtext)
-							}
-						}
-					case "errtext":
-						token, err := decoder.Token()
-						if err != nil {
-							return nil, err
-						}
-						qb_errtext = string(token.(xml.CharData))
-						if qb_errcode {
-							return nil, fmt.Errorf(qb_errtext)
-						}
-					case "record":
-						go func() {
-							defer resp.Body.Close()
-
-							record := make(map[string]string, last_record_len)
-							last_field := ""
-							last_data := ""
-							in_record := true
-						record:
-							for token, err := decoder.Token(); err != io.EOF; token, err = decoder.Token() {
-								switch token := token.(type) {
-								case xml.StartElement:
-									switch {
-									case in_record == true:
-										last_data = ""
-										last_field = token.Name.Local
-									case token.Name.Local != "record":
-										close(records)
-										break record
-									default:
-										in_record = true
-									}
-								case xml.EndElement:
-									switch {
-									case !in_record && token.Name.Local == "qdbapi":
-										close(records)
-										break record
-									case in_record && token.Name.Local == "record":
-										in_record = false
-										records <- record
-									default:
-										record[last_field] = last_data
-										last_field = ""
-									}
-								case xml.CharData:
-									last_data += string(token)
-								}
-							}
-
-						}()
-						return records, nil
-					}
-				}
+		}
+	}
+}
+
+// streamRecords sends each record of a DoQuery response to records,
+// the decoder having just read the start of the first.  A malformed
+// or truncated response ends the stream early.
+func streamRecords(decoder *xml.Decoder, records chan<- map[string]string) {
+	record := make(map[string]string)
+	field := ""
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			switch {
+			case record == nil && token.Name.Local == "record":
+				record = make(map[string]string)
+			case record == nil:
+				return
+			case field == "":
+				field = token.Name.Local
+				record[field] = ""
+			case token.Name.Local == "BR":
+				// apparently, QuickBase internally uses carriage returns to separate lines
+				record[field] += "\r"
+			}
+		case xml.EndElement:
+			switch {
+			case record == nil:
+				return
+			case field == "":
+				records <- record
+				record = nil
+			case token.Name.Local == field:
+				field = ""
+			}
+		case xml.CharData:
+			if record != nil && field != "" {
+				record[field] += string(token)
 			}
 		}
 	}
-	panic("should never have gotten here")
 }
 
 // GenResultTable queries QuickBase, returning the results an
@@ -545,99 +969,95 @@ func GenResultsTable(ticket Ticket, dbid, query string, columns []int) (resp *ht
 		strCols[i] = strconv.Itoa(col)
 	}
 	clist := strings.Join(strCols, ".")
-	params := map[string]string{
-		"clist":    clist,
-		"options":  "csv",
-		"slist":    "3",
-		"ticket":   ticket.ticket,
-		"apptoken": ticket.Apptoken,
-	}
+	params := ticket.params()
+	params["clist"] = clist
+	params["options"] = "csv"
+	params["slist"] = "3"
 	if query != "" {
 		params["query"] = query
 	}
-	return executeRawApiCall(ticket.url+"/db/"+dbid, "API_GenResultsTable", params)
+	return executeRawApiCall(ticket, ticket.url+"db/"+dbid, "API_GenResultsTable", params)
 }
 
 // AddRecord adds a record; it uses the same conventions as
 // EditRecord.  It returns the record ID of the newly-created record.
 func AddRecord(ticket Ticket, dbid string, fields map[string]string) (rid int, err error) {
-	params := map[string]string{"ticket": ticket.ticket}
-	if ticket.Apptoken != "" {
-		params["apptoken"] = ticket.Apptoken
+	var result addRecordResponse
+	if err = addRecordCall(ticket, dbid, fields).execute(&result); err != nil {
+		return 0, err
 	}
+	return result.recordId()
+}
+
+// AddRecordByFid is like AddRecord, but the fields argument is a map
+// from field IDs rather than labels.
+func AddRecordByFid(ticket Ticket, dbid string, fields map[int]string) (rid int, err error) {
+	var result addRecordResponse
+	if err = addRecordByFidCall(ticket, dbid, fields).execute(&result); err != nil {
+		return 0, err
+	}
+	return result.recordId()
+}
+
+func addRecordCall(ticket Ticket, dbid string, fields map[string]string) apiCall {
+	params := ticket.params()
 	for field, value := range fields {
 		params["_fnm_"+field] = value
 	}
-	doc, err := executeApiCall(ticket.url+"db/"+dbid, "API_AddRecord", params)
-	if err != nil {
-		return 0, err
-	}
-	ridNode := doc.SelectNode("", "rid")
-	if ridNode == nil {
-		return 0, fmt.Errorf("No rid returned from API_AddRecord")
+	return apiCall{ticket, ticket.url + "db/" + dbid, "API_AddRecord", params}
+}
+
+func addRecordByFidCall(ticket Ticket, dbid string, fields map[int]string) apiCall {
+	params := ticket.params()
+	for fid, value := range fields {
+		params["_fid_"+strconv.Itoa(fid)] = value
 	}
-	return strconv.Atoi(ridNode.GetValue())
+	return apiCall{ticket, ticket.url + "db/" + dbid, "API_AddRecord", params}
 }
 
 // DeleteRecord does what it says on the tin: deletes a particular
 // record from a QuickBase table.
 func DeleteRecord(ticket Ticket, dbid string, rid int) (err error) {
-	params := map[string]string{"ticket": ticket.ticket}
-	if ticket.Apptoken != "" {
-		params["apptoken"] = ticket.Apptoken
-	}
+	return deleteRecordCall(ticket, dbid, rid).execute(nil)
+}
+
+func deleteRecordCall(ticket Ticket, dbid string, rid int) apiCall {
+	params := ticket.params()
 	params["rid"] = strconv.Itoa(rid)
-	_, err = executeApiCall(ticket.url+"db/"+dbid, "API_DeleteRecord", params)
-	return err
+	return apiCall{ticket, ticket.url + "db/" + dbid, "API_DeleteRecord", params}
 }
 
 // ChangeRecordOwner changes a record's owner, with arguments as
 // documented at
 // <http://www.quickbase.com/api-guide/index.html#change_record_owner.html>.
 func ChangeRecordOwner(ticket Ticket, dbid string, rid int, owner string) (err error) {
-	params := map[string]string{"ticket": ticket.ticket}
-	if ticket.Apptoken != "" {
-		params["apptoken"] = ticket.Apptoken
-	}
+	return changeRecordOwnerCall(ticket, dbid, rid, owner).execute(nil)
+}
+
+func changeRecordOwnerCall(ticket Ticket, dbid string, rid int, owner string) apiCall {
+	params := ticket.params()
 	params["rid"] = strconv.Itoa(rid)
 	params["newowner"] = owner
-	_, err = executeApiCall(ticket.url+"db/"+dbid, "API_ChangeRecordOwner", params)
-	return err
+	return apiCall{ticket, ticket.url + "db/" + dbid, "API_ChangeRecordOwner", params}
 }
 
+// A User is a user of an application, with the roles they have in it.
 type User struct {
-	Id   string
-	Name string
-	//Roles []Role
+	Id    string
+	Name  string
+	Roles []Role
 }
 
-/*
-not needed yet
-type Role struct {
-	Id       int
-	Name     string
-	Accesses []Access
-}
-
-type Access struct {
-	Id   int
-	Name string
-}*/
-
-// UserRoles will eventually return users with their roles; right now
-// it just returns the user's IDs and name.
+// UserRoles returns the users of the application dbid, each with their
+// roles.
 func UserRoles(ticket Ticket, dbid string) (users []User, err error) {
-	params := map[string]string{"ticket": ticket.ticket}
-	if ticket.Apptoken != "" {
-		params["apptoken"] = ticket.Apptoken
-	}
-	doc, err := executeApiCall(ticket.url+"db/"+dbid, "API_UserRoles", params)
-	if err != nil {
+	params := ticket.params()
+	var result userRolesResponse
+	if err = executeApiCall(ticket, ticket.url+"db/"+dbid, "API_UserRoles", params, &result); err != nil {
 		return nil, err
 	}
-	for _, userNode := range doc.SelectNodes("", "user") {
-		user := User{Id: userNode.As("", "id"), Name: userNode.S("", "name")}
-		users = append(users, user)
+	for _, user := range result.Users {
+		users = append(users, User{Id: user.Id, Name: string(user.Name), Roles: user.Roles.roles()})
 	}
 	return users, nil
 }
@@ -645,12 +1065,24 @@ func UserRoles(ticket Ticket, dbid string) (users []User, err error) {
 // Download retrieves a file from QuickBase, per
 // <http://www.quickbase.com/api-guide/index.html>.
 func Download(ticket Ticket, dbid string, rid, fid, vid int) (file io.ReadCloser, err error) {
-	url := fmt.Sprintf("%sup/%s/a/r%d/e%d/v%d?ticket=%s&apptoken=%s", ticket.url, dbid, rid, fid, vid, ticket.ticket, ticket.Apptoken)
-	if response, err := http.Get(url); err != nil {
+	query := make(url.Values)
+	for name, value := range ticket.params() {
+		query.Set(name, value)
+	}
+	fileUrl := fmt.Sprintf("%sup/%s/a/r%d/e%d/v%d?%s", ticket.url, dbid, rid, fid, vid, query.Encode())
+	req, err := http.NewRequest("GET", fileUrl, nil)
+	if err != nil {
 		return nil, err
-	} else {
-		return response.Body, nil
 	}
+	response, err := ticket.do(req)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		closeBody(response.Body)
+		return nil, fmt.Errorf("Download failed: %s", response.Status)
+	}
+	return response.Body, nil
 }
 
 // Upload uploads a single file to a field in a QuickBase record.
@@ -659,7 +1091,6 @@ func Upload(ticket Ticket, dbid string, rid, fid int, filename string, r io.Read
 	// interface for field values yet, files must be individually uploaded
 	// to records.  This is a prime opportunity for refactoring.
 	reqReader, reqWriter := io.Pipe()
-	client := &http.Client{}
 	http_req, err := http.NewRequest("POST", ticket.url+"db/"+dbid, reqReader)
 	if err != nil {
 		return err
@@ -667,56 +1098,250 @@ func Upload(ticket Ticket, dbid string, rid, fid int, filename string, r io.Read
 	http_req.Header.Add("QUICKBASE-ACTION", "API_EditRecord")
 	http_req.Header.Add("Content-Type", "application/xml")
 	go func() {
-		fmt.Fprintf(reqWriter, "<qdbapi><ticket>%s</ticket><apptoken>%s</apptoken><rid>%d</rid><field fid='%d' filename='%s'>",
-			ticket.ticket, ticket.Apptoken, rid, fid, filename)
-		encoder := base64.NewEncoder(base64.StdEncoding, reqWriter)
-		io.Copy(encoder, r)
-		encoder.Close() // flush & close the encoder, so that all data are sent
-		fmt.Fprintf(reqWriter, "</field></qdbapi>")
-		reqWriter.Close()
+		reqWriter.CloseWithError(writeUploadBody(reqWriter, ticket, rid, fid, filename, r))
 	}()
-	resp, err := client.Do(http_req)
-	if err != nil {
+	err = executeRequest(ticket, http_req, nil)
+	reqReader.Close()
+	return err
+}
+
+// writeUploadBody writes the API_EditRecord request Upload sends,
+// escaping the parameters and filename as writeImportBody does.
+func writeUploadBody(w io.Writer, ticket Ticket, rid, fid int, filename string, r io.Reader) (err error) {
+	buffered := bufio.NewWriter(w)
+	buffered.WriteString("<qdbapi>")
+	for _, param := range apiParams(ticket.params()) {
+		buffered.WriteString("<" + param.XMLName.Local + ">")
+		xml.EscapeText(buffered, []byte(param.Value))
+		buffered.WriteString("</" + param.XMLName.Local + ">")
+	}
+	fmt.Fprintf(buffered, "<rid>%d</rid><field fid='%d' filename='", rid, fid)
+	xml.EscapeText(buffered, []byte(filename))
+	buffered.WriteString("'>")
+	encoder := base64.NewEncoder(base64.StdEncoding, buffered)
+	if _, err = io.Copy(encoder, r); err != nil {
+		return err
+	}
+	encoder.Close() // flush & close the encoder, so that all data are sent
+	buffered.WriteString("</field></qdbapi>")
+	return buffered.Flush()
+}
+
+// ImportFromCSV imports a CSV into QuickBase.  It expects the CSV to
+// have a header line, which is skipped.  The columns argument becomes
+// the clist documented in
+// <http://www.quickbase.com/api-guide/index.html#importfromcsv.html>
+//
+// The CSV is streamed to QuickBase as it is read, so that imports of
+// any size need little memory.
+func ImportFromCSV(ticket Ticket, dbid string, columns []int, r io.Reader) (err error) {
+	return ImportFromCSVWithOptions(ticket, dbid, columns, r, ImportOptions{})
+}
+
+// DefaultCompressMin is the size of the smallest CSV compressed by
+// ImportFromCSVWithOptions when ImportOptions.CompressMin is unset.
+const DefaultCompressMin = 64 << 10
+
+// ImportOptions modify how ImportFromCSVWithOptions sends its CSV.
+type ImportOptions struct {
+	// Compress gzips the requests of imports of at least CompressMin
+	// bytes, which saves upload time from slow sites.  If QuickBase
+	// refuses a compressed request as such, with HTTP status 415 or
+	// as XML it cannot parse, later imports to it are sent
+	// uncompressed, and the refused import is retried uncompressed
+	// if its reader is an io.Seeker; otherwise it fails.
+	Compress    bool
+	CompressMin int
+	// MergeFid, if set, makes rows whose value of this field, which
+	// must be unique, matches an existing record's update it rather
+	// than add a record.  See UpsertRecords.
+	MergeFid int
+}
+
+// refusedCompression holds the URLs of the QuickBase instances which
+// have refused compressed requests.
+var refusedCompression sync.Map
+
+// errCompressionRefused is returned by importFromCSV when QuickBase
+// refuses a compressed request: with HTTP status 415, or with 400 and
+// errcodeUnparsableXML, having read the gzipped body as XML.  Other
+// refusals are the import's own errors, and leave compression on.
+var errCompressionRefused = fmt.Errorf("QuickBase refused a compressed request")
+
+// errcodeUnparsableXML is the error code with which QuickBase refuses
+// a request whose body it cannot parse as XML.
+const errcodeUnparsableXML = 11
+
+// ImportFromCSVWithOptions is as ImportFromCSV, sending the CSV as
+// directed by options.
+func ImportFromCSVWithOptions(ticket Ticket, dbid string, columns []int, r io.Reader, options ImportOptions) (err error) {
+	params := importFromCSVParams(ticket, columns, options.MergeFid)
+	if _, refused := refusedCompression.Load(ticket.url); !options.Compress || refused {
+		return importFromCSV(ticket, dbid, params, r, false)
+	}
+	start := int64(-1)
+	seeker, seekable := r.(io.Seeker)
+	if seekable {
+		if start, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			start = -1
+		}
+	}
+	min := options.CompressMin
+	if min <= 0 {
+		min = DefaultCompressMin
+	}
+	head := make([]byte, min)
+	n, err := io.ReadFull(r, head)
+	switch err {
+	case io.EOF, io.ErrUnexpectedEOF:
+		return importFromCSV(ticket, dbid, params, bytes.NewReader(head[:n]), false)
+	case nil:
+	default:
+		return err
+	}
+	err = importFromCSV(ticket, dbid, params, io.MultiReader(bytes.NewReader(head), r), true)
+	if err != errCompressionRefused {
+		return err
+	}
+	ticket.hooks.retry("API_ImportFromCSV", 2, err)
+	refusedCompression.Store(ticket.url, true)
+	if start < 0 {
+		return fmt.Errorf("%s, and the CSV cannot be reread to retry uncompressed", err)
+	}
+	if _, err = seeker.Seek(start, io.SeekStart); err != nil {
 		return err
 	}
-	defer resp.Body.Close()
+	return importFromCSV(ticket, dbid, params, r, false)
+}
 
-	// FIXME: do we need to go through this rigamarole, or can we just return above?
-	//tee := io.TeeReader(resp.Body, os.Stderr)
-	doc := xmlx.New()
-	err = doc.LoadStream(resp.Body, nil)
-	//err = doc.LoadStream(tee, nil)
+// importFromCSV streams an API_ImportFromCSV request, gzipped if
+// compress is set.
+func importFromCSV(ticket Ticket, dbid string, params map[string]string, r io.Reader, compress bool) (err error) {
+	reqReader, reqWriter := io.Pipe()
+	defer reqReader.Close()
+	http_req, err := http.NewRequest("POST", ticket.url+"db/"+dbid, reqReader)
 	if err != nil {
 		return err
 	}
-	if errcode := doc.SelectNode("", "errcode").GetValue(); errcode != "0" {
-		err = fmt.Errorf(doc.SelectNode("", "errtext").GetValue())
-		return
+	http_req.Header.Add("QUICKBASE-ACTION", "API_ImportFromCSV")
+	http_req.Header.Add("Content-Type", "application/xml")
+	if compress {
+		http_req.Header.Add("Content-Encoding", "gzip")
 	}
-	return nil
+	go func() {
+		if !compress {
+			reqWriter.CloseWithError(writeImportBody(reqWriter, params, r))
+			return
+		}
+		gz := gzip.NewWriter(reqWriter)
+		err := writeImportBody(gz, params, r)
+		if err == nil {
+			err = gz.Close()
+		}
+		reqWriter.CloseWithError(err)
+	}()
+	resp, err := ticket.do(http_req)
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp.Body)
+	if compress && resp.StatusCode == http.StatusUnsupportedMediaType {
+		return errCompressionRefused
+	}
+	err = decodeResponse(resp.Body, resp.StatusCode, &qdbapiResponse{})
+	if qbErr, ok := err.(QuickBaseError); ok && compress && resp.StatusCode == http.StatusBadRequest && qbErr.Code == errcodeUnparsableXML {
+		return errCompressionRefused
+	}
+	return err
 }
 
-// ImportFromCSV imports a CSV into QuickBase.  It expects the CSV not
-// to have a header line.  The columns argument becomes the clist
-// documented in
-// <http://www.quickbase.com/api-guide/index.html#importfromcsv.html>
-func ImportFromCSV(ticket Ticket, dbid string, columns []int, r io.Reader) (err error) {
-	params := map[string]string{"ticket": ticket.ticket}
-	if ticket.Apptoken != "" {
-		params["apptoken"] = ticket.Apptoken
+// importFromCSVCall returns an API_ImportFromCSV call holding all of
+// the CSV, for a Client to journal or log.
+func importFromCSVCall(ticket Ticket, dbid string, columns []int, r io.Reader, mergeFid int) (call apiCall, err error) {
+	params := importFromCSVParams(ticket, columns, mergeFid)
+	var csv strings.Builder
+	if _, err = io.Copy(&csv, r); err != nil {
+		return
 	}
+	params["records_csv"] = csv.String()
+	return apiCall{ticket, ticket.url + "db/" + dbid, "API_ImportFromCSV", params}, nil
+}
+
+func importFromCSVParams(ticket Ticket, columns []int, mergeFid int) map[string]string {
+	params := ticket.params()
 	strCols := make([]string, len(columns))
 	for i, col := range columns {
 		strCols[i] = strconv.Itoa(col)
 	}
 	params["clist"] = strings.Join(strCols, ".")
 	params["skipfirst"] = "1"
-	// FIXME: it'd be nice to stream this, but how to properly escape CDATA in the CSV?
-	var csv []byte
-	if csv, err = ioutil.ReadAll(r); err != nil {
-		return
+	if mergeFid != 0 {
+		params["mergefieldid"] = strconv.Itoa(mergeFid)
 	}
-	params["records_csv"] = string(csv)
-	_, err = executeApiCall(ticket.url+"db/"+dbid, "API_ImportFromCSV", params)
+	return params
+}
+
+// writeImportBody writes the API_ImportFromCSV request for the given
+// parameters, escaping the CSV read from r into its records_csv as it
+// goes.  It writes just what requestBody would return were the CSV
+// among the parameters.
+func writeImportBody(w io.Writer, parameters map[string]string, r io.Reader) (err error) {
+	buffered := bufio.NewWriter(w)
+	parameters["records_csv"] = ""
+	buffered.WriteString("<qdbapi>")
+	for _, param := range apiParams(parameters) {
+		name := param.XMLName.Local
+		buffered.WriteString("<" + name + ">")
+		if name == "records_csv" {
+			escaper := &escapeWriter{w: buffered}
+			if _, err = io.Copy(escaper, r); err != nil {
+				return err
+			}
+			if err = escaper.Flush(); err != nil {
+				return err
+			}
+		} else if err = xml.EscapeText(buffered, []byte(param.Value)); err != nil {
+			return err
+		}
+		buffered.WriteString("</" + name + ">")
+	}
+	buffered.WriteString("</qdbapi>")
+	return buffered.Flush()
+}
+
+// An escapeWriter escapes the text written to it as XML character
+// data, holding back any incomplete UTF-8 sequence at the end of one
+// write until the next completes it.
+type escapeWriter struct {
+	w       io.Writer
+	partial []byte
+}
+
+func (e *escapeWriter) Write(p []byte) (n int, err error) {
+	data := p
+	if len(e.partial) > 0 {
+		data = append(e.partial, p...)
+	}
+	end := len(data)
+	for i := 1; i <= utf8.UTFMax && i <= len(data); i++ {
+		if utf8.RuneStart(data[len(data)-i]) {
+			if !utf8.FullRune(data[len(data)-i:]) {
+				end = len(data) - i
+			}
+			break
+		}
+	}
+	if err = xml.EscapeText(e.w, data[:end]); err != nil {
+		return 0, err
+	}
+	e.partial = append(e.partial[:0:0], data[end:]...)
+	return len(p), nil
+}
+
+// Flush escapes any incomplete UTF-8 sequence held back, which no
+// further write can complete.
+func (e *escapeWriter) Flush() error {
+	err := xml.EscapeText(e.w, e.partial)
+	e.partial = nil
 	return err
 }
@@ -21,61 +21,369 @@
 package quickbase_test
 
 import (
-	quickbase "."
+	"encoding/xml"
+	"errors"
 	"fmt"
+	"github.com/WesTower/quickbase"
+	"github.com/WesTower/quickbase/quickbasetest"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"testing/iotest"
 )
 
 var _ = fmt.Println
 
+const (
+	testAppDbid   = "bck7gp3q1"
+	testTableDbid = "bck7gp3q2"
+)
+
+// newServer starts a fake QuickBase holding a single app with one
+// table of sites; the caller must Close it.
+func newServer() *quickbasetest.Server {
+	server := quickbasetest.NewServer()
+	server.AddUser("jdoe", "secret")
+	server.AddTable(testTableDbid, map[int]string{6: "Site", 7: "Cost"})
+	server.AddApp(testAppDbid, testTableDbid)
+	server.Seed(testTableDbid, map[int]string{6: "Denver", 7: "12.50"})
+	server.Seed(testTableDbid, map[int]string{6: "Boise", 7: "3"})
+	return server
+}
+
+func authenticate(server *quickbasetest.Server) (ticket quickbase.Ticket, err error) {
+	return quickbase.Authenticate(server.BaseUrl(), "jdoe", "secret")
+}
+
 func TestAuthentication(t *testing.T) {
-	if _, err := authenticate(); err != nil {
+	server := newServer()
+	defer server.Close()
+	if _, err := authenticate(server); err != nil {
 		t.Error(err.Error())
 		return
 	}
+	_, err := quickbase.Authenticate(server.BaseUrl(), "jdoe", "wrong")
+	if qbErr, ok := err.(quickbase.QuickBaseError); !ok || qbErr.Code != 20 {
+		t.Errorf("bad password gave %v", err)
+	}
 }
 
-func authenticate() (ticket quickbase.Ticket, err error) {
-	return quickbase.Authenticate(os.Getenv("QUICKBASE_URL"),
-		os.Getenv("QUICKBASE_USERNAME"),
-		os.Getenv("QUICKBASE_PASSWORD"))
+func TestRestoreTicket(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	ticket, err := authenticate(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored := quickbase.RestoreTicket(ticket.Credentials())
+	if count, err := quickbase.DoQueryCount(restored, testTableDbid, ""); err != nil || count != 2 {
+		t.Errorf("DoQueryCount with a restored ticket gave %d, %v", count, err)
+	}
+}
+
+func TestUserTokenTicket(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	server.AddUserToken("jdoe", "b12345_token")
+	ticket := quickbase.UserTokenTicket(server.BaseUrl(), "b12345_token")
+	if count, err := quickbase.DoQueryCount(ticket, testTableDbid, ""); err != nil || count != 2 {
+		t.Errorf("DoQueryCount with a user token gave %d, %v", count, err)
+	}
+	ticket = quickbase.UserTokenTicket(server.BaseUrl(), "b12345_wrong")
+	if _, err := quickbase.DoQueryCount(ticket, testTableDbid, ""); err == nil {
+		t.Error("DoQueryCount with a bad user token succeeded")
+	}
 }
 
 func TestDoQueryCount(t *testing.T) {
+	server := newServer()
+	defer server.Close()
 	var ticket quickbase.Ticket
 	var err error
-	if ticket, err = authenticate(); err != nil {
-		t.Error(err)
+	if ticket, err = authenticate(server); err != nil {
+		t.Fatal(err)
 	}
-	if _, err := quickbase.DoQueryCount(ticket, os.Getenv("QUICKBASE_TABLE_DBID"), ""); err != nil {
-		t.Error(err)
+	if count, err := quickbase.DoQueryCount(ticket, testTableDbid, ""); err != nil || count != 2 {
+		t.Errorf("counted %d (%v)", count, err)
+	}
+	if count, err := quickbase.DoQueryCount(ticket, testTableDbid, "{'6'.EX.'boise'}"); err != nil || count != 1 {
+		t.Errorf("counted %d (%v)", count, err)
+	}
+}
+
+func TestDoQuery(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	ticket, err := authenticate(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	records, err := quickbase.DoQuery(ticket, testTableDbid, "{'7'.GT.'5'}", "3.6.7", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0]["site"] != "Denver" || records[0]["record_id_"] != "1" {
+		t.Errorf("records are %v", records)
+	}
+	structured, err := quickbase.DoStructuredQuery(ticket, testTableDbid, "", "6", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(structured) != 2 || structured[1][6] != "Boise" {
+		t.Errorf("structured records are %v", structured)
+	}
+}
+
+func TestAddEditDeleteRecord(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	ticket, err := authenticate(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rid, err := quickbase.AddRecord(ticket, testTableDbid, map[string]string{"site": "Reno"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = quickbase.EditRecord(ticket, testTableDbid, rid, map[string]string{"cost": "8"}); err != nil {
+		t.Fatal(err)
+	}
+	records := server.Records(testTableDbid)
+	if len(records) != 3 || records[2][6] != "Reno" || records[2][7] != "8" {
+		t.Errorf("records are %v", records)
+	}
+	if err = quickbase.DeleteRecord(ticket, testTableDbid, rid); err != nil {
+		t.Fatal(err)
+	}
+	if records := server.Records(testTableDbid); len(records) != 2 {
+		t.Errorf("records are %v", records)
+	}
+}
+
+func TestImportFromCSV(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	ticket, err := authenticate(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	csv := "Site,Cost\nTulsa,4\nMesa,5\n"
+	if err = quickbase.ImportFromCSV(ticket, testTableDbid, []int{6, 7}, strings.NewReader(csv)); err != nil {
+		t.Fatal(err)
+	}
+	records := server.Records(testTableDbid)
+	if len(records) != 4 || records[3][6] != "Mesa" {
+		t.Errorf("records are %v", records)
+	}
+}
+
+func TestImportFromCSVStreaming(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	ticket, err := authenticate(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	site := "Sites & <Stores> ]]> Zürich €😀"
+	csv := "Site,Cost\n\"" + site + "\",4\n"
+	// reading a byte at a time splits every multibyte character
+	r := iotest.OneByteReader(strings.NewReader(csv))
+	if err = quickbase.ImportFromCSV(ticket, testTableDbid, []int{6, 7}, r); err != nil {
+		t.Fatal(err)
+	}
+	records := server.Records(testTableDbid)
+	if len(records) != 3 || records[2][6] != site {
+		t.Errorf("records are %v", records)
+	}
+	r = io.MultiReader(strings.NewReader("Site,Cost\nTulsa,4\n"), iotest.ErrReader(errors.New("disk gone")))
+	if err = quickbase.ImportFromCSV(ticket, testTableDbid, []int{6, 7}, r); err == nil {
+		t.Error("import of an unreadable CSV succeeded")
+	}
+	if records := server.Records(testTableDbid); len(records) != 3 {
+		t.Errorf("unreadable CSV imported %v", records)
+	}
+}
+
+func TestImportFromCSVCompressed(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	// front serves through server, counting compressed requests and
+	// answering them with refuse if it is set
+	front := func(refuse http.HandlerFunc) (*httptest.Server, *int32) {
+		compressed := new(int32)
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Content-Encoding") == "gzip" {
+				atomic.AddInt32(compressed, 1)
+				if refuse != nil {
+					refuse(w, r)
+					return
+				}
+			}
+			server.ServeHTTP(w, r)
+		})), compressed
+	}
+	unsupported := func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Unsupported Media Type", http.StatusUnsupportedMediaType)
+	}
+	// badRequest answers with HTTP status 400 and errcode
+	badRequest := func(errcode int) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, "<qdbapi><errcode>%d</errcode><errtext>Refused</errtext></qdbapi>", errcode)
+		}
+	}
+	options := quickbase.ImportOptions{Compress: true, CompressMin: 32}
+	large := "Site,Cost\nTulsa,1\nTopeka,2\nTacoma,3\n"
+
+	accepting, compressed := front(nil)
+	defer accepting.Close()
+	ticket, err := quickbase.Authenticate(accepting.URL+"/", "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = quickbase.ImportFromCSVWithOptions(ticket, testTableDbid, []int{6, 7}, strings.NewReader("Site,Cost\nTaos,4\n"), options); err != nil {
+		t.Fatal(err)
+	}
+	if err = quickbase.ImportFromCSVWithOptions(ticket, testTableDbid, []int{6, 7}, iotest.OneByteReader(strings.NewReader(large)), options); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(compressed); n != 1 {
+		t.Errorf("%d of 1 large imports were compressed", n)
+	}
+	if records := server.Records(testTableDbid); len(records) != 6 || records[5][6] != "Tacoma" {
+		t.Errorf("records are %v", records)
+	}
+
+	refusing, compressed := front(unsupported)
+	defer refusing.Close()
+	ticket, err = quickbase.Authenticate(refusing.URL+"/", "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = quickbase.ImportFromCSVWithOptions(ticket, testTableDbid, []int{6, 7}, iotest.OneByteReader(strings.NewReader(large)), options); err == nil {
+		t.Error("refused import of an unseekable CSV succeeded")
+	}
+	if err = quickbase.ImportFromCSVWithOptions(ticket, testTableDbid, []int{6, 7}, iotest.OneByteReader(strings.NewReader(large)), options); err != nil {
+		t.Errorf("import after a refusal: %v", err)
+	}
+	if n := atomic.LoadInt32(compressed); n != 1 {
+		t.Errorf("%d imports were compressed after a refusal", n)
+	}
+	if records := server.Records(testTableDbid); len(records) != 9 {
+		t.Errorf("%d records after a refusal", len(records))
+	}
+
+	retrying, compressed := front(badRequest(11))
+	defer retrying.Close()
+	ticket, err = quickbase.Authenticate(retrying.URL+"/", "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := strings.NewReader(large)
+	r.Seek(10, io.SeekStart) // the retry must start where the first try did
+	if err = quickbase.ImportFromCSVWithOptions(ticket, testTableDbid, []int{6, 7}, r, quickbase.ImportOptions{Compress: true, CompressMin: 8}); err != nil {
+		t.Errorf("retry of a refused import: %v", err)
+	}
+	if n := atomic.LoadInt32(compressed); n != 1 {
+		t.Errorf("%d of 1 imports were compressed", n)
+	}
+	if records := server.Records(testTableDbid); len(records) != 11 || records[10][6] != "Tacoma" {
+		t.Errorf("records after a retry are %v", records)
+	}
+
+	// A 400 for any other reason is the import's own error, and
+	// leaves compression on.
+	invalid, compressed := front(badRequest(2))
+	defer invalid.Close()
+	ticket, err = quickbase.Authenticate(invalid.URL+"/", "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		err = quickbase.ImportFromCSVWithOptions(ticket, testTableDbid, []int{6, 7}, strings.NewReader(large), options)
+		if qbErr, ok := err.(quickbase.QuickBaseError); !ok || qbErr.Code != 2 {
+			t.Errorf("import answered with errcode 2 gave %v", err)
+		}
+	}
+	if n := atomic.LoadInt32(compressed); n != 2 {
+		t.Errorf("%d of 2 imports were compressed after a 400", n)
+	}
+	if records := server.Records(testTableDbid); len(records) != 11 {
+		t.Errorf("%d records after a 400", len(records))
+	}
+}
+
+func TestUploadEscaping(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client.Ticket.Apptoken = "<&'app\">"
+	filename := `a'b"<c>]]>&d.txt`
+	if err = client.Upload(testTableDbid, 1, 7, filename, strings.NewReader("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if records := server.Records(testTableDbid); records[0][7] != "aGVsbG8=" {
+		t.Errorf("uploaded %q", records[0][7])
+	}
+	if err = client.Upload(testTableDbid, 1, 7, filename, iotest.ErrReader(errors.New("disk gone"))); err == nil {
+		t.Error("upload of an unreadable file succeeded")
+	}
+
+	var body []byte
+	client.DryRun = true
+	client.DryRunLog = func(req quickbase.DryRunRequest) { body = req.Body }
+	if err = client.Upload(testTableDbid, 1, 7, filename, strings.NewReader("hello")); err != nil {
+		t.Fatal(err)
+	}
+	var request struct {
+		Apptoken string `xml:"apptoken"`
+		Field    struct {
+			Filename string `xml:"filename,attr"`
+			Value    string `xml:",chardata"`
+		} `xml:"field"`
+	}
+	if err = xml.Unmarshal(body, &request); err != nil {
+		t.Fatalf("%s: %v", body, err)
+	}
+	if request.Apptoken != client.Ticket.Apptoken || request.Field.Filename != filename || request.Field.Value != "aGVsbG8=" {
+		t.Errorf("Upload would have sent %s", body)
 	}
 }
 
 func TestGetAppDTMInfo(t *testing.T) {
-	received, nextAllowed, schemaModification, tableModifications, err := quickbase.GetAppDTMInfo(os.Getenv("QUICKBASE_URL"), os.Getenv("QUICKBASE_APP_DBID"))
+	server := newServer()
+	defer server.Close()
+	received, nextAllowed, schemaModification, tableModifications, err := quickbase.GetAppDTMInfo(server.BaseUrl(), testAppDbid)
 	if err != nil {
 		t.Error(err)
 	}
 	if received.After(nextAllowed) {
-		t.Error("received %s is after nextAllowed %s", received, nextAllowed)
+		t.Errorf("received %s is after nextAllowed %s", received, nextAllowed)
 	}
 	if schemaModification.SchemaModified.After(received) {
-		t.Error("schemaModification.SchemaModified %s is after received %s", schemaModification.SchemaModified, received)
+		t.Errorf("schemaModification.SchemaModified %s is after received %s", schemaModification.SchemaModified, received)
 	}
 	if schemaModification.RecordModified.After(received) {
-		t.Error("schemaModification.RecordModified %s is after received %s", schemaModification.RecordModified, received)
+		t.Errorf("schemaModification.RecordModified %s is after received %s", schemaModification.RecordModified, received)
 	}
 	for _, tableModification := range tableModifications {
 		if tableModification.SchemaModified.After(received) {
-			t.Error("tableModification.SchemaModified %s is after received %s", tableModification.SchemaModified, received)
+			t.Errorf("tableModification.SchemaModified %s is after received %s", tableModification.SchemaModified, received)
 		}
 		if tableModification.RecordModified.After(received) {
-			t.Error("tableModification.RecordModified %s is after received %s", tableModification.RecordModified, received)
+			t.Errorf("tableModification.RecordModified %s is after received %s", tableModification.RecordModified, received)
 		}
 	}
-	_, _, _, _, err = quickbase.GetAppDTMInfo(os.Getenv("QUICKBASE_URL"), "no-such-app-dbid")
+	_, _, _, _, err = quickbase.GetAppDTMInfo(server.BaseUrl(), "no-such-app-dbid")
 	switch err := err.(type) {
 	case nil:
 		t.Error(fmt.Errorf("'no-such-app-dbid' should error out"))
@@ -90,13 +398,67 @@ func TestGetAppDTMInfo(t *testing.T) {
 }
 
 func TestUserRoles(t *testing.T) {
+	server := newServer()
+	defer server.Close()
 	var ticket quickbase.Ticket
 	var err error
-	if ticket, err = authenticate(); err != nil {
-		t.Error(err)
+	if ticket, err = authenticate(server); err != nil {
+		t.Fatal(err)
 	}
-	_, err = quickbase.UserRoles(ticket, os.Getenv("QUICKBASE_APP_DBID"))
+	users, err := quickbase.UserRoles(ticket, testAppDbid)
 	if err != nil {
 		t.Error(err)
 	}
+	if len(users) != 1 || users[0].Name != "jdoe" {
+		t.Errorf("users are %v", users)
+	}
+}
+
+func TestRecordReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "quickbase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fixture := filepath.Join(dir, "fixture.json")
+	defer func() { quickbase.Transport = nil }()
+
+	server := newServer()
+	recorder := quickbasetest.NewRecorder(fixture, nil)
+	quickbase.Transport = recorder
+	ticket, err := authenticate(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	recorded, err := quickbase.DoQueryCount(ticket, testTableDbid, "{'6'.EX.'Boise'}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+	if err = recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+	contents, _ := ioutil.ReadFile(fixture)
+	if strings.Contains(string(contents), "secret") {
+		t.Error("fixture contains the password")
+	}
+
+	replayer, err := quickbasetest.LoadRecorder(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	quickbase.Transport = replayer
+	if ticket, err = authenticate(server); err != nil {
+		t.Fatal(err)
+	}
+	replayed, err := quickbase.DoQueryCount(ticket, testTableDbid, "{'6'.EX.'Boise'}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed != recorded {
+		t.Errorf("replayed count %d, recorded %d", replayed, recorded)
+	}
+	if _, err = quickbase.DoQueryCount(ticket, testTableDbid, ""); err == nil {
+		t.Error("unrecorded call succeeded")
+	}
 }
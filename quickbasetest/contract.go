@@ -0,0 +1,390 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbasetest
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"github.com/WesTower/quickbase/backend"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ContractConfig describes the sandbox table against which
+// RunContractTests runs.  Only Dbid and TextFid are required; the
+// checks needing the other fields are skipped if they are unset.
+type ContractConfig struct {
+	Dbid      string // a table to which records may be added, and from which they are deleted again
+	TextFid   int    // an ordinary text field of the table
+	NumberFid int    // a numeric field, to check sorting
+	FileFid   int    // a file attachment field, to check Upload and Download
+	AppDbid   string // the table's application, to check GetAppDTMInfo and UserRoles
+}
+
+// RunContractTests checks that qb behaves as QuickBase does, by
+// exercising each of its operations against a sandbox table.  It
+// passes against a Client connected to QuickBase itself, and against
+// a Server or Fake; a fork or another implementation of
+// quickbase.QuickBase may run it to check its compatibility.
+//
+// The records added are marked with a unique value of the text field,
+// and deleted again, so the table may be one in use.
+func RunContractTests(t *testing.T, qb quickbase.QuickBase, cfg ContractConfig) {
+	c := newContract(cfg)
+	defer c.cleanUp(qb)
+	text := strconv.Itoa(cfg.TextFid)
+	var label string
+	var rids []int
+
+	t.Run("GetSchema", func(t *testing.T) {
+		schema, err := qb.GetSchema(cfg.Dbid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, field := range schema.Fields {
+			if field.Id == cfg.TextFid {
+				label = field.Label
+			}
+		}
+		if label == "" {
+			t.Fatalf("schema of %s has no field %d: %+v", cfg.Dbid, cfg.TextFid, schema.Fields)
+		}
+	})
+	if label == "" {
+		t.FailNow()
+	}
+
+	t.Run("AddRecord", func(t *testing.T) {
+		fields := map[int]string{cfg.TextFid: c.value(1)}
+		if cfg.NumberFid != 0 {
+			fields[cfg.NumberFid] = "20"
+		}
+		rid, err := qb.AddRecordByFid(cfg.Dbid, fields)
+		if err != nil || rid <= 0 {
+			t.Fatalf("AddRecordByFid gave %d, %v", rid, err)
+		}
+		rids = append(rids, rid)
+		if rid, err = qb.AddRecord(cfg.Dbid, map[string]string{label: c.value(2)}); err != nil || rid <= 0 {
+			t.Fatalf("AddRecord gave %d, %v", rid, err)
+		}
+		rids = append(rids, rid)
+		if cfg.NumberFid != 0 {
+			if err = qb.EditRecordByFid(cfg.Dbid, rid, map[int]string{cfg.NumberFid: "10"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	})
+	if len(rids) != 2 {
+		t.FailNow()
+	}
+
+	t.Run("DoStructuredQuery", func(t *testing.T) {
+		records, err := qb.DoStructuredQuery(cfg.Dbid, c.query(), "3."+text, text, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.expect(t, records, rids, []string{c.value(1), c.value(2)})
+	})
+
+	t.Run("DoQuery", func(t *testing.T) {
+		records, err := qb.DoQuery(cfg.Dbid, c.query(), "3."+text, text, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(records) != 2 {
+			t.Fatalf("DoQuery gave %v", records)
+		}
+		for i, record := range records {
+			if !hasValue(record, c.value(i+1)) || !hasValue(record, strconv.Itoa(rids[i])) {
+				t.Errorf("DoQuery record %d is %v", i, record)
+			}
+		}
+	})
+
+	t.Run("DoQueryCount", func(t *testing.T) {
+		if count, err := qb.DoQueryCount(cfg.Dbid, c.query()); err != nil || count != 2 {
+			t.Errorf("DoQueryCount gave %d, %v", count, err)
+		}
+		if count, err := qb.DoQueryCount(cfg.Dbid, "{'"+text+"'.EX.'"+c.value(99)+"'}"); err != nil || count != 0 {
+			t.Errorf("DoQueryCount of nothing gave %d, %v", count, err)
+		}
+	})
+
+	if cfg.NumberFid != 0 {
+		t.Run("Sorting", func(t *testing.T) {
+			number := strconv.Itoa(cfg.NumberFid)
+			records, err := qb.DoStructuredQuery(cfg.Dbid, c.query(), "3."+text, number, "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.expect(t, records, []int{rids[1], rids[0]}, []string{c.value(2), c.value(1)})
+			records, err = qb.DoStructuredQuery(cfg.Dbid, c.query(), "3."+text, number, "sortorder-D.num-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.expect(t, records, rids[:1], []string{c.value(1)})
+			records, err = qb.DoStructuredQuery(cfg.Dbid, c.query(), "3."+text, number, "skp-1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			c.expect(t, records, rids[:1], []string{c.value(1)})
+		})
+	}
+
+	t.Run("EditRecord", func(t *testing.T) {
+		if err := qb.EditRecordByFid(cfg.Dbid, rids[0], map[int]string{cfg.TextFid: c.value(1) + " edited"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := qb.EditRecord(cfg.Dbid, rids[1], map[string]string{label: c.value(2) + " edited"}); err != nil {
+			t.Fatal(err)
+		}
+		records, err := qb.DoStructuredQuery(cfg.Dbid, c.query(), "3."+text, text, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.expect(t, records, rids, []string{c.value(1) + " edited", c.value(2) + " edited"})
+		if err = qb.EditRecordByFid(cfg.Dbid, 999999999, map[int]string{cfg.TextFid: c.value(0)}); err == nil {
+			t.Error("EditRecordByFid of a missing record succeeded")
+		}
+	})
+
+	t.Run("ImportFromCSV", func(t *testing.T) {
+		csv := fmt.Sprintf("%s\n%s\n\"%s, quoted\"\n", label, c.value(3), c.value(4))
+		if err := qb.ImportFromCSV(cfg.Dbid, []int{cfg.TextFid}, strings.NewReader(csv)); err != nil {
+			t.Fatal(err)
+		}
+		records, err := qb.DoStructuredQuery(cfg.Dbid, c.query(), text, text, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		var values []string
+		for _, record := range records {
+			values = append(values, record[cfg.TextFid])
+		}
+		sort.Strings(values)
+		want := []string{c.value(1) + " edited", c.value(2) + " edited", c.value(3), c.value(4) + ", quoted"}
+		if strings.Join(values, "|") != strings.Join(want, "|") {
+			t.Errorf("after ImportFromCSV the records hold %q, want %q", values, want)
+		}
+	})
+
+	if cfg.FileFid != 0 {
+		t.Run("UploadDownload", func(t *testing.T) {
+			contents := []byte("contract test file\n")
+			if err := qb.Upload(cfg.Dbid, rids[0], cfg.FileFid, "contract.txt", bytes.NewReader(contents)); err != nil {
+				t.Fatal(err)
+			}
+			file, err := qb.Download(cfg.Dbid, rids[0], cfg.FileFid, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+			got, err := ioutil.ReadAll(file)
+			if err != nil || !bytes.Equal(got, contents) {
+				t.Errorf("Download gave %q, %v", got, err)
+			}
+		})
+	}
+
+	if cfg.AppDbid != "" {
+		t.Run("GetAppDTMInfo", func(t *testing.T) {
+			received, _, _, tables, err := qb.GetAppDTMInfo(cfg.AppDbid)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if received.IsZero() {
+				t.Error("GetAppDTMInfo gave no request time")
+			}
+			found := false
+			for _, table := range tables {
+				found = found || table.Dbid == cfg.Dbid
+			}
+			if !found {
+				t.Errorf("GetAppDTMInfo did not list %s: %+v", cfg.Dbid, tables)
+			}
+		})
+		t.Run("UserRoles", func(t *testing.T) {
+			if users, err := qb.UserRoles(cfg.AppDbid); err != nil || len(users) == 0 {
+				t.Errorf("UserRoles gave %v, %v", users, err)
+			}
+		})
+	}
+
+	t.Run("DeleteRecord", func(t *testing.T) {
+		records, err := qb.DoStructuredQuery(cfg.Dbid, c.query(), "3", "", "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, record := range records {
+			rid, _ := strconv.Atoi(record[3])
+			if err = qb.DeleteRecord(cfg.Dbid, rid); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if count, err := qb.DoQueryCount(cfg.Dbid, c.query()); err != nil || count != 0 {
+			t.Errorf("after DeleteRecord DoQueryCount gave %d, %v", count, err)
+		}
+		if err = qb.DeleteRecord(cfg.Dbid, rids[0]); err == nil {
+			t.Error("DeleteRecord of a deleted record succeeded")
+		}
+	})
+}
+
+// RunBackendContractTests is like RunContractTests, but checks a
+// backend.Backend, such as the REST backend, against the sandbox
+// table.  NumberFid, FileFid and AppDbid are not used.
+func RunBackendContractTests(t *testing.T, b backend.Backend, cfg ContractConfig) {
+	c := newContract(cfg)
+	defer c.cleanUpBackend(b)
+	var rids []int
+
+	t.Run("Schema", func(t *testing.T) {
+		schema, err := b.Schema(cfg.Dbid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := schema.Field(cfg.TextFid); !ok {
+			t.Fatalf("schema of %s has no field %d: %+v", cfg.Dbid, cfg.TextFid, schema.Fields)
+		}
+	})
+
+	t.Run("Insert", func(t *testing.T) {
+		for i := 1; i <= 2; i++ {
+			rid, err := b.Insert(cfg.Dbid, backend.Record{cfg.TextFid: c.value(i)})
+			if err != nil || rid <= 0 {
+				t.Fatalf("Insert gave %d, %v", rid, err)
+			}
+			rids = append(rids, rid)
+		}
+	})
+	if len(rids) != 2 {
+		t.FailNow()
+	}
+
+	query := backend.Query{Where: c.query(), Select: []int{3, cfg.TextFid}, SortBy: []int{cfg.TextFid}}
+	t.Run("Query", func(t *testing.T) {
+		records, err := b.Query(cfg.Dbid, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.expectBackend(t, records, rids, []string{c.value(1), c.value(2)})
+		top := query
+		top.Top = 1
+		if records, err = b.Query(cfg.Dbid, top); err != nil || len(records) != 1 {
+			t.Errorf("Query with Top 1 gave %v, %v", records, err)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		if err := b.Update(cfg.Dbid, rids[1], backend.Record{cfg.TextFid: c.value(2) + " edited"}); err != nil {
+			t.Fatal(err)
+		}
+		records, err := b.Query(cfg.Dbid, query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.expectBackend(t, records, rids, []string{c.value(1), c.value(2) + " edited"})
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		for _, rid := range rids {
+			if err := b.Delete(cfg.Dbid, rid); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if records, err := b.Query(cfg.Dbid, query); err != nil || len(records) != 0 {
+			t.Errorf("after Delete Query gave %v, %v", records, err)
+		}
+	})
+}
+
+// A contract is a run of the contract tests, whose records' text
+// field values all begin with its marker.
+type contract struct {
+	cfg    ContractConfig
+	marker string
+}
+
+func newContract(cfg ContractConfig) *contract {
+	return &contract{cfg, fmt.Sprintf("quickbasetest contract %d", time.Now().UnixNano())}
+}
+
+// value returns the text field value of the contract's nth record.
+func (c *contract) value(n int) string {
+	return fmt.Sprintf("%s #%d", c.marker, n)
+}
+
+// query returns a query selecting the contract's records.
+func (c *contract) query() string {
+	return fmt.Sprintf("{'%d'.SW.'%s'}", c.cfg.TextFid, c.marker)
+}
+
+func (c *contract) expect(t *testing.T, records []map[int]string, rids []int, values []string) {
+	t.Helper()
+	if len(records) != len(rids) {
+		t.Fatalf("got %d records, want %d: %v", len(records), len(rids), records)
+	}
+	for i, record := range records {
+		if record[3] != strconv.Itoa(rids[i]) || record[c.cfg.TextFid] != values[i] {
+			t.Errorf("record %d is %v, want rid %d and %q", i, record, rids[i], values[i])
+		}
+	}
+}
+
+func (c *contract) expectBackend(t *testing.T, records []backend.Record, rids []int, values []string) {
+	t.Helper()
+	converted := make([]map[int]string, len(records))
+	for i, record := range records {
+		converted[i] = record
+	}
+	c.expect(t, converted, rids, values)
+}
+
+func hasValue(record map[string]string, value string) bool {
+	for _, v := range record {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// cleanUp deletes any of the contract's records left by a failure.
+func (c *contract) cleanUp(qb quickbase.QuickBase) {
+	records, _ := qb.DoStructuredQuery(c.cfg.Dbid, c.query(), "3", "", "")
+	for _, record := range records {
+		rid, _ := strconv.Atoi(record[3])
+		qb.DeleteRecord(c.cfg.Dbid, rid)
+	}
+}
+
+func (c *contract) cleanUpBackend(b backend.Backend) {
+	records, _ := b.Query(c.cfg.Dbid, backend.Query{Where: c.query(), Select: []int{3}})
+	for _, record := range records {
+		rid, _ := strconv.Atoi(record[3])
+		b.Delete(c.cfg.Dbid, rid)
+	}
+}
@@ -0,0 +1,65 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbasetest_test
+
+import (
+	"github.com/WesTower/quickbase"
+	"github.com/WesTower/quickbase/backend"
+	"github.com/WesTower/quickbase/quickbasetest"
+	"testing"
+)
+
+const contractApp = "bck7gp3q1"
+
+var contractConfig = quickbasetest.ContractConfig{
+	Dbid:      sites,
+	TextFid:   6,
+	NumberFid: 7,
+	AppDbid:   contractApp,
+}
+
+func TestServerContract(t *testing.T) {
+	server := quickbasetest.NewServer()
+	defer server.Close()
+	server.AddUser("jdoe", "secret")
+	server.AddTable(sites, map[int]string{6: "Site", 7: "Cost"})
+	server.AddApp(contractApp, sites)
+	server.Seed(sites, map[int]string{6: "Denver", 7: "12.50"})
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	quickbasetest.RunContractTests(t, client, contractConfig)
+	quickbasetest.RunBackendContractTests(t, backend.NewXML(client.Ticket), contractConfig)
+	if records := server.Records(sites); len(records) != 1 {
+		t.Errorf("the contract tests left %v", records)
+	}
+}
+
+func TestFakeContract(t *testing.T) {
+	fake := quickbasetest.NewFake()
+	fake.AddTable(sites, map[int]string{6: "Site", 7: "Cost", 8: "Photo"})
+	fake.AddApp(contractApp, sites)
+	fake.AddUser(quickbase.User{Id: "56789.abcd", Name: "jdoe"})
+	cfg := contractConfig
+	cfg.FileFid = 8
+	quickbasetest.RunContractTests(t, fake, cfg)
+}
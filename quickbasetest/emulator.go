@@ -0,0 +1,315 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbasetest
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// SetLatency delays every response by latency, plus a random amount
+// up to jitter.
+func (s *Server) SetLatency(latency, jitter time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.latency, s.jitter = latency, jitter
+}
+
+// SetFailureRate makes the given fraction of requests, chosen at
+// random, fail with HTTP 503 Service Unavailable, as QuickBase does
+// when overloaded.
+func (s *Server) SetFailureRate(rate float64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.failureRate = rate
+}
+
+// FailNext makes the next request for action, e.g. 'API_EditRecord',
+// fail with the given QuickBase error code and text, without being
+// carried out.  Errors for the same action are returned in the order
+// they were added.
+func (s *Server) FailNext(action string, code int, text string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.failures[action] = append(s.failures[action], apiError{code, text})
+}
+
+// fault decides how a request for action is to misbehave; the caller
+// must hold the mutex.
+func (s *Server) fault(action string) (delay time.Duration, unavailable bool, injected *apiError) {
+	delay = s.latency
+	if s.jitter > 0 {
+		delay += time.Duration(s.random.Int63n(int64(s.jitter)))
+	}
+	if s.failureRate > 0 && s.random.Float64() < s.failureRate {
+		return delay, true, nil
+	}
+	if queued := s.failures[action]; len(queued) > 0 {
+		injected = &queued[0]
+		s.failures[action] = queued[1:]
+	}
+	return delay, false, injected
+}
+
+// writes reports whether action changes the realm's state.
+func writes(action string) bool {
+	switch action {
+	case "API_Authenticate", "API_AddRecord", "API_EditRecord", "API_DeleteRecord", "API_PurgeRecords", "API_ImportFromCSV",
+		"API_SetFieldProperties", "API_AddField", "API_DeleteField", "API_FieldAddChoices", "API_CreateTable",
+		"API_CreateDatabase", "API_CloneDatabase", "API_DeleteDatabase", "API_SetDBvar", "API_AddReplaceDBPage",
+		"API_AddUserToRole", "API_RemoveUserFromRole", "API_ChangeUserRole", "API_ProvisionUser", "API_SendInvitation":
+		return true
+	}
+	return false
+}
+
+// serverState is the persistent form of a Server's realm.
+type serverState struct {
+	Users    map[string]userState              `json:"users"`
+	Tickets  map[string]string                 `json:"tickets,omitempty"`
+	Tokens   map[string]string                 `json:"tokens,omitempty"`
+	Apps     map[string][]string               `json:"apps,omitempty"`
+	AppNames map[string]string                 `json:"app_names,omitempty"`
+	DBVars   map[string]map[string]string      `json:"dbvars,omitempty"`
+	Pages    map[string][]*dbPage              `json:"pages,omitempty"`
+	Roles    map[string]map[string][]roleGrant `json:"roles,omitempty"`
+	Tables   []tableState                      `json:"tables"`
+}
+
+type userState struct {
+	Id        string `json:"id"`
+	Password  string `json:"password"`
+	FirstName string `json:"first_name,omitempty"`
+	LastName  string `json:"last_name,omitempty"`
+	Invited   bool   `json:"invited,omitempty"`
+}
+
+type tableState struct {
+	Dbid         string                 `json:"dbid"`
+	Name         string                 `json:"name"`
+	Fields       map[int]string         `json:"fields"`
+	Types        map[int]string         `json:"types,omitempty"`
+	Formulas     map[int]string         `json:"formulas,omitempty"`
+	Choices      map[int][]string       `json:"choices,omitempty"`
+	KeyFid       int                    `json:"key_fid,omitempty"`
+	Queries      map[int]string         `json:"queries,omitempty"`
+	Reports      map[int]Report         `json:"reports,omitempty"`
+	Pnoun        string                 `json:"pnoun,omitempty"`
+	Description  string                 `json:"description,omitempty"`
+	Icon         string                 `json:"icon,omitempty"`
+	Records      map[int]map[int]string `json:"records"`
+	UpdateIds    map[int]int            `json:"update_ids,omitempty"`
+	NextRid      int                    `json:"next_rid"`
+	NextUpdateId int                    `json:"next_update_id"`
+}
+
+// SaveState writes the realm's users, tables and records as JSON.
+func (s *Server) SaveState(w io.Writer) (err error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.saveState(w)
+}
+
+func (s *Server) saveState(w io.Writer) (err error) {
+	state := serverState{
+		Users:    make(map[string]userState),
+		Tickets:  s.tickets,
+		Tokens:   s.tokens,
+		Apps:     s.apps,
+		AppNames: s.appNames,
+		DBVars:   s.dbvars,
+		Pages:    s.pages,
+		Roles:    s.roles,
+	}
+	for name, u := range s.users {
+		state.Users[name] = userState{u.id, u.password, u.firstName, u.lastName, u.invited}
+	}
+	for _, dbid := range sortedKeys(s.tables) {
+		table := s.tables[dbid]
+		state.Tables = append(state.Tables, tableState{
+			Dbid:         table.Dbid,
+			Name:         table.Name,
+			Fields:       table.Fields,
+			Types:        table.Types,
+			Formulas:     table.Formulas,
+			Choices:      table.Choices,
+			KeyFid:       table.KeyFid,
+			Queries:      table.Queries,
+			Reports:      table.Reports,
+			Pnoun:        table.Pnoun,
+			Description:  table.Description,
+			Icon:         table.Icon,
+			Records:      table.Records,
+			UpdateIds:    table.updateIds,
+			NextRid:      table.nextRid,
+			NextUpdateId: table.nextUpdateId,
+		})
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(state)
+}
+
+// LoadState replaces the realm with one written by SaveState.  The
+// JSON may also be written by hand to describe a realm's users and
+// tables; the built-in fields 1 to 5 need not be listed.
+func (s *Server) LoadState(r io.Reader) (err error) {
+	var state serverState
+	if err = json.NewDecoder(r).Decode(&state); err != nil {
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.users = make(map[string]user)
+	s.tickets = make(map[string]string)
+	s.tokens = make(map[string]string)
+	s.tables = make(map[string]*Table)
+	s.apps = make(map[string][]string)
+	s.appNames = make(map[string]string)
+	s.dbvars = make(map[string]map[string]string)
+	s.pages = make(map[string][]*dbPage)
+	s.roles = make(map[string]map[string][]roleGrant)
+	for name, u := range state.Users {
+		s.users[name] = user{u.Id, u.Password, u.FirstName, u.LastName, u.Invited}
+	}
+	s.nextUid = len(s.users)
+	for ticket, name := range state.Tickets {
+		s.tickets[ticket] = name
+	}
+	s.issued = len(s.tickets)
+	for token, name := range state.Tokens {
+		s.tokens[token] = name
+	}
+	for dbid, tables := range state.Apps {
+		s.apps[dbid] = tables
+	}
+	for dbid, name := range state.AppNames {
+		s.appNames[dbid] = name
+	}
+	for dbid, vars := range state.DBVars {
+		s.dbvars[dbid] = vars
+	}
+	for dbid, pages := range state.Pages {
+		s.pages[dbid] = pages
+	}
+	for dbid, grants := range state.Roles {
+		s.roles[dbid] = grants
+	}
+	for _, ts := range state.Tables {
+		table := newTable(ts.Dbid, ts.Fields)
+		if ts.Name != "" {
+			table.Name = ts.Name
+		}
+		table.KeyFid = ts.KeyFid
+		table.Pnoun, table.Description, table.Icon = ts.Pnoun, ts.Description, ts.Icon
+		for fid, fieldType := range ts.Types {
+			table.Types[fid] = fieldType
+		}
+		for fid, formula := range ts.Formulas {
+			table.Formulas[fid] = formula
+		}
+		for fid, choices := range ts.Choices {
+			table.Choices[fid] = choices
+		}
+		for qid, q := range ts.Queries {
+			table.Queries[qid] = q
+		}
+		for qid, report := range ts.Reports {
+			table.Reports[qid] = report
+		}
+		for rid, record := range ts.Records {
+			if record == nil {
+				record = make(map[int]string)
+			}
+			record[3] = strconv.Itoa(rid)
+			table.Records[rid] = record
+			if rid >= table.nextRid {
+				table.nextRid = rid + 1
+			}
+		}
+		if ts.NextRid > table.nextRid {
+			table.nextRid = ts.NextRid
+		}
+		for rid, id := range ts.UpdateIds {
+			table.updateIds[rid] = id
+		}
+		table.nextUpdateId = ts.NextUpdateId
+		s.tables[ts.Dbid] = table
+	}
+	return nil
+}
+
+// Persist keeps the realm in the file at path: if the file exists it
+// is loaded with LoadState, and after every request which changes
+// the realm it is rewritten with SaveState.
+func (s *Server) Persist(path string) (err error) {
+	file, err := os.Open(path)
+	switch {
+	case err == nil:
+		err = s.LoadState(file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.stateFile = path
+	return s.persist()
+}
+
+// persist saves the realm to the state file, if there is one; the
+// caller must hold the mutex.  The file is replaced atomically, so a
+// crash never leaves it half-written.
+func (s *Server) persist() (err error) {
+	if s.stateFile == "" {
+		return nil
+	}
+	temp, err := ioutil.TempFile(filepath.Dir(s.stateFile), filepath.Base(s.stateFile)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	if err = s.saveState(temp); err != nil {
+		temp.Close()
+		return err
+	}
+	if err = temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), s.stateFile)
+}
+
+func sortedKeys(tables map[string]*Table) (dbids []string) {
+	for dbid := range tables {
+		dbids = append(dbids, dbid)
+	}
+	sort.Strings(dbids)
+	return dbids
+}
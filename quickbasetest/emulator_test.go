@@ -0,0 +1,114 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbasetest_test
+
+import (
+	"github.com/WesTower/quickbase"
+	"github.com/WesTower/quickbase/quickbasetest"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestServerPersist(t *testing.T) {
+	dir, err := ioutil.TempDir("", "quickbasetest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "realm.json")
+
+	server := quickbasetest.NewServer()
+	if err = server.Persist(path); err != nil {
+		t.Fatal(err)
+	}
+	server.AddUser("jdoe", "secret")
+	server.AddTable(sites, map[int]string{6: "Site", 7: "Cost"}).KeyFid = 6
+	ticket, err := quickbase.Authenticate(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = quickbase.AddRecordByFid(ticket, sites, map[int]string{6: "Denver", 7: "12.50"}); err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+
+	server = quickbasetest.NewServer()
+	defer server.Close()
+	if err = server.Persist(path); err != nil {
+		t.Fatal(err)
+	}
+	records := server.Records(sites)
+	if len(records) != 1 || records[0][6] != "Denver" || records[0][3] != "1" {
+		t.Fatalf("reloaded records are %v", records)
+	}
+	ticket, err = quickbase.Authenticate(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rid, err := quickbase.AddRecordByFid(ticket, sites, map[int]string{6: "Boise"})
+	if err != nil || rid != 2 {
+		t.Errorf("AddRecordByFid after reload gave %d, %v", rid, err)
+	}
+	if _, err = quickbase.AddRecordByFid(ticket, sites, map[int]string{6: "Denver"}); err == nil {
+		t.Error("the key field was not persisted")
+	}
+}
+
+func TestServerFaults(t *testing.T) {
+	server := quickbasetest.NewServer()
+	defer server.Close()
+	server.AddUser("jdoe", "secret")
+	server.AddTable(sites, map[int]string{6: "Site"})
+	ticket, err := quickbase.Authenticate(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.FailNext("API_AddRecord", 77, "Table is locked")
+	_, err = quickbase.AddRecordByFid(ticket, sites, map[int]string{6: "Denver"})
+	if qbErr, ok := err.(quickbase.QuickBaseError); !ok || qbErr.Code != 77 {
+		t.Errorf("injected error gave %v", err)
+	}
+	if records := server.Records(sites); len(records) != 0 {
+		t.Errorf("a failed call added %v", records)
+	}
+	if _, err = quickbase.AddRecordByFid(ticket, sites, map[int]string{6: "Denver"}); err != nil {
+		t.Errorf("the injected error was repeated: %v", err)
+	}
+
+	server.SetLatency(20*time.Millisecond, 0)
+	start := time.Now()
+	if _, err = quickbase.DoQueryCount(ticket, sites, ""); err != nil {
+		t.Error(err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("a call with latency took only %v", elapsed)
+	}
+	server.SetLatency(0, 0)
+
+	server.SetFailureRate(1)
+	if _, err = quickbase.DoQueryCount(ticket, sites, ""); err == nil {
+		t.Error("a call succeeded with a failure rate of 1")
+	}
+}
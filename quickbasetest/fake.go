@@ -0,0 +1,501 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbasetest
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// A Fake is an in-memory implementation of quickbase.QuickBase with
+// the behaviour of a real realm: record IDs are allocated in turn,
+// queries are filtered, sorted and paged, key fields are unique and
+// every write gives the record a new update ID.  It is much faster
+// than a Server, as no HTTP or XML is involved, and suits tests of
+// application logic written against quickbase.QuickBase.
+//
+// Errors are returned as quickbase.QuickBaseErrors with the codes
+// QuickBase itself would use.
+type Fake struct {
+	mutex  sync.Mutex
+	tables map[string]*Table
+	apps   map[string][]string
+	users  []quickbase.User
+	files  map[fileKey][][]byte
+}
+
+type fileKey struct {
+	dbid     string
+	rid, fid int
+}
+
+var _ quickbase.QuickBase = (*Fake)(nil)
+
+// NewFake returns an empty Fake.
+func NewFake() *Fake {
+	return &Fake{
+		tables: make(map[string]*Table),
+		apps:   make(map[string][]string),
+		files:  make(map[fileKey][][]byte),
+	}
+}
+
+// AddTable adds a table with the given fields, in addition to the
+// built-in fields 1 to 5.  Set the returned Table's KeyFid, before
+// adding records, to make another field the key.
+func (f *Fake) AddTable(dbid string, fields map[int]string) *Table {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	table := newTable(dbid, fields)
+	f.tables[dbid] = table
+	return table
+}
+
+// AddApp adds an application holding the given tables, for
+// GetAppDTMInfo.
+func (f *Fake) AddApp(dbid string, tableDbids ...string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.apps[dbid] = tableDbids
+}
+
+// AddUser adds a user to be returned by UserRoles.
+func (f *Fake) AddUser(user quickbase.User) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.users = append(f.users, user)
+}
+
+// Seed adds a record to a table, as AddRecordByFid does, panicking
+// on error.
+func (f *Fake) Seed(dbid string, record map[int]string) (rid int) {
+	rid, err := f.AddRecordByFid(dbid, record)
+	if err != nil {
+		panic(err)
+	}
+	return rid
+}
+
+// Records returns a copy of a table's records, in record ID order.
+func (f *Fake) Records(dbid string) (records []map[int]string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	table := f.tables[dbid]
+	for _, rid := range table.rids() {
+		records = append(records, copyRecord(table.Records[rid]))
+	}
+	return records
+}
+
+// UpdateId returns the update ID of a record, which changes whenever
+// the record is written, or zero if there is no such record.
+func (f *Fake) UpdateId(dbid string, rid int) int {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if table, ok := f.tables[dbid]; ok {
+		return table.updateIds[rid]
+	}
+	return 0
+}
+
+func copyRecord(record map[int]string) map[int]string {
+	copied := make(map[int]string, len(record))
+	for fid, value := range record {
+		copied[fid] = value
+	}
+	return copied
+}
+
+// table returns the table with the given dbid; the caller must hold
+// the mutex.
+func (f *Fake) table(dbid string) (table *Table, err error) {
+	table, ok := f.tables[dbid]
+	if !ok {
+		return nil, qbError(errNoSuchTable)
+	}
+	return table, nil
+}
+
+// qbError converts an error to the form package quickbase returns.
+func qbError(err error) error {
+	if e, ok := err.(apiError); ok {
+		return quickbase.QuickBaseError{Message: e.text, Code: e.code}
+	}
+	return err
+}
+
+// fidsByLabel converts a map keyed by field label to one keyed by
+// field ID.
+func fidsByLabel(table *Table, fields map[string]string) (record map[int]string, err error) {
+	record = make(map[int]string)
+	for label, value := range fields {
+		fid, ok := table.fidByLabel(label)
+		if !ok {
+			return nil, qbError(apiError{51, "No such field " + label})
+		}
+		record[fid] = value
+	}
+	return record, nil
+}
+
+func (f *Fake) GetAppDTMInfo(dbid string) (received, nextAllowed time.Time, schemaModification quickbase.SchemaModification, tableModification []quickbase.SchemaModification, err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	tables, ok := f.apps[dbid]
+	if !ok {
+		err = qbError(errMissingValue)
+		return
+	}
+	received = time.Now()
+	schemaModification = quickbase.SchemaModification{Dbid: dbid, SchemaModified: received, RecordModified: received}
+	for _, tableDbid := range tables {
+		modified := received
+		if table, ok := f.tables[tableDbid]; ok {
+			modified = table.modified
+		}
+		tableModification = append(tableModification, quickbase.SchemaModification{
+			Dbid:           tableDbid,
+			SchemaModified: modified,
+			RecordModified: modified,
+		})
+	}
+	return received, received, schemaModification, tableModification, nil
+}
+
+func (f *Fake) GetSchema(dbid string) (schema quickbase.Schema, err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if tables, ok := f.apps[dbid]; ok {
+		return quickbase.Schema{Dbid: dbid, Name: dbid, Tables: append([]string(nil), tables...)}, nil
+	}
+	table, err := f.table(dbid)
+	if err != nil {
+		return schema, err
+	}
+	schema = quickbase.Schema{Dbid: table.Dbid, Name: table.Name}
+	fids, _ := clist(table, "")
+	for _, fid := range fids {
+		schema.Fields = append(schema.Fields, quickbase.Field{Id: fid, Label: table.Fields[fid], Type: table.fieldType(fid)})
+	}
+	var qids []int
+	for qid := range table.Queries {
+		qids = append(qids, qid)
+	}
+	sort.Ints(qids)
+	for _, qid := range qids {
+		report := table.report(qid)
+		schema.Queries = append(schema.Queries, quickbase.Query{
+			Id:       qid,
+			Name:     report.Name,
+			Type:     "table",
+			Criteria: table.Queries[qid],
+			Clist:    report.Clist,
+			Slist:    report.Slist,
+		})
+	}
+	return schema, nil
+}
+
+func (f *Fake) GetRelationshipGraph(dbids ...string) (graph *quickbase.RelationshipGraph, err error) {
+	graph = &quickbase.RelationshipGraph{}
+	for _, dbid := range dbids {
+		schema, err := f.GetSchema(dbid)
+		if err != nil {
+			return nil, err
+		}
+		graph.Relationships = append(graph.Relationships, schema.Relationships()...)
+	}
+	return graph, nil
+}
+
+func (f *Fake) DoQueryCount(dbid, q string) (count int64, err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	table, err := f.table(dbid)
+	if err != nil {
+		return 0, err
+	}
+	records, err := query(table, q)
+	return int64(len(records)), qbError(err)
+}
+
+// doQuery returns copies of the selected fields of the matching
+// records, and the field IDs selected.
+func (f *Fake) doQuery(dbid, q, columns, slist, options string) (table *Table, records []map[int]string, fids []int, err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	if table, err = f.table(dbid); err != nil {
+		return nil, nil, nil, err
+	}
+	matched, err := query(table, q)
+	if err != nil {
+		return nil, nil, nil, qbError(err)
+	}
+	if fids, err = clist(table, columns); err != nil {
+		return nil, nil, nil, qbError(err)
+	}
+	if matched, err = sortAndPage(table, matched, slist, options); err != nil {
+		return nil, nil, nil, qbError(err)
+	}
+	for _, record := range matched {
+		selected := make(map[int]string, len(fids))
+		for _, fid := range fids {
+			selected[fid] = record[fid]
+		}
+		records = append(records, selected)
+	}
+	return table, records, fids, nil
+}
+
+func (f *Fake) DoQuery(dbid, q, clist, slist, options string) (records []map[string]string, err error) {
+	table, matched, fids, err := f.doQuery(dbid, q, clist, slist, options)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range matched {
+		labelled := make(map[string]string, len(fids))
+		for _, fid := range fids {
+			labelled[tag(table.Fields[fid])] = record[fid]
+		}
+		records = append(records, labelled)
+	}
+	return records, nil
+}
+
+func (f *Fake) DoStructuredQuery(dbid, q, clist, slist, options string) (records []map[int]string, err error) {
+	_, records, _, err = f.doQuery(dbid, q, clist, slist, options)
+	return records, err
+}
+
+// DoQueryByQid runs one of the queries in the table's Queries.
+func (f *Fake) DoQueryByQid(dbid string, qid int, clist, slist, options string) (records []map[int]string, err error) {
+	f.mutex.Lock()
+	table, err := f.table(dbid)
+	var q string
+	var report Report
+	if err == nil {
+		q, report, err = table.savedQuery(strconv.Itoa(qid), "", "")
+	}
+	f.mutex.Unlock()
+	if err != nil {
+		return nil, qbError(err)
+	}
+	_, records, _, err = f.doQuery(dbid, q, reportList(clist, report.Clist), reportList(slist, report.Slist), options)
+	return records, err
+}
+
+// GenResultsTable returns a response whose body is the CSV
+// QuickBase would send, with a header line of field labels.
+func (f *Fake) GenResultsTable(dbid, q string, columns []int) (resp *http.Response, err error) {
+	list := ""
+	for i, fid := range columns {
+		if i > 0 {
+			list += "."
+		}
+		list += strconv.Itoa(fid)
+	}
+	table, records, fids, err := f.doQuery(dbid, q, list, "3", "")
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	row := make([]string, len(fids))
+	for i, fid := range fids {
+		row[i] = table.Fields[fid]
+	}
+	w.Write(row)
+	for _, record := range records {
+		for i, fid := range fids {
+			row[i] = record[fid]
+		}
+		w.Write(row)
+	}
+	w.Flush()
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {"text/csv"}},
+		Body:          ioutil.NopCloser(&buf),
+		ContentLength: int64(buf.Len()),
+	}, nil
+}
+
+func (f *Fake) AddRecord(dbid string, fields map[string]string) (rid int, err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	table, err := f.table(dbid)
+	if err != nil {
+		return 0, err
+	}
+	record, err := fidsByLabel(table, fields)
+	if err != nil {
+		return 0, err
+	}
+	rid, err = table.add(record)
+	return rid, qbError(err)
+}
+
+func (f *Fake) AddRecordByFid(dbid string, fields map[int]string) (rid int, err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	table, err := f.table(dbid)
+	if err != nil {
+		return 0, err
+	}
+	for fid := range fields {
+		if table.Fields[fid] == "" {
+			return 0, qbError(apiError{51, fmt.Sprintf("No such field %d", fid)})
+		}
+	}
+	rid, err = table.add(fields)
+	return rid, qbError(err)
+}
+
+func (f *Fake) EditRecord(dbid string, recordId int, fields map[string]string) (err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	table, err := f.table(dbid)
+	if err != nil {
+		return err
+	}
+	record, err := fidsByLabel(table, fields)
+	if err != nil {
+		return err
+	}
+	return qbError(table.edit(recordId, record))
+}
+
+func (f *Fake) EditRecordByFid(dbid string, recordId int, fields map[int]string) (err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	table, err := f.table(dbid)
+	if err != nil {
+		return err
+	}
+	for fid := range fields {
+		if table.Fields[fid] == "" {
+			return qbError(apiError{51, fmt.Sprintf("No such field %d", fid)})
+		}
+	}
+	return qbError(table.edit(recordId, fields))
+}
+
+func (f *Fake) DeleteRecord(dbid string, rid int) (err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	table, err := f.table(dbid)
+	if err != nil {
+		return err
+	}
+	if _, ok := table.Records[rid]; !ok {
+		return qbError(errNoSuchRecord)
+	}
+	delete(table.Records, rid)
+	delete(table.updateIds, rid)
+	table.modified = time.Now()
+	return nil
+}
+
+func (f *Fake) ChangeRecordOwner(dbid string, rid int, owner string) (err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	table, err := f.table(dbid)
+	if err != nil {
+		return err
+	}
+	return qbError(table.edit(rid, map[int]string{4: owner}))
+}
+
+// ImportFromCSV skips the CSV's header line and adds a record for
+// each other row or, where the columns include the table's key field
+// and its value matches an existing record, updates that record.
+func (f *Fake) ImportFromCSV(dbid string, columns []int, r io.Reader) (err error) {
+	rows, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return qbError(apiError{100, "Bad CSV: " + err.Error()})
+	}
+	if len(rows) > 0 {
+		rows = rows[1:]
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	table, err := f.table(dbid)
+	if err != nil {
+		return err
+	}
+	_, _, _, err = table.importRows(columns, rows, 0)
+	return qbError(err)
+}
+
+func (f *Fake) UserRoles(dbid string) (users []quickbase.User, err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append(users, f.users...), nil
+}
+
+// Download returns a version of an uploaded file; version 0 is the
+// latest.
+func (f *Fake) Download(dbid string, rid, fid, vid int) (file io.ReadCloser, err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	versions := f.files[fileKey{dbid, rid, fid}]
+	if vid == 0 {
+		vid = len(versions)
+	}
+	if vid < 1 || vid > len(versions) {
+		return nil, qbError(errNoSuchRecord)
+	}
+	return ioutil.NopCloser(bytes.NewReader(versions[vid-1])), nil
+}
+
+// Upload stores a new version of a file, setting the field's value
+// to its name.
+func (f *Fake) Upload(dbid string, rid, fid int, filename string, r io.Reader) (err error) {
+	contents, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	table, err := f.table(dbid)
+	if err != nil {
+		return err
+	}
+	if err = table.edit(rid, map[int]string{fid: filename}); err != nil {
+		return qbError(err)
+	}
+	key := fileKey{dbid, rid, fid}
+	f.files[key] = append(f.files[key], contents)
+	return nil
+}
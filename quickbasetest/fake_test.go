@@ -0,0 +1,131 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbasetest_test
+
+import (
+	"github.com/WesTower/quickbase"
+	"github.com/WesTower/quickbase/quickbasetest"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+const sites = "bck7gp3q2"
+
+func newFake() *quickbasetest.Fake {
+	fake := quickbasetest.NewFake()
+	fake.AddTable(sites, map[int]string{6: "Site", 7: "Cost"}).KeyFid = 6
+	fake.Seed(sites, map[int]string{6: "Denver", 7: "12.50"})
+	fake.Seed(sites, map[int]string{6: "Boise", 7: "3"})
+	return fake
+}
+
+func TestFakeRecords(t *testing.T) {
+	var qb quickbase.QuickBase = newFake()
+	rid, err := qb.AddRecord(sites, map[string]string{"Site": "Austin", "Cost": "40"})
+	if err != nil || rid != 3 {
+		t.Fatalf("AddRecord gave %d, %v", rid, err)
+	}
+	_, err = qb.AddRecordByFid(sites, map[int]string{6: "austin"})
+	if err != nil {
+		t.Errorf("AddRecordByFid with a distinct key failed: %v", err)
+	}
+	_, err = qb.AddRecordByFid(sites, map[int]string{6: "Austin"})
+	if qbErr, ok := err.(quickbase.QuickBaseError); !ok || qbErr.Code == 0 {
+		t.Errorf("duplicate key gave %v", err)
+	}
+	if err = qb.EditRecordByFid(sites, 1, map[int]string{6: "Boise"}); err == nil {
+		t.Error("EditRecordByFid to a duplicate key succeeded")
+	}
+	if err = qb.EditRecordByFid(sites, 99, map[int]string{7: "1"}); err == nil {
+		t.Error("EditRecordByFid of a missing record succeeded")
+	}
+	if err = qb.DeleteRecord(sites, 4); err != nil {
+		t.Error(err)
+	}
+	if count, err := qb.DoQueryCount(sites, ""); err != nil || count != 3 {
+		t.Errorf("DoQueryCount gave %d, %v", count, err)
+	}
+}
+
+func TestFakeQuery(t *testing.T) {
+	qb := newFake()
+	qb.Seed(sites, map[int]string{6: "Boston", 7: "100"})
+	records, err := qb.DoStructuredQuery(sites, "{'6'.CT.'bo'}", "3.7", "7", "sortorder-D")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0][7] != "100" || records[1][7] != "3" {
+		t.Errorf("DoStructuredQuery gave %v", records)
+	}
+	if _, ok := records[0][6]; ok {
+		t.Errorf("DoStructuredQuery returned an unselected field: %v", records[0])
+	}
+	labelled, err := qb.DoQuery(sites, "{'6'.EX.'denver'}", "6.7", "", "")
+	if err != nil || len(labelled) != 1 || labelled[0]["cost"] != "12.50" {
+		t.Errorf("DoQuery gave %v, %v", labelled, err)
+	}
+	page, err := qb.DoStructuredQuery(sites, "", "6", "6", "skp-1.num-1")
+	if err != nil || len(page) != 1 || page[0][6] != "Boston" {
+		t.Errorf("paged DoStructuredQuery gave %v, %v", page, err)
+	}
+	grouped, err := qb.DoQueryCount(sites, "({'6'.SW.'bo'}OR{'6'.EX.'denver'})AND{'7'.LT.'50'}")
+	if err != nil || grouped != 2 {
+		t.Errorf("grouped DoQueryCount gave %d, %v", grouped, err)
+	}
+	if _, err = qb.DoQueryCount(sites, "{'99'.EX.'x'}"); err == nil {
+		t.Error("query on a missing field succeeded")
+	}
+	resp, err := qb.GenResultsTable(sites, "{'7'.GT.'10'}", []int{6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	csv, _ := ioutil.ReadAll(resp.Body)
+	if string(csv) != "Site\nDenver\nBoston\n" {
+		t.Errorf("GenResultsTable gave %q", csv)
+	}
+}
+
+func TestFakeImportAndUpdateIds(t *testing.T) {
+	qb := newFake()
+	before := qb.UpdateId(sites, 2)
+	err := qb.ImportFromCSV(sites, []int{6, 7}, strings.NewReader("Site,Cost\nBoise,4\nTulsa,5\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := qb.Records(sites)
+	if len(records) != 3 || records[1][7] != "4" || records[2][6] != "Tulsa" {
+		t.Errorf("ImportFromCSV left %v", records)
+	}
+	if after := qb.UpdateId(sites, 2); after <= before {
+		t.Errorf("update ID went from %d to %d", before, after)
+	}
+	if err = qb.Upload(sites, 3, 7, "a.txt", strings.NewReader("hello")); err != nil {
+		t.Fatal(err)
+	}
+	file, err := qb.Download(sites, 3, 7, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contents, _ := ioutil.ReadAll(file); string(contents) != "hello" {
+		t.Errorf("Download gave %q", contents)
+	}
+}
@@ -0,0 +1,221 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbasetest
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Fault is a way in which a call to QuickBase fails.
+type Fault int
+
+const (
+	// FaultTimeout makes the call hang, unsent, for
+	// FaultTransport.Timeout and then fail as a timeout.
+	FaultTimeout Fault = iota
+	// FaultError83 answers the call, unsent, with QuickBase error
+	// 83, which QuickBase returns when it cannot process a request
+	// for the time being.
+	FaultError83
+	// FaultMalformed answers the call, unsent, with malformed XML.
+	FaultMalformed
+	// FaultPartial sends the call, so that it takes effect, but
+	// cuts the response off half-way through.
+	FaultPartial
+	// FaultUnavailable answers the call, unsent, with HTTP 503
+	// Service Unavailable and an HTML page, as a proxy in front of
+	// QuickBase does.
+	FaultUnavailable
+)
+
+var faultNames = []string{"timeout", "error 83", "malformed XML", "partial response", "service unavailable"}
+
+func (f Fault) String() string {
+	if int(f) < len(faultNames) {
+		return faultNames[f]
+	}
+	return fmt.Sprintf("Fault(%d)", int(f))
+}
+
+// A FaultTransport is an http.RoundTripper which makes a proportion of
+// calls fail in the ways QuickBase calls fail, so that applications'
+// retry and fallback logic can be tested.  The faults are injected at
+// the HTTP level, so that the code parsing the responses sees them as
+// it would in production.
+type FaultTransport struct {
+	Transport http.RoundTripper // if nil, quickbase.Transport or http.DefaultTransport
+	Rate      float64           // the fraction of calls which fail
+	Faults    []Fault           // the faults chosen among at random; if empty, all of them
+	Timeout   time.Duration     // how long a FaultTimeout hangs; if zero, a second
+
+	mutex    sync.Mutex
+	random   *rand.Rand
+	injected map[Fault]int
+}
+
+// NewFaultTransport returns a FaultTransport failing the given
+// fraction of calls with the given faults, or all kinds if none are
+// given.
+func NewFaultTransport(rate float64, faults ...Fault) *FaultTransport {
+	return &FaultTransport{Rate: rate, Faults: faults}
+}
+
+// Faulty returns a copy of client whose calls fail as t makes them.
+func Faulty(client *quickbase.Client, t *FaultTransport) *quickbase.Client {
+	faulty := *client
+	httpClient := &http.Client{}
+	*httpClient = *quickbase.DefaultClient
+	if client.HttpClient != nil {
+		*httpClient = *client.HttpClient
+	}
+	if t.Transport == nil {
+		t.Transport = httpClient.Transport
+	}
+	httpClient.Transport = t
+	faulty.HttpClient = httpClient
+	return &faulty
+}
+
+// Injected returns the number of faults of each kind injected so far.
+func (t *FaultTransport) Injected() map[Fault]int {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	injected := make(map[Fault]int, len(t.injected))
+	for fault, n := range t.injected {
+		injected[fault] = n
+	}
+	return injected
+}
+
+// choose decides whether a call fails, and how.
+func (t *FaultTransport) choose() (fault Fault, ok bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.random == nil {
+		t.random = rand.New(rand.NewSource(time.Now().UnixNano()))
+		t.injected = make(map[Fault]int)
+	}
+	if t.random.Float64() >= t.Rate {
+		return 0, false
+	}
+	if len(t.Faults) == 0 {
+		fault = Fault(t.random.Intn(len(faultNames)))
+	} else {
+		fault = t.Faults[t.random.Intn(len(t.Faults))]
+	}
+	t.injected[fault]++
+	return fault, true
+}
+
+// timeoutError is the error of a call which timed out.
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "quickbasetest: injected timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func (t *FaultTransport) RoundTrip(req *http.Request) (resp *http.Response, err error) {
+	fault, ok := t.choose()
+	if !ok {
+		return t.transport().RoundTrip(req)
+	}
+	if req.Body != nil && fault != FaultPartial {
+		io.Copy(ioutil.Discard, req.Body)
+		req.Body.Close()
+	}
+	action := req.Header.Get("QUICKBASE-ACTION")
+	switch fault {
+	case FaultTimeout:
+		timeout := t.Timeout
+		if timeout == 0 {
+			timeout = time.Second
+		}
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		return nil, timeoutError{}
+	case FaultError83:
+		return faultResponse(req, http.StatusOK, "text/xml", fmt.Sprintf(`<?xml version="1.0" ?>
+<qdbapi><action>%s</action><errcode>83</errcode><errtext>Unable to process request</errtext></qdbapi>`, escape(action))), nil
+	case FaultMalformed:
+		return faultResponse(req, http.StatusOK, "text/xml", fmt.Sprintf(`<?xml version="1.0" ?>
+<qdbapi><action>%s</action><errcode>0<errtext>No error</errcode></qdbapi>`, escape(action))), nil
+	case FaultUnavailable:
+		return faultResponse(req, http.StatusServiceUnavailable, "text/html",
+			"<html><head><title>503 Service Unavailable</title></head><body><h1>Service Unavailable</h1></body></html>"), nil
+	}
+	resp, err = t.transport().RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(io.MultiReader(bytes.NewReader(body[:len(body)/2]), errorReader{io.ErrUnexpectedEOF}))
+	resp.ContentLength = -1
+	resp.Header.Del("Content-Length")
+	return resp, nil
+}
+
+func (t *FaultTransport) transport() http.RoundTripper {
+	if t.Transport != nil {
+		return t.Transport
+	}
+	return quickbase.DefaultClient.Transport
+}
+
+func faultResponse(req *http.Request, status int, contentType, body string) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": {contentType}},
+		Body:          ioutil.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+// errorReader returns err once its data is exhausted.
+type errorReader struct {
+	err error
+}
+
+func (r errorReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
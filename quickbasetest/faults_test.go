@@ -0,0 +1,78 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbasetest_test
+
+import (
+	"github.com/WesTower/quickbase"
+	"github.com/WesTower/quickbase/quickbasetest"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestFaultTransport(t *testing.T) {
+	server := quickbasetest.NewServer()
+	defer server.Close()
+	server.AddUser("jdoe", "secret")
+	server.AddTable(sites, map[int]string{6: "Site"})
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, fault := range []quickbasetest.Fault{quickbasetest.FaultTimeout, quickbasetest.FaultError83,
+		quickbasetest.FaultMalformed, quickbasetest.FaultPartial, quickbasetest.FaultUnavailable} {
+		transport := quickbasetest.NewFaultTransport(1, fault)
+		transport.Timeout = time.Millisecond
+		faulty := quickbasetest.Faulty(client, transport)
+		before := len(server.Records(sites))
+		_, err := faulty.AddRecordByFid(sites, map[int]string{6: "Denver"})
+		if err == nil {
+			t.Errorf("%s: AddRecordByFid succeeded", fault)
+			continue
+		}
+		added := len(server.Records(sites)) - before
+		switch fault {
+		case quickbasetest.FaultTimeout:
+			if netErr, ok := err.(net.Error); !ok || !netErr.Timeout() {
+				t.Errorf("%s gave %#v", fault, err)
+			}
+		case quickbasetest.FaultError83:
+			if qbErr, ok := err.(quickbase.QuickBaseError); !ok || qbErr.Code != 83 {
+				t.Errorf("%s gave %#v", fault, err)
+			}
+		}
+		if want := map[bool]int{true: 1, false: 0}[fault == quickbasetest.FaultPartial]; added != want {
+			t.Errorf("%s: %d records were added, want %d", fault, added, want)
+		}
+		if n := transport.Injected()[fault]; n != 1 {
+			t.Errorf("%s was injected %d times", fault, n)
+		}
+	}
+
+	faulty := quickbasetest.Faulty(client, quickbasetest.NewFaultTransport(0))
+	if _, err = faulty.DoQueryCount(sites, ""); err != nil {
+		t.Errorf("a call failed at a fault rate of 0: %v", err)
+	}
+	if client.HttpClient != nil {
+		t.Error("Faulty changed the original Client")
+	}
+}
@@ -0,0 +1,141 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbasetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// UpdateGoldenEnv is the environment variable which, when set to a
+// non-empty value, makes AssertGolden rewrite golden files rather
+// than compare against them.
+const UpdateGoldenEnv = "QUICKBASE_UPDATE_GOLDEN"
+
+// LoadFixture returns the contents of a canned qdbapi response.
+func LoadFixture(path string) (response []byte, err error) {
+	return ioutil.ReadFile(path)
+}
+
+// A FixtureServer answers each call with a canned qdbapi response,
+// chosen by its QUICKBASE-ACTION.  API_Authenticate always succeeds,
+// so that tests can obtain a quickbase.Ticket for it.
+type FixtureServer struct {
+	*httptest.Server
+
+	mutex     sync.Mutex
+	responses map[string][]byte
+}
+
+// NewFixtureServer starts a FixtureServer answering each action with
+// the given response, e.g. {"API_DoQuery": doQueryXml}.  The caller
+// should Close it when finished.
+func NewFixtureServer(responses map[string][]byte) *FixtureServer {
+	s := &FixtureServer{responses: make(map[string][]byte)}
+	for action, response := range responses {
+		s.responses[action] = response
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// NewFixtureFileServer is like NewFixtureServer, but loads each
+// response from a file.
+func NewFixtureFileServer(files map[string]string) (server *FixtureServer, err error) {
+	responses := make(map[string][]byte)
+	for action, file := range files {
+		if responses[action], err = LoadFixture(file); err != nil {
+			return nil, err
+		}
+	}
+	return NewFixtureServer(responses), nil
+}
+
+// BaseUrl returns the URL to pass to quickbase.Authenticate.
+func (s *FixtureServer) BaseUrl() string {
+	return s.URL + "/"
+}
+
+// SetResponse changes the response to an action.
+func (s *FixtureServer) SetResponse(action string, response []byte) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.responses[action] = response
+}
+
+func (s *FixtureServer) serve(w http.ResponseWriter, r *http.Request) {
+	action := r.Header.Get("QUICKBASE-ACTION")
+	s.mutex.Lock()
+	response, ok := s.responses[action]
+	s.mutex.Unlock()
+	w.Header().Set("Content-Type", "text/xml")
+	switch {
+	case ok:
+		w.Write(response)
+	case action == "API_Authenticate":
+		fmt.Fprint(w, `<?xml version="1.0" ?><qdbapi><action>API_Authenticate</action><errcode>0</errcode>`+
+			`<errtext>No error</errtext><ticket>fixture_ticket</ticket><userid>56789.fixt</userid></qdbapi>`)
+	default:
+		fmt.Fprintf(w, `<?xml version="1.0" ?><qdbapi><action>%s</action><errcode>%d</errcode><errtext>No fixture for %s</errtext></qdbapi>`,
+			escape(action), errUnknownAction.code, escape(action))
+	}
+}
+
+// AssertGolden compares got, encoded as indented JSON, with the
+// contents of the golden file at path, failing t if they differ.  If
+// the environment variable named by UpdateGoldenEnv is set, the
+// golden file is written instead.
+func AssertGolden(t testing.TB, path string, got interface{}) {
+	t.Helper()
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(got); err != nil {
+		t.Fatalf("%s: %s", path, err)
+	}
+	encoded := buf.Bytes()
+	if os.Getenv(UpdateGoldenEnv) != "" {
+		var err error
+		if err = os.MkdirAll(filepath.Dir(path), os.FileMode(0755)); err == nil {
+			err = ioutil.WriteFile(path, encoded, os.FileMode(0644))
+		}
+		if err != nil {
+			t.Fatalf("%s: %s", path, err)
+		}
+		return
+	}
+	want, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%s: %s (set %s=1 to create it)", path, err, UpdateGoldenEnv)
+	}
+	if !bytes.Equal(encoded, want) {
+		t.Errorf("%s: got\n%s\nwant\n%s", path, encoded, want)
+	}
+}
@@ -0,0 +1,177 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbasetest
+
+import (
+	"github.com/WesTower/quickbase/criteria"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// query returns the records of table matching q, in record ID order.
+func query(table *Table, q string) (records []map[int]string, err error) {
+	expr, err := criteria.Parse(q)
+	if err != nil {
+		return nil, errBadQuery
+	}
+	for _, c := range criteria.Criteria(expr) {
+		if table.Fields[c.Fid] == "" {
+			return nil, apiError{51, "No such field " + strconv.Itoa(c.Fid)}
+		}
+	}
+	for _, rid := range table.rids() {
+		record := table.Records[rid]
+		if expr == nil || matchesExpr(expr, record) {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func matchesExpr(expr criteria.Expr, record map[int]string) bool {
+	switch e := expr.(type) {
+	case criteria.And:
+		for _, sub := range e {
+			if !matchesExpr(sub, record) {
+				return false
+			}
+		}
+		return true
+	case criteria.Or:
+		for _, sub := range e {
+			if matchesExpr(sub, record) {
+				return true
+			}
+		}
+		return false
+	case criteria.Criterion:
+		return criterion(e).matches(record[e.Fid])
+	}
+	return false
+}
+
+type criterion criteria.Criterion
+
+func (c criterion) matches(value string) bool {
+	lv, lc := strings.ToLower(value), strings.ToLower(c.Value)
+	switch c.Op {
+	case "EX":
+		return lv == lc
+	case "XEX":
+		return lv != lc
+	case "CT":
+		return strings.Contains(lv, lc)
+	case "XCT":
+		return !strings.Contains(lv, lc)
+	case "SW":
+		return strings.HasPrefix(lv, lc)
+	case "XSW":
+		return !strings.HasPrefix(lv, lc)
+	}
+	cmp := compare(value, c.Value)
+	switch c.Op {
+	case "LT":
+		return cmp < 0
+	case "LTE":
+		return cmp <= 0
+	case "GT":
+		return cmp > 0
+	case "GTE":
+		return cmp >= 0
+	// dates compare as numbers, being held as milliseconds
+	case "AF":
+		return cmp > 0
+	case "OAF":
+		return cmp >= 0
+	case "BF":
+		return cmp < 0
+	case "OBF":
+		return cmp <= 0
+	}
+	return false
+}
+
+// sortAndPage sorts records by the fields of slist and then applies
+// the sortorder-, skp- and num- options of API_DoQuery.
+func sortAndPage(table *Table, records []map[int]string, slist, options string) (sorted []map[int]string, err error) {
+	var fids []int
+	if slist != "" {
+		for _, s := range strings.Split(slist, ".") {
+			fid, err := strconv.Atoi(s)
+			if err != nil || table.Fields[fid] == "" {
+				return nil, apiError{51, "No such field " + s}
+			}
+			fids = append(fids, fid)
+		}
+	}
+	order := ""
+	skip, num := 0, -1
+	for _, option := range strings.Split(options, ".") {
+		switch {
+		case strings.HasPrefix(option, "sortorder-"):
+			order = strings.TrimPrefix(option, "sortorder-")
+		case strings.HasPrefix(option, "skp-"):
+			skip, _ = strconv.Atoi(strings.TrimPrefix(option, "skp-"))
+		case strings.HasPrefix(option, "num-"):
+			num, _ = strconv.Atoi(strings.TrimPrefix(option, "num-"))
+		}
+	}
+	sorted = append(sorted, records...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		for k, fid := range fids {
+			cmp := compare(sorted[i][fid], sorted[j][fid])
+			if cmp == 0 {
+				continue
+			}
+			if k < len(order) && order[k] == 'D' {
+				return cmp > 0
+			}
+			return cmp < 0
+		}
+		return false
+	})
+	if skip > len(sorted) {
+		skip = len(sorted)
+	}
+	sorted = sorted[skip:]
+	if num >= 0 && num < len(sorted) {
+		sorted = sorted[:num]
+	}
+	return sorted, nil
+}
+
+// compare compares two values numerically if both are numbers, else
+// case-insensitively as text.
+func compare(a, b string) int {
+	if f, err := strconv.ParseFloat(a, 64); err == nil {
+		if g, err := strconv.ParseFloat(b, 64); err == nil {
+			switch {
+			case f < g:
+				return -1
+			case f > g:
+				return 1
+			}
+			return 0
+		}
+	}
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
@@ -0,0 +1,256 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbasetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"sync"
+)
+
+// An Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is the scrubbed form of a request.
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	Url    string      `json:"url"`
+	Header http.Header `json:"header"`
+	Body   string      `json:"body"`
+}
+
+// RecordedResponse is the scrubbed form of a response.
+type RecordedResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header"`
+	Body       string      `json:"body"`
+}
+
+// A Recorder is an http.RoundTripper which either records the
+// requests made through it, and their responses, to a fixture file,
+// or replays the responses from such a file without touching the
+// network.  Credentials (tickets, passwords, tokens and the like) are
+// scrubbed before anything is written.
+//
+// To record, set quickbase.Transport (or restapi.Client's HttpClient
+// transport) to NewRecorder(file, nil), run against a live realm and
+// call Save.  To replay, use LoadRecorder(file) instead.
+type Recorder struct {
+	file      string
+	transport http.RoundTripper // nil when replaying
+
+	mutex        sync.Mutex
+	interactions []Interaction
+	used         []bool
+}
+
+// NewRecorder returns a Recorder which passes requests to transport
+// (http.DefaultTransport if nil), recording them for Save to write to
+// file.
+func NewRecorder(file string, transport http.RoundTripper) *Recorder {
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	return &Recorder{file: file, transport: transport}
+}
+
+// LoadRecorder returns a Recorder which replays the interactions
+// recorded in file.  A request which matches no unused recorded
+// interaction fails; the parameters of a request may be written in any
+// order.
+func LoadRecorder(file string) (recorder *Recorder, err error) {
+	contents, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	recorder = &Recorder{file: file}
+	if err = json.Unmarshal(contents, &recorder.interactions); err != nil {
+		return nil, fmt.Errorf("%s: %s", file, err)
+	}
+	recorder.used = make([]bool, len(recorder.interactions))
+	return recorder, nil
+}
+
+// Interactions returns the interactions recorded or loaded so far.
+func (r *Recorder) Interactions() []Interaction {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return append([]Interaction(nil), r.interactions...)
+}
+
+// Save writes the recorded interactions to the Recorder's file.
+func (r *Recorder) Save() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	contents, err := json.MarshalIndent(r.interactions, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.file, append(contents, '\n'), os.FileMode(0644))
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (resp *http.Response, err error) {
+	var body []byte
+	if req.Body != nil {
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	recorded := RecordedRequest{
+		Method: req.Method,
+		Url:    Scrub(req.URL.String()),
+		Header: scrubHeader(req.Header),
+		Body:   Scrub(string(body)),
+	}
+	if r.transport == nil {
+		return r.replay(req, recorded)
+	}
+	if resp, err = r.transport.RoundTrip(req); err != nil {
+		return nil, err
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+	r.mutex.Lock()
+	r.interactions = append(r.interactions, Interaction{recorded, RecordedResponse{
+		StatusCode: resp.StatusCode,
+		Header:     scrubHeader(resp.Header),
+		Body:       Scrub(string(respBody)),
+	}})
+	r.mutex.Unlock()
+	return resp, nil
+}
+
+func (r *Recorder) replay(req *http.Request, recorded RecordedRequest) (resp *http.Response, err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for i, interaction := range r.interactions {
+		if r.used[i] || !matches(interaction.Request, recorded) {
+			continue
+		}
+		r.used[i] = true
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.StatusCode, http.StatusText(interaction.Response.StatusCode)),
+			StatusCode:    interaction.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        interaction.Response.Header,
+			Body:          ioutil.NopCloser(bytes.NewReader([]byte(interaction.Response.Body))),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("No recorded interaction in %s matches %s %s (%s)", r.file, recorded.Method, recorded.Url,
+		recorded.Header.Get("QUICKBASE-ACTION"))
+}
+
+func matches(a, b RecordedRequest) bool {
+	return a.Method == b.Method && a.Url == b.Url && sameBody(a.Body, b.Body) &&
+		a.Header.Get("QUICKBASE-ACTION") == b.Header.Get("QUICKBASE-ACTION")
+}
+
+// sameBody reports whether two request bodies are the same call: equal,
+// or the same parameters of an XML API call or the same JSON, in
+// whatever order they were written.
+func sameBody(a, b string) bool {
+	if a == b {
+		return true
+	}
+	if aParams, ok := xmlParams(a); ok {
+		bParams, ok := xmlParams(b)
+		return ok && reflect.DeepEqual(aParams, bParams)
+	}
+	var aJson, bJson interface{}
+	return json.Unmarshal([]byte(a), &aJson) == nil && json.Unmarshal([]byte(b), &bJson) == nil &&
+		reflect.DeepEqual(aJson, bJson)
+}
+
+// xmlParams returns the parameters of an XML API call's body, each
+// written with its attributes and value, in sorted order.
+func xmlParams(body string) (params []string, ok bool) {
+	var request struct {
+		Params []struct {
+			XMLName xml.Name
+			Attrs   []xml.Attr `xml:",any,attr"`
+			Value   string     `xml:",innerxml"`
+		} `xml:",any"`
+	}
+	if xml.Unmarshal([]byte(body), &request) != nil {
+		return nil, false
+	}
+	for _, param := range request.Params {
+		attrs := make([]string, len(param.Attrs))
+		for i, attr := range param.Attrs {
+			attrs[i] = fmt.Sprintf("%s=%q", attr.Name.Local, attr.Value)
+		}
+		sort.Strings(attrs)
+		params = append(params, fmt.Sprintf("%s %v %q", param.XMLName.Local, attrs, param.Value))
+	}
+	sort.Strings(params)
+	return params, true
+}
+
+const redacted = "REDACTED"
+
+var (
+	xmlCredentials   = regexp.MustCompile(`<(ticket|password|apptoken|usertoken)>[^<]*</(ticket|password|apptoken|usertoken)>`)
+	queryCredentials = regexp.MustCompile(`\b(ticket|password|apptoken|usertoken)=[^&]*`)
+	jsonCredentials  = regexp.MustCompile(`"(token|temporaryAuthorization|password)"\s*:\s*"[^"]*"`)
+	scrubbedHeaders  = []string{"Authorization", "QB-App-Token", "Cookie", "Set-Cookie"}
+)
+
+// Scrub replaces the credentials in a URL or request or response body
+// with REDACTED.
+func Scrub(s string) string {
+	s = xmlCredentials.ReplaceAllString(s, "<$1>"+redacted+"</$2>")
+	s = queryCredentials.ReplaceAllString(s, "$1="+redacted)
+	return jsonCredentials.ReplaceAllString(s, `"$1": "`+redacted+`"`)
+}
+
+func scrubHeader(header http.Header) http.Header {
+	scrubbed := make(http.Header, len(header))
+	for key, values := range header {
+		scrubbed[key] = append([]string(nil), values...)
+	}
+	for _, key := range scrubbedHeaders {
+		if scrubbed.Get(key) != "" {
+			scrubbed.Set(key, redacted)
+		}
+	}
+	return scrubbed
+}
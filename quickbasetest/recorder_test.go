@@ -0,0 +1,70 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbasetest_test
+
+import (
+	"encoding/json"
+	"github.com/WesTower/quickbase/quickbasetest"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecorderParameterOrder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "quickbasetest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fixture := filepath.Join(dir, "fixture.json")
+	interactions := []quickbasetest.Interaction{{
+		Request: quickbasetest.RecordedRequest{
+			Method: "POST",
+			Url:    "https://example.quickbase.com/db/bck7gp3q2",
+			Header: http.Header{"Quickbase-Action": {"API_EditRecord"}},
+			Body:   `<qdbapi><rid>1</rid><field fid="6">Boise</field><field fid="7">3</field></qdbapi>`,
+		},
+		Response: quickbasetest.RecordedResponse{StatusCode: 200, Body: "<qdbapi><errcode>0</errcode></qdbapi>"},
+	}}
+	contents, _ := json.Marshal(interactions)
+	if err = ioutil.WriteFile(fixture, contents, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, body := range []string{
+		`<qdbapi><rid>1</rid><field fid="6">Tempe</field><field fid="7">3</field></qdbapi>`,
+		`<qdbapi><field fid="7">3</field><rid>1</rid><field fid="6">Boise</field></qdbapi>`,
+	} {
+		recorder, err := quickbasetest.LoadRecorder(fixture)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req, _ := http.NewRequest("POST", interactions[0].Request.Url, strings.NewReader(body))
+		req.Header.Set("QUICKBASE-ACTION", "API_EditRecord")
+		resp, err := recorder.RoundTrip(req)
+		if matched := err == nil; matched != strings.Contains(body, "Boise") {
+			t.Errorf("replaying %s gave %v, %v", body, resp, err)
+		}
+	}
+}
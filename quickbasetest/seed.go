@@ -0,0 +1,190 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbasetest
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Generator makes random but plausible records for a table, for
+// load testing and for populating demo applications.  Values suit
+// each field's type: numbers for numeric fields, dates within the
+// last year for dates, one of the field's choices for a
+// multiple-choice field, and so on.  Required fields are always
+// filled in and unique fields never repeat a value.
+//
+// Built-in, virtual, lookup and summary fields are never generated,
+// nor are fields whose values must refer to something which exists,
+// such as user and reference fields, unless they are given Values.
+type Generator struct {
+	Schema quickbase.Schema
+	// Values, if it has an entry for a field, lists the values from
+	// which the field's are chosen, e.g. the keys of parent records
+	// for a reference field or the e-mail addresses of users.
+	Values map[int][]string
+	// BlankRate is the fraction of values of optional fields left
+	// empty.
+	BlankRate float64
+
+	random *rand.Rand
+	serial int
+}
+
+// NewGenerator returns a Generator for the table described by
+// schema.  Generators given the same seed make the same records.
+func NewGenerator(schema quickbase.Schema, seed int64) *Generator {
+	return &Generator{
+		Schema:    schema,
+		Values:    make(map[int][]string),
+		BlankRate: 0.1,
+		random:    rand.New(rand.NewSource(seed)),
+	}
+}
+
+// Fields returns the fields for which the Generator makes values.
+func (g *Generator) Fields() (fields []quickbase.Field) {
+	for _, field := range g.Schema.Fields {
+		if _, ok := g.Values[field.Id]; ok {
+			fields = append(fields, field)
+		} else if field.Id > 5 && field.Mode == "" && field.ParentDbid == "" && generated(field.Type) {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+func generated(fieldType string) bool {
+	switch fieldType {
+	case "userid", "multiuserid", "file", "recordid", "dblink", "formula":
+		return false
+	}
+	return true
+}
+
+// Record returns a new random record, by field ID.
+func (g *Generator) Record() (record map[int]string) {
+	g.serial++
+	record = make(map[int]string)
+	for _, field := range g.Fields() {
+		if !field.Required && !field.Unique && g.random.Float64() < g.BlankRate {
+			continue
+		}
+		record[field.Id] = g.value(field)
+	}
+	return record
+}
+
+var words = strings.Fields(`alpha bravo charlie delta echo foxtrot golf hotel
+india juliet kilo lima mike november oscar papa quebec romeo sierra tango
+uniform victor whiskey xray yankee zulu north south east west tower site
+cable fiber relay crew route`)
+
+func (g *Generator) words(n int) string {
+	chosen := make([]string, n)
+	for i := range chosen {
+		chosen[i] = words[g.random.Intn(len(words))]
+	}
+	return strings.Join(chosen, " ")
+}
+
+// value returns a random value for a field; unique fields have the
+// record's serial number worked in.
+func (g *Generator) value(field quickbase.Field) string {
+	if values := g.Values[field.Id]; len(values) > 0 {
+		return values[g.random.Intn(len(values))]
+	}
+	if len(field.Choices) > 0 && !field.Unique {
+		return field.Choices[g.random.Intn(len(field.Choices))]
+	}
+	day := 24 * time.Hour
+	switch field.Type {
+	case "float", "numeric", "rating":
+		if field.Unique {
+			return strconv.Itoa(g.serial)
+		}
+		return strconv.Itoa(g.random.Intn(1000))
+	case "currency":
+		return fmt.Sprintf("%d.%02d", g.random.Intn(10000), g.random.Intn(100))
+	case "percent":
+		return fmt.Sprintf("%.2f", g.random.Float64())
+	case "checkbox":
+		return strconv.Itoa(g.random.Intn(2))
+	case "date":
+		t := time.Now().Add(-time.Duration(g.random.Intn(365)) * day).Truncate(day)
+		return strconv.FormatInt(msecs(t), 10)
+	case "timestamp":
+		t := time.Now().Add(-time.Duration(g.random.Int63n(int64(365 * day))))
+		return strconv.FormatInt(msecs(t), 10)
+	case "timeofday", "duration":
+		return strconv.FormatInt(g.random.Int63n(int64(day/time.Millisecond)), 10)
+	case "email":
+		return fmt.Sprintf("%s.%d@example.com", words[g.random.Intn(len(words))], g.serial)
+	case "url":
+		return fmt.Sprintf("https://example.com/%s/%d", words[g.random.Intn(len(words))], g.serial)
+	case "phone":
+		return fmt.Sprintf("(555) %03d-%04d", g.random.Intn(1000), g.random.Intn(10000))
+	case "multitext":
+		return strings.Title(g.words(8+g.random.Intn(8))) + "."
+	}
+	if field.Unique {
+		return fmt.Sprintf("%s %d", strings.Title(g.words(2)), g.serial)
+	}
+	return strings.Title(g.words(1 + g.random.Intn(3)))
+}
+
+// Populate adds n generated records to the Generator's table, with
+// an API_ImportFromCSV call for each batch of batchSize.
+func (g *Generator) Populate(qb quickbase.QuickBase, n, batchSize int) (err error) {
+	if batchSize < 1 {
+		return fmt.Errorf("Batch size must be positive")
+	}
+	fields := g.Fields()
+	fids := make([]int, len(fields))
+	header := make([]string, len(fields))
+	for i, field := range fields {
+		fids[i], header[i] = field.Id, field.Label
+	}
+	for done := 0; done < n; done += batchSize {
+		var buf bytes.Buffer
+		w := csv.NewWriter(&buf)
+		w.Write(header) // skipped by ImportFromCSV
+		for i := done; i < n && i < done+batchSize; i++ {
+			record := g.Record()
+			row := make([]string, len(fids))
+			for j, fid := range fids {
+				row[j] = record[fid]
+			}
+			w.Write(row)
+		}
+		w.Flush()
+		if err = qb.ImportFromCSV(g.Schema.Dbid, fids, &buf); err != nil {
+			return fmt.Errorf("Records %d on: %s", done+1, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbasetest_test
+
+import (
+	"github.com/WesTower/quickbase"
+	"github.com/WesTower/quickbase/quickbasetest"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+var workOrders = quickbase.Schema{
+	Dbid: "bck7gp3q3",
+	Fields: []quickbase.Field{
+		{Id: 3, Label: "Record ID#", Type: "recordid"},
+		{Id: 6, Label: "Order", Type: "text", Unique: true, Required: true},
+		{Id: 7, Label: "Status", Type: "text", Required: true, Choices: []string{"Open", "Closed"}},
+		{Id: 8, Label: "Cost", Type: "currency"},
+		{Id: 9, Label: "Due", Type: "date"},
+		{Id: 10, Label: "Site", Type: "float", ParentDbid: sites},
+		{Id: 11, Label: "Total", Type: "float", Mode: "virtual"},
+		{Id: 12, Label: "Assignee", Type: "userid"},
+	},
+}
+
+func TestGenerator(t *testing.T) {
+	g := quickbasetest.NewGenerator(workOrders, 1)
+	g.Values[10] = []string{"1", "2"}
+	var fids []int
+	for _, field := range g.Fields() {
+		fids = append(fids, field.Id)
+	}
+	if !reflect.DeepEqual(fids, []int{6, 7, 8, 9, 10}) {
+		t.Errorf("generated fields are %v", fids)
+	}
+	orders := make(map[string]bool)
+	for i := 0; i < 200; i++ {
+		record := g.Record()
+		if orders[record[6]] || record[6] == "" {
+			t.Fatalf("unique field repeated or blank: %q", record[6])
+		}
+		orders[record[6]] = true
+		if status := record[7]; status != "Open" && status != "Closed" {
+			t.Fatalf("Status is %q", status)
+		}
+		if site := record[10]; site != "" && site != "1" && site != "2" {
+			t.Fatalf("Site is %q", site)
+		}
+		if due := record[9]; due != "" {
+			if _, err := strconv.ParseInt(due, 10, 64); err != nil {
+				t.Fatalf("Due is %q", due)
+			}
+		}
+	}
+	if a, b := quickbasetest.NewGenerator(workOrders, 7).Record(), quickbasetest.NewGenerator(workOrders, 7).Record(); !reflect.DeepEqual(a, b) {
+		t.Errorf("the same seed gave %v and %v", a, b)
+	}
+}
+
+func TestGeneratorPopulate(t *testing.T) {
+	fake := quickbasetest.NewFake()
+	fake.AddTable(workOrders.Dbid, map[int]string{6: "Order", 7: "Status", 8: "Cost", 9: "Due"}).KeyFid = 6
+	if err := quickbasetest.NewGenerator(workOrders, 1).Populate(fake, 25, 10); err != nil {
+		t.Fatal(err)
+	}
+	if records := fake.Records(workOrders.Dbid); len(records) != 25 {
+		t.Errorf("Populate added %d records", len(records))
+	}
+}
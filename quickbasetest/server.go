@@ -0,0 +1,1564 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+// Package quickbasetest provides a fake QuickBase server for testing
+// code which uses package quickbase, without live credentials.
+//
+// The server understands the qdbapi XML protocol for the common
+// actions: API_Authenticate, API_DoQuery, API_DoQueryCount,
+// API_GenResultsTable (as CSV), API_AddRecord, API_EditRecord,
+// API_DeleteRecord, API_PurgeRecords, API_ImportFromCSV,
+// API_GetRecordInfo, API_GetSchema, API_GrantedDBs, API_GetUserInfo,
+// API_ProvisionUser, API_SendInvitation, API_UserRoles,
+// API_GetRoleInfo, API_AddUserToRole, API_RemoveUserFromRole,
+// API_ChangeUserRole, API_SetFieldProperties (of labels),
+// API_AddField, API_DeleteField, API_FieldAddChoices,
+// API_CreateTable, API_CreateDatabase, API_CloneDatabase,
+// API_DeleteDatabase, API_GetDBvar, API_SetDBvar, API_ListDBPages,
+// API_GetDBPage, API_AddReplaceDBPage and API_GetAppDTMInfo.  Queries
+// support criteria of the form {'fid'.OP.'value'} with the operators
+// EX, XEX, CT, XCT, SW, LT, LTE, GT and GTE, and for dates given in
+// milliseconds AF, OAF, BF and OBF, joined by AND and OR and grouped
+// by parentheses.
+// Requests may be gzipped.
+//
+// A typical test looks like:
+//
+//	server := quickbasetest.NewServer()
+//	defer server.Close()
+//	server.AddUser("jdoe", "secret")
+//	server.AddTable("bck7gp3q2", map[int]string{6: "Site", 7: "Cost"})
+//	ticket, err := quickbase.Authenticate(server.BaseUrl(), "jdoe", "secret")
+//
+// Code written against the quickbase.QuickBase interface may instead
+// be tested with a Fake, which behaves the same way without any HTTP.
+//
+// A Server may also stand in for a shared QuickBase sandbox in
+// integration environments: NewServerAt listens on a fixed address,
+// Persist keeps the realm's data on disk between runs, and SetLatency,
+// SetFailureRate and FailNext make it slow or unreliable.  Command
+// qbemulator runs such a Server as a standalone process.
+//
+// A Generator fills a table with random but plausible records, for
+// load testing and demonstrations.
+//
+// RunContractTests checks any implementation of quickbase.QuickBase,
+// including a Client connected to a sandbox table, against the
+// behaviour of QuickBase.  A FaultTransport makes a Client's calls
+// fail as QuickBase's sometimes do, to test applications' handling of
+// those failures.
+package quickbasetest
+
+import (
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A Server is a fake QuickBase realm.
+type Server struct {
+	*httptest.Server
+
+	mutex    sync.Mutex
+	users    map[string]user   // by username
+	tickets  map[string]string // ticket → username
+	tokens   map[string]string // user token → username
+	tables   map[string]*Table // by dbid
+	apps     map[string][]string
+	appNames map[string]string                 // by app dbid; if absent, the dbid
+	dbvars   map[string]map[string]string      // app dbid → name → value
+	pages    map[string][]*dbPage              // by app dbid
+	roles    map[string]map[string][]roleGrant // app dbid → user ID → roles
+	nextUid  int
+	nextDbid int
+	issued   int // tickets issued
+
+	latency     time.Duration
+	jitter      time.Duration
+	failureRate float64
+	failures    map[string][]apiError // injected errors by action
+	random      *rand.Rand
+	stateFile   string
+}
+
+// A dbPage is a page stored in an application.
+type dbPage struct {
+	Id   int    `json:"id"`
+	Name string `json:"name"`
+	Type int    `json:"type"`
+	Body string `json:"body"`
+}
+
+type user struct {
+	id        string
+	password  string // empty for a provisioned user, who cannot sign in
+	firstName string
+	lastName  string
+	invited   bool
+}
+
+// A Report is the name, columns and sort order of a saved query, for
+// qname and for a qid given without clist or slist.  A saved query
+// without one is named 'Query N' after its ID.
+type Report struct {
+	Name  string
+	Clist string
+	Slist string
+}
+
+// A Table is a table of the fake realm.
+type Table struct {
+	Dbid     string
+	Name     string
+	Fields   map[int]string   // field ID → label; 1-5 are always present
+	Types    map[int]string   // field ID → field_type, e.g. "float"; if absent, "text"
+	Formulas map[int]string   // field ID → formula, for API_GetSchema
+	Choices  map[int][]string // field ID → multiple choices, for API_GetSchema
+	Records  map[int]map[int]string
+	KeyFid   int            // the key field; if zero, Record ID# (3)
+	Queries  map[int]string // saved queries by query ID, for qid
+	Reports  map[int]Report // the names, columns and sort orders of saved queries
+
+	// Set by API_CreateTable
+	Pnoun       string
+	Description string
+	Icon        string
+
+	nextRid      int
+	nextUpdateId int
+	updateIds    map[int]int
+	modified     time.Time
+}
+
+// NewServer starts and returns a new Server, which the caller should
+// Close when finished.
+func NewServer() *Server {
+	s := newServer()
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// NewServerAt is like NewServer, but listens on the given TCP
+// address, such as 'localhost:8080', rather than on a random port.
+func NewServerAt(addr string) (s *Server, err error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s = newServer()
+	s.Server = &httptest.Server{Listener: listener, Config: &http.Server{Handler: s}}
+	s.Start()
+	return s, nil
+}
+
+func newServer() *Server {
+	return &Server{
+		users:    make(map[string]user),
+		tickets:  make(map[string]string),
+		tokens:   make(map[string]string),
+		tables:   make(map[string]*Table),
+		apps:     make(map[string][]string),
+		appNames: make(map[string]string),
+		dbvars:   make(map[string]map[string]string),
+		pages:    make(map[string][]*dbPage),
+		roles:    make(map[string]map[string][]roleGrant),
+		failures: make(map[string][]apiError),
+		random:   rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// BaseUrl returns the URL to pass to quickbase.Authenticate, with the
+// trailing slash it requires.
+func (s *Server) BaseUrl() string {
+	return s.URL + "/"
+}
+
+// AddUser adds a user who may authenticate.
+func (s *Server) AddUser(username, password string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.nextUid++
+	s.users[username] = user{id: fmt.Sprintf("%d.fake", 50000+s.nextUid), password: password}
+}
+
+// AddUserToken adds a user token with which a user may make calls.
+func (s *Server) AddUserToken(username, token string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.tokens[token] = username
+}
+
+// ExpireTickets expires every ticket issued so far, so that calls
+// made with them fail as calls with a stale ticket do.
+func (s *Server) ExpireTickets() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.tickets = make(map[string]string)
+}
+
+// AddTable adds a table with the given fields, in addition to the
+// built-in fields 1 to 5 (Date Created, Date Modified, Record ID#,
+// Record Owner and Last Modified By).
+func (s *Server) AddTable(dbid string, fields map[int]string) *Table {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	table := newTable(dbid, fields)
+	s.tables[dbid] = table
+	return table
+}
+
+// AddApp adds an application holding the given tables, for
+// API_GetAppDTMInfo and API_UserRoles.
+func (s *Server) AddApp(dbid string, tableDbids ...string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.apps[dbid] = tableDbids
+}
+
+// Seed adds a record to a table directly, returning its record ID.
+func (s *Server) Seed(dbid string, record map[int]string) (rid int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	rid, err := s.tables[dbid].add(record)
+	if err != nil {
+		panic(err)
+	}
+	return rid
+}
+
+// Records returns a copy of a table's records, in record ID order.
+func (s *Server) Records(dbid string) (records []map[int]string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	table := s.tables[dbid]
+	for _, rid := range table.rids() {
+		record := make(map[int]string)
+		for fid, value := range table.Records[rid] {
+			record[fid] = value
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func newTable(dbid string, fields map[int]string) *Table {
+	table := &Table{
+		Dbid:      dbid,
+		Name:      dbid,
+		Fields:    map[int]string{1: "Date Created", 2: "Date Modified", 3: "Record ID#", 4: "Record Owner", 5: "Last Modified By"},
+		Types:     make(map[int]string),
+		Formulas:  make(map[int]string),
+		Choices:   make(map[int][]string),
+		Records:   make(map[int]map[int]string),
+		Queries:   make(map[int]string),
+		Reports:   make(map[int]Report),
+		nextRid:   1,
+		updateIds: make(map[int]int),
+		modified:  time.Now(),
+	}
+	for fid, label := range fields {
+		table.Fields[fid] = label
+	}
+	return table
+}
+
+func (t *Table) keyFid() int {
+	if t.KeyFid == 0 {
+		return 3
+	}
+	return t.KeyFid
+}
+
+// add stores a new record, which must not duplicate the key of an
+// existing one.
+func (t *Table) add(record map[int]string) (rid int, err error) {
+	if key := t.keyFid(); key != 3 {
+		if _, ok := t.ridByKey(record[key]); ok {
+			return 0, errDuplicateKey
+		}
+	}
+	rid = t.nextRid
+	t.nextRid++
+	stored := map[int]string{3: strconv.Itoa(rid)}
+	now := strconv.FormatInt(msecs(time.Now()), 10)
+	stored[1], stored[2] = now, now
+	for fid, value := range record {
+		if fid != 3 {
+			stored[fid] = value
+		}
+	}
+	t.Records[rid] = stored
+	t.touch(rid)
+	return rid, nil
+}
+
+// edit changes the fields of an existing record.
+func (t *Table) edit(rid int, record map[int]string) (err error) {
+	stored, ok := t.Records[rid]
+	if !ok {
+		return errNoSuchRecord
+	}
+	if key := t.keyFid(); key != 3 {
+		if value, ok := record[key]; ok {
+			if other, ok := t.ridByKey(value); ok && other != rid {
+				return errDuplicateKey
+			}
+		}
+	}
+	for fid, value := range record {
+		if fid != 3 {
+			stored[fid] = value
+		}
+	}
+	stored[2] = strconv.FormatInt(msecs(time.Now()), 10)
+	t.touch(rid)
+	return nil
+}
+
+// touch gives a record a new update ID.
+func (t *Table) touch(rid int) {
+	t.nextUpdateId++
+	t.updateIds[rid] = t.nextUpdateId
+	t.modified = time.Now()
+}
+
+// ridByKey returns the record whose key field has the given value.
+func (t *Table) ridByKey(value string) (rid int, ok bool) {
+	key := t.keyFid()
+	if key == 3 {
+		rid, err := strconv.Atoi(value)
+		_, ok = t.Records[rid]
+		return rid, err == nil && ok
+	}
+	for rid, record := range t.Records {
+		if record[key] == value && value != "" {
+			return rid, true
+		}
+	}
+	return 0, false
+}
+
+// ridByField returns the record whose field fid has the given value.
+func (t *Table) ridByField(fid int, value string) (rid int, ok bool) {
+	for rid, record := range t.Records {
+		if record[fid] == value {
+			return rid, true
+		}
+	}
+	return 0, false
+}
+
+func (t *Table) rids() (rids []int) {
+	for rid := range t.Records {
+		rids = append(rids, rid)
+	}
+	sort.Ints(rids)
+	return rids
+}
+
+// fidByLabel returns the field ID with the given label or XML tag.
+func (t *Table) fidByLabel(label string) (fid int, ok bool) {
+	for fid, l := range t.Fields {
+		if l == label || tag(l) == label {
+			return fid, true
+		}
+	}
+	return 0, false
+}
+
+// tag converts a field label to the XML tag API_DoQuery uses for it.
+var tag = quickbase.FieldTag
+
+// request is a parsed qdbapi request.
+type request struct {
+	params map[string]string
+	fields []fieldParam
+}
+
+type fieldParam struct {
+	fid   string
+	name  string
+	value string
+}
+
+func parseRequest(r io.Reader) (req request, err error) {
+	req.params = make(map[string]string)
+	decoder := xml.NewDecoder(r)
+	depth := 0
+	var name string
+	var attrs []xml.Attr
+	var value string
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return req, nil
+		}
+		if err != nil {
+			return req, err
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			depth++
+			if depth == 2 {
+				name, attrs, value = token.Name.Local, token.Attr, ""
+			}
+		case xml.CharData:
+			if depth == 2 {
+				value += string(token)
+			}
+		case xml.EndElement:
+			if depth == 2 {
+				if name == "field" {
+					field := fieldParam{value: value}
+					for _, attr := range attrs {
+						switch attr.Name.Local {
+						case "fid":
+							field.fid = attr.Value
+						case "name":
+							field.name = attr.Value
+						}
+					}
+					req.fields = append(req.fields, field)
+				} else {
+					req.params[name] = value
+				}
+			}
+			depth--
+		}
+	}
+}
+
+// apiError is a QuickBase error to be returned in a response.
+type apiError struct {
+	code int
+	text string
+}
+
+func (e apiError) Error() string {
+	return e.text
+}
+
+var (
+	errBadTicket      = apiError{4, "User not authorized"}
+	errNoSuchTable    = apiError{32, "No such database"}
+	errMissingValue   = apiError{50, "Missing required value"}
+	errNoSuchRecord   = apiError{30, "No such record"}
+	errNoSuchQuery    = apiError{33, "No such query"}
+	errBadQuery       = apiError{14, "Bad query"}
+	errUnknownAction  = apiError{11, "Unknown action"}
+	errNoSuchPage     = apiError{2, "Invalid input: no such page"}
+	errDuplicateKey   = apiError{31, "Duplicate value in key field"}
+	errUpdateConflict = apiError{60, "Update conflict detected"}
+)
+
+// ServeHTTP answers a qdbapi request.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	in := r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		in = gz
+	}
+	req, err := parseRequest(in)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	action := r.Header.Get("QUICKBASE-ACTION")
+	dbid := strings.TrimPrefix(r.URL.Path, "/db/")
+	s.mutex.Lock()
+	delay, unavailable, injected := s.fault(action)
+	s.mutex.Unlock()
+	time.Sleep(delay)
+	if unavailable {
+		http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	var body string
+	s.mutex.Lock()
+	if injected != nil {
+		err = *injected
+	} else if body, err = s.handle(action, dbid, req); err == nil && writes(action) {
+		if err := s.persist(); err != nil {
+			s.mutex.Unlock()
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	s.mutex.Unlock()
+	if action == "API_GenResultsTable" && err == nil {
+		w.Header().Set("Content-Type", "text/csv")
+		fmt.Fprint(w, body)
+		return
+	}
+	if action == "API_GetDBPage" && err == nil {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, body)
+		return
+	}
+	w.Header().Set("Content-Type", "text/xml")
+	fmt.Fprintf(w, `<?xml version="1.0" ?>`+"\n<qdbapi><action>%s</action>", escape(action))
+	switch err := err.(type) {
+	case nil:
+		fmt.Fprint(w, "<errcode>0</errcode><errtext>No error</errtext>", body)
+	case apiError:
+		fmt.Fprintf(w, "<errcode>%d</errcode><errtext>%s</errtext>", err.code, escape(err.text))
+	default:
+		fmt.Fprintf(w, "<errcode>%d</errcode><errtext>%s</errtext>", errBadQuery.code, escape(err.Error()))
+	}
+	fmt.Fprint(w, "</qdbapi>")
+}
+
+func (s *Server) handle(action, dbid string, req request) (body string, err error) {
+	if action == "API_Authenticate" {
+		return s.authenticate(req)
+	}
+	if action == "API_GetAppDTMInfo" {
+		return s.getAppDTMInfo(req)
+	}
+	username, ticketOk := s.tickets[req.params["ticket"]]
+	if !ticketOk {
+		var tokenOk bool
+		if username, tokenOk = s.tokens[req.params["usertoken"]]; !tokenOk {
+			return "", errBadTicket
+		}
+	}
+	if action == "API_GetUserInfo" {
+		if email := req.params["email"]; email != "" {
+			if _, ok := s.users[email]; !ok {
+				return "", apiError{2, "Invalid input: no user with email " + email}
+			}
+			username = email
+		}
+		return s.getUserInfo(username)
+	}
+	if action == "API_UserRoles" {
+		return s.userRoles(dbid)
+	}
+	if action == "API_CreateDatabase" {
+		return s.createDatabase(req)
+	}
+	if action == "API_GrantedDBs" {
+		return s.grantedDBs(req), nil
+	}
+	if tables, ok := s.apps[dbid]; ok {
+		switch action {
+		case "API_GetSchema":
+			return s.getAppSchema(dbid, tables), nil
+		case "API_CreateTable":
+			return s.createTable(dbid, req)
+		case "API_ProvisionUser":
+			return s.provisionUser(dbid, req)
+		case "API_SendInvitation":
+			return s.sendInvitation(req)
+		case "API_GetRoleInfo":
+			return getRoleInfo(), nil
+		case "API_AddUserToRole", "API_RemoveUserFromRole", "API_ChangeUserRole":
+			return s.changeRoles(dbid, action, req)
+		case "API_GetDBvar":
+			value, ok := s.dbvars[dbid][req.params["varname"]]
+			if !ok {
+				return "", apiError{31, "No such variable " + req.params["varname"]}
+			}
+			return fmt.Sprintf("<value>%s</value>", escape(value)), nil
+		case "API_SetDBvar":
+			if req.params["varname"] == "" {
+				return "", apiError{2, "Invalid input: missing varname"}
+			}
+			if s.dbvars[dbid] == nil {
+				s.dbvars[dbid] = make(map[string]string)
+			}
+			s.dbvars[dbid][req.params["varname"]] = req.params["value"]
+			return "", nil
+		case "API_ListDBPages":
+			return s.listDBPages(dbid), nil
+		case "API_GetDBPage":
+			page := s.findPage(dbid, req.params["pageID"])
+			if page == nil {
+				return "", errNoSuchPage
+			}
+			return page.Body, nil
+		case "API_AddReplaceDBPage":
+			return s.addReplaceDBPage(dbid, req)
+		case "API_CloneDatabase":
+			return s.cloneDatabase(dbid, req)
+		case "API_DeleteDatabase":
+			for _, table := range tables {
+				delete(s.tables, table)
+			}
+			delete(s.apps, dbid)
+			delete(s.appNames, dbid)
+			delete(s.dbvars, dbid)
+			delete(s.pages, dbid)
+			delete(s.roles, dbid)
+			return "", nil
+		}
+	}
+	table, ok := s.tables[dbid]
+	if !ok {
+		return "", errNoSuchTable
+	}
+	switch action {
+	case "API_DoQuery":
+		return doQuery(table, req)
+	case "API_GenResultsTable":
+		return genResultsTable(table, req)
+	case "API_DoQueryCount":
+		records, err := query(table, req.params["query"])
+		return fmt.Sprintf("<numMatches>%d</numMatches>", len(records)), err
+	case "API_AddRecord":
+		record, err := recordFields(table, req)
+		if err != nil {
+			return "", err
+		}
+		rid, err := table.add(record)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("<rid>%d</rid><update_id>%d</update_id>", rid, table.updateIds[rid]), nil
+	case "API_EditRecord":
+		return editRecord(table, req)
+	case "API_DeleteRecord":
+		rid, _ := strconv.Atoi(req.params["rid"])
+		if _, ok := table.Records[rid]; !ok {
+			return "", errNoSuchRecord
+		}
+		delete(table.Records, rid)
+		table.modified = time.Now()
+		return fmt.Sprintf("<rid>%d</rid>", rid), nil
+	case "API_ImportFromCSV":
+		return importFromCSV(table, req)
+	case "API_GetSchema":
+		return getSchema(table), nil
+	case "API_SetFieldProperties":
+		return setFieldProperties(table, req)
+	case "API_AddField":
+		return addField(table, req)
+	case "API_DeleteField":
+		return deleteField(table, req)
+	case "API_GetRecordInfo":
+		return getRecordInfo(table, req)
+	case "API_PurgeRecords":
+		q, _, err := table.savedQuery(req.params["qid"], req.params["qname"], req.params["query"])
+		if err != nil {
+			return "", err
+		}
+		records, err := query(table, q)
+		if err != nil {
+			return "", err
+		}
+		for _, record := range records {
+			rid, _ := strconv.Atoi(record[3])
+			delete(table.Records, rid)
+		}
+		table.modified = time.Now()
+		return fmt.Sprintf("<num_records_deleted>%d</num_records_deleted>", len(records)), nil
+	case "API_FieldAddChoices":
+		return fieldAddChoices(table, req)
+	case "API_DeleteDatabase":
+		delete(s.tables, dbid)
+		for app, tables := range s.apps {
+			s.apps[app] = removeDbid(tables, dbid)
+		}
+		return "", nil
+	}
+	return "", errUnknownAction
+}
+
+func (s *Server) authenticate(req request) (body string, err error) {
+	u, ok := s.users[req.params["username"]]
+	if !ok || u.password == "" || u.password != req.params["password"] {
+		return "", apiError{20, "Unknown username/password"}
+	}
+	s.issued++
+	ticket := fmt.Sprintf("fake_ticket_%d_%s", s.issued, u.id)
+	s.tickets[ticket] = req.params["username"]
+	return fmt.Sprintf("<ticket>%s</ticket><userid>%s</userid>", ticket, u.id), nil
+}
+
+func (s *Server) getAppDTMInfo(req request) (body string, err error) {
+	tables, ok := s.apps[req.params["dbid"]]
+	if !ok {
+		return "", errMissingValue
+	}
+	now := time.Now()
+	var b strings.Builder
+	fmt.Fprintf(&b, "<RequestTime>%d</RequestTime><RequestNextAllowedTime>%d</RequestNextAllowedTime>",
+		msecs(now), msecs(now))
+	fmt.Fprintf(&b, `<app id="%s"><lastModifiedTime>%d</lastModifiedTime><lastRecModTime>%d</lastRecModTime></app><tables>`,
+		escape(req.params["dbid"]), msecs(now), msecs(now))
+	for _, dbid := range tables {
+		modified := now
+		if table, ok := s.tables[dbid]; ok {
+			modified = table.modified
+		}
+		fmt.Fprintf(&b, `<table id="%s"><lastModifiedTime>%d</lastModifiedTime><lastRecModTime>%d</lastRecModTime></table>`,
+			escape(dbid), msecs(modified), msecs(modified))
+	}
+	b.WriteString("</tables>")
+	return b.String(), nil
+}
+
+// getUserInfo describes the user making the call.
+func (s *Server) getUserInfo(username string) (body string, err error) {
+	u := s.users[username]
+	return fmt.Sprintf(`<user id="%s"><firstName>%s</firstName><lastName>%s</lastName><login>%s</login><email>%s</email><screenName>%s</screenName><isVerified>1</isVerified></user>`,
+		escape(u.id), escape(u.firstName), escape(u.lastName), escape(username), escape(username), escape(username)), nil
+}
+
+// Invited reports whether the user with the given username, which for
+// a provisioned user is their email address, has been sent an
+// invitation.
+func (s *Server) Invited(username string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.users[username].invited
+}
+
+func (s *Server) provisionUser(appDbid string, req request) (body string, err error) {
+	email := req.params["email"]
+	if email == "" {
+		return "", apiError{2, "Invalid input: missing email"}
+	}
+	if _, ok := s.users[email]; ok {
+		return "", apiError{2, "Invalid input: " + email + " is already a user"}
+	}
+	grants := []roleGrant{{Id: 12}}
+	if roleid := req.params["roleid"]; roleid != "" {
+		r, ok := findRole(roleid)
+		if !ok {
+			return "", apiError{2, "Invalid input: no such role " + roleid}
+		}
+		grants = []roleGrant{{Id: r.id}}
+	}
+	s.nextUid++
+	u := user{id: fmt.Sprintf("%d.fake", 50000+s.nextUid), firstName: req.params["fname"], lastName: req.params["lname"]}
+	s.users[email] = u
+	if s.roles[appDbid] == nil {
+		s.roles[appDbid] = make(map[string][]roleGrant)
+	}
+	s.roles[appDbid][u.id] = grants
+	return fmt.Sprintf("<userid>%s</userid>", escape(u.id)), nil
+}
+
+func (s *Server) sendInvitation(req request) (body string, err error) {
+	for name, u := range s.users {
+		if u.id == req.params["userid"] {
+			u.invited = true
+			s.users[name] = u
+			return "", nil
+		}
+	}
+	return "", apiError{2, "Invalid input: no such user " + req.params["userid"]}
+}
+
+// A role is one of the roles every application has.
+type role struct {
+	id         int
+	name       string
+	accessId   int
+	accessName string
+}
+
+var roles = []role{
+	{11, "Viewer", 3, "Basic Access"},
+	{12, "Participant", 3, "Basic Access"},
+	{13, "Administrator", 1, "Administrator"},
+}
+
+// A roleGrant is a role given to a user in an application.  A user
+// given none has the role Participant.
+type roleGrant struct {
+	Id       int  `json:"id"`
+	NoAccess bool `json:"no_access,omitempty"` // kept in the role, without access
+}
+
+func findRole(roleid string) (r role, ok bool) {
+	for _, r = range roles {
+		if strconv.Itoa(r.id) == roleid {
+			return r, true
+		}
+	}
+	return role{}, false
+}
+
+func writeRole(b *strings.Builder, r role, noAccess bool) {
+	accessId, accessName := r.accessId, r.accessName
+	if noAccess {
+		accessId, accessName = 0, "None"
+	}
+	fmt.Fprintf(b, `<role id="%d"><name>%s</name><access id="%d">%s</access></role>`, r.id, escape(r.name), accessId, escape(accessName))
+}
+
+// grants returns the roles of a user in an application.
+func (s *Server) grants(appDbid, userid string) []roleGrant {
+	if grants, ok := s.roles[appDbid][userid]; ok {
+		return grants
+	}
+	return []roleGrant{{Id: 12}}
+}
+
+func (s *Server) userRoles(dbid string) (body string, err error) {
+	var names []string
+	for name := range s.users {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	b.WriteString("<users>")
+	for _, name := range names {
+		fmt.Fprintf(&b, `<user type="user" id="%s"><name>%s</name><roles>`, escape(s.users[name].id), escape(name))
+		for _, grant := range s.grants(dbid, s.users[name].id) {
+			r, _ := findRole(strconv.Itoa(grant.Id))
+			writeRole(&b, r, grant.NoAccess)
+		}
+		b.WriteString("</roles></user>")
+	}
+	b.WriteString("</users>")
+	return b.String(), nil
+}
+
+func getRoleInfo() string {
+	var b strings.Builder
+	b.WriteString("<roles>")
+	for _, r := range roles {
+		writeRole(&b, r, false)
+	}
+	b.WriteString("</roles>")
+	return b.String()
+}
+
+// changeRoles answers API_AddUserToRole, API_RemoveUserFromRole and
+// API_ChangeUserRole.
+func (s *Server) changeRoles(appDbid, action string, req request) (body string, err error) {
+	userid := req.params["userid"]
+	found := false
+	for _, u := range s.users {
+		found = found || u.id == userid
+	}
+	if !found {
+		return "", apiError{2, "Invalid input: no such user " + userid}
+	}
+	r, ok := findRole(req.params["roleid"])
+	if !ok {
+		return "", apiError{2, "Invalid input: no such role " + req.params["roleid"]}
+	}
+	grants := append([]roleGrant(nil), s.grants(appDbid, userid)...)
+	index := -1
+	for i, grant := range grants {
+		if grant.Id == r.id {
+			index = i
+		}
+	}
+	switch action {
+	case "API_AddUserToRole":
+		if index >= 0 {
+			return "", apiError{2, "Invalid input: user already has role " + req.params["roleid"]}
+		}
+		grants = append(grants, roleGrant{Id: r.id})
+	case "API_RemoveUserFromRole", "API_ChangeUserRole":
+		if index < 0 {
+			return "", apiError{2, "Invalid input: user does not have role " + req.params["roleid"]}
+		}
+		if action == "API_RemoveUserFromRole" {
+			grants = append(grants[:index], grants[index+1:]...)
+		} else if newRoleid := req.params["newroleid"]; newRoleid == "" {
+			grants[index].NoAccess = true
+		} else if newRole, ok := findRole(newRoleid); ok {
+			grants[index] = roleGrant{Id: newRole.id}
+		} else {
+			return "", apiError{2, "Invalid input: no such role " + newRoleid}
+		}
+	}
+	if s.roles[appDbid] == nil {
+		s.roles[appDbid] = make(map[string][]roleGrant)
+	}
+	s.roles[appDbid][userid] = grants
+	return "", nil
+}
+
+func msecs(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// recordFields collects the _fid_N and _fnm_label parameters and the
+// field elements of a request.
+func recordFields(table *Table, req request) (record map[int]string, err error) {
+	record = make(map[int]string)
+	set := func(key, value string) error {
+		switch {
+		case strings.HasPrefix(key, "_fid_"):
+			fid, err := strconv.Atoi(strings.TrimPrefix(key, "_fid_"))
+			if err != nil || table.Fields[fid] == "" {
+				return apiError{51, "No such field " + key}
+			}
+			record[fid] = value
+		case strings.HasPrefix(key, "_fnm_"):
+			fid, ok := table.fidByLabel(strings.TrimPrefix(key, "_fnm_"))
+			if !ok {
+				return apiError{51, "No such field " + key}
+			}
+			record[fid] = value
+		}
+		return nil
+	}
+	for key, value := range req.params {
+		if err := set(key, value); err != nil {
+			return nil, err
+		}
+	}
+	for _, field := range req.fields {
+		key := "_fid_" + field.fid
+		if field.fid == "" {
+			key = "_fnm_" + field.name
+		}
+		if err := set(key, field.value); err != nil {
+			return nil, err
+		}
+	}
+	return record, nil
+}
+
+func editRecord(table *Table, req request) (body string, err error) {
+	rid, _ := strconv.Atoi(req.params["rid"])
+	if updateId, ok := req.params["update_id"]; ok && updateId != strconv.Itoa(table.updateIds[rid]) {
+		if _, ok := table.Records[rid]; ok {
+			return "", errUpdateConflict
+		}
+	}
+	record, err := recordFields(table, req)
+	if err != nil {
+		return "", err
+	}
+	if err = table.edit(rid, record); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("<rid>%d</rid><num_fields_changed>%d</num_fields_changed><update_id>%d</update_id>",
+		rid, len(record), table.updateIds[rid]), nil
+}
+
+func importFromCSV(table *Table, req request) (body string, err error) {
+	var fids []int
+	for _, s := range strings.Split(req.params["clist"], ".") {
+		fid, err := strconv.Atoi(s)
+		if err != nil {
+			return "", errMissingValue
+		}
+		fids = append(fids, fid)
+	}
+	rows, err := csv.NewReader(strings.NewReader(req.params["records_csv"])).ReadAll()
+	if err != nil {
+		return "", apiError{100, "Bad CSV: " + err.Error()}
+	}
+	if req.params["skipfirst"] == "1" && len(rows) > 0 {
+		rows = rows[1:]
+	}
+	merge, _ := strconv.Atoi(req.params["mergefieldid"])
+	rids, added, updated, err := table.importRows(fids, rows, merge)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	b.WriteString("<rids>")
+	for _, rid := range rids {
+		fmt.Fprintf(&b, `<rid update_id="%d">%d</rid>`, table.updateIds[rid], rid)
+	}
+	b.WriteString("</rids>")
+	return fmt.Sprintf("<num_recs_input>%d</num_recs_input><num_recs_added>%d</num_recs_added><num_recs_updated>%d</num_recs_updated>%s",
+		len(rows), added, updated, b.String()), nil
+}
+
+// importRows adds or, if they include the key field, or the merge
+// field if it is not zero, and its value matches an existing record,
+// updates a record for each row.
+func (t *Table) importRows(fids []int, rows [][]string, merge int) (rids []int, added, updated int, err error) {
+	key := t.keyFid()
+	if merge != 0 {
+		key = merge
+	}
+	for _, row := range rows {
+		record := make(map[int]string)
+		for i, value := range row {
+			if i < len(fids) {
+				record[fids[i]] = value
+			}
+		}
+		if value, ok := record[key]; ok && value != "" {
+			rid, ok := t.ridByKey(value)
+			if merge != 0 {
+				rid, ok = t.ridByField(merge, value)
+			}
+			if ok {
+				if err = t.edit(rid, record); err != nil {
+					return nil, 0, 0, err
+				}
+				rids = append(rids, rid)
+				updated++
+				continue
+			}
+			if key == 3 {
+				return nil, 0, 0, errNoSuchRecord
+			}
+		}
+		rid, err := t.add(record)
+		if err != nil {
+			return nil, 0, 0, err
+		}
+		rids = append(rids, rid)
+		added++
+	}
+	return rids, added, updated, nil
+}
+
+// fieldType returns the field_type reported for a field.
+func (t *Table) fieldType(fid int) string {
+	if fieldType, ok := t.Types[fid]; ok {
+		return fieldType
+	}
+	switch fid {
+	case 1, 2:
+		return "timestamp"
+	case 3:
+		return "recordid"
+	case 4, 5:
+		return "userid"
+	}
+	return "text"
+}
+
+func getSchema(table *Table) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<table><name>%s</name><original><table_id>%s</table_id></original><fields>", escape(table.Name), escape(table.Dbid))
+	var fids []int
+	for fid := range table.Fields {
+		fids = append(fids, fid)
+	}
+	sort.Ints(fids)
+	for _, fid := range fids {
+		fmt.Fprintf(&b, `<field id="%d" field_type="%s" base_type="text"><label>%s</label>`, fid, table.fieldType(fid), escape(table.Fields[fid]))
+		if formula, ok := table.Formulas[fid]; ok {
+			fmt.Fprintf(&b, "<formula>%s</formula>", escape(formula))
+		}
+		if choices := table.Choices[fid]; len(choices) > 0 {
+			b.WriteString("<choices>")
+			for _, choice := range choices {
+				fmt.Fprintf(&b, "<choice>%s</choice>", escape(choice))
+			}
+			b.WriteString("</choices>")
+		}
+		b.WriteString("</field>")
+	}
+	b.WriteString("</fields><queries>")
+	var qids []int
+	for qid := range table.Queries {
+		qids = append(qids, qid)
+	}
+	sort.Ints(qids)
+	for _, qid := range qids {
+		report := table.report(qid)
+		fmt.Fprintf(&b, `<query id="%d"><qyname>%s</qyname><qytype>table</qytype><qycrit>%s</qycrit><qyclst>%s</qyclst><qyslst>%s</qyslst></query>`,
+			qid, escape(report.Name), escape(table.Queries[qid]), escape(report.Clist), escape(report.Slist))
+	}
+	b.WriteString("</queries></table>")
+	return b.String()
+}
+
+// setFieldProperties changes a field's label; its other properties
+// are not modelled.
+func setFieldProperties(table *Table, req request) (body string, err error) {
+	fid, _ := strconv.Atoi(req.params["fid"])
+	if table.Fields[fid] == "" {
+		return "", apiError{51, "No such field " + req.params["fid"]}
+	}
+	if label, ok := req.params["label"]; ok {
+		for other, existing := range table.Fields {
+			if other != fid && strings.EqualFold(existing, label) {
+				return "", apiError{2, "Invalid input: a field is already labelled " + label}
+			}
+		}
+		table.Fields[fid] = label
+		table.modified = time.Now()
+	}
+	return fmt.Sprintf("<field_id>%d</field_id>", fid), nil
+}
+
+// recordInfoTypes are the names API_GetRecordInfo gives field types.
+var recordInfoTypes = map[string]string{
+	"text":      "Text",
+	"float":     "Numeric",
+	"currency":  "Numeric-Currency",
+	"date":      "Date",
+	"timestamp": "Date / Time",
+	"checkbox":  "Checkbox",
+	"recordid":  "Record ID#",
+	"userid":    "User",
+}
+
+func getRecordInfo(table *Table, req request) (body string, err error) {
+	rid, _ := strconv.Atoi(req.params["rid"])
+	record, ok := table.Records[rid]
+	if !ok {
+		return "", errNoSuchRecord
+	}
+	var fids []int
+	for fid := range table.Fields {
+		fids = append(fids, fid)
+	}
+	sort.Ints(fids)
+	var b strings.Builder
+	fmt.Fprintf(&b, "<rid>%d</rid><num_fields>%d</num_fields><update_id>%d</update_id>", rid, len(fids), table.updateIds[rid])
+	for _, fid := range fids {
+		fieldType := table.fieldType(fid)
+		if name, ok := recordInfoTypes[fieldType]; ok {
+			fieldType = name
+		}
+		fmt.Fprintf(&b, "<field><fid>%d</fid><name>%s</name><type>%s</type><value>%s</value></field>",
+			fid, escape(table.Fields[fid]), escape(fieldType), escape(record[fid]))
+	}
+	return b.String(), nil
+}
+
+func addField(table *Table, req request) (body string, err error) {
+	label := req.params["label"]
+	if label == "" {
+		return "", apiError{2, "Invalid input: missing label"}
+	}
+	fid := 6
+	for existing, existingLabel := range table.Fields {
+		if strings.EqualFold(existingLabel, label) {
+			return "", apiError{2, "Invalid input: a field is already labelled " + label}
+		}
+		if existing >= fid {
+			fid = existing + 1
+		}
+	}
+	table.Fields[fid] = label
+	if fieldType := req.params["type"]; fieldType != "" && fieldType != "text" {
+		table.Types[fid] = fieldType
+	}
+	table.modified = time.Now()
+	return fmt.Sprintf("<fid>%d</fid><label>%s</label>", fid, escape(label)), nil
+}
+
+func deleteField(table *Table, req request) (body string, err error) {
+	fid, _ := strconv.Atoi(req.params["fid"])
+	if table.Fields[fid] == "" {
+		return "", apiError{51, "No such field " + req.params["fid"]}
+	}
+	if fid <= 5 || fid == table.KeyFid {
+		return "", apiError{2, "Invalid input: cannot delete built-in or key field " + req.params["fid"]}
+	}
+	delete(table.Fields, fid)
+	delete(table.Types, fid)
+	delete(table.Formulas, fid)
+	delete(table.Choices, fid)
+	for _, record := range table.Records {
+		delete(record, fid)
+	}
+	table.modified = time.Now()
+	return "", nil
+}
+
+func fieldAddChoices(table *Table, req request) (body string, err error) {
+	fid, _ := strconv.Atoi(req.params["fid"])
+	if table.Fields[fid] == "" {
+		return "", apiError{51, "No such field " + req.params["fid"]}
+	}
+	added := 0
+	if choice := req.params["choice"]; choice != "" {
+		found := false
+		for _, existing := range table.Choices[fid] {
+			found = found || existing == choice
+		}
+		if !found {
+			table.Choices[fid] = append(table.Choices[fid], choice)
+			added++
+		}
+	}
+	table.modified = time.Now()
+	return fmt.Sprintf("<fid>%d</fid><fname>%s</fname><numadded>%d</numadded>", fid, escape(table.Fields[fid]), added), nil
+}
+
+// newDbid returns a dbid used by no table or application.
+func (s *Server) newDbid() string {
+	for {
+		s.nextDbid++
+		dbid := fmt.Sprintf("bfake%04d", s.nextDbid)
+		if _, app := s.apps[dbid]; !app && s.tables[dbid] == nil {
+			return dbid
+		}
+	}
+}
+
+func removeDbid(dbids []string, dbid string) (remaining []string) {
+	for _, other := range dbids {
+		if other != dbid {
+			remaining = append(remaining, other)
+		}
+	}
+	return remaining
+}
+
+func (s *Server) createDatabase(req request) (body string, err error) {
+	if req.params["dbname"] == "" {
+		return "", apiError{2, "Invalid input: missing dbname"}
+	}
+	dbid := s.newDbid()
+	s.apps[dbid] = []string{}
+	s.appNames[dbid] = req.params["dbname"]
+	var apptoken string
+	if req.params["createapptoken"] == "1" {
+		apptoken = fmt.Sprintf("fake%08x", s.random.Uint32())
+	}
+	return fmt.Sprintf("<dbid>%s</dbid><appdbid>%s</appdbid><apptoken>%s</apptoken>", dbid, dbid, apptoken), nil
+}
+
+func (s *Server) cloneDatabase(dbid string, req request) (body string, err error) {
+	if req.params["newdbname"] == "" {
+		return "", apiError{2, "Invalid input: missing newdbname"}
+	}
+	newDbid := s.newDbid()
+	s.apps[newDbid] = []string{}
+	s.appNames[newDbid] = req.params["newdbname"]
+	for _, page := range s.pages[dbid] {
+		copied := *page
+		s.pages[newDbid] = append(s.pages[newDbid], &copied)
+	}
+	for name, value := range s.dbvars[dbid] {
+		if s.dbvars[newDbid] == nil {
+			s.dbvars[newDbid] = make(map[string]string)
+		}
+		s.dbvars[newDbid][name] = value
+	}
+	for _, original := range s.apps[dbid] {
+		if table, ok := s.tables[original]; ok {
+			clone := table.clone(s.newDbid(), req.params["keepData"] == "1")
+			s.tables[clone.Dbid] = clone
+			s.apps[newDbid] = append(s.apps[newDbid], clone.Dbid)
+		}
+	}
+	return fmt.Sprintf("<newdbid>%s</newdbid>", newDbid), nil
+}
+
+// clone returns a copy of the table with the given dbid, with copies of
+// its records if keepData is set.
+func (t *Table) clone(dbid string, keepData bool) *Table {
+	clone := newTable(dbid, t.Fields)
+	clone.Name, clone.KeyFid, clone.Pnoun, clone.Description, clone.Icon = t.Name, t.KeyFid, t.Pnoun, t.Description, t.Icon
+	for fid, fieldType := range t.Types {
+		clone.Types[fid] = fieldType
+	}
+	for fid, formula := range t.Formulas {
+		clone.Formulas[fid] = formula
+	}
+	for fid, choices := range t.Choices {
+		clone.Choices[fid] = append([]string(nil), choices...)
+	}
+	for qid, q := range t.Queries {
+		clone.Queries[qid] = q
+	}
+	for qid, report := range t.Reports {
+		clone.Reports[qid] = report
+	}
+	if keepData {
+		for rid, record := range t.Records {
+			copied := make(map[int]string, len(record))
+			for fid, value := range record {
+				copied[fid] = value
+			}
+			clone.Records[rid] = copied
+			clone.updateIds[rid] = t.updateIds[rid]
+		}
+		clone.nextRid, clone.nextUpdateId = t.nextRid, t.nextUpdateId
+	}
+	return clone
+}
+
+func (s *Server) listDBPages(appDbid string) string {
+	var b strings.Builder
+	b.WriteString("<pages>")
+	for _, page := range s.pages[appDbid] {
+		fmt.Fprintf(&b, `<page id="%d" type="%d">%s</page>`, page.Id, page.Type, escape(page.Name))
+	}
+	b.WriteString("</pages>")
+	return b.String()
+}
+
+// findPage returns the page of an application with the given ID or
+// name, or nil.
+func (s *Server) findPage(appDbid, idOrName string) *dbPage {
+	for _, page := range s.pages[appDbid] {
+		if strconv.Itoa(page.Id) == idOrName || strings.EqualFold(page.Name, idOrName) {
+			return page
+		}
+	}
+	return nil
+}
+
+func (s *Server) addReplaceDBPage(appDbid string, req request) (body string, err error) {
+	pageType, _ := strconv.Atoi(req.params["pagetype"])
+	var page *dbPage
+	if id := req.params["pageid"]; id != "" {
+		for _, existing := range s.pages[appDbid] {
+			if strconv.Itoa(existing.Id) == id {
+				page = existing
+			}
+		}
+		if page == nil {
+			return "", errNoSuchPage
+		}
+	} else {
+		if req.params["pagename"] == "" {
+			return "", apiError{2, "Invalid input: missing pagename"}
+		}
+		page = &dbPage{Id: 1, Name: req.params["pagename"]}
+		for _, existing := range s.pages[appDbid] {
+			if existing.Id >= page.Id {
+				page.Id = existing.Id + 1
+			}
+		}
+		s.pages[appDbid] = append(s.pages[appDbid], page)
+	}
+	page.Type, page.Body = pageType, req.params["pagebody"]
+	return fmt.Sprintf("<pageID>%d</pageID>", page.Id), nil
+}
+
+func (s *Server) createTable(appDbid string, req request) (body string, err error) {
+	if req.params["tname"] == "" {
+		return "", apiError{2, "Invalid input: missing tname"}
+	}
+	table := newTable(s.newDbid(), nil)
+	table.Name = req.params["tname"]
+	table.Pnoun = req.params["pnoun"]
+	table.Description = req.params["description"]
+	table.Icon = req.params["icon"]
+	s.tables[table.Dbid] = table
+	s.apps[appDbid] = append(s.apps[appDbid], table.Dbid)
+	return fmt.Sprintf("<newdbid>%s</newdbid>", table.Dbid), nil
+}
+
+func (s *Server) appName(dbid string) string {
+	if name, ok := s.appNames[dbid]; ok {
+		return name
+	}
+	return dbid
+}
+
+// grantedDBs lists every application, each followed by its tables, as
+// though the user administers all of them.
+func (s *Server) grantedDBs(req request) string {
+	var b strings.Builder
+	b.WriteString("<databases>")
+	var apps []string
+	for dbid := range s.apps {
+		apps = append(apps, dbid)
+	}
+	sort.Strings(apps)
+	for _, app := range apps {
+		if req.params["excludeparents"] != "1" {
+			fmt.Fprintf(&b, "<dbinfo><dbname>%s</dbname><dbid>%s</dbid></dbinfo>", escape(s.appName(app)), escape(app))
+		}
+		if req.params["withembeddedtables"] == "0" {
+			continue
+		}
+		for _, dbid := range s.apps[app] {
+			if table, ok := s.tables[dbid]; ok {
+				fmt.Fprintf(&b, "<dbinfo><dbname>%s: %s</dbname><dbid>%s</dbid></dbinfo>", escape(s.appName(app)), escape(table.Name), escape(dbid))
+			}
+		}
+	}
+	b.WriteString("</databases>")
+	return b.String()
+}
+
+func (s *Server) getAppSchema(dbid string, tables []string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<table><name>%s</name><original><app_id>%s</app_id></original><variables>", escape(s.appName(dbid)), escape(dbid))
+	var names []string
+	for name := range s.dbvars[dbid] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&b, `<var name="%s">%s</var>`, escape(name), escape(s.dbvars[dbid][name]))
+	}
+	b.WriteString("</variables><chdbids>")
+	for _, table := range tables {
+		fmt.Fprintf(&b, `<chdbid name="_dbid_%s">%s</chdbid>`, escape(tag(table)), escape(table))
+	}
+	b.WriteString("</chdbids></table>")
+	return b.String()
+}
+
+// report returns the report of the saved query qid.
+func (t *Table) report(qid int) Report {
+	report := t.Reports[qid]
+	if report.Name == "" {
+		report.Name = fmt.Sprintf("Query %d", qid)
+	}
+	return report
+}
+
+// savedQuery returns the query with the given qid or, failing that,
+// qname, with its report, or q if there is neither.
+func (t *Table) savedQuery(qid, qname, q string) (string, Report, error) {
+	if qid == "" && qname == "" {
+		return q, Report{}, nil
+	}
+	for id, saved := range t.Queries {
+		report := t.report(id)
+		if qid == strconv.Itoa(id) || qid == "" && strings.EqualFold(qname, report.Name) {
+			return saved, report, nil
+		}
+	}
+	return "", Report{}, errNoSuchQuery
+}
+
+// reportList returns list, or the report's list if it is empty.
+func reportList(list, reportList string) string {
+	if list == "" {
+		return reportList
+	}
+	return list
+}
+
+func doQuery(table *Table, req request) (body string, err error) {
+	q, report, err := table.savedQuery(req.params["qid"], req.params["qname"], req.params["query"])
+	if err != nil {
+		return "", err
+	}
+	records, err := query(table, q)
+	if err != nil {
+		return "", err
+	}
+	fids, err := clist(table, reportList(req.params["clist"], report.Clist))
+	if err != nil {
+		return "", err
+	}
+	if records, err = sortAndPage(table, records, reportList(req.params["slist"], report.Slist), req.params["options"]); err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	structured := req.params["fmt"] == "structured"
+	if structured {
+		b.WriteString("<table><fields>")
+		for _, fid := range fids {
+			fmt.Fprintf(&b, `<field id="%d" field_type="%s" base_type="text"><label>%s</label></field>`, fid, table.fieldType(fid), escape(table.Fields[fid]))
+		}
+		b.WriteString("</fields><records>")
+	}
+	for _, record := range records {
+		if structured {
+			rid, _ := strconv.Atoi(record[3])
+			fmt.Fprintf(&b, `<record rid="%d" update_id="%d">`, rid, table.updateIds[rid])
+		} else {
+			b.WriteString("<record>")
+		}
+		for _, fid := range fids {
+			value := escape(record[fid])
+			if structured {
+				fmt.Fprintf(&b, `<f id="%d">%s</f>`, fid, value)
+			} else {
+				t := tag(table.Fields[fid])
+				fmt.Fprintf(&b, "<%s>%s</%s>", t, strings.Replace(value, "\r", "<BR/>", -1), t)
+			}
+		}
+		b.WriteString("</record>")
+	}
+	if structured {
+		b.WriteString("</records></table>")
+	}
+	return b.String(), nil
+}
+
+// genResultsTable answers a query as CSV, headed by field labels, as
+// API_GenResultsTable does with options=csv.
+func genResultsTable(table *Table, req request) (body string, err error) {
+	q, report, err := table.savedQuery(req.params["qid"], req.params["qname"], req.params["query"])
+	if err != nil {
+		return "", err
+	}
+	records, err := query(table, q)
+	if err != nil {
+		return "", err
+	}
+	fids, err := clist(table, reportList(req.params["clist"], report.Clist))
+	if err != nil {
+		return "", err
+	}
+	if records, err = sortAndPage(table, records, reportList(req.params["slist"], report.Slist), req.params["options"]); err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	row := make([]string, len(fids))
+	for i, fid := range fids {
+		row[i] = table.Fields[fid]
+	}
+	w.Write(row)
+	for _, record := range records {
+		for i, fid := range fids {
+			row[i] = record[fid]
+		}
+		w.Write(row)
+	}
+	w.Flush()
+	return b.String(), nil
+}
+
+// clist parses a column list; empty or 'a' selects every field.
+func clist(table *Table, list string) (fids []int, err error) {
+	if list == "" || list == "a" {
+		for fid := range table.Fields {
+			fids = append(fids, fid)
+		}
+		sort.Ints(fids)
+		return fids, nil
+	}
+	for _, s := range strings.Split(list, ".") {
+		fid, err := strconv.Atoi(s)
+		if err != nil || table.Fields[fid] == "" {
+			return nil, apiError{51, "No such field " + s}
+		}
+		fids = append(fids, fid)
+	}
+	return fids, nil
+}
@@ -0,0 +1,113 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// A RateLimiter paces calls to QuickBase.  It may be shared between
+// goroutines, and between the XML and REST (package restapi) clients,
+// so that all the calls made on behalf of one set of credentials are
+// paced together.
+type RateLimiter struct {
+	mutex    sync.Mutex
+	interval time.Duration // minimum time between the starts of calls
+	next     time.Time     // earliest time the next call may start
+}
+
+// NewRateLimiter returns a RateLimiter which spaces calls at least
+// interval apart; an interval of zero only honors back-offs.
+func NewRateLimiter(interval time.Duration) *RateLimiter {
+	return &RateLimiter{interval: interval}
+}
+
+// Wait blocks until a call may be made, and reserves that call's slot,
+// unless ctx is done first, when it returns ctx.Err().
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	l.mutex.Lock()
+	now := time.Now()
+	start := l.next
+	if start.Before(now) {
+		start = now
+	}
+	l.next = start.Add(l.interval)
+	l.mutex.Unlock()
+	timer := time.NewTimer(start.Sub(now))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// BackOff prevents any further call from starting before until.
+func (l *RateLimiter) BackOff(until time.Time) {
+	l.mutex.Lock()
+	if until.After(l.next) {
+		l.next = until
+	}
+	l.mutex.Unlock()
+}
+
+// BackOffFromHeaders inspects the rate-limiting headers of a response
+// and backs off accordingly, returning the time until which calls are
+// held off, or the zero time if the headers call for none.
+//
+// A Retry-After header (in seconds or as an HTTP date) is always
+// honored; X-RateLimit-Reset (seconds since the epoch) is honored
+// once X-RateLimit-Remaining reaches zero.
+func (l *RateLimiter) BackOffFromHeaders(header http.Header) (until time.Time) {
+	if until = headerBackOff(header); !until.IsZero() {
+		l.BackOff(until)
+	}
+	return until
+}
+
+// headerBackOff returns the time until which the rate-limiting headers
+// of a response hold calls off, as BackOffFromHeaders does.
+func headerBackOff(header http.Header) (until time.Time) {
+	now := time.Now()
+	if retryAfter := header.Get("Retry-After"); retryAfter != "" {
+		if secs, err := strconv.Atoi(retryAfter); err == nil {
+			until = now.Add(time.Duration(secs) * time.Second)
+		} else if t, err := http.ParseTime(retryAfter); err == nil {
+			until = t
+		}
+	}
+	if header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			if t := time.Unix(reset, 0); t.After(until) {
+				until = t
+			}
+		}
+	}
+	if until.After(now) {
+		return until
+	}
+	return time.Time{}
+}
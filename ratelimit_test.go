@@ -0,0 +1,56 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"context"
+	"github.com/WesTower/quickbase"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterInterval(t *testing.T) {
+	limiter := quickbase.NewRateLimiter(20 * time.Millisecond)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		limiter.Wait(context.Background())
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Errorf("three calls took only %s", elapsed)
+	}
+}
+
+func TestRateLimiterRetryAfter(t *testing.T) {
+	limiter := quickbase.NewRateLimiter(0)
+	header := http.Header{}
+	header.Set("Retry-After", "1")
+	until := limiter.BackOffFromHeaders(header)
+	if d := time.Until(until); d < 900*time.Millisecond || d > time.Second {
+		t.Errorf("backing off for %s", d)
+	}
+	header = http.Header{}
+	header.Set("X-RateLimit-Remaining", "5")
+	header.Set("X-RateLimit-Reset", "99999999999")
+	if until := limiter.BackOffFromHeaders(header); !until.IsZero() {
+		t.Errorf("backing off with requests remaining, until %s", until)
+	}
+}
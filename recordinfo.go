@@ -0,0 +1,100 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// A RecordInfo is a record as returned by GetRecordInfo, with the
+// label and type of each of its fields.
+type RecordInfo struct {
+	Rid      int
+	UpdateId int
+	Fields   []FieldValue
+}
+
+// A FieldValue is the value of one field of a record.
+type FieldValue struct {
+	Fid   int
+	Label string
+	Type  string // e.g. 'Text', 'Numeric' or 'Date'
+	Value string // as stored, e.g. milliseconds for a date
+	// Printable is the value as QuickBase displays it, e.g. a user's
+	// name rather than their ID, or Value if QuickBase gave none.
+	Printable string
+}
+
+// Field returns the value of the field fid, if the record has it.
+func (r RecordInfo) Field(fid int) (value FieldValue, ok bool) {
+	for _, value = range r.Fields {
+		if value.Fid == fid {
+			return value, true
+		}
+	}
+	return FieldValue{}, false
+}
+
+// Values returns the values of the record by field ID, as
+// DoStructuredQuery returns records.
+func (r RecordInfo) Values() map[int]string {
+	values := make(map[int]string, len(r.Fields))
+	for _, field := range r.Fields {
+		values[field.Fid] = field.Value
+	}
+	return values
+}
+
+// GetRecordInfo returns every field of the record rid, each with its
+// label and type.  Unlike DoQuery, it identifies fields by ID, so
+// fields whose labels collide are kept apart.
+func GetRecordInfo(ticket Ticket, dbid string, rid int) (info RecordInfo, err error) {
+	var result recordInfoResponse
+	params := ticket.params()
+	params["rid"] = strconv.Itoa(rid)
+	if err = (apiCall{ticket, ticket.url + "db/" + dbid, "API_GetRecordInfo", params}).execute(&result); err != nil {
+		return info, err
+	}
+	if result.Rid == nil {
+		return info, fmt.Errorf("No rid returned from API_GetRecordInfo")
+	}
+	if info.Rid, err = strconv.Atoi(string(*result.Rid)); err != nil {
+		return info, err
+	}
+	if result.UpdateId != "" {
+		if info.UpdateId, err = strconv.Atoi(string(result.UpdateId)); err != nil {
+			return info, err
+		}
+	}
+	for _, field := range result.Fields {
+		value := FieldValue{field.Fid, string(field.Name), string(field.Type), string(field.Value), string(field.Printable)}
+		if value.Printable == "" {
+			value.Printable = value.Value
+		}
+		info.Fields = append(info.Fields, value)
+	}
+	return info, nil
+}
+
+func (c *Client) GetRecordInfo(dbid string, rid int) (info RecordInfo, err error) {
+	return GetRecordInfo(c.ticket(), dbid, rid)
+}
@@ -0,0 +1,75 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"github.com/WesTower/quickbase"
+	"github.com/WesTower/quickbase/quickbasetest"
+	"reflect"
+	"testing"
+)
+
+func TestGetRecordInfo(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := client.GetRecordInfo(testTableDbid, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Rid != 1 || info.UpdateId == 0 {
+		t.Errorf("GetRecordInfo gave rid %d, update ID %d", info.Rid, info.UpdateId)
+	}
+	site, ok := info.Field(6)
+	if !ok || site.Label != "Site" || site.Type != "Text" || site.Value != "Denver" || site.Printable != "Denver" {
+		t.Errorf("field 6 is %+v", site)
+	}
+	if values := info.Values(); values[7] != "12.50" || values[3] != "1" {
+		t.Errorf("Values gave %v", values)
+	}
+	if _, err = client.GetRecordInfo(testTableDbid, 99); err == nil {
+		t.Error("GetRecordInfo succeeded for a missing record")
+	}
+}
+
+func TestGetRecordInfoPrintable(t *testing.T) {
+	fixture := quickbasetest.NewFixtureServer(map[string][]byte{"API_GetRecordInfo": []byte(`<?xml version="1.0" ?>
+<qdbapi><errcode>0</errcode><errtext>No error</errtext><rid>4</rid><num_fields>2</num_fields><update_id>1205849076097</update_id>
+<field><fid>4</fid><name>Record Owner</name><type>User</type><value>56760415.bkxs</value><printable>Jane Doe</printable></field>
+<field><fid>6</fid><name>Site</name><type>Text</type><value>Boise</value></field>
+</qdbapi>`)})
+	defer fixture.Close()
+	ticket, err := quickbase.Authenticate(fixture.BaseUrl(), "fixture", "fixture")
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := quickbase.GetRecordInfo(ticket, testTableDbid, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []quickbase.FieldValue{{4, "Record Owner", "User", "56760415.bkxs", "Jane Doe"}, {6, "Site", "Text", "Boise", "Boise"}}
+	if info.Rid != 4 || info.UpdateId != 1205849076097 || !reflect.DeepEqual(info.Fields, want) {
+		t.Errorf("GetRecordInfo gave %+v", info)
+	}
+}
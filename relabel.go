@@ -0,0 +1,197 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// A Relabel is a change to the label of a field, which is what
+// QuickBase's UI calls renaming it.
+type Relabel struct {
+	Dbid  string
+	Fid   int
+	Label string // the new label
+}
+
+// A LabelReference is a use of a field which a Relabel may affect.
+type LabelReference struct {
+	Relabel Relabel
+	Dbid    string // the table in which the field is used
+	Fid     int    // the field using it, if any
+	Qid     int    // the report using it, if any
+	Detail  string
+	Breaks  bool // whether the use is by label, and so will break
+}
+
+func (r LabelReference) String() string {
+	effect := "unaffected"
+	if r.Breaks {
+		effect = "BREAKS"
+	}
+	return fmt.Sprintf("%s field %d: %s (%s)", r.Relabel.Dbid, r.Relabel.Fid, r.Detail, effect)
+}
+
+// PlanRelabels checks relabels against the schemas of the tables of an
+// application, returning the uses of the fields they relabel: those
+// by formulas, which name fields by label and so break; those by
+// reports and lookup fields, which use field IDs and so do not; and
+// those by code using DoQuery, whose records are keyed by the tags of
+// fields' labels.  It is an error for a relabel to name a field not in
+// schemas, or to give a field the label of another in its table.
+func PlanRelabels(schemas []Schema, relabels []Relabel) (references []LabelReference, err error) {
+	tables := make(map[string]*Schema)
+	for i := range schemas {
+		tables[schemas[i].Dbid] = &schemas[i]
+	}
+	labels := make(map[string]map[int]string) // the labels after relabelling
+	for _, relabel := range relabels {
+		table := tables[relabel.Dbid]
+		if table == nil {
+			return nil, fmt.Errorf("No schema for table %s", relabel.Dbid)
+		}
+		if fieldById(*table, relabel.Fid) == nil {
+			return nil, fmt.Errorf("No field %d in %s", relabel.Fid, relabel.Dbid)
+		}
+		if labels[relabel.Dbid] == nil {
+			labels[relabel.Dbid] = make(map[int]string)
+			for _, field := range table.Fields {
+				labels[relabel.Dbid][field.Id] = field.Label
+			}
+		}
+		labels[relabel.Dbid][relabel.Fid] = relabel.Label
+	}
+	for dbid, table := range labels {
+		var fids []int
+		for fid := range table {
+			fids = append(fids, fid)
+		}
+		sort.Ints(fids)
+		seen := make(map[string]int)
+		for _, fid := range fids {
+			label := table[fid]
+			if other, ok := seen[strings.ToLower(label)]; ok {
+				return nil, fmt.Errorf("Fields %d and %d of %s would both be labelled %q", other, fid, dbid, label)
+			}
+			seen[strings.ToLower(label)] = fid
+		}
+	}
+	for _, relabel := range relabels {
+		table := tables[relabel.Dbid]
+		old := fieldById(*table, relabel.Fid).Label
+		if old == relabel.Label {
+			continue
+		}
+		add := func(fid, qid int, breaks bool, format string, args ...interface{}) {
+			references = append(references, LabelReference{relabel, relabel.Dbid, fid, qid, fmt.Sprintf(format, args...), breaks})
+		}
+		ref := "[" + strings.ToLower(old) + "]"
+		for _, field := range table.Fields {
+			if strings.Contains(strings.ToLower(field.Formula), ref) {
+				add(field.Id, 0, true, "formula of field %d (%s) refers to [%s]", field.Id, field.Label, old)
+			}
+			if field.ReferenceFid == relabel.Fid && field.Mode == "lookup" {
+				add(field.Id, 0, false, "lookup field %d (%s) looks up through it", field.Id, field.Label)
+			}
+		}
+		fid := strconv.Itoa(relabel.Fid)
+		for _, query := range table.Queries {
+			if containsFid(query.Clist, fid) || containsFid(query.Slist, fid) || strings.Contains(query.Criteria, "'"+fid+"'.") {
+				add(0, query.Id, false, "report %d (%s) uses it by ID", query.Id, query.Name)
+			}
+		}
+		if oldTag, newTag := FieldTag(old), FieldTag(relabel.Label); oldTag != newTag {
+			add(0, 0, true, "DoQuery's records will key it by %q rather than %q", newTag, oldTag)
+			for _, other := range table.Fields {
+				label := labels[relabel.Dbid][other.Id]
+				if other.Id != relabel.Fid && FieldTag(label) == newTag {
+					add(0, 0, true, "DoQuery's records will key both it and field %d (%s) by %q", other.Id, label, newTag)
+				}
+			}
+		}
+	}
+	return references, nil
+}
+
+func fieldById(schema Schema, fid int) *Field {
+	for i := range schema.Fields {
+		if schema.Fields[i].Id == fid {
+			return &schema.Fields[i]
+		}
+	}
+	return nil
+}
+
+// RelabelFields relabels fields of the tables of the application
+// appDbid, first checking the uses of the fields as PlanRelabels does.
+// Unless force is set, no field is relabelled if any use would break.
+// The uses found are returned either way.
+func RelabelFields(ticket Ticket, appDbid string, relabels []Relabel, force bool) (references []LabelReference, err error) {
+	return relabelFields(func(dbid string) (Schema, error) {
+		return GetSchema(ticket, dbid)
+	}, func(relabel Relabel) error {
+		return SetFieldProperties(ticket, relabel.Dbid, relabel.Fid, map[string]string{"label": relabel.Label})
+	}, appDbid, relabels, force)
+}
+
+func relabelFields(getSchema func(string) (Schema, error), set func(Relabel) error, appDbid string, relabels []Relabel, force bool) (references []LabelReference, err error) {
+	app, err := getSchema(appDbid)
+	if err != nil {
+		return nil, err
+	}
+	var schemas []Schema
+	for _, dbid := range app.Tables {
+		schema, err := getSchema(dbid)
+		if err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, schema)
+	}
+	if references, err = PlanRelabels(schemas, relabels); err != nil {
+		return nil, err
+	}
+	breaks := 0
+	for _, reference := range references {
+		if reference.Breaks {
+			breaks++
+		}
+	}
+	if breaks > 0 && !force {
+		return references, fmt.Errorf("Relabelling would break %d uses of the fields", breaks)
+	}
+	for _, relabel := range relabels {
+		if err = set(relabel); err != nil {
+			return references, err
+		}
+	}
+	return references, nil
+}
+
+// RelabelFields is as the package-level function, relabelling the
+// fields with the Client's SetFieldProperties.
+func (c *Client) RelabelFields(appDbid string, relabels []Relabel, force bool) (references []LabelReference, err error) {
+	return relabelFields(c.GetSchema, func(relabel Relabel) error {
+		return c.SetFieldProperties(relabel.Dbid, relabel.Fid, map[string]string{"label": relabel.Label})
+	}, appDbid, relabels, force)
+}
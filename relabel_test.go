@@ -0,0 +1,114 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/WesTower/quickbase"
+)
+
+func TestPlanRelabels(t *testing.T) {
+	schemas := []quickbase.Schema{{
+		Dbid: testTableDbid,
+		Fields: []quickbase.Field{
+			{Id: 6, Label: "Site"},
+			{Id: 7, Label: "Cost"},
+			{Id: 8, Label: "Cost with tax", Type: "formula", Formula: "[cost] * 1.08"},
+			{Id: 9, Label: "Parent", ParentDbid: "bck7gp3q1"},
+			{Id: 10, Label: "Parent Name", Mode: "lookup", ReferenceFid: 9},
+			{Id: 11, Label: "Total cost"},
+		},
+		Queries: []quickbase.Query{
+			{Id: 1, Name: "List All", Clist: "6.7.8"},
+			{Id: 5, Name: "Cheap", Criteria: "{'7'.LT.'5'}"},
+		},
+	}}
+	references, err := quickbase.PlanRelabels(schemas, []quickbase.Relabel{
+		{testTableDbid, 7, "Total Cost"},
+		{testTableDbid, 11, "Total"},
+		{testTableDbid, 9, "Owning Site"},
+		{testTableDbid, 6, "Site"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, reference := range references {
+		got = append(got, reference.String())
+	}
+	want := []string{
+		"bck7gp3q2 field 7: formula of field 8 (Cost with tax) refers to [Cost] (BREAKS)",
+		"bck7gp3q2 field 7: report 1 (List All) uses it by ID (unaffected)",
+		"bck7gp3q2 field 7: report 5 (Cheap) uses it by ID (unaffected)",
+		`bck7gp3q2 field 7: DoQuery's records will key it by "total_cost" rather than "cost" (BREAKS)`,
+		`bck7gp3q2 field 11: DoQuery's records will key it by "total" rather than "total_cost" (BREAKS)`,
+		"bck7gp3q2 field 9: lookup field 10 (Parent Name) looks up through it (unaffected)",
+		`bck7gp3q2 field 9: DoQuery's records will key it by "owning_site" rather than "parent" (BREAKS)`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	for relabels, message := range map[quickbase.Relabel]string{
+		{testTableDbid, 7, "site"}: `Fields 6 and 7 of bck7gp3q2 would both be labelled "site"`,
+		{testTableDbid, 99, "New"}: "No field 99 in bck7gp3q2",
+		{"bck7gp3q9", 6, "New"}:    "No schema for table bck7gp3q9",
+	} {
+		_, err = quickbase.PlanRelabels(schemas, []quickbase.Relabel{relabels})
+		if err == nil || err.Error() != message {
+			t.Errorf("%v gave %v, want %q", relabels, err, message)
+		}
+	}
+}
+
+func TestRelabelFields(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	server.AddTable(testTableDbid, map[int]string{6: "Site", 7: "Cost", 8: "Cost with tax"}).Formulas[8] = "[Cost] * 1.08"
+	ticket, err := authenticate(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := quickbase.NewClient(ticket)
+	relabels := []quickbase.Relabel{{testTableDbid, 7, "Price"}}
+	references, err := client.RelabelFields(testAppDbid, relabels, false)
+	if err == nil || len(references) != 2 || !references[0].Breaks || references[0].Fid != 8 {
+		t.Fatalf("unforced relabel gave %v, %v", references, err)
+	}
+	schema, err := client.GetSchema(testTableDbid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if field := schema.Fields[6]; field.Id != 7 || field.Label != "Cost" {
+		t.Errorf("unforced relabel changed field: %+v", field)
+	}
+	if _, err = client.RelabelFields(testAppDbid, relabels, true); err != nil {
+		t.Fatal(err)
+	}
+	if schema, err = client.GetSchema(testTableDbid); err != nil {
+		t.Fatal(err)
+	}
+	if field := schema.Fields[6]; field.Label != "Price" {
+		t.Errorf("forced relabel left field: %+v", field)
+	}
+}
@@ -0,0 +1,58 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/WesTower/quickbase"
+	"github.com/WesTower/quickbase/quickbasetest"
+)
+
+func TestDoReport(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	table := server.AddTable("bck7gp3q9", map[int]string{6: "Site", 7: "Cost"})
+	table.Queries[10] = "{'7'.GT.'2'}"
+	table.Reports[10] = quickbasetest.Report{Name: "Costly Sites", Clist: "6", Slist: "7"}
+	for _, site := range []map[int]string{{6: "Denver", 7: "12.50"}, {6: "Boise", 7: "3"}, {6: "Moab", 7: "1"}} {
+		server.Seed("bck7gp3q9", site)
+	}
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []map[int]string{{6: "Boise"}, {6: "Denver"}}
+	if records, err := client.DoReport("bck7gp3q9", 10); err != nil || !reflect.DeepEqual(records, want) {
+		t.Errorf("DoReport gave %v, %v, not %v", records, err, want)
+	}
+	if records, err := client.DoReportByName("bck7gp3q9", "costly sites"); err != nil || !reflect.DeepEqual(records, want) {
+		t.Errorf("DoReportByName gave %v, %v, not %v", records, err, want)
+	}
+	want = []map[int]string{{7: "3"}, {7: "12.50"}}
+	if records, err := client.DoQueryByQname("bck7gp3q9", "Costly Sites", "7", "6", ""); err != nil || !reflect.DeepEqual(records, want) {
+		t.Errorf("DoQueryByQname gave %v, %v, not %v", records, err, want)
+	}
+	if _, err = client.DoReportByName("bck7gp3q9", "Cheap Sites"); err == nil {
+		t.Error("DoReportByName succeeded for a missing report")
+	}
+}
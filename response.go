@@ -0,0 +1,379 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A response is the decoded response to a qdbapi call.  The response
+// types of particular calls embed qdbapiResponse, which provides the
+// elements common to them all.
+type response interface {
+	header() *qdbapiResponse
+}
+
+// qdbapiResponse holds the elements of every qdbapi response.
+type qdbapiResponse struct {
+	Errcode   *text `xml:"errcode"`
+	Errtext   text  `xml:"errtext"`
+	Errdetail text  `xml:"errdetail"`
+}
+
+func (r *qdbapiResponse) header() *qdbapiResponse {
+	return r
+}
+
+// text is the text of an element, less the white space surrounding it
+// where QuickBase pretty-prints its responses.
+type text string
+
+func (t *text) UnmarshalXML(decoder *xml.Decoder, start xml.StartElement) error {
+	var s string
+	if err := decoder.DecodeElement(&s, &start); err != nil {
+		return err
+	}
+	*t = text(strings.TrimSpace(s))
+	return nil
+}
+
+// decodeResponse decodes a qdbapi response into result, returning the
+// error it reports, if any.  A response without an errcode, e.g. an
+// HTML error page from a proxy, is itself an error.
+func decodeResponse(r io.Reader, status int, result response) error {
+	if err := xml.NewDecoder(r).Decode(result); err != nil {
+		if err == io.EOF {
+			return fmt.Errorf("Empty response from QuickBase (HTTP status %d)", status)
+		}
+		return err
+	}
+	header := result.header()
+	if header.Errcode == nil {
+		return responseError("", "", "", status)
+	}
+	return responseError(string(*header.Errcode), string(header.Errtext), string(header.Errdetail), status)
+}
+
+type authenticateResponse struct {
+	qdbapiResponse
+	Ticket *text `xml:"ticket"`
+	Userid text  `xml:"userid"`
+}
+
+type doQueryCountResponse struct {
+	qdbapiResponse
+	NumMatches *text `xml:"numMatches"`
+}
+
+type addRecordResponse struct {
+	qdbapiResponse
+	Rid *text `xml:"rid"`
+}
+
+// recordId returns the record ID of the added record.
+func (r *addRecordResponse) recordId() (rid int, err error) {
+	if r.Rid == nil {
+		return 0, fmt.Errorf("No rid returned from API_AddRecord")
+	}
+	return strconv.Atoi(string(*r.Rid))
+}
+
+type addFieldResponse struct {
+	qdbapiResponse
+	Fid *text `xml:"fid"`
+}
+
+func (r *addFieldResponse) fieldId() (fid int, err error) {
+	if r.Fid == nil {
+		return 0, fmt.Errorf("No fid returned from API_AddField")
+	}
+	return strconv.Atoi(string(*r.Fid))
+}
+
+type createDatabaseResponse struct {
+	qdbapiResponse
+	Dbid     text `xml:"dbid"`
+	AppDbid  text `xml:"appdbid"`
+	Apptoken text `xml:"apptoken"`
+}
+
+type cloneDatabaseResponse struct {
+	qdbapiResponse
+	NewDbid text `xml:"newdbid"`
+}
+
+type dbvarResponse struct {
+	qdbapiResponse
+	Value *text `xml:"value"`
+}
+
+type listDBPagesResponse struct {
+	qdbapiResponse
+	Pages []struct {
+		Id   int    `xml:"id,attr"`
+		Type int    `xml:"type,attr"`
+		Name string `xml:",chardata"`
+	} `xml:"pages>page"`
+}
+
+type addReplaceDBPageResponse struct {
+	qdbapiResponse
+	PageId *text `xml:"pageID"`
+}
+
+type recordInfoResponse struct {
+	qdbapiResponse
+	Rid      *text `xml:"rid"`
+	UpdateId text  `xml:"update_id"`
+	Fields   []struct {
+		Fid       int  `xml:"fid"`
+		Name      text `xml:"name"`
+		Type      text `xml:"type"`
+		Value     text `xml:"value"`
+		Printable text `xml:"printable"`
+	} `xml:"field"`
+}
+
+type purgeRecordsResponse struct {
+	qdbapiResponse
+	Deleted *text `xml:"num_records_deleted"`
+}
+
+func (r *purgeRecordsResponse) deleted() (count int, err error) {
+	if r.Deleted == nil {
+		return 0, fmt.Errorf("No num_records_deleted returned from API_PurgeRecords")
+	}
+	return strconv.Atoi(string(*r.Deleted))
+}
+
+type copyMasterDetailResponse struct {
+	qdbapiResponse
+	ParentRid *text `xml:"parentrid"`
+}
+
+func (r *copyMasterDetailResponse) parentRid() (rid int, err error) {
+	if r.ParentRid == nil {
+		return 0, fmt.Errorf("No parentrid returned from API_CopyMasterDetail")
+	}
+	return strconv.Atoi(string(*r.ParentRid))
+}
+
+type grantedDBsResponse struct {
+	qdbapiResponse
+	Databases []struct {
+		Name text `xml:"dbname"`
+		Dbid text `xml:"dbid"`
+	} `xml:"databases>dbinfo"`
+}
+
+type createTableResponse struct {
+	qdbapiResponse
+	NewDbid *text `xml:"newdbid"`
+}
+
+func (r *createTableResponse) dbid() (dbid string, err error) {
+	if r.NewDbid == nil || *r.NewDbid == "" {
+		return "", fmt.Errorf("No newdbid returned from API_CreateTable")
+	}
+	return string(*r.NewDbid), nil
+}
+
+// An importResponse is the response to API_ImportFromCSV, listing the
+// record IDs of the rows imported, in order.
+type importResponse struct {
+	qdbapiResponse
+	Rids    []int `xml:"rids>rid"`
+	Added   int   `xml:"num_recs_added"`
+	Updated int   `xml:"num_recs_updated"`
+}
+
+type editRecordResponse struct {
+	qdbapiResponse
+	UpdateId *text `xml:"update_id"`
+}
+
+// updateId returns the new update ID of the edited record.
+func (r *editRecordResponse) updateId() (id int, err error) {
+	if r.UpdateId == nil {
+		return 0, fmt.Errorf("No update_id returned from API_EditRecord")
+	}
+	return strconv.Atoi(string(*r.UpdateId))
+}
+
+type userRolesResponse struct {
+	qdbapiResponse
+	Users []struct {
+		Id    string    `xml:"id,attr"`
+		Name  text      `xml:"name"`
+		Roles roleItems `xml:"roles>role"`
+	} `xml:"users>user"`
+}
+
+// roleItems are the roles listed by API_UserRoles and API_GetRoleInfo.
+type roleItems []struct {
+	Id     int  `xml:"id,attr"`
+	Name   text `xml:"name"`
+	Access struct {
+		Id   int  `xml:"id,attr"`
+		Name text `xml:",chardata"`
+	} `xml:"access"`
+}
+
+func (items roleItems) roles() (roles []Role) {
+	for _, item := range items {
+		roles = append(roles, Role{item.Id, string(item.Name), Access{item.Access.Id, string(item.Access.Name)}})
+	}
+	return roles
+}
+
+type roleInfoResponse struct {
+	qdbapiResponse
+	Roles roleItems `xml:"roles>role"`
+}
+
+type userInfoResponse struct {
+	qdbapiResponse
+	User *struct {
+		Id         string `xml:"id,attr"`
+		FirstName  text   `xml:"firstName"`
+		LastName   text   `xml:"lastName"`
+		Login      text   `xml:"login"`
+		Email      text   `xml:"email"`
+		ScreenName text   `xml:"screenName"`
+		IsVerified text   `xml:"isVerified"`
+	} `xml:"user"`
+}
+
+type provisionUserResponse struct {
+	qdbapiResponse
+	Userid text `xml:"userid"`
+}
+
+type appDTMInfoResponse struct {
+	qdbapiResponse
+	RequestTime            *text      `xml:"RequestTime"`
+	RequestNextAllowedTime *text      `xml:"RequestNextAllowedTime"`
+	App                    *dtmInfo   `xml:"app"`
+	Tables                 *[]dtmInfo `xml:"tables>table"`
+}
+
+// dtmInfo is the modification information of an app or table.
+type dtmInfo struct {
+	Id               string `xml:"id,attr"`
+	LastModifiedTime *text  `xml:"lastModifiedTime"`
+	LastRecModTime   *text  `xml:"lastRecModTime"`
+}
+
+// msecTime returns the time given by the named element, whose value
+// is in milliseconds since the epoch.
+func msecTime(value *text, name string) (t time.Time, err error) {
+	if value == nil {
+		return t, fmt.Errorf("Tag named %s not found", name)
+	}
+	msecs, err := strconv.ParseInt(string(*value), 10, 64)
+	if err != nil {
+		return t, err
+	}
+	return time.Unix(msecs/1000, (msecs%1000)*int64(time.Millisecond)), nil
+}
+
+// A queryResponse is the response to API_DoQuery, whose records are
+// those of the structured format if it was asked for.
+type queryResponse struct {
+	qdbapiResponse
+	Records           []queryRecord    `xml:"record"`
+	StructuredRecords []queryRecord    `xml:"table>records>record"`
+	StructuredFields  []queryFieldType `xml:"table>fields>field"` // in the structured format
+	Lusers            []queryLuser     `xml:"table>lusers>luser"` // in the structured format
+}
+
+// A queryFieldType gives the type of a field of a structured query
+// result.
+type queryFieldType struct {
+	Id        int    `xml:"id,attr"`
+	FieldType string `xml:"field_type,attr"`
+}
+
+// A queryLuser gives the name of a user whose ID appears in the user
+// fields of a structured query result.
+type queryLuser struct {
+	Id   string `xml:"id,attr"`
+	Name string `xml:",chardata"`
+}
+
+type queryRecord struct {
+	UpdateId int          `xml:"update_id,attr"` // in the structured format
+	Fields   []queryField `xml:",any"`
+}
+
+// A queryField is a field of a query result: in the default format
+// its element is named for the field's label, and in the structured
+// format it is an f element giving the field's ID.
+type queryField struct {
+	Name  string
+	Id    int
+	Value string
+	Url   string // for a file attachment in the structured format
+}
+
+// UnmarshalXML collects the value of a field.  A multi-line field may
+// have multiple text nodes, separated by "<BR/>" elements, so these
+// are concatenated with newlines interpolated where necessary.
+func (f *queryField) UnmarshalXML(decoder *xml.Decoder, start xml.StartElement) error {
+	f.Name = start.Name.Local
+	for _, attr := range start.Attr {
+		if attr.Name.Space == "" && attr.Name.Local == "id" {
+			f.Id, _ = strconv.Atoi(attr.Value)
+		}
+	}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return err
+		}
+		switch token := token.(type) {
+		case xml.CharData:
+			f.Value += string(token)
+		case xml.StartElement:
+			if token.Name.Local == "url" {
+				if err = decoder.DecodeElement(&f.Url, &token); err != nil {
+					return err
+				}
+				continue
+			}
+			if token.Name.Local != "BR" {
+				return fmt.Errorf("Cannot handle tag %s within value for field %s", token.Name.Local, f.Name)
+			}
+			// apparently, QuickBase internally uses carriage returns to separate lines
+			f.Value += "\r"
+			if err = decoder.Skip(); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
@@ -0,0 +1,122 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package restapi
+
+import (
+	"net/url"
+	"time"
+)
+
+// Reads holds a realm's API read counts for one day.
+type Reads struct {
+	Date  string `json:"date"`
+	Reads struct {
+		Realm        int `json:"realm"`
+		User         int `json:"user"`
+		Integrations struct {
+			Api       int `json:"api"`
+			Eoti      int `json:"eoti"`
+			Pipelines int `json:"pipelines"`
+		} `json:"integrations"`
+	} `json:"reads"`
+}
+
+// GetReads returns the realm's read counts for a single day, in the
+// realm's time zone.
+func (c *Client) GetReads(day time.Time) (reads Reads, err error) {
+	err = c.do("GET", "/analytics/reads", url.Values{"day": {day.Format("2006-01-02")}}, nil, &reads)
+	return reads, err
+}
+
+// EventTotals breaks down a count of events by their source.
+type EventTotals struct {
+	All          int `json:"all"`
+	User         int `json:"user"`
+	Integrations struct {
+		All       int `json:"all"`
+		Api       int `json:"api"`
+		Pipelines int `json:"pipelines"`
+	} `json:"integrations"`
+}
+
+// EventSummary totals the events for one user or app.
+type EventSummary struct {
+	Id         string      `json:"id"`
+	Name       string      `json:"name"`
+	Totals     EventTotals `json:"totals"`
+	EventTypes []struct {
+		EventType string `json:"eventType"`
+		Count     int    `json:"count"`
+	} `json:"eventTypes"`
+}
+
+// EventFilter restricts an event summary to particular users or apps.
+type EventFilter struct {
+	Id   string `json:"id"`
+	Type string `json:"type"` // 'user' or 'app'
+}
+
+// EventSummaryRequest describes the summary wanted from
+// GetEventSummaries; GroupBy is 'user' or 'app'.
+type EventSummaryRequest struct {
+	AccountId string        `json:"-"`
+	Start     time.Time     `json:"start"`
+	End       time.Time     `json:"end"`
+	GroupBy   string        `json:"groupBy"`
+	Where     []EventFilter `json:"where,omitempty"`
+	NextToken string        `json:"nextToken,omitempty"`
+}
+
+// EventSummaries is the result of GetEventSummaries.
+type EventSummaries struct {
+	AccountId string         `json:"accountId"`
+	Start     time.Time      `json:"start"`
+	End       time.Time      `json:"end"`
+	GroupBy   string         `json:"groupBy"`
+	Results   []EventSummary `json:"results"`
+	Totals    EventTotals    `json:"totals"`
+	Metadata  struct {
+		NextToken string `json:"nextToken"`
+	} `json:"metadata"`
+}
+
+// GetEventSummaries returns event counts for an account between two
+// times, grouped by user or by app.  QuickBase pages large summaries;
+// GetEventSummaries follows the pages and returns all results.
+func (c *Client) GetEventSummaries(request EventSummaryRequest) (summaries EventSummaries, err error) {
+	var query url.Values
+	if request.AccountId != "" {
+		query = url.Values{"accountId": {request.AccountId}}
+	}
+	for {
+		var page EventSummaries
+		if err = c.do("POST", "/analytics/events/summaries", query, request, &page); err != nil {
+			return summaries, err
+		}
+		results := append(summaries.Results, page.Results...)
+		summaries = page
+		summaries.Results = results
+		if page.Metadata.NextToken == "" {
+			return summaries, nil
+		}
+		request.NextToken = page.Metadata.NextToken
+	}
+}
@@ -0,0 +1,78 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package restapi_test
+
+import (
+	"encoding/json"
+	"github.com/WesTower/quickbase/restapi"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestGetReads(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/analytics/reads" || r.URL.Query().Get("day") != "2015-04-03" {
+			t.Errorf("unexpected request %s?%s", r.URL.Path, r.URL.RawQuery)
+		}
+		w.Write([]byte(`{"date": "2015-04-03", "reads": {"realm": 100, "user": 60, "integrations": {"api": 30, "eoti": 4, "pipelines": 6}}}`))
+	})
+	defer server.Close()
+	reads, err := client.GetReads(time.Date(2015, 4, 3, 12, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reads.Reads.Realm != 100 || reads.Reads.Integrations.Api != 30 {
+		t.Errorf("unexpected reads %+v", reads)
+	}
+}
+
+func TestGetEventSummariesPaging(t *testing.T) {
+	calls := 0
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		var request map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&request)
+		if r.URL.Query().Get("accountId") != "123" || request["groupBy"] != "app" {
+			t.Errorf("unexpected request %s %v", r.URL.RawQuery, request)
+		}
+		if request["nextToken"] == nil {
+			w.Write([]byte(`{"groupBy": "app", "results": [{"id": "bck7gp3q2", "name": "Sites", "totals": {"all": 5}}],
+				"metadata": {"nextToken": "page2"}}`))
+		} else {
+			w.Write([]byte(`{"groupBy": "app", "results": [{"id": "bck7gp3q3", "name": "Crews", "totals": {"all": 7}}],
+				"totals": {"all": 12}, "metadata": {}}`))
+		}
+	})
+	defer server.Close()
+	summaries, err := client.GetEventSummaries(restapi.EventSummaryRequest{
+		AccountId: "123",
+		Start:     time.Date(2015, 4, 1, 0, 0, 0, 0, time.UTC),
+		End:       time.Date(2015, 4, 3, 0, 0, 0, 0, time.UTC),
+		GroupBy:   "app",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 || len(summaries.Results) != 2 || summaries.Totals.All != 12 {
+		t.Errorf("unexpected summaries after %d calls: %+v", calls, summaries)
+	}
+}
@@ -0,0 +1,83 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package restapi
+
+import (
+	"net/url"
+)
+
+// An App describes an application, as returned by the /apps
+// endpoints.
+type App struct {
+	Id                       string     `json:"id"`
+	Name                     string     `json:"name"`
+	Description              string     `json:"description"`
+	Created                  string     `json:"created"`
+	Updated                  string     `json:"updated"`
+	DateFormat               string     `json:"dateFormat"`
+	TimeZone                 string     `json:"timeZone"`
+	AncestorId               string     `json:"ancestorId"`
+	HasEveryoneOnTheInternet bool       `json:"hasEveryoneOnTheInternet"`
+	DataClassification       string     `json:"dataClassification"`
+	Variables                []Variable `json:"variables"`
+}
+
+// A Variable is an application variable (DBVar).
+type Variable struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// CopyAppRequest describes a copy of an application.  By default the
+// copy has the original's structure only: no records, and no users
+// other than the caller.
+type CopyAppRequest struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Properties  CopyAppProperties `json:"properties"`
+}
+
+// CopyAppProperties holds the options of CopyAppRequest.
+type CopyAppProperties struct {
+	KeepData        bool `json:"keepData"`        // copy the records
+	ExcludeFiles    bool `json:"excludeFiles"`    // with KeepData, leave out file attachments
+	UsersAndRoles   bool `json:"usersAndRoles"`   // copy the users and their roles
+	AssignUserToken bool `json:"assignUserToken"` // assign the caller's user token to the copy
+}
+
+// CopyApp copies an application, returning the description of the
+// copy.  For example, a sanitized training copy holding structure and
+// users but no data is:
+//
+//	client.CopyApp(appId, CopyAppRequest{
+//		Name:       "Training",
+//		Properties: CopyAppProperties{UsersAndRoles: true, AssignUserToken: true},
+//	})
+func (c *Client) CopyApp(appId string, request CopyAppRequest) (app App, err error) {
+	err = c.do("POST", "/apps/"+url.PathEscape(appId)+"/copy", nil, request, &app)
+	return app, err
+}
+
+// GetApp returns the description of an application.
+func (c *Client) GetApp(appId string) (app App, err error) {
+	err = c.do("GET", "/apps/"+url.PathEscape(appId), nil, nil, &app)
+	return app, err
+}
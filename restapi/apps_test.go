@@ -0,0 +1,61 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package restapi_test
+
+import (
+	"encoding/json"
+	"github.com/WesTower/quickbase/restapi"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestCopyApp(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/apps/bck7gp3q1/copy" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		expected := map[string]interface{}{
+			"name": "Training",
+			"properties": map[string]interface{}{
+				"keepData": true, "excludeFiles": true, "usersAndRoles": false, "assignUserToken": true,
+			},
+		}
+		if !reflect.DeepEqual(body, expected) {
+			t.Errorf("unexpected body %v", body)
+		}
+		w.Write([]byte(`{"id": "bck7gp3q9", "name": "Training", "ancestorId": "bck7gp3q1",
+			"variables": [{"name": "Region", "value": "West"}]}`))
+	})
+	defer server.Close()
+	app, err := client.CopyApp("bck7gp3q1", restapi.CopyAppRequest{
+		Name:       "Training",
+		Properties: restapi.CopyAppProperties{KeepData: true, ExcludeFiles: true, AssignUserToken: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if app.Id != "bck7gp3q9" || app.AncestorId != "bck7gp3q1" || len(app.Variables) != 1 {
+		t.Errorf("unexpected app %+v", app)
+	}
+}
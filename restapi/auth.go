@@ -0,0 +1,81 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package restapi
+
+import (
+	"encoding/json"
+	"net/url"
+)
+
+type tempAuthorization struct {
+	TemporaryAuthorization string `json:"temporaryAuthorization"`
+}
+
+// GetTempToken exchanges the Client's credentials for a temporary
+// token scoped to a single app or table dbid.  Temporary tokens
+// expire after five minutes.
+func (c *Client) GetTempToken(dbid string) (token string, err error) {
+	req, err := c.newRequest("GET", "/auth/temporary/"+url.PathEscape(dbid), nil, nil)
+	if err != nil {
+		return "", err
+	}
+	if c.AppToken != "" {
+		req.Header.Set("QB-App-Token", c.AppToken)
+	}
+	resp, err := c.send(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var result tempAuthorization
+	if err = json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	return result.TemporaryAuthorization, nil
+}
+
+// WithTempToken returns a copy of the Client which authenticates with
+// a freshly-issued temporary token scoped to dbid, and which does not
+// carry the original user token at all.
+func (c *Client) WithTempToken(dbid string) (scoped *Client, err error) {
+	token, err := c.GetTempToken(dbid)
+	if err != nil {
+		return nil, err
+	}
+	scoped = &Client{}
+	*scoped = *c
+	scoped.UserToken = ""
+	scoped.TempToken = token
+	return scoped, nil
+}
+
+// Scoped runs a short-lived operation with a Client authenticated by
+// a temporary token scoped to dbid, limiting what a leaked token
+// could do to that one app or table for a few minutes.  The
+// operation should complete well within the token's five-minute
+// lifetime.
+func (c *Client) Scoped(dbid string, operation func(scoped *Client) error) error {
+	scoped, err := c.WithTempToken(dbid)
+	if err != nil {
+		return err
+	}
+	return operation(scoped)
+}
@@ -0,0 +1,81 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package restapi_test
+
+import (
+	"github.com/WesTower/quickbase"
+	"github.com/WesTower/quickbase/restapi"
+	"net/http"
+	"testing"
+)
+
+func TestScoped(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/auth/temporary/bck7gp3q2":
+			if r.Header.Get("Authorization") != "QB-USER-TOKEN b12345_token" {
+				t.Errorf("unexpected authorization %q", r.Header.Get("Authorization"))
+			}
+			if r.Header.Get("QB-App-Token") != "apptoken" {
+				t.Errorf("unexpected app token %q", r.Header.Get("QB-App-Token"))
+			}
+			w.Write([]byte(`{"temporaryAuthorization": "temp123"}`))
+		case "/reports":
+			if r.Header.Get("Authorization") != "QB-TEMP-TOKEN temp123" {
+				t.Errorf("unexpected authorization %q", r.Header.Get("Authorization"))
+			}
+			w.Write([]byte(`[]`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+	defer server.Close()
+	client.AppToken = "apptoken"
+	err := client.Scoped("bck7gp3q2", func(scoped *restapi.Client) error {
+		if scoped.UserToken != "" {
+			t.Errorf("scoped client carries user token")
+		}
+		_, err := scoped.ListReports("bck7gp3q2")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.TempToken != "" {
+		t.Errorf("original client was modified")
+	}
+}
+
+func TestNewClientWith(t *testing.T) {
+	client, err := restapi.NewClientWith("example.quickbase.com", quickbase.NewUserTokenAuth("b12345_token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if client.Realm != "example.quickbase.com" || client.UserToken != "b12345_token" {
+		t.Errorf("unexpected client %+v", client)
+	}
+	password := quickbase.CredentialsFunc(func(url string) (quickbase.Credentials, error) {
+		return quickbase.Credentials{Username: "jdoe", Password: "secret"}, nil
+	})
+	if _, err := restapi.NewClientWith("example.quickbase.com", password); err == nil {
+		t.Error("NewClientWith password credentials succeeded")
+	}
+}
@@ -0,0 +1,252 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+// Package restapi provides access to QuickBase's JSON RESTful API, as
+// documented at <https://developer.quickbase.com/>.
+//
+// Error responses are returned as quickbase.QuickBaseError, so that
+// callers can handle errors uniformly whichever API they use.
+//
+// The RESTful API lives alongside the legacy XML API wrapped by the
+// parent quickbase package; it offers a number of features which the
+// XML API never will.
+package restapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// DefaultBaseUrl is the root of the RESTful API.
+const DefaultBaseUrl = "https://api.quickbase.com/v1"
+
+// A Client makes calls against the RESTful API on behalf of a single
+// realm.
+type Client struct {
+	Realm      string       // realm hostname, e.g. 'instance.quickbase.com'
+	UserToken  string       // sent as QB-USER-TOKEN authorization
+	TempToken  string       // if set, sent as QB-TEMP-TOKEN authorization instead
+	AppToken   string       // if set, sent when requesting temporary tokens
+	BaseUrl    string       // if empty, DefaultBaseUrl is used
+	HttpClient *http.Client // if nil, http.DefaultClient is used
+	UserAgent  string       // if set, sent with each request
+
+	// Limiter, if set, paces calls and is told of the rate limits
+	// QuickBase reports; share it between Clients (and with the XML
+	// API) using the same credentials.
+	Limiter *quickbase.RateLimiter
+	// RateLimitRetries is the number of times a call refused with
+	// 429 Too Many Requests is retried, after waiting as told by
+	// QuickBase.  If zero, DefaultRateLimitRetries is used; if
+	// negative, such calls are not retried.
+	RateLimitRetries int
+	// RateLimitBackOff is how long the first retry of such a call
+	// waits if QuickBase does not say, doubling for each further
+	// retry.  If zero, DefaultRateLimitBackOff is used.
+	RateLimitBackOff time.Duration
+
+	ctx context.Context // if set, the context of each call; see WithContext
+}
+
+// DefaultRateLimitRetries is the default for Client.RateLimitRetries.
+const DefaultRateLimitRetries = 3
+
+// DefaultRateLimitBackOff is the default for Client.RateLimitBackOff.
+const DefaultRateLimitBackOff = time.Second
+
+// NewClient returns a Client for the given realm hostname which
+// authenticates with a user token.
+func NewClient(realm, userToken string) *Client {
+	return &Client{Realm: realm, UserToken: userToken}
+}
+
+// NewClientWith returns a Client for the given realm hostname which
+// authenticates with the user token provider gives for the realm's
+// URL, e.g. one from quickbase.NewUserTokenAuth.  The REST API does not
+// accept a username and password.
+func NewClientWith(realm string, provider quickbase.CredentialsProvider) (client *Client, err error) {
+	credentials, err := provider.Credentials("https://" + realm + "/")
+	if err != nil {
+		return nil, err
+	}
+	if credentials.Usertoken == "" {
+		return nil, fmt.Errorf("No user token provided for %s", realm)
+	}
+	return NewClient(realm, credentials.Usertoken), nil
+}
+
+// WithContext returns a shallow copy of the Client whose calls are
+// made with ctx, so that they, and the waits between them for rate
+// limits or pipeline runs, are abandoned, returning ctx.Err(), once
+// ctx is done.
+func (c *Client) WithContext(ctx context.Context) *Client {
+	copied := *c
+	copied.ctx = ctx
+	return &copied
+}
+
+func (c *Client) context() context.Context {
+	if c.ctx != nil {
+		return c.ctx
+	}
+	return context.Background()
+}
+
+type errorBody struct {
+	Message     string `json:"message"`
+	Description string `json:"description"`
+}
+
+func (c *Client) baseUrl() string {
+	if c.BaseUrl != "" {
+		return c.BaseUrl
+	}
+	return DefaultBaseUrl
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HttpClient != nil {
+		return c.HttpClient
+	}
+	return http.DefaultClient
+}
+
+// newRequest builds a request for path, relative to the API root,
+// with the realm and authorization headers set.  If body is non-nil
+// it is sent as JSON.
+func (c *Client) newRequest(method, path string, query url.Values, body interface{}) (req *http.Request, err error) {
+	if body == nil {
+		return c.newRawRequest(method, path, query, nil, "")
+	}
+	buf, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+	return c.newRawRequest(method, path, query, bytes.NewReader(buf), "application/json")
+}
+
+// newRawRequest is like newRequest, but sends body as-is with the
+// given content type.
+func (c *Client) newRawRequest(method, path string, query url.Values, body io.Reader, contentType string) (req *http.Request, err error) {
+	reqUrl := c.baseUrl() + path
+	if len(query) > 0 {
+		reqUrl += "?" + query.Encode()
+	}
+	req, err = http.NewRequestWithContext(c.context(), method, reqUrl, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("QB-Realm-Hostname", c.Realm)
+	if c.TempToken != "" {
+		req.Header.Set("Authorization", "QB-TEMP-TOKEN "+c.TempToken)
+	} else {
+		req.Header.Set("Authorization", "QB-USER-TOKEN "+c.UserToken)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	return req, nil
+}
+
+// send executes req, returning the response if it succeeded and a
+// quickbase.QuickBaseError otherwise.  The caller is responsible for closing the
+// response body.
+func (c *Client) send(req *http.Request) (resp *http.Response, err error) {
+	limiter := c.Limiter
+	if limiter == nil {
+		limiter = quickbase.NewRateLimiter(0)
+	}
+	retries := c.RateLimitRetries
+	if retries == 0 {
+		retries = DefaultRateLimitRetries
+	}
+	backOff := c.RateLimitBackOff
+	if backOff <= 0 {
+		backOff = DefaultRateLimitBackOff
+	}
+	for attempt := 0; ; attempt++ {
+		if err = limiter.Wait(req.Context()); err != nil {
+			return nil, err
+		}
+		resp, err = c.httpClient().Do(req)
+		if err != nil {
+			return nil, err
+		}
+		until := limiter.BackOffFromHeaders(resp.Header)
+		if resp.StatusCode != http.StatusTooManyRequests || attempt >= retries || (req.Body != nil && req.GetBody == nil) {
+			break
+		}
+		if until.IsZero() {
+			limiter.BackOff(time.Now().Add(backOff << uint(attempt)))
+		}
+		resp.Body.Close()
+		if req.GetBody != nil {
+			if req.Body, err = req.GetBody(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		var errBody errorBody
+		if err := json.Unmarshal(body, &errBody); err != nil || errBody.Message == "" {
+			errBody.Message = http.StatusText(resp.StatusCode)
+		}
+		return nil, quickbase.QuickBaseError{
+			Message:    errBody.Message,
+			Detail:     errBody.Description,
+			HttpStatus: resp.StatusCode,
+		}
+	}
+	return resp, nil
+}
+
+// do executes a call, decoding the JSON response into result unless
+// result is nil.
+func (c *Client) do(method, path string, query url.Values, body, result interface{}) (err error) {
+	req, err := c.newRequest(method, path, query, body)
+	if err != nil {
+		return err
+	}
+	resp, err := c.send(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
@@ -0,0 +1,205 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package restapi
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// FieldInfo describes a field of a table, as returned by GET /fields.
+// FieldType is the REST name of the type, e.g. 'text', 'numeric',
+// 'checkbox', 'date' or 'timestamp'.  Mode is empty for an ordinary
+// field, or e.g. 'formula', 'lookup' or 'summary'.  Properties holds
+// the type-specific properties, e.g. "formula" or "choices".
+type FieldInfo struct {
+	Id         int                    `json:"id"`
+	Label      string                 `json:"label"`
+	FieldType  string                 `json:"fieldType"`
+	Mode       string                 `json:"mode"`
+	NoWrap     bool                   `json:"noWrap"`
+	Bold       bool                   `json:"bold"`
+	Required   bool                   `json:"required"`
+	Unique     bool                   `json:"unique"`
+	FieldHelp  string                 `json:"fieldHelp"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+// NewField describes a field to be created by CreateField.
+// Properties holds type-specific properties, as documented for POST
+// /fields, e.g. "choices" for a multiple-choice text field.
+type NewField struct {
+	Label      string                 `json:"label"`
+	FieldType  string                 `json:"fieldType"`
+	FieldHelp  string                 `json:"fieldHelp,omitempty"`
+	AddToForms bool                   `json:"addToForms,omitempty"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+// CreateField adds a field to a table.
+func (c *Client) CreateField(tableId string, field NewField) (created FieldInfo, err error) {
+	err = c.do("POST", "/fields", url.Values{"tableId": {tableId}}, field, &created)
+	return created, err
+}
+
+// GetFields returns the fields of a table.
+func (c *Client) GetFields(tableId string) (fields []FieldInfo, err error) {
+	err = c.do("GET", "/fields", url.Values{"tableId": {tableId}}, nil, &fields)
+	return fields, err
+}
+
+// GetField returns a field of a table.
+func (c *Client) GetField(tableId string, fid int) (field FieldInfo, err error) {
+	err = c.do("GET", "/fields/"+strconv.Itoa(fid), url.Values{"tableId": {tableId}}, nil, &field)
+	return field, err
+}
+
+// FieldUpdate describes the changes UpdateField makes to a field; empty
+// and nil members are left as they are.
+type FieldUpdate struct {
+	Label      string                 `json:"label,omitempty"`
+	FieldHelp  string                 `json:"fieldHelp,omitempty"`
+	Required   *bool                  `json:"required,omitempty"`
+	Unique     *bool                  `json:"unique,omitempty"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+// UpdateField changes a field of a table.
+func (c *Client) UpdateField(tableId string, fid int, update FieldUpdate) (field FieldInfo, err error) {
+	err = c.do("POST", "/fields/"+strconv.Itoa(fid), url.Values{"tableId": {tableId}}, update, &field)
+	return field, err
+}
+
+type deleteFieldsRequest struct {
+	FieldIds []int `json:"fieldIds"`
+}
+
+// DeleteFields deletes fields of a table, returning the IDs of those
+// deleted.  QuickBase refuses to delete built-in and key fields,
+// reporting them in an error.
+func (c *Client) DeleteFields(tableId string, fids ...int) (deleted []int, err error) {
+	var result struct {
+		DeletedFieldIds []int    `json:"deletedFieldIds"`
+		Errors          []string `json:"errors"`
+	}
+	err = c.do("DELETE", "/fields", url.Values{"tableId": {tableId}}, deleteFieldsRequest{fids}, &result)
+	if err == nil && len(result.Errors) > 0 {
+		err = fmt.Errorf("Failed to delete fields: %s", strings.Join(result.Errors, "; "))
+	}
+	return result.DeletedFieldIds, err
+}
+
+// UsageCount is the number of places of one kind in which a field is
+// used.
+type UsageCount struct {
+	Count int `json:"count"`
+}
+
+// FieldUsage reports where a field is used, as returned by GET
+// /fields/usage.
+type FieldUsage struct {
+	Field struct {
+		Id   int    `json:"id"`
+		Name string `json:"name"`
+		Type string `json:"type"`
+	} `json:"field"`
+	Usage struct {
+		Actions         UsageCount `json:"actions"`
+		AppHomePages    UsageCount `json:"appHomePages"`
+		Dashboards      UsageCount `json:"dashboards"`
+		DefaultReports  UsageCount `json:"defaultReports"`
+		ExactForms      UsageCount `json:"exactForms"`
+		Fields          UsageCount `json:"fields"`
+		Forms           UsageCount `json:"forms"`
+		Notifications   UsageCount `json:"notifications"`
+		PersonalReports UsageCount `json:"personalReports"`
+		Pipelines       UsageCount `json:"pipelines"`
+		Relationships   UsageCount `json:"relationships"`
+		Reminders       UsageCount `json:"reminders"`
+		Reports         UsageCount `json:"reports"`
+		Roles           UsageCount `json:"roles"`
+		TableImports    UsageCount `json:"tableImports"`
+		TableRules      UsageCount `json:"tableRules"`
+		Webhooks        UsageCount `json:"webhooks"`
+	} `json:"usage"`
+}
+
+// Total returns the number of places in which the field is used.
+func (u FieldUsage) Total() int {
+	usage := u.Usage
+	return usage.Actions.Count + usage.AppHomePages.Count + usage.Dashboards.Count +
+		usage.DefaultReports.Count + usage.ExactForms.Count + usage.Fields.Count +
+		usage.Forms.Count + usage.Notifications.Count + usage.PersonalReports.Count +
+		usage.Pipelines.Count + usage.Relationships.Count + usage.Reminders.Count +
+		usage.Reports.Count + usage.Roles.Count + usage.TableImports.Count +
+		usage.TableRules.Count + usage.Webhooks.Count
+}
+
+// GetFieldsUsage returns the usage of every field of a table,
+// following QuickBase's paging.
+func (c *Client) GetFieldsUsage(tableId string) (usages []FieldUsage, err error) {
+	for {
+		query := url.Values{"tableId": {tableId}}
+		if len(usages) > 0 {
+			query.Set("skip", strconv.Itoa(len(usages)))
+		}
+		var page []FieldUsage
+		if err = c.do("GET", "/fields/usage", query, nil, &page); err != nil {
+			return nil, err
+		}
+		if len(page) == 0 {
+			return usages, nil
+		}
+		usages = append(usages, page...)
+	}
+}
+
+// GetFieldUsage returns the usage of a single field.
+func (c *Client) GetFieldUsage(tableId string, fid int) (usage FieldUsage, err error) {
+	var usages []FieldUsage
+	err = c.do("GET", "/fields/usage/"+strconv.Itoa(fid), url.Values{"tableId": {tableId}}, nil, &usages)
+	if err == nil && len(usages) != 1 {
+		err = fmt.Errorf("Expected usage of one field, got %d", len(usages))
+	}
+	if err != nil {
+		return usage, err
+	}
+	return usages[0], nil
+}
+
+// UnusedFields returns the fields of a table which are used nowhere
+// at all: in no report, form, relationship, formula, webhook,
+// notification, pipeline or anything else QuickBase tracks.  These
+// are the candidates for deletion in a schema clean-up.
+func (c *Client) UnusedFields(tableId string) (unused []FieldUsage, err error) {
+	usages, err := c.GetFieldsUsage(tableId)
+	if err != nil {
+		return nil, err
+	}
+	for _, usage := range usages {
+		if usage.Total() == 0 {
+			unused = append(unused, usage)
+		}
+	}
+	return unused, nil
+}
@@ -0,0 +1,87 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package restapi_test
+
+import (
+	"encoding/json"
+	"github.com/WesTower/quickbase/restapi"
+	"net/http"
+	"testing"
+)
+
+func TestUnusedFields(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/fields/usage" || r.URL.Query().Get("tableId") != "bck7gp3q2" {
+			t.Errorf("unexpected request %s?%s", r.URL.Path, r.URL.RawQuery)
+		}
+		switch r.URL.Query().Get("skip") {
+		case "":
+			w.Write([]byte(`[{"field": {"id": 6, "name": "Site", "type": "text"}, "usage": {"reports": {"count": 3}, "forms": {"count": 1}}},
+				{"field": {"id": 7, "name": "Legacy Code", "type": "text"}, "usage": {"reports": {"count": 0}}}]`))
+		case "2":
+			w.Write([]byte(`[{"field": {"id": 8, "name": "Parent", "type": "numeric"}, "usage": {"relationships": {"count": 1}}},
+				{"field": {"id": 9, "name": "Old Notes", "type": "text"}, "usage": {}}]`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	})
+	defer server.Close()
+	unused, err := client.UnusedFields("bck7gp3q2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unused) != 2 || unused[0].Field.Id != 7 || unused[1].Field.Id != 9 {
+		t.Errorf("unexpected unused fields %+v", unused)
+	}
+}
+
+func TestUpdateAndDeleteFields(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("tableId") != "bck7gp3q2" {
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "POST /fields/6":
+			if len(body) != 2 || body["label"] != "Site Name" || body["required"] != false {
+				t.Errorf("unexpected update %v", body)
+			}
+			w.Write([]byte(`{"id": 6, "label": "Site Name", "fieldType": "text"}`))
+		case "DELETE /fields":
+			w.Write([]byte(`{"deletedFieldIds": [7], "errors": ["Field: 3 is a built in field and cannot be deleted."]}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+	defer server.Close()
+	required := false
+	field, err := client.UpdateField("bck7gp3q2", 6, restapi.FieldUpdate{Label: "Site Name", Required: &required})
+	if err != nil || field.Label != "Site Name" {
+		t.Errorf("UpdateField gave %+v, %v", field, err)
+	}
+	deleted, err := client.DeleteFields("bck7gp3q2", 3, 7)
+	if err == nil || len(deleted) != 1 || deleted[0] != 7 {
+		t.Errorf("DeleteFields gave %v, %v", deleted, err)
+	}
+}
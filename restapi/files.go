@@ -0,0 +1,71 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package restapi
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+)
+
+// FileVersion describes one version of a file attachment.
+type FileVersion struct {
+	VersionNumber int    `json:"versionNumber"`
+	FileName      string `json:"fileName"`
+	Uploaded      string `json:"uploaded"`
+	Creator       User   `json:"creator"`
+}
+
+type base64ReadCloser struct {
+	io.Reader
+	body io.Closer
+}
+
+func (r base64ReadCloser) Close() error {
+	return r.body.Close()
+}
+
+func filePath(tableId string, rid, fid, version int) string {
+	return fmt.Sprintf("/files/%s/%d/%d/%d", tableId, rid, fid, version)
+}
+
+// DownloadFile retrieves a version of the file attached to a field of
+// a record.  The RESTful API transmits the file base64-encoded; the
+// returned reader yields the decoded contents, and must be closed by
+// the caller.
+func (c *Client) DownloadFile(tableId string, rid, fid, version int) (file io.ReadCloser, err error) {
+	req, err := c.newRequest("GET", filePath(tableId, rid, fid, version), nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+	return base64ReadCloser{base64.NewDecoder(base64.StdEncoding, resp.Body), resp.Body}, nil
+}
+
+// DeleteFile deletes a single version of the file attached to a field
+// of a record, returning the description of the deleted version.
+func (c *Client) DeleteFile(tableId string, rid, fid, version int) (deleted FileVersion, err error) {
+	err = c.do("DELETE", filePath(tableId, rid, fid, version), nil, nil, &deleted)
+	return deleted, err
+}
@@ -0,0 +1,67 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package restapi_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"testing"
+)
+
+func TestDownloadFile(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/files/bck7gp3q2/12/8/2" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte("aGVsbG8sIHdvcmxk"))
+	})
+	defer server.Close()
+	file, err := client.DownloadFile("bck7gp3q2", 12, 8, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	contents, err := ioutil.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(contents) != "hello, world" {
+		t.Errorf("contents are %q", contents)
+	}
+}
+
+func TestDeleteFile(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "DELETE" || r.URL.Path != "/files/bck7gp3q2/12/8/2" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(`{"versionNumber": 2, "fileName": "site.jpg", "uploaded": "2015-04-03T10:00:00Z",
+			"creator": {"email": "jdoe@example.com", "id": "123.abcd", "name": "J Doe", "userName": "jdoe"}}`))
+	})
+	defer server.Close()
+	deleted, err := client.DeleteFile("bck7gp3q2", 12, 8, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted.VersionNumber != 2 || deleted.FileName != "site.jpg" || deleted.Creator.UserName != "jdoe" {
+		t.Errorf("unexpected result %+v", deleted)
+	}
+}
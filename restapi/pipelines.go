@@ -0,0 +1,111 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package restapi
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// A Pipeline describes a QuickBase Pipeline.
+type Pipeline struct {
+	Id      string `json:"id"`
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	Owner   User   `json:"owner"`
+	Updated string `json:"updated"`
+}
+
+// A PipelineRun describes one execution of a Pipeline.  Status is
+// one of 'queued', 'running', 'succeeded', 'failed' or 'cancelled'.
+type PipelineRun struct {
+	Id         string `json:"id"`
+	PipelineId string `json:"pipelineId"`
+	Status     string `json:"status"`
+	Started    string `json:"started"`
+	Finished   string `json:"finished"`
+	Error      string `json:"error"`
+}
+
+// IsFinished reports whether the run has reached a final status.
+func (r PipelineRun) IsFinished() bool {
+	switch r.Status {
+	case "succeeded", "failed", "cancelled":
+		return true
+	}
+	return false
+}
+
+// ListPipelines returns the pipelines visible to the Client's user.
+func (c *Client) ListPipelines() (pipelines []Pipeline, err error) {
+	err = c.do("GET", "/pipelines", nil, nil, &pipelines)
+	return pipelines, err
+}
+
+// TriggerPipeline starts a run of a pipeline whose first step is a
+// callable trigger, passing payload (which is sent as JSON) as the
+// trigger's input.  Unlike the pipeline's webhook URL, the call is
+// authenticated with the Client's credentials.
+func (c *Client) TriggerPipeline(pipelineId string, payload interface{}) (run PipelineRun, err error) {
+	if payload == nil {
+		payload = map[string]interface{}{}
+	}
+	err = c.do("POST", "/pipelines/"+url.PathEscape(pipelineId)+"/trigger", nil, payload, &run)
+	return run, err
+}
+
+// GetPipelineRun returns the current state of a pipeline run.
+func (c *Client) GetPipelineRun(pipelineId, runId string) (run PipelineRun, err error) {
+	err = c.do("GET", "/pipelines/"+url.PathEscape(pipelineId)+"/runs/"+url.PathEscape(runId), nil, nil, &run)
+	return run, err
+}
+
+// DefaultPipelinePollInterval is used by WaitForPipelineRun when its
+// interval is not positive.
+const DefaultPipelinePollInterval = 5 * time.Second
+
+// WaitForPipelineRun polls a pipeline run every interval until it
+// finishes or timeout elapses, returning its last known state.  An
+// interval of zero polls every DefaultPipelinePollInterval, and a
+// timeout of zero waits indefinitely.
+func (c *Client) WaitForPipelineRun(pipelineId, runId string, interval, timeout time.Duration) (run PipelineRun, err error) {
+	if interval <= 0 {
+		interval = DefaultPipelinePollInterval
+	}
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = time.Now().Add(timeout)
+	}
+	for {
+		if run, err = c.GetPipelineRun(pipelineId, runId); err != nil || run.IsFinished() {
+			return run, err
+		}
+		if !deadline.IsZero() && time.Now().Add(interval).After(deadline) {
+			return run, fmt.Errorf("Timed out waiting for pipeline run %s", runId)
+		}
+		select {
+		case <-time.After(interval):
+		case <-c.context().Done():
+			return run, c.context().Err()
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package restapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestTriggerAndWaitForPipeline(t *testing.T) {
+	polls := 0
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/pipelines/p1/trigger":
+			var payload map[string]string
+			json.NewDecoder(r.Body).Decode(&payload)
+			if payload["site"] != "Denver" {
+				t.Errorf("unexpected payload %v", payload)
+			}
+			w.Write([]byte(`{"id": "run1", "pipelineId": "p1", "status": "queued"}`))
+		case r.Method == "GET" && r.URL.Path == "/pipelines/p1/runs/run1":
+			polls++
+			if polls < 3 {
+				w.Write([]byte(`{"id": "run1", "pipelineId": "p1", "status": "running"}`))
+			} else {
+				w.Write([]byte(`{"id": "run1", "pipelineId": "p1", "status": "succeeded"}`))
+			}
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+	defer server.Close()
+	run, err := client.TriggerPipeline("p1", map[string]string{"site": "Denver"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run, err = client.WaitForPipelineRun("p1", run.Id, time.Millisecond, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if run.Status != "succeeded" || polls != 3 {
+		t.Errorf("run is %+v after %d polls", run, polls)
+	}
+
+	// without an interval, the default is too long to poll again
+	// within the timeout
+	polls = 0
+	if run, err = client.WaitForPipelineRun("p1", run.Id, 0, time.Second); err == nil || polls != 1 {
+		t.Errorf("wait without an interval gave %+v, %v after %d polls", run, err, polls)
+	}
+
+	polls = 0
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if run, err = client.WithContext(ctx).WaitForPipelineRun("p1", run.Id, time.Hour, 0); err != context.DeadlineExceeded || polls != 1 {
+		t.Errorf("wait past its deadline gave %+v, %v after %d polls", run, err, polls)
+	}
+}
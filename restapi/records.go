@@ -0,0 +1,247 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package restapi
+
+import (
+	"encoding/json"
+)
+
+// A Query describes a records query, as documented for POST
+// /records/query.  Where uses the same query language as the XML
+// API, e.g. "{'7'.EX.'foo'}".
+type Query struct {
+	From    string        `json:"from"`
+	Select  []int         `json:"select,omitempty"`
+	Where   string        `json:"where,omitempty"`
+	SortBy  []SortField   `json:"sortBy,omitempty"`
+	GroupBy []GroupField  `json:"groupBy,omitempty"`
+	Options *QueryOptions `json:"options,omitempty"`
+}
+
+// QueryOptions controls which slice of the matching records is
+// returned.
+type QueryOptions struct {
+	Skip                    int  `json:"skip,omitempty"`
+	Top                     int  `json:"top,omitempty"`
+	CompareWithAppLocalTime bool `json:"compareWithAppLocalTime,omitempty"`
+}
+
+// QueryResult holds the records matching a query.
+type QueryResult struct {
+	Fields   []Field  `json:"fields"`
+	Data     []Record `json:"data"`
+	Metadata Metadata `json:"metadata"`
+}
+
+// QueryRecordsPage runs a query, returning only the single page of
+// results which QuickBase chooses to send.
+func (c *Client) QueryRecordsPage(query Query) (result QueryResult, err error) {
+	err = c.do("POST", "/records/query", nil, query, &result)
+	return result, err
+}
+
+// QueryRecords runs a query, following the returned metadata to
+// retrieve every matching record.  If query.Options sets Skip, the
+// first Skip records are skipped; if it sets Top, at most Top records
+// are returned.
+func (c *Client) QueryRecords(query Query) (result QueryResult, err error) {
+	it := c.IterateRecords(query)
+	for it.Next() {
+		result.Data = append(result.Data, it.Record())
+	}
+	result.Fields = it.Fields()
+	result.Metadata = it.Metadata()
+	return result, it.Err()
+}
+
+// IterateRecords runs a query, returning an iterator which fetches
+// further pages as the caller consumes records.  Skip and Top in
+// query.Options are honored as in QueryRecords.
+func (c *Client) IterateRecords(query Query) *RecordIterator {
+	var options QueryOptions
+	if query.Options != nil {
+		options = *query.Options
+	}
+	return newRecordIterator(options.Skip, options.Top, func(skip, top int) (page QueryResult, err error) {
+		pageOptions := options
+		pageOptions.Skip = skip
+		pageOptions.Top = top
+		pageQuery := query
+		pageQuery.Options = &pageOptions
+		return c.QueryRecordsPage(pageQuery)
+	})
+}
+
+// RunReportAll runs a saved report, following the returned metadata
+// to retrieve every record in it.
+func (c *Client) RunReportAll(tableId, reportId string) (result ReportResult, err error) {
+	it := c.IterateReport(tableId, reportId)
+	for it.Next() {
+		result.Data = append(result.Data, it.Record())
+	}
+	result.Fields = it.Fields()
+	result.Metadata = it.Metadata()
+	return result, it.Err()
+}
+
+// IterateReport runs a saved report, returning an iterator which
+// fetches further pages as the caller consumes records.
+func (c *Client) IterateReport(tableId, reportId string) *RecordIterator {
+	return newRecordIterator(0, 0, func(skip, top int) (page QueryResult, err error) {
+		result, err := c.RunReport(tableId, reportId, skip, top)
+		return QueryResult(result), err
+	})
+}
+
+// A RecordIterator steps through a result set one record at a time,
+// requesting pages from QuickBase as needed:
+//
+//	it := client.IterateRecords(query)
+//	for it.Next() {
+//		record := it.Record()
+//		...
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type RecordIterator struct {
+	fetch    func(skip, top int) (QueryResult, error)
+	skip     int // offset of the next page to request
+	limit    int // maximum number of records to yield; 0 for no limit
+	yielded  int
+	page     []Record
+	index    int
+	fields   []Field
+	metadata Metadata
+	started  bool
+	done     bool
+	err      error
+}
+
+func newRecordIterator(skip, limit int, fetch func(skip, top int) (QueryResult, error)) *RecordIterator {
+	return &RecordIterator{fetch: fetch, skip: skip, limit: limit, index: -1}
+}
+
+// Next advances to the next record, returning false when the result
+// set is exhausted or an error occurs.
+func (it *RecordIterator) Next() bool {
+	if it.err != nil || (it.limit > 0 && it.yielded >= it.limit) {
+		return false
+	}
+	it.index++
+	for it.index >= len(it.page) {
+		if it.done {
+			return false
+		}
+		top := 0
+		if it.limit > 0 {
+			top = it.limit - it.yielded
+		}
+		page, err := it.fetch(it.skip, top)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		if !it.started {
+			it.fields = page.Fields
+			it.started = true
+		}
+		it.metadata = page.Metadata
+		it.page = page.Data
+		it.index = 0
+		it.skip += len(page.Data)
+		if len(page.Data) == 0 || it.skip >= page.Metadata.TotalRecords {
+			it.done = true
+		}
+	}
+	it.yielded++
+	return true
+}
+
+// Record returns the current record.
+func (it *RecordIterator) Record() Record {
+	return it.page[it.index]
+}
+
+// Fields returns the fields of the result set, once Next has been
+// called.
+func (it *RecordIterator) Fields() []Field {
+	return it.fields
+}
+
+// Metadata returns the metadata of the most recently fetched page.
+func (it *RecordIterator) Metadata() Metadata {
+	return it.metadata
+}
+
+// Err returns the error, if any, which stopped iteration.
+func (it *RecordIterator) Err() error {
+	return it.err
+}
+
+// UpsertRequest describes records to insert or update.  Records
+// whose MergeFieldId value matches an existing record update it;
+// others are inserted.  If MergeFieldId is zero, the table's key
+// field is used.
+type UpsertRequest struct {
+	To             string   `json:"to"`
+	Data           []Record `json:"data"`
+	MergeFieldId   int      `json:"mergeFieldId,omitempty"`
+	FieldsToReturn []int    `json:"fieldsToReturn,omitempty"`
+}
+
+// UpsertResult reports the outcome of UpsertRecords.
+type UpsertResult struct {
+	Data     []Record `json:"data"`
+	Metadata struct {
+		CreatedRecordIds              []int `json:"createdRecordIds"`
+		UpdatedRecordIds              []int `json:"updatedRecordIds"`
+		UnchangedRecordIds            []int `json:"unchangedRecordIds"`
+		TotalNumberOfRecordsProcessed int   `json:"totalNumberOfRecordsProcessed"`
+	} `json:"metadata"`
+}
+
+// NewValue returns a Value holding v, which is encoded as JSON.
+func NewValue(v interface{}) (value Value, err error) {
+	value.Value, err = json.Marshal(v)
+	return value, err
+}
+
+// UpsertRecords inserts and updates records.
+func (c *Client) UpsertRecords(request UpsertRequest) (result UpsertResult, err error) {
+	err = c.do("POST", "/records", nil, request, &result)
+	return result, err
+}
+
+type deleteRequest struct {
+	From  string `json:"from"`
+	Where string `json:"where"`
+}
+
+// DeleteRecords deletes the records of a table which match where,
+// returning the number deleted.
+func (c *Client) DeleteRecords(tableId, where string) (deleted int, err error) {
+	var result struct {
+		NumberDeleted int `json:"numberDeleted"`
+	}
+	err = c.do("DELETE", "/records", nil, deleteRequest{tableId, where}, &result)
+	return result.NumberDeleted, err
+}
@@ -0,0 +1,94 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package restapi_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/WesTower/quickbase/restapi"
+	"net/http"
+	"testing"
+)
+
+// pagedRecords serves total records with IDs 1…total in pages of at
+// most pageSize, as QuickBase does for oversized queries.
+func pagedRecords(t *testing.T, total, pageSize int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var query restapi.Query
+		if err := json.NewDecoder(r.Body).Decode(&query); err != nil {
+			t.Fatal(err)
+		}
+		skip, top := 0, pageSize
+		if query.Options != nil {
+			skip = query.Options.Skip
+			if query.Options.Top > 0 && query.Options.Top < top {
+				top = query.Options.Top
+			}
+		}
+		var data []string
+		for rid := skip + 1; rid <= total && len(data) < top; rid++ {
+			data = append(data, fmt.Sprintf(`{"3": {"value": %d}}`, rid))
+		}
+		fmt.Fprintf(w, `{"fields": [{"id": 3, "label": "Record ID#", "type": "recordid"}], "data": [`)
+		for i, record := range data {
+			if i > 0 {
+				fmt.Fprint(w, ",")
+			}
+			fmt.Fprint(w, record)
+		}
+		fmt.Fprintf(w, `], "metadata": {"numFields": 1, "numRecords": %d, "skip": %d, "top": %d, "totalRecords": %d}}`,
+			len(data), skip, top, total)
+	}
+}
+
+func TestQueryRecords(t *testing.T) {
+	client, server := newTestClient(pagedRecords(t, 25, 10))
+	defer server.Close()
+	result, err := client.QueryRecords(restapi.Query{From: "bck7gp3q2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Data) != 25 {
+		t.Fatalf("got %d records", len(result.Data))
+	}
+	for i, record := range result.Data {
+		if rid, _ := record[3].Int(); rid != int64(i+1) {
+			t.Errorf("record %d has rid %d", i, rid)
+		}
+	}
+}
+
+func TestIterateRecordsSkipTop(t *testing.T) {
+	client, server := newTestClient(pagedRecords(t, 25, 10))
+	defer server.Close()
+	it := client.IterateRecords(restapi.Query{From: "bck7gp3q2", Options: &restapi.QueryOptions{Skip: 5, Top: 12}})
+	var rids []int64
+	for it.Next() {
+		rid, _ := it.Record()[3].Int()
+		rids = append(rids, rid)
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(rids) != 12 || rids[0] != 6 || rids[11] != 17 {
+		t.Errorf("got rids %v", rids)
+	}
+}
@@ -0,0 +1,162 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package restapi
+
+import (
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"net/url"
+	"strconv"
+)
+
+// A Relationship is a parent-child relationship between two tables.
+// Its ID is the field ID of the reference (foreign key) field in the
+// child table.
+type Relationship struct {
+	Id              int     `json:"id"`
+	ParentTableId   string  `json:"parentTableId"`
+	ChildTableId    string  `json:"childTableId"`
+	IsCrossApp      bool    `json:"isCrossApp"`
+	ForeignKeyField Field   `json:"foreignKeyField"`
+	LookupFields    []Field `json:"lookupFields"`
+	SummaryFields   []Field `json:"summaryFields"`
+}
+
+// SummaryField describes a summary field to create in the parent
+// table.  AccumulationType is one of 'SUM', 'AVG', 'MAX', 'MIN',
+// 'STD-DEV', 'COUNT', 'COMBINED-TEXT', 'COMBINED-USER',
+// 'DISTINCT-COUNT'.
+type SummaryField struct {
+	SummaryFid       int    `json:"summaryFid,omitempty"`
+	Label            string `json:"label,omitempty"`
+	AccumulationType string `json:"accumulationType"`
+	Where            string `json:"where,omitempty"`
+}
+
+// RelationshipRequest describes a relationship to create, or the
+// lookup and summary fields to add to an existing one.
+// ParentTableId and ForeignKeyLabel are only used on creation.
+type RelationshipRequest struct {
+	ParentTableId   string         `json:"parentTableId,omitempty"`
+	ForeignKeyField *foreignKey    `json:"foreignKeyField,omitempty"`
+	LookupFieldIds  []int          `json:"lookupFieldIds,omitempty"`
+	SummaryFields   []SummaryField `json:"summaryFields,omitempty"`
+}
+
+type foreignKey struct {
+	Label string `json:"label"`
+}
+
+// ForeignKeyLabel sets the label of the reference field which will be
+// created in the child table.
+func (r *RelationshipRequest) ForeignKeyLabel(label string) {
+	r.ForeignKeyField = &foreignKey{Label: label}
+}
+
+func relationshipPath(childTableId string) string {
+	return "/tables/" + url.PathEscape(childTableId) + "/relationship"
+}
+
+// CreateRelationship creates a relationship between a parent table
+// and childTableId, along with the requested lookup fields (parent
+// field IDs to look up into the child) and summary fields (child
+// fields to summarize into the parent).
+func (c *Client) CreateRelationship(childTableId string, request RelationshipRequest) (relationship Relationship, err error) {
+	err = c.do("POST", relationshipPath(childTableId), nil, request, &relationship)
+	return relationship, err
+}
+
+// UpdateRelationship adds lookup and summary fields to an existing
+// relationship.
+func (c *Client) UpdateRelationship(childTableId string, relationshipId int, request RelationshipRequest) (relationship Relationship, err error) {
+	request.ParentTableId = ""
+	request.ForeignKeyField = nil
+	err = c.do("POST", fmt.Sprintf("%s/%d", relationshipPath(childTableId), relationshipId), nil, request, &relationship)
+	return relationship, err
+}
+
+// DeleteRelationship deletes a relationship, along with its lookup
+// and summary fields; the reference field is kept.
+func (c *Client) DeleteRelationship(childTableId string, relationshipId int) (err error) {
+	return c.do("DELETE", fmt.Sprintf("%s/%d", relationshipPath(childTableId), relationshipId), nil, nil, nil)
+}
+
+type relationshipsPage struct {
+	Relationships []Relationship `json:"relationships"`
+	Metadata      struct {
+		NumRelationships   int `json:"numRelationships"`
+		Skip               int `json:"skip"`
+		TotalRelationships int `json:"totalRelationships"`
+	} `json:"metadata"`
+}
+
+// GetRelationships returns the relationships in which childTableId is
+// the child, following QuickBase's paging.
+func (c *Client) GetRelationships(childTableId string) (relationships []Relationship, err error) {
+	for {
+		var query url.Values
+		if len(relationships) > 0 {
+			query = url.Values{"skip": {strconv.Itoa(len(relationships))}}
+		}
+		var page relationshipsPage
+		if err = c.do("GET", "/tables/"+url.PathEscape(childTableId)+"/relationships", query, nil, &page); err != nil {
+			return nil, err
+		}
+		relationships = append(relationships, page.Relationships...)
+		if len(page.Relationships) == 0 || len(relationships) >= page.Metadata.TotalRelationships {
+			return relationships, nil
+		}
+	}
+}
+
+// Model converts r to the relationship model of the quickbase
+// package, which is shared with the XML API's schema introspection.
+func (r Relationship) Model() quickbase.Relationship {
+	model := quickbase.Relationship{
+		ParentDbid:   r.ParentTableId,
+		ChildDbid:    r.ChildTableId,
+		ReferenceFid: r.ForeignKeyField.Id,
+	}
+	for _, field := range r.LookupFields {
+		model.LookupFids = append(model.LookupFids, field.Id)
+	}
+	for _, field := range r.SummaryFields {
+		model.SummaryFids = append(model.SummaryFids, field.Id)
+	}
+	return model
+}
+
+// GetRelationshipGraph builds the graph of the relationships in which
+// the given tables are children, as quickbase.GetRelationshipGraph
+// does with the XML API.
+func (c *Client) GetRelationshipGraph(tableIds ...string) (graph *quickbase.RelationshipGraph, err error) {
+	graph = &quickbase.RelationshipGraph{}
+	for _, tableId := range tableIds {
+		relationships, err := c.GetRelationships(tableId)
+		if err != nil {
+			return nil, err
+		}
+		for _, relationship := range relationships {
+			graph.Relationships = append(graph.Relationships, relationship.Model())
+		}
+	}
+	return graph, nil
+}
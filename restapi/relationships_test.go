@@ -0,0 +1,105 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package restapi_test
+
+import (
+	"encoding/json"
+	"github.com/WesTower/quickbase/restapi"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestCreateRelationship(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/tables/bck7gp3q3/relationship" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["parentTableId"] != "bck7gp3q2" || body["foreignKeyField"].(map[string]interface{})["label"] != "Related Site" {
+			t.Errorf("unexpected body %v", body)
+		}
+		w.Write([]byte(`{"id": 9, "parentTableId": "bck7gp3q2", "childTableId": "bck7gp3q3",
+			"foreignKeyField": {"id": 9, "label": "Related Site", "type": "numeric"},
+			"lookupFields": [{"id": 10, "label": "Site Name", "type": "text"}],
+			"summaryFields": [{"id": 20, "label": "Total Cost", "type": "currency"}]}`))
+	})
+	defer server.Close()
+	request := restapi.RelationshipRequest{
+		ParentTableId:  "bck7gp3q2",
+		LookupFieldIds: []int{6},
+		SummaryFields:  []restapi.SummaryField{{SummaryFid: 7, Label: "Total Cost", AccumulationType: "SUM"}},
+	}
+	request.ForeignKeyLabel("Related Site")
+	relationship, err := client.CreateRelationship("bck7gp3q3", request)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if relationship.Id != 9 || len(relationship.LookupFields) != 1 || len(relationship.SummaryFields) != 1 {
+		t.Errorf("unexpected relationship %+v", relationship)
+	}
+}
+
+func TestDeleteRelationship(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "DELETE" || r.URL.Path != "/tables/bck7gp3q3/relationship/9" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(`{"relationshipId": 9}`))
+	})
+	defer server.Close()
+	if err := client.DeleteRelationship("bck7gp3q3", 9); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGetRelationshipGraph(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/tables/crews/relationships":
+			w.Write([]byte(`{"relationships": [{"id": 9, "parentTableId": "sites", "childTableId": "crews",
+				"foreignKeyField": {"id": 9}, "lookupFields": [{"id": 10}], "summaryFields": []}],
+				"metadata": {"numRelationships": 1, "skip": 0, "totalRelationships": 1}}`))
+		case "/tables/visits/relationships":
+			w.Write([]byte(`{"relationships": [{"id": 6, "parentTableId": "crews", "childTableId": "visits",
+				"foreignKeyField": {"id": 6}, "lookupFields": [], "summaryFields": [{"id": 20}]}],
+				"metadata": {"numRelationships": 1, "skip": 0, "totalRelationships": 1}}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+	defer server.Close()
+	graph, err := client.GetRelationshipGraph("visits", "crews")
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, err := graph.DependencyOrder()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(order, []string{"sites", "crews", "visits"}) {
+		t.Errorf("dependency order is %v", order)
+	}
+	if children := graph.Children("crews"); len(children) != 1 || children[0].SummaryFids[0] != 20 {
+		t.Errorf("children of crews are %+v", children)
+	}
+}
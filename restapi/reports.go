@@ -0,0 +1,108 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package restapi
+
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+)
+
+// A Report describes a saved report, as returned by GET /reports.
+type Report struct {
+	Id          string                     `json:"id"`
+	Name        string                     `json:"name"`
+	Type        string                     `json:"type"` // e.g. 'table', 'summary', 'calendar'
+	Description string                     `json:"description"`
+	OwnerId     int                        `json:"ownerId"`
+	Query       ReportQuery                `json:"query"`
+	Properties  map[string]json.RawMessage `json:"properties"`
+	UsedLast    string                     `json:"usedLast"`
+	UsedCount   int                        `json:"usedCount"`
+}
+
+// ReportQuery is the query underlying a saved report.
+type ReportQuery struct {
+	TableId       string          `json:"tableId"`
+	Filter        string          `json:"filter"`
+	FormulaFields json.RawMessage `json:"formulaFields"`
+	Fields        []int           `json:"fields"`
+	SortBy        []SortField     `json:"sortBy"`
+	GroupBy       []GroupField    `json:"groupBy"`
+}
+
+// SortField is a single sort criterion.
+type SortField struct {
+	FieldId int    `json:"fieldId"`
+	Order   string `json:"order"` // 'ASC' or 'DESC'
+}
+
+// GroupField is a single grouping criterion.
+type GroupField struct {
+	FieldId  int    `json:"fieldId"`
+	Grouping string `json:"grouping"`
+}
+
+// ReportResult is one page of the results of running a report.
+type ReportResult struct {
+	Fields   []Field  `json:"fields"`
+	Data     []Record `json:"data"`
+	Metadata Metadata `json:"metadata"`
+}
+
+// Field returns the description of the field with the given ID, and
+// whether it is part of the result.
+func (r ReportResult) Field(fid int) (field Field, ok bool) {
+	for _, field := range r.Fields {
+		if field.Id == fid {
+			return field, true
+		}
+	}
+	return field, false
+}
+
+// ListReports returns the reports defined on a table.
+func (c *Client) ListReports(tableId string) (reports []Report, err error) {
+	err = c.do("GET", "/reports", url.Values{"tableId": {tableId}}, nil, &reports)
+	return reports, err
+}
+
+// GetReport returns the definition of a single report.
+func (c *Client) GetReport(tableId, reportId string) (report Report, err error) {
+	err = c.do("GET", "/reports/"+url.PathEscape(reportId), url.Values{"tableId": {tableId}}, nil, &report)
+	return report, err
+}
+
+// RunReport runs a saved report, returning at most top records after
+// skipping the first skip.  A top of zero leaves the page size to
+// QuickBase; compare the returned Metadata's TotalRecords to see
+// whether there are more records to fetch.
+func (c *Client) RunReport(tableId, reportId string, skip, top int) (result ReportResult, err error) {
+	query := url.Values{"tableId": {tableId}}
+	if skip > 0 {
+		query.Set("skip", strconv.Itoa(skip))
+	}
+	if top > 0 {
+		query.Set("top", strconv.Itoa(top))
+	}
+	err = c.do("POST", "/reports/"+url.PathEscape(reportId)+"/run", query, nil, &result)
+	return result, err
+}
@@ -0,0 +1,170 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package restapi_test
+
+import (
+	"github.com/WesTower/quickbase"
+	"github.com/WesTower/quickbase/restapi"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestClient(handler http.HandlerFunc) (client *restapi.Client, server *httptest.Server) {
+	server = httptest.NewServer(handler)
+	client = restapi.NewClient("example.quickbase.com", "b12345_token")
+	client.BaseUrl = server.URL
+	return client, server
+}
+
+func TestRunReport(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/reports/7/run" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if r.URL.Query().Get("tableId") != "bck7gp3q2" || r.URL.Query().Get("skip") != "10" {
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+		if r.Header.Get("Authorization") != "QB-USER-TOKEN b12345_token" {
+			t.Errorf("unexpected authorization %q", r.Header.Get("Authorization"))
+		}
+		if r.Header.Get("QB-Realm-Hostname") != "example.quickbase.com" {
+			t.Errorf("unexpected realm %q", r.Header.Get("QB-Realm-Hostname"))
+		}
+		w.Write([]byte(`{
+			"fields": [{"id": 6, "label": "Site", "type": "text"},
+			           {"id": 7, "label": "Cost", "type": "currency"},
+			           {"id": 8, "label": "Done", "type": "checkbox"},
+			           {"id": 9, "label": "Due", "type": "date"}],
+			"data": [{"6": {"value": "Denver"}, "7": {"value": 12.5}, "8": {"value": true}, "9": {"value": "2015-04-03"}},
+			         {"6": {"value": ""}, "7": {"value": null}, "8": {"value": false}, "9": {"value": null}}],
+			"metadata": {"numFields": 4, "numRecords": 2, "skip": 10, "top": 2, "totalRecords": 14}}`))
+	})
+	defer server.Close()
+	result, err := client.RunReport("bck7gp3q2", "7", 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Metadata.TotalRecords != 14 || len(result.Data) != 2 {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if field, ok := result.Field(7); !ok || field.Type != "currency" {
+		t.Errorf("field 7 is %+v", field)
+	}
+	if site := result.Data[0][6].String(); site != "Denver" {
+		t.Errorf("site is %q", site)
+	}
+	if cost, err := result.Data[0][7].Float(); err != nil || cost != 12.5 {
+		t.Errorf("cost is %v (%v)", cost, err)
+	}
+	if done, err := result.Data[0][8].Bool(); err != nil || !done {
+		t.Errorf("done is %v (%v)", done, err)
+	}
+	if due, err := result.Data[0][9].Time(); err != nil || due.Year() != 2015 {
+		t.Errorf("due is %v (%v)", due, err)
+	}
+	if due, err := result.Data[1][9].Time(); err != nil || !due.IsZero() {
+		t.Errorf("blank due is %v (%v)", due, err)
+	}
+}
+
+func TestReportError(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "Report not found", "description": "Report 99 not found"}`))
+	})
+	defer server.Close()
+	_, err := client.GetReport("bck7gp3q2", "99")
+	switch err := err.(type) {
+	case quickbase.QuickBaseError:
+		if err.HttpStatus != http.StatusNotFound || err.Message != "Report not found" || err.Detail != "Report 99 not found" {
+			t.Errorf("unexpected error %+v", err)
+		}
+		if err.Error() != "Report not found: Report 99 not found" {
+			t.Errorf("unexpected message %q", err.Error())
+		}
+	default:
+		t.Errorf("expected quickbase.QuickBaseError, got %v", err)
+	}
+}
+
+func TestNonJsonError(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		w.Write([]byte("<html>Bad Gateway</html>"))
+	})
+	defer server.Close()
+	_, err := client.ListReports("bck7gp3q2")
+	if qbErr, ok := err.(quickbase.QuickBaseError); !ok || qbErr.HttpStatus != http.StatusBadGateway || qbErr.Message != "Bad Gateway" {
+		t.Errorf("unexpected error %#v", err)
+	}
+}
+
+func TestRateLimitRetry(t *testing.T) {
+	calls := 0
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"message": "Too many requests"}`))
+			return
+		}
+		w.Write([]byte(`[]`))
+	})
+	defer server.Close()
+	client.Limiter = quickbase.NewRateLimiter(0)
+	start := time.Now()
+	if _, err := client.ListReports("bck7gp3q2"); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 {
+		t.Errorf("made %d calls", calls)
+	}
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Errorf("retried after only %s", elapsed)
+	}
+}
+
+func TestRateLimitRetryWithoutHeaders(t *testing.T) {
+	var starts []time.Time
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		starts = append(starts, time.Now())
+		if len(starts) < 3 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"message": "Too many requests"}`))
+			return
+		}
+		w.Write([]byte(`[]`))
+	})
+	defer server.Close()
+	client.RateLimitBackOff = 50 * time.Millisecond
+	if _, err := client.ListReports("bck7gp3q2"); err != nil {
+		t.Fatal(err)
+	}
+	if len(starts) != 3 {
+		t.Fatalf("made %d calls", len(starts))
+	}
+	if first, second := starts[1].Sub(starts[0]), starts[2].Sub(starts[1]); first < 50*time.Millisecond || second < 100*time.Millisecond {
+		t.Errorf("retried after %s and %s", first, second)
+	}
+}
@@ -0,0 +1,188 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package restapi
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A Sandbox pairs a production application with a sandbox copy of
+// it, in which schema changes can be made and tested before being
+// promoted to production.  The tables of the two apps have different
+// dbids; a Sandbox translates between them by matching table aliases.
+type Sandbox struct {
+	AppId        string // the production application
+	SandboxAppId string // the sandbox copy
+
+	toSandbox    map[string]string // production table ID → sandbox table ID
+	toProduction map[string]string
+}
+
+// OpenSandbox checks that sandboxAppId is a copy of appId and returns
+// the Sandbox pairing them.
+func (c *Client) OpenSandbox(appId, sandboxAppId string) (sandbox *Sandbox, err error) {
+	app, err := c.GetApp(sandboxAppId)
+	if err != nil {
+		return nil, err
+	}
+	if app.AncestorId != appId {
+		return nil, fmt.Errorf("App %s is not a copy of %s", sandboxAppId, appId)
+	}
+	production, err := c.GetTables(appId)
+	if err != nil {
+		return nil, err
+	}
+	copied, err := c.GetTables(sandboxAppId)
+	if err != nil {
+		return nil, err
+	}
+	sandbox = &Sandbox{
+		AppId:        appId,
+		SandboxAppId: sandboxAppId,
+		toSandbox:    map[string]string{appId: sandboxAppId},
+		toProduction: map[string]string{sandboxAppId: appId},
+	}
+	byAlias := make(map[string]string)
+	for _, table := range copied {
+		byAlias[table.Alias] = table.Id
+	}
+	for _, table := range production {
+		if id, ok := byAlias[table.Alias]; ok {
+			sandbox.toSandbox[table.Id] = id
+			sandbox.toProduction[id] = table.Id
+		}
+	}
+	return sandbox, nil
+}
+
+// TableId returns the sandbox's ID for a production table or app ID,
+// or the empty string if the sandbox has no such table.
+func (s *Sandbox) TableId(productionId string) string {
+	return s.toSandbox[productionId]
+}
+
+// ProductionTableId returns the production ID for a sandbox table or
+// app ID, or the empty string if production has no such table, e.g.
+// because it was created in the sandbox.
+func (s *Sandbox) ProductionTableId(sandboxId string) string {
+	return s.toProduction[sandboxId]
+}
+
+// TranslateUrl rewrites the production dbids in a QuickBase URL, e.g.
+// 'https://instance.quickbase.com/db/bck7gp3q2?a=q&qid=1', to their
+// sandbox equivalents.
+func (s *Sandbox) TranslateUrl(productionUrl string) string {
+	return translateDbids(productionUrl, s.toSandbox)
+}
+
+// ProductionUrl rewrites the sandbox dbids in a QuickBase URL to their
+// production equivalents.
+func (s *Sandbox) ProductionUrl(sandboxUrl string) string {
+	return translateDbids(sandboxUrl, s.toProduction)
+}
+
+func translateDbids(u string, dbids map[string]string) string {
+	var pairs []string
+	for from, to := range dbids {
+		pairs = append(pairs, "/"+from, "/"+to, "="+from, "="+to)
+	}
+	return strings.NewReplacer(pairs...).Replace(u)
+}
+
+// A FieldPromotion is a field which exists in a sandbox table but not
+// in the corresponding production table.
+type FieldPromotion struct {
+	ProductionTableId string
+	Field             FieldInfo
+}
+
+// PromotionPlan compares the fields of each table of the sandbox with
+// those of its production counterpart, returning the fields (matched
+// by label) which promotion would add to production.  Tables created
+// in the sandbox cannot be promoted, and are reported as an error.
+func (c *Client) PromotionPlan(sandbox *Sandbox) (plan []FieldPromotion, err error) {
+	tables, err := c.GetTables(sandbox.SandboxAppId)
+	if err != nil {
+		return nil, err
+	}
+	for _, table := range tables {
+		productionId := sandbox.ProductionTableId(table.Id)
+		if productionId == "" {
+			return nil, fmt.Errorf("Table %s (%s) exists only in the sandbox", table.Name, table.Id)
+		}
+		sandboxFields, err := c.GetFields(table.Id)
+		if err != nil {
+			return nil, err
+		}
+		productionFields, err := c.GetFields(productionId)
+		if err != nil {
+			return nil, err
+		}
+		existing := make(map[string]bool)
+		for _, field := range productionFields {
+			existing[field.Label] = true
+		}
+		for _, field := range sandboxFields {
+			if !existing[field.Label] {
+				plan = append(plan, FieldPromotion{productionId, field})
+			}
+		}
+	}
+	return plan, nil
+}
+
+// Promote creates the fields of a promotion plan in production, with
+// their type-specific properties, such as a formula or the choices of
+// a multiple-choice field, and then makes them required or unique as
+// they are in the sandbox.  Properties naming other fields or tables
+// by ID are copied as they are, so should name those of production.
+// Lookup and summary fields cannot be promoted, since they belong to
+// relationships, which must be made in production first; a plan
+// holding any is refused before anything is created.
+func (c *Client) Promote(plan []FieldPromotion) (err error) {
+	for _, promotion := range plan {
+		switch promotion.Field.Mode {
+		case "lookup", "summary":
+			return fmt.Errorf("Cannot promote %s field %s: create it with its relationship", promotion.Field.Mode, promotion.Field.Label)
+		}
+	}
+	for _, promotion := range plan {
+		field := NewField{
+			Label:      promotion.Field.Label,
+			FieldType:  promotion.Field.FieldType,
+			FieldHelp:  promotion.Field.FieldHelp,
+			Properties: promotion.Field.Properties,
+		}
+		created, err := c.CreateField(promotion.ProductionTableId, field)
+		if err != nil {
+			return fmt.Errorf("Promoting field %s: %s", promotion.Field.Label, err)
+		}
+		if !promotion.Field.Required && !promotion.Field.Unique {
+			continue
+		}
+		update := FieldUpdate{Required: &promotion.Field.Required, Unique: &promotion.Field.Unique}
+		if _, err = c.UpdateField(promotion.ProductionTableId, created.Id, update); err != nil {
+			return fmt.Errorf("Promoting field %s: %s", promotion.Field.Label, err)
+		}
+	}
+	return nil
+}
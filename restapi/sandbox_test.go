@@ -0,0 +1,114 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package restapi_test
+
+import (
+	"encoding/json"
+	"github.com/WesTower/quickbase/restapi"
+	"net/http"
+	"testing"
+)
+
+func sandboxHandler(t *testing.T, created *[]string) http.HandlerFunc {
+	var createdProperties map[string]interface{}
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch {
+		case r.URL.Path == "/apps/sbx":
+			w.Write([]byte(`{"id": "sbx", "name": "Sites (sandbox)", "ancestorId": "prod"}`))
+		case r.URL.Path == "/tables" && query.Get("appId") == "prod":
+			w.Write([]byte(`[{"id": "prodsites", "name": "Sites", "alias": "_DBID_SITES"}]`))
+		case r.URL.Path == "/tables" && query.Get("appId") == "sbx":
+			w.Write([]byte(`[{"id": "sbxsites", "name": "Sites", "alias": "_DBID_SITES"}]`))
+		case r.URL.Path == "/fields" && r.Method == "GET" && query.Get("tableId") == "prodsites":
+			w.Write([]byte(`[{"id": 6, "label": "Site", "fieldType": "text"}]`))
+		case r.URL.Path == "/fields" && r.Method == "GET" && query.Get("tableId") == "sbxsites":
+			w.Write([]byte(`[{"id": 6, "label": "Site", "fieldType": "text"}, {"id": 7, "label": "Region", "fieldType": "text", "required": true,
+				"properties": {"choices": ["East", "West"]}}]`))
+		case r.URL.Path == "/fields" && r.Method == "POST" && query.Get("tableId") == "prodsites":
+			var field struct {
+				Label      string                 `json:"label"`
+				Properties map[string]interface{} `json:"properties"`
+			}
+			json.NewDecoder(r.Body).Decode(&field)
+			*created = append(*created, field.Label)
+			createdProperties = field.Properties
+			w.Write([]byte(`{"id": 7, "label": "Region", "fieldType": "text"}`))
+		case r.URL.Path == "/fields/7" && r.Method == "POST" && query.Get("tableId") == "prodsites":
+			var update map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&update)
+			if update["required"] != true || update["unique"] != false {
+				t.Errorf("updated promoted field with %v", update)
+			}
+			if choices, _ := createdProperties["choices"].([]interface{}); len(choices) != 2 {
+				t.Errorf("created promoted field with properties %v", createdProperties)
+			}
+			*created = append(*created, "required")
+			w.Write([]byte(`{"id": 7, "label": "Region", "fieldType": "text", "required": true}`))
+		default:
+			t.Errorf("unexpected request %s %s?%s", r.Method, r.URL.Path, r.URL.RawQuery)
+		}
+	}
+}
+
+func TestSandboxPromotion(t *testing.T) {
+	var created []string
+	client, server := newTestClient(sandboxHandler(t, &created))
+	defer server.Close()
+	sandbox, err := client.OpenSandbox("prod", "sbx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id := sandbox.TableId("prodsites"); id != "sbxsites" {
+		t.Errorf("sandbox table is %q", id)
+	}
+	if u := sandbox.TranslateUrl("https://example.quickbase.com/db/prodsites?a=dr&rid=5"); u != "https://example.quickbase.com/db/sbxsites?a=dr&rid=5" {
+		t.Errorf("translated URL is %s", u)
+	}
+	plan, err := client.PromotionPlan(sandbox)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan) != 1 || plan[0].Field.Label != "Region" || plan[0].ProductionTableId != "prodsites" {
+		t.Fatalf("plan is %+v", plan)
+	}
+	if err := client.Promote(plan); err != nil {
+		t.Fatal(err)
+	}
+	if len(created) != 2 || created[0] != "Region" || created[1] != "required" {
+		t.Errorf("created %v", created)
+	}
+
+	created = nil
+	plan = append(plan, restapi.FieldPromotion{ProductionTableId: "prodsites", Field: restapi.FieldInfo{Id: 8, Label: "Manager", FieldType: "text", Mode: "lookup"}})
+	if err := client.Promote(plan); err == nil || len(created) != 0 {
+		t.Errorf("Promote of a lookup field gave %v, created %v", err, created)
+	}
+}
+
+func TestOpenSandboxNotACopy(t *testing.T) {
+	var created []string
+	client, server := newTestClient(sandboxHandler(t, &created))
+	defer server.Close()
+	if _, err := client.OpenSandbox("other", "sbx"); err == nil {
+		t.Error("opened a sandbox of the wrong app")
+	}
+}
@@ -0,0 +1,84 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package restapi
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+)
+
+// A Solution identifies a solution created or updated from QBL.
+type Solution struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+const qblContentType = "application/x-yaml"
+
+// ExportSolution exports a solution as QBL (Quickbase Language, a
+// YAML dialect).  The returned reader must be closed by the caller.
+func (c *Client) ExportSolution(solutionId string) (qbl io.ReadCloser, err error) {
+	req, err := c.newRawRequest("GET", "/solutions/"+url.PathEscape(solutionId), nil, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", qblContentType)
+	resp, err := c.send(req)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// CreateSolution creates a new solution from QBL, typically the
+// output of ExportSolution run against another realm or solution.
+func (c *Client) CreateSolution(qbl io.Reader) (solution Solution, err error) {
+	err = c.sendQbl("POST", "/solutions", qbl, &solution)
+	return solution, err
+}
+
+// UpdateSolution updates an existing solution to match QBL, e.g. to
+// promote changes made in a staging solution to production.
+func (c *Client) UpdateSolution(solutionId string, qbl io.Reader) (solution Solution, err error) {
+	err = c.sendQbl("PUT", "/solutions/"+url.PathEscape(solutionId), qbl, &solution)
+	return solution, err
+}
+
+// SolutionChangeset returns, as raw JSON, the changes UpdateSolution
+// would make for the same QBL without making them.
+func (c *Client) SolutionChangeset(solutionId string, qbl io.Reader) (changeset json.RawMessage, err error) {
+	err = c.sendQbl("PUT", "/solutions/"+url.PathEscape(solutionId)+"/changeset", qbl, &changeset)
+	return changeset, err
+}
+
+func (c *Client) sendQbl(method, path string, qbl io.Reader, result interface{}) (err error) {
+	req, err := c.newRawRequest(method, path, nil, qbl, qblContentType)
+	if err != nil {
+		return err
+	}
+	resp, err := c.send(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(result)
+}
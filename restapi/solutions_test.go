@@ -0,0 +1,70 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package restapi_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+const testQbl = "qbl-version: 0.1\nresources:\n  app_1:\n    type: app\n"
+
+func TestExportSolution(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" || r.URL.Path != "/solutions/sol1" || r.Header.Get("Accept") != "application/x-yaml" {
+			t.Errorf("unexpected request %s %s (%s)", r.Method, r.URL.Path, r.Header.Get("Accept"))
+		}
+		w.Write([]byte(testQbl))
+	})
+	defer server.Close()
+	qbl, err := client.ExportSolution("sol1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer qbl.Close()
+	contents, _ := ioutil.ReadAll(qbl)
+	if string(contents) != testQbl {
+		t.Errorf("exported %q", contents)
+	}
+}
+
+func TestUpdateSolution(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Method != "PUT" || r.URL.Path != "/solutions/sol2" || string(body) != testQbl {
+			t.Errorf("unexpected request %s %s %q", r.Method, r.URL.Path, body)
+		}
+		if r.Header.Get("Content-Type") != "application/x-yaml" {
+			t.Errorf("unexpected content type %q", r.Header.Get("Content-Type"))
+		}
+		w.Write([]byte(`{"id": "sol2", "name": "Production"}`))
+	})
+	defer server.Close()
+	solution, err := client.UpdateSolution("sol2", strings.NewReader(testQbl))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if solution.Id != "sol2" {
+		t.Errorf("unexpected solution %+v", solution)
+	}
+}
@@ -0,0 +1,171 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package restapi
+
+import (
+	"fmt"
+)
+
+// A Summary is the result of running a summary report: one row for
+// each group of records, holding the values of the fields grouped by
+// and of the fields summarized, e.g. the total of a numeric field.
+type Summary struct {
+	Report          Report
+	GroupFields     []Field // the fields grouped by, in the report's order
+	AggregateFields []Field // the summarized fields, in the result's order
+	Rows            []SummaryRow
+}
+
+// A SummaryRow is one group of a Summary.
+type SummaryRow struct {
+	Keys       []Value // by GroupFields
+	Aggregates []Value // by AggregateFields
+}
+
+// RunSummaryReport runs a saved summary report, retrieving every row
+// of it, and decodes the rows into a Summary.
+func (c *Client) RunSummaryReport(tableId, reportId string) (summary Summary, err error) {
+	report, err := c.GetReport(tableId, reportId)
+	if err != nil {
+		return summary, err
+	}
+	if report.Type != "summary" {
+		return summary, fmt.Errorf("Report %s is a %s report, not a summary report", reportId, report.Type)
+	}
+	result, err := c.RunReportAll(tableId, reportId)
+	if err != nil {
+		return summary, err
+	}
+	return NewSummary(report, result)
+}
+
+// NewSummary decodes the result of running a summary report whose
+// definition is report.  The fields of the result which the report
+// groups by are the keys of each row, and the rest its aggregates.
+func NewSummary(report Report, result ReportResult) (summary Summary, err error) {
+	summary.Report = report
+	grouped := make(map[int]bool)
+	for _, group := range report.Query.GroupBy {
+		field, ok := result.Field(group.FieldId)
+		if !ok {
+			return summary, fmt.Errorf("Summary report %s groups by field %d, which its result lacks", report.Id, group.FieldId)
+		}
+		summary.GroupFields = append(summary.GroupFields, field)
+		grouped[field.Id] = true
+	}
+	for _, field := range result.Fields {
+		if !grouped[field.Id] {
+			summary.AggregateFields = append(summary.AggregateFields, field)
+		}
+	}
+	for _, record := range result.Data {
+		row := SummaryRow{
+			Keys:       make([]Value, len(summary.GroupFields)),
+			Aggregates: make([]Value, len(summary.AggregateFields)),
+		}
+		for i, field := range summary.GroupFields {
+			row.Keys[i] = record[field.Id]
+		}
+		for i, field := range summary.AggregateFields {
+			row.Aggregates[i] = record[field.Id]
+		}
+		summary.Rows = append(summary.Rows, row)
+	}
+	return summary, nil
+}
+
+// Find returns the row whose keys, as text, are keys.
+func (s Summary) Find(keys ...string) (row SummaryRow, ok bool) {
+	for _, row := range s.Rows {
+		if len(row.Keys) == len(keys) && row.matches(keys) {
+			return row, true
+		}
+	}
+	return row, false
+}
+
+func (r SummaryRow) matches(keys []string) bool {
+	for i, key := range keys {
+		if r.Keys[i].String() != key {
+			return false
+		}
+	}
+	return true
+}
+
+// A Crosstab is a Summary pivoted so that the groups of its last
+// group field form columns: each row holds, for each column, the
+// aggregates of the records in both the row's and the column's group.
+type Crosstab struct {
+	RowFields       []Field // the fields grouping rows
+	ColumnField     Field   // the field grouping columns
+	AggregateFields []Field
+	Columns         []Value // the column groups, in order of appearance
+	Rows            []CrosstabRow
+}
+
+// A CrosstabRow is one row of a Crosstab.
+type CrosstabRow struct {
+	Keys  []Value   // by RowFields
+	Cells [][]Value // by Columns, then AggregateFields; nil for an empty cell
+}
+
+// Crosstab pivots the summary on its last group field, as QuickBase
+// displays a summary report with a column grouping.  The summary
+// must be grouped by at least two fields.
+func (s Summary) Crosstab() (crosstab Crosstab, err error) {
+	n := len(s.GroupFields)
+	if n < 2 {
+		return crosstab, fmt.Errorf("Summary report %s has %d group fields; a crosstab needs two or more", s.Report.Id, n)
+	}
+	crosstab.RowFields = s.GroupFields[:n-1]
+	crosstab.ColumnField = s.GroupFields[n-1]
+	crosstab.AggregateFields = s.AggregateFields
+	columns := make(map[string]int)
+	rows := make(map[string]int)
+	for _, row := range s.Rows {
+		column := row.Keys[n-1].String()
+		if _, ok := columns[column]; !ok {
+			columns[column] = len(crosstab.Columns)
+			crosstab.Columns = append(crosstab.Columns, row.Keys[n-1])
+		}
+		key := fmt.Sprintf("%q", keyStrings(row.Keys[:n-1]))
+		if _, ok := rows[key]; !ok {
+			rows[key] = len(crosstab.Rows)
+			crosstab.Rows = append(crosstab.Rows, CrosstabRow{Keys: row.Keys[:n-1]})
+		}
+	}
+	for i := range crosstab.Rows {
+		crosstab.Rows[i].Cells = make([][]Value, len(crosstab.Columns))
+	}
+	for _, row := range s.Rows {
+		r := rows[fmt.Sprintf("%q", keyStrings(row.Keys[:n-1]))]
+		crosstab.Rows[r].Cells[columns[row.Keys[n-1].String()]] = row.Aggregates
+	}
+	return crosstab, nil
+}
+
+func keyStrings(keys []Value) (strings []string) {
+	for _, key := range keys {
+		strings = append(strings, key.String())
+	}
+	return strings
+}
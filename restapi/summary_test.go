@@ -0,0 +1,102 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package restapi_test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func summaryHandler(t *testing.T, reportType string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/reports/9":
+			w.Write([]byte(`{"id": "9", "name": "Cost by region and year", "type": "` + reportType + `",
+				"query": {"tableId": "bck7gp3q2", "groupBy": [{"fieldId": 10, "grouping": "equal-values"},
+				                                             {"fieldId": 11, "grouping": "equal-values"}]}}`))
+		case r.Method == "POST" && r.URL.Path == "/reports/9/run":
+			w.Write([]byte(`{
+				"fields": [{"id": 10, "label": "Region", "type": "text"},
+				           {"id": 11, "label": "Year", "type": "numeric"},
+				           {"id": 7, "label": "Cost", "type": "currency"},
+				           {"id": 3, "label": "Record ID#", "type": "recordid"}],
+				"data": [{"10": {"value": "West"}, "11": {"value": 2014}, "7": {"value": 15.5}, "3": {"value": 2}},
+				         {"10": {"value": "West"}, "11": {"value": 2015}, "7": {"value": 3}, "3": {"value": 1}},
+				         {"10": {"value": "East"}, "11": {"value": 2015}, "7": {"value": 40}, "3": {"value": 4}}],
+				"metadata": {"numFields": 4, "numRecords": 3, "skip": 0, "top": 3, "totalRecords": 3}}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+}
+
+func TestRunSummaryReport(t *testing.T) {
+	client, server := newTestClient(summaryHandler(t, "summary"))
+	defer server.Close()
+	summary, err := client.RunSummaryReport("bck7gp3q2", "9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(summary.GroupFields) != 2 || summary.GroupFields[0].Label != "Region" || summary.GroupFields[1].Label != "Year" {
+		t.Errorf("group fields %+v", summary.GroupFields)
+	}
+	if len(summary.AggregateFields) != 2 || summary.AggregateFields[0].Id != 7 || summary.AggregateFields[1].Id != 3 {
+		t.Errorf("aggregate fields %+v", summary.AggregateFields)
+	}
+	row, ok := summary.Find("West", "2014")
+	if !ok {
+		t.Fatal("no row for West, 2014")
+	}
+	if total, err := row.Aggregates[0].Float(); err != nil || total != 15.5 {
+		t.Errorf("West 2014 total is %v (%v)", total, err)
+	}
+	if _, ok = summary.Find("East", "2014"); ok {
+		t.Error("found a row for East, 2014")
+	}
+
+	crosstab, err := summary.Crosstab()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if crosstab.ColumnField.Id != 11 || len(crosstab.Columns) != 2 || crosstab.Columns[1].String() != "2015" {
+		t.Errorf("crosstab columns %+v %v", crosstab.ColumnField, crosstab.Columns)
+	}
+	if len(crosstab.Rows) != 2 || crosstab.Rows[1].Keys[0].String() != "East" {
+		t.Fatalf("crosstab rows %+v", crosstab.Rows)
+	}
+	if crosstab.Rows[1].Cells[0] != nil {
+		t.Errorf("East 2014 cell is %v", crosstab.Rows[1].Cells[0])
+	}
+	if count, err := crosstab.Rows[0].Cells[1][1].Int(); err != nil || count != 1 {
+		t.Errorf("West 2015 count is %v (%v)", count, err)
+	}
+}
+
+func TestRunSummaryReportOfTable(t *testing.T) {
+	client, server := newTestClient(summaryHandler(t, "table"))
+	defer server.Close()
+	_, err := client.RunSummaryReport("bck7gp3q2", "9")
+	if err == nil || !strings.Contains(err.Error(), "not a summary report") {
+		t.Errorf("table report gave %v", err)
+	}
+}
@@ -0,0 +1,83 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package restapi
+
+import (
+	"net/url"
+)
+
+// A Table describes a table, as returned by the /tables endpoints.
+// Its Alias, e.g. '_DBID_WORK_ORDERS', is the same in every copy of
+// an application, whereas its Id is not.
+type Table struct {
+	Id                 string `json:"id"`
+	Name               string `json:"name"`
+	Alias              string `json:"alias"`
+	Description        string `json:"description"`
+	Created            string `json:"created"`
+	Updated            string `json:"updated"`
+	KeyFieldId         int    `json:"keyFieldId"`
+	NextFieldId        int    `json:"nextFieldId"`
+	NextRecordId       int    `json:"nextRecordId"`
+	DefaultSortFieldId int    `json:"defaultSortFieldId"`
+	DefaultSortOrder   string `json:"defaultSortOrder"`
+	SingleRecordName   string `json:"singleRecordName"`
+	PluralRecordName   string `json:"pluralRecordName"`
+}
+
+// GetTables returns the tables of an application.
+func (c *Client) GetTables(appId string) (tables []Table, err error) {
+	err = c.do("GET", "/tables", url.Values{"appId": {appId}}, nil, &tables)
+	return tables, err
+}
+
+// TableProperties describes a table to be created by CreateTable, or
+// the properties UpdateTable changes; empty properties are left as
+// they are.
+type TableProperties struct {
+	Name             string `json:"name,omitempty"`
+	Description      string `json:"description,omitempty"`
+	SingleRecordName string `json:"singleRecordName,omitempty"`
+	PluralRecordName string `json:"pluralRecordName,omitempty"`
+}
+
+// GetTable returns a table of an application.
+func (c *Client) GetTable(appId, tableId string) (table Table, err error) {
+	err = c.do("GET", "/tables/"+url.PathEscape(tableId), url.Values{"appId": {appId}}, nil, &table)
+	return table, err
+}
+
+// CreateTable adds a table to an application.
+func (c *Client) CreateTable(appId string, properties TableProperties) (table Table, err error) {
+	err = c.do("POST", "/tables", url.Values{"appId": {appId}}, properties, &table)
+	return table, err
+}
+
+// UpdateTable changes the properties of a table.
+func (c *Client) UpdateTable(appId, tableId string, properties TableProperties) (table Table, err error) {
+	err = c.do("POST", "/tables/"+url.PathEscape(tableId), url.Values{"appId": {appId}}, properties, &table)
+	return table, err
+}
+
+// DeleteTable deletes a table, with its records, from an application.
+func (c *Client) DeleteTable(appId, tableId string) (err error) {
+	return c.do("DELETE", "/tables/"+url.PathEscape(tableId), url.Values{"appId": {appId}}, nil, nil)
+}
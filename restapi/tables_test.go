@@ -0,0 +1,64 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package restapi_test
+
+import (
+	"encoding/json"
+	"github.com/WesTower/quickbase/restapi"
+	"net/http"
+	"testing"
+)
+
+func TestTables(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("appId") != "bck7gp3q1" {
+			t.Errorf("unexpected query %s", r.URL.RawQuery)
+		}
+		switch r.Method + " " + r.URL.Path {
+		case "POST /tables":
+			var properties map[string]string
+			if err := json.NewDecoder(r.Body).Decode(&properties); err != nil {
+				t.Error(err)
+			}
+			if len(properties) != 2 || properties["name"] != "Sites" || properties["singleRecordName"] != "Site" {
+				t.Errorf("unexpected properties %v", properties)
+			}
+			w.Write([]byte(`{"id": "bck7gp3q3", "name": "Sites", "alias": "_DBID_SITES", "singleRecordName": "Site"}`))
+		case "GET /tables/bck7gp3q3":
+			w.Write([]byte(`{"id": "bck7gp3q3", "name": "Sites", "keyFieldId": 3}`))
+		case "DELETE /tables/bck7gp3q3":
+			w.Write([]byte(`{"deletedTableId": "bck7gp3q3"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	})
+	defer server.Close()
+	table, err := client.CreateTable("bck7gp3q1", restapi.TableProperties{Name: "Sites", SingleRecordName: "Site"})
+	if err != nil || table.Id != "bck7gp3q3" || table.Alias != "_DBID_SITES" {
+		t.Errorf("CreateTable gave %+v, %v", table, err)
+	}
+	if table, err = client.GetTable("bck7gp3q1", "bck7gp3q3"); err != nil || table.KeyFieldId != 3 {
+		t.Errorf("GetTable gave %+v, %v", table, err)
+	}
+	if err = client.DeleteTable("bck7gp3q1", "bck7gp3q3"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,79 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package restapi
+
+// A UserToken describes a user token, as returned by the user token
+// endpoints.  Token holds the token itself, and is only populated
+// when a token is created by CloneUserToken.
+type UserToken struct {
+	Id          int      `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Active      bool     `json:"active"`
+	Apps        []AppRef `json:"apps"`
+	LastUsed    string   `json:"lastUsed"`
+	Token       string   `json:"token"`
+}
+
+// An AppRef identifies an application.
+type AppRef struct {
+	Id   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type tokenId struct {
+	Id int `json:"id"`
+}
+
+// CloneUserToken creates a new user token with the same application
+// assignments as the token the Client authenticates with.  The new
+// token is returned in the result's Token field.
+func (c *Client) CloneUserToken(name, description string) (token UserToken, err error) {
+	body := map[string]string{"name": name, "description": description}
+	err = c.do("POST", "/usertoken/clone", nil, body, &token)
+	return token, err
+}
+
+// TransferUserToken transfers the user token with the given ID from
+// one user to another; from and to are QuickBase user IDs.  The
+// Client's user token must belong to a realm or account admin.
+func (c *Client) TransferUserToken(id int, from, to string) (token UserToken, err error) {
+	body := map[string]interface{}{"id": id, "from": from, "to": to}
+	err = c.do("POST", "/usertoken/transfer", nil, body, &token)
+	return token, err
+}
+
+// DeactivateUserToken deactivates the user token the Client
+// authenticates with, returning its ID.  The Client cannot make
+// further calls afterwards.
+func (c *Client) DeactivateUserToken() (id int, err error) {
+	var result tokenId
+	err = c.do("POST", "/usertoken/deactivate", nil, nil, &result)
+	return result.Id, err
+}
+
+// DeleteUserToken permanently deletes the user token the Client
+// authenticates with, returning its ID.
+func (c *Client) DeleteUserToken() (id int, err error) {
+	var result tokenId
+	err = c.do("DELETE", "/usertoken", nil, nil, &result)
+	return result.Id, err
+}
@@ -0,0 +1,64 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package restapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestCloneUserToken(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/usertoken/clone" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if body["name"] != "rotated" {
+			t.Errorf("unexpected body %v", body)
+		}
+		w.Write([]byte(`{"id": 42, "name": "rotated", "active": true, "apps": [{"id": "bck7gp3q2", "name": "Sites"}], "token": "b12345_new"}`))
+	})
+	defer server.Close()
+	token, err := client.CloneUserToken("rotated", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token.Token != "b12345_new" || len(token.Apps) != 1 || !token.Active {
+		t.Errorf("unexpected token %+v", token)
+	}
+}
+
+func TestDeactivateUserToken(t *testing.T) {
+	client, server := newTestClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/usertoken/deactivate" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		w.Write([]byte(`{"id": 42}`))
+	})
+	defer server.Close()
+	if id, err := client.DeactivateUserToken(); err != nil || id != 42 {
+		t.Errorf("id is %d (%v)", id, err)
+	}
+}
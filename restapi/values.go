@@ -0,0 +1,142 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package restapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// A Field describes a column of a result set.
+type Field struct {
+	Id    int    `json:"id"`
+	Label string `json:"label"`
+	Type  string `json:"type"`
+}
+
+// A Value is a single field value from a result set.  The RESTful API
+// represents values with the JSON type appropriate to the field type;
+// the accessors convert it to the corresponding Go type.
+type Value struct {
+	Value json.RawMessage `json:"value"`
+}
+
+// A Record maps field IDs to values.
+type Record map[int]Value
+
+// A User is the value of a user field.
+type User struct {
+	Email    string `json:"email"`
+	Id       string `json:"id"`
+	Name     string `json:"name"`
+	UserName string `json:"userName"`
+}
+
+// Metadata describes the slice of a result set which was returned.
+type Metadata struct {
+	NumFields    int `json:"numFields"`
+	NumRecords   int `json:"numRecords"`
+	Skip         int `json:"skip"`
+	Top          int `json:"top"`
+	TotalRecords int `json:"totalRecords"`
+}
+
+// IsNull reports whether the value is missing or JSON null.
+func (v Value) IsNull() bool {
+	return len(v.Value) == 0 || bytes.Equal(v.Value, []byte("null"))
+}
+
+// String returns the value as text: strings are returned as-is, null
+// as the empty string and anything else as its JSON representation.
+func (v Value) String() string {
+	if v.IsNull() {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(v.Value, &s); err == nil {
+		return s
+	}
+	return string(v.Value)
+}
+
+// Float returns the value of a numeric, currency, percent or rating
+// field.
+func (v Value) Float() (f float64, err error) {
+	if v.IsNull() {
+		return 0, nil
+	}
+	err = json.Unmarshal(v.Value, &f)
+	return f, err
+}
+
+// Int returns the value of a numeric field which holds an integer,
+// e.g. a record ID.
+func (v Value) Int() (i int64, err error) {
+	if v.IsNull() {
+		return 0, nil
+	}
+	err = json.Unmarshal(v.Value, &i)
+	return i, err
+}
+
+// Bool returns the value of a checkbox field.
+func (v Value) Bool() (b bool, err error) {
+	if v.IsNull() {
+		return false, nil
+	}
+	err = json.Unmarshal(v.Value, &b)
+	return b, err
+}
+
+// Time returns the value of a date, date/time or time-of-day field.
+// Blank values yield the zero time.
+func (v Value) Time() (t time.Time, err error) {
+	s := v.String()
+	if s == "" {
+		return t, nil
+	}
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02", "15:04:05"} {
+		if t, err = time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return t, fmt.Errorf("Cannot parse %q as a date or time", s)
+}
+
+// User returns the value of a user field.
+func (v Value) User() (u User, err error) {
+	if v.IsNull() {
+		return u, nil
+	}
+	err = json.Unmarshal(v.Value, &u)
+	return u, err
+}
+
+// Decode unmarshals the value into dst, for types which have no
+// dedicated accessor, e.g. multi-select text or file attachments.
+func (v Value) Decode(dst interface{}) error {
+	if v.IsNull() {
+		return nil
+	}
+	return json.Unmarshal(v.Value, dst)
+}
@@ -0,0 +1,100 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"strconv"
+)
+
+// A Role is a role of an application, which grants its users an
+// access level and permissions.
+type Role struct {
+	Id     int
+	Name   string
+	Access Access
+}
+
+// An Access is the access level a role grants, e.g. 'Basic Access' or
+// 'Administrator'.
+type Access struct {
+	Id   int
+	Name string
+}
+
+// GetRoleInfo returns the roles of the application appDbid.
+func GetRoleInfo(ticket Ticket, appDbid string) (roles []Role, err error) {
+	var result roleInfoResponse
+	if err = executeApiCall(ticket, ticket.url+"db/"+appDbid, "API_GetRoleInfo", ticket.params(), &result); err != nil {
+		return nil, err
+	}
+	return result.Roles.roles(), nil
+}
+
+// AddUserToRole gives the user userid the role roleid in the
+// application appDbid, in addition to any roles they have.
+func AddUserToRole(ticket Ticket, appDbid, userid string, roleid int) (err error) {
+	return userRoleCall(ticket, appDbid, "API_AddUserToRole", userid, roleid).execute(nil)
+}
+
+// RemoveUserFromRole takes the role roleid away from the user userid
+// in the application appDbid.
+func RemoveUserFromRole(ticket Ticket, appDbid, userid string, roleid int) (err error) {
+	return userRoleCall(ticket, appDbid, "API_RemoveUserFromRole", userid, roleid).execute(nil)
+}
+
+// ChangeUserRole replaces the role roleid of the user userid in the
+// application appDbid with newRoleid.  If newRoleid is zero, the user
+// keeps the role but loses their access to the application.
+func ChangeUserRole(ticket Ticket, appDbid, userid string, roleid, newRoleid int) (err error) {
+	return changeUserRoleCall(ticket, appDbid, userid, roleid, newRoleid).execute(nil)
+}
+
+func userRoleCall(ticket Ticket, appDbid, action, userid string, roleid int) apiCall {
+	params := ticket.params()
+	params["userid"] = userid
+	params["roleid"] = strconv.Itoa(roleid)
+	return apiCall{ticket, ticket.url + "db/" + appDbid, action, params}
+}
+
+func changeUserRoleCall(ticket Ticket, appDbid, userid string, roleid, newRoleid int) apiCall {
+	call := userRoleCall(ticket, appDbid, "API_ChangeUserRole", userid, roleid)
+	call.params["newroleid"] = ""
+	if newRoleid != 0 {
+		call.params["newroleid"] = strconv.Itoa(newRoleid)
+	}
+	return call
+}
+
+func (c *Client) GetRoleInfo(appDbid string) (roles []Role, err error) {
+	return GetRoleInfo(c.ticket(), appDbid)
+}
+
+func (c *Client) AddUserToRole(appDbid, userid string, roleid int) (err error) {
+	return c.mutate(userRoleCall(c.ticket(), appDbid, "API_AddUserToRole", userid, roleid), nil)
+}
+
+func (c *Client) RemoveUserFromRole(appDbid, userid string, roleid int) (err error) {
+	return c.mutate(userRoleCall(c.ticket(), appDbid, "API_RemoveUserFromRole", userid, roleid), nil)
+}
+
+func (c *Client) ChangeUserRole(appDbid, userid string, roleid, newRoleid int) (err error) {
+	return c.mutate(changeUserRoleCall(c.ticket(), appDbid, userid, roleid, newRoleid), nil)
+}
@@ -0,0 +1,83 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"github.com/WesTower/quickbase"
+	"reflect"
+	"testing"
+)
+
+func TestRoles(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	roles, err := client.GetRoleInfo(testAppDbid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := make(map[string]quickbase.Role)
+	for _, role := range roles {
+		byName[role.Name] = role
+	}
+	viewer, participant, admin := byName["Viewer"], byName["Participant"], byName["Administrator"]
+	if viewer.Id == 0 || participant.Id == 0 || admin.Access.Name != "Administrator" {
+		t.Fatalf("roles are %v", roles)
+	}
+	userRoles := func() []quickbase.Role {
+		users, err := client.UserRoles(testAppDbid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(users) != 1 {
+			t.Fatalf("users are %v", users)
+		}
+		return users[0].Roles
+	}
+	if got := userRoles(); !reflect.DeepEqual(got, []quickbase.Role{participant}) {
+		t.Errorf("initial roles are %v", got)
+	}
+	_, _, userid := client.Ticket.Credentials()
+	if err = client.AddUserToRole(testAppDbid, userid, admin.Id); err != nil {
+		t.Fatal(err)
+	}
+	if err = client.ChangeUserRole(testAppDbid, userid, participant.Id, viewer.Id); err != nil {
+		t.Fatal(err)
+	}
+	if got := userRoles(); !reflect.DeepEqual(got, []quickbase.Role{viewer, admin}) {
+		t.Errorf("after adding and changing roles, roles are %v", got)
+	}
+	if err = client.RemoveUserFromRole(testAppDbid, userid, admin.Id); err != nil {
+		t.Fatal(err)
+	}
+	if err = client.ChangeUserRole(testAppDbid, userid, viewer.Id, 0); err != nil {
+		t.Fatal(err)
+	}
+	if got := userRoles(); len(got) != 1 || got[0].Id != viewer.Id || got[0].Access.Name != "None" {
+		t.Errorf("after removing access, roles are %v", got)
+	}
+	if err = client.RemoveUserFromRole(testAppDbid, userid, admin.Id); err == nil {
+		t.Error("RemoveUserFromRole succeeded for a role the user lacks")
+	}
+}
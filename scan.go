@@ -0,0 +1,501 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// timeType is the type of a time.Time.
+var timeType = reflect.TypeOf(time.Time{})
+
+// A Record is a record of a query result read by a Scanner, holding
+// its values in the order of its fields.  The Scanner reuses a Record
+// for every record it reads, so neither the Record nor the slices
+// returned by its Bytes method may be kept past the next call to
+// Next; the strings returned by Value are the caller's own.
+type Record struct {
+	Fids   []int
+	values []byte // the values, concatenated
+	ends   []int  // the end of each value in values
+}
+
+// Bytes returns the value of the field with the given ID without
+// copying it, and whether the record has that field.
+func (r *Record) Bytes(fid int) (value []byte, ok bool) {
+	for i, f := range r.Fids {
+		if f == fid {
+			return r.values[r.start(i):r.ends[i]], true
+		}
+	}
+	return nil, false
+}
+
+// Value returns the value of the field with the given ID, or "" if
+// the record has no such field.
+func (r *Record) Value(fid int) string {
+	value, _ := r.Bytes(fid)
+	return string(value)
+}
+
+// Map returns the record as DoStructuredQuery would.
+func (r *Record) Map() map[int]string {
+	record := make(map[int]string, len(r.Fids))
+	for i, fid := range r.Fids {
+		record[fid] = string(r.values[r.start(i):r.ends[i]])
+	}
+	return record
+}
+
+func (r *Record) start(i int) int {
+	if i == 0 {
+		return 0
+	}
+	return r.ends[i-1]
+}
+
+func (r *Record) reset() {
+	r.Fids, r.values, r.ends = r.Fids[:0], r.values[:0], r.ends[:0]
+}
+
+// A Scanner reads the records of a query one at a time as QuickBase
+// sends them, decoding each into the same Record, so that even
+// queries of millions of rows make little garbage.  Typical use is:
+//
+//	scanner, err := quickbase.ScanQuery(ticket, dbid, query, clist, "", "")
+//	if err != nil {
+//		return err
+//	}
+//	defer scanner.Close()
+//	for scanner.Next() {
+//		record := scanner.Record()
+//		...
+//	}
+//	return scanner.Err()
+type Scanner struct {
+	body     io.ReadCloser
+	decoder  *xml.Decoder
+	record   Record
+	fids     map[string]int // field IDs, by the text of their id attributes
+	only     map[int]bool   // if set, the fields decoded; others are skipped
+	atRecord bool           // whether the start of a record has been read
+	err      error
+}
+
+// ScanQuery starts a query as DoStructuredQuery does, returning a
+// Scanner reading its records.  The caller must Close the Scanner.
+func ScanQuery(ticket Ticket, dbid, query, clist, slist, options string) (scanner *Scanner, err error) {
+	return scanQuery(context.Background(), ticket, dbid, query, clist, slist, options)
+}
+
+func scanQuery(ctx context.Context, ticket Ticket, dbid, query, clist, slist, options string) (scanner *Scanner, err error) {
+	params := ticket.params()
+	params["fmt"] = "structured"
+	if query != "" {
+		params["query"] = query
+	}
+	if clist != "" {
+		params["clist"] = clist
+	}
+	if slist != "" {
+		params["slist"] = slist
+	}
+	if options != "" {
+		params["options"] = options
+	}
+	http_req, release, err := newApiRequest(ticket.url+"db/"+dbid, "API_DoQuery", params)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	resp, err := ticket.do(http_req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	return newScanner(resp)
+}
+
+// ScanOptions selects the records of a query read by ForEachRecord,
+// with the meanings of the arguments of ScanQuery.  If Only is set,
+// it limits the fields decoded as Scanner.Only does.
+type ScanOptions struct {
+	Query   string
+	Clist   string
+	Slist   string
+	Options string
+	Only    []int
+}
+
+// ForEachRecord runs a query, calling each with every record as it is
+// read.  It stops at the first error returned by each, which it
+// returns, or when ctx is done, returning ctx.Err().  As with
+// Scanner.Record, each must not keep the Record after it returns.
+func ForEachRecord(ctx context.Context, ticket Ticket, dbid string, options ScanOptions, each func(*Record) error) (err error) {
+	scanner, err := scanQuery(ctx, ticket, dbid, options.Query, options.Clist, options.Slist, options.Options)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	defer scanner.Close()
+	if options.Only != nil {
+		scanner.Only(options.Only...)
+	}
+	for scanner.Next() {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		if err = each(scanner.Record()); err != nil {
+			return err
+		}
+	}
+	if err = scanner.Err(); err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+func newScanner(resp *http.Response) (scanner *Scanner, err error) {
+	scanner = &Scanner{body: resp.Body, decoder: xml.NewDecoder(resp.Body), fids: make(map[string]int)}
+	if scanner.atRecord, err = readQueryHeader(scanner.decoder, resp.StatusCode); err != nil {
+		closeBody(resp.Body)
+		return nil, err
+	}
+	return scanner, nil
+}
+
+// Only limits the fields decoded into each Record to those with the
+// given IDs; the values of others are skipped without being copied.
+// This saves work when a query's clist must include fields the caller
+// does not need, e.g. those of a saved report.  Only should be called
+// before the first call to Next.
+func (s *Scanner) Only(fids ...int) {
+	s.only = make(map[int]bool, len(fids))
+	for _, fid := range fids {
+		s.only[fid] = true
+	}
+}
+
+// Next reads the next record, returning false at the end of the
+// records or on an error, which Err then returns.
+func (s *Scanner) Next() bool {
+	if s.err != nil {
+		return false
+	}
+	if !s.atRecord {
+		if s.atRecord, s.err = s.nextRecord(); !s.atRecord {
+			return false
+		}
+	}
+	s.atRecord = false
+	s.record.reset()
+	if s.err = s.readRecord(); s.err != nil {
+		return false
+	}
+	return true
+}
+
+// nextRecord reads up to the start of the next record, returning
+// false at the end of the response.
+func (s *Scanner) nextRecord() (ok bool, err error) {
+	for {
+		token, err := s.decoder.RawToken()
+		if err == io.EOF {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			if token.Name.Local == "record" {
+				return true, nil
+			}
+		case xml.EndElement:
+			if token.Name.Local == "qdbapi" {
+				return false, nil
+			}
+		}
+	}
+}
+
+// readRecord reads the fields of a record, its start having been read.
+func (s *Scanner) readRecord() error {
+	inField := false
+	for {
+		token, err := s.decoder.RawToken()
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return err
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			switch {
+			case !inField && token.Name.Local == "f":
+				fid := s.fid(token.Attr)
+				if s.only != nil && !s.only[fid] {
+					if err = s.skip(); err != nil {
+						return err
+					}
+					continue
+				}
+				inField = true
+				s.record.Fids = append(s.record.Fids, fid)
+			case inField && token.Name.Local == "BR":
+				// apparently, QuickBase internally uses carriage returns to separate lines
+				s.record.values = append(s.record.values, '\r')
+			case inField:
+				return fmt.Errorf("Cannot handle tag %s within value for field %d", token.Name.Local, s.record.Fids[len(s.record.Fids)-1])
+			default:
+				if err = s.skip(); err != nil {
+					return err
+				}
+			}
+		case xml.EndElement:
+			switch {
+			case inField && token.Name.Local == "f":
+				inField = false
+				s.record.ends = append(s.record.ends, len(s.record.values))
+			case !inField && token.Name.Local == "record":
+				return nil
+			}
+		case xml.CharData:
+			if inField {
+				s.record.values = append(s.record.values, token...)
+			}
+		}
+	}
+}
+
+// skip reads to the end of the element whose start was just read.
+func (s *Scanner) skip() error {
+	for depth := 1; depth > 0; {
+		token, err := s.decoder.RawToken()
+		if err == io.EOF {
+			return io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return err
+		}
+		switch token.(type) {
+		case xml.StartElement:
+			depth++
+		case xml.EndElement:
+			depth--
+		}
+	}
+	return nil
+}
+
+// fid returns the field ID given by an f element's attributes,
+// parsing each distinct id only once.
+func (s *Scanner) fid(attrs []xml.Attr) int {
+	for _, attr := range attrs {
+		if attr.Name.Local != "id" {
+			continue
+		}
+		fid, ok := s.fids[attr.Value]
+		if !ok {
+			fid, _ = strconv.Atoi(attr.Value)
+			s.fids[attr.Value] = fid
+		}
+		return fid
+	}
+	return 0
+}
+
+// Record returns the record last read by Next.  It is overwritten by
+// the next call to Next.
+func (s *Scanner) Record() *Record {
+	return &s.record
+}
+
+// Scan copies the record last read by Next into the struct to which
+// dst points.  Each exported field tagged `qb:"fid=N"` receives the
+// value of field N, converted to the field's type, which may be a
+// string, bool, any integer or floating-point type, a time.Time (from
+// a date or date/time field), or a Null type.  An empty value sets the
+// zero value, or a Null type not Valid; a field not in the record
+// leaves a Null type not Present.  Fields tagged by label, which
+// Unmarshal accepts, are an error.
+func (s *Scanner) Scan(dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("Cannot scan into %T; a pointer to a struct is required", dst)
+	}
+	v = v.Elem()
+	fields, err := scanFields(v.Type())
+	if err != nil {
+		return err
+	}
+	for _, field := range fields {
+		if field.fid == 0 {
+			return fmt.Errorf("Cannot scan field %s by label; tag it with its field ID", field.label)
+		}
+		value, present := s.record.Bytes(field.fid)
+		if err = decodeField(v.Field(field.index), field, value, present); err != nil {
+			return fmt.Errorf("Field %d: %s", field.fid, err)
+		}
+	}
+	return nil
+}
+
+// Err returns the error which ended the records early, if any.
+func (s *Scanner) Err() error {
+	return s.err
+}
+
+// Close closes the response, which may be done before all of its
+// records are read.
+func (s *Scanner) Close() error {
+	closeBody(s.body)
+	return nil
+}
+
+// A scanField is a struct field into which Scan copies a value, and
+// from which Marshal takes one, as its qb tag says: `qb:"fid=N"` or
+// `qb:"label=L"`, optionally followed by ",date" (a time.Time is
+// written as a date rather than a date/time) and ",omitempty" (a zero
+// value is not written).
+type scanField struct {
+	index     int
+	fid       int    // if zero, the field is tagged by label
+	label     string // the label's tag, as FieldTag gives
+	date      bool
+	omitempty bool
+}
+
+// scanFieldCache holds the scanFields of each struct type scanned.
+var scanFieldCache sync.Map
+
+// scanFields returns the fields of a struct type tagged with the field
+// each receives.
+func scanFields(t reflect.Type) (fields []scanField, err error) {
+	if cached, ok := scanFieldCache.Load(t); ok {
+		return cached.([]scanField), nil
+	}
+	for i := 0; i < t.NumField(); i++ {
+		tag := t.Field(i).Tag.Get("qb")
+		if tag == "" || t.Field(i).PkgPath != "" {
+			continue
+		}
+		field, ok := parseScanTag(tag)
+		if !ok {
+			return nil, fmt.Errorf("Malformed qb tag %q on %s.%s", tag, t.Name(), t.Field(i).Name)
+		}
+		field.index = i
+		fields = append(fields, field)
+	}
+	scanFieldCache.Store(t, fields)
+	return fields, nil
+}
+
+func parseScanTag(tag string) (field scanField, ok bool) {
+	parts := strings.Split(tag, ",")
+	switch {
+	case strings.HasPrefix(parts[0], "fid="):
+		fid, err := strconv.Atoi(strings.TrimPrefix(parts[0], "fid="))
+		if err != nil || fid <= 0 {
+			return field, false
+		}
+		field.fid = fid
+	case strings.HasPrefix(parts[0], "label=") && len(parts[0]) > len("label="):
+		field.label = FieldTag(strings.TrimPrefix(parts[0], "label="))
+	default:
+		return field, false
+	}
+	for _, option := range parts[1:] {
+		switch option {
+		case "date":
+			field.date = true
+		case "omitempty":
+			field.omitempty = true
+		default:
+			return field, false
+		}
+	}
+	return field, true
+}
+
+// decodeField sets a struct field from a QuickBase value, which is
+// present if the record has the field.  A date is given in UTC, so
+// that its calendar date is that of the field.
+func decodeField(v reflect.Value, field scanField, value []byte, present bool) (err error) {
+	if n, ok := v.Addr().Interface().(nullDecoder); ok {
+		return n.decode(value, present)
+	}
+	if err = setField(v, value); err == nil && field.date && v.Type() == timeType {
+		v.Set(reflect.ValueOf(v.Interface().(time.Time).UTC()))
+	}
+	return err
+}
+
+// setField sets a struct field from a QuickBase value.
+func setField(v reflect.Value, value []byte) (err error) {
+	if len(value) == 0 {
+		v.Set(reflect.Zero(v.Type()))
+		return nil
+	}
+	if v.Type() == timeType {
+		msecs, err := strconv.ParseInt(string(value), 10, 64)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(time.Unix(msecs/1000, (msecs%1000)*int64(time.Millisecond))))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(string(value))
+	case reflect.Bool:
+		v.SetBool(string(value) == "1" || strings.EqualFold(string(value), "true"))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var n int64
+		if n, err = strconv.ParseInt(string(value), 10, v.Type().Bits()); err == nil {
+			v.SetInt(n)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var n uint64
+		if n, err = strconv.ParseUint(string(value), 10, v.Type().Bits()); err == nil {
+			v.SetUint(n)
+		}
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		if f, err = strconv.ParseFloat(string(value), v.Type().Bits()); err == nil {
+			v.SetFloat(f)
+		}
+	default:
+		return fmt.Errorf("Cannot scan into a %s", v.Type())
+	}
+	return err
+}
@@ -0,0 +1,255 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"github.com/WesTower/quickbase/quickbasetest"
+	"strings"
+	"testing"
+	"time"
+)
+
+type site struct {
+	Name    string  `qb:"fid=6"`
+	Cost    float64 `qb:"fid=7"`
+	Rid     int     `qb:"fid=3"`
+	ignored string
+}
+
+func TestScanQuery(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	scanner, err := client.ScanQuery(testTableDbid, "", "3.6.7", "6", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer scanner.Close()
+	var sites []site
+	for scanner.Next() {
+		var s site
+		if err = scanner.Scan(&s); err != nil {
+			t.Fatal(err)
+		}
+		sites = append(sites, s)
+		if record := scanner.Record().Map(); record[6] != s.Name {
+			t.Errorf("Map gave %v for %v", record, s)
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(sites) != 2 || sites[0].Name != "Boise" || sites[0].Cost != 3 || sites[1].Cost != 12.5 || sites[1].Rid == 0 {
+		t.Errorf("scanned %+v", sites)
+	}
+	if err = scanner.Scan(&struct {
+		Bad string `qb:"6"`
+	}{}); err == nil {
+		t.Error("Scan with a malformed tag succeeded")
+	}
+	if _, err = client.ScanQuery(testTableDbid, "{'99'.EX.'x'}", "", "", ""); err == nil {
+		t.Error("ScanQuery of a missing field succeeded")
+	}
+}
+
+func TestScanNulls(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	server.Seed(testTableDbid, map[int]string{6: "Mesa"})
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	scanner, err := client.ScanQuery(testTableDbid, "", "2.6.7", "3", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer scanner.Close()
+	type nullSite struct {
+		Modified quickbase.NullTime   `qb:"fid=2"`
+		Name     quickbase.NullString `qb:"fid=6"`
+		Cost     quickbase.NullFloat  `qb:"fid=7"`
+		Rid      quickbase.NullInt    `qb:"fid=3"`
+	}
+	var sites []nullSite
+	for scanner.Next() {
+		var s nullSite
+		if err = scanner.Scan(&s); err != nil {
+			t.Fatal(err)
+		}
+		sites = append(sites, s)
+	}
+	if len(sites) != 3 {
+		t.Fatalf("scanned %+v", sites)
+	}
+	if s := sites[0]; !s.Modified.Valid || time.Since(s.Modified.Time) > time.Minute || s.Name.String != "Denver" || s.Cost.Float != 12.5 || !s.Cost.Valid {
+		t.Errorf("scanned %+v", s)
+	}
+	if s := sites[2]; s.Cost.Valid || !s.Cost.Present || s.Rid.Present || s.Rid.Valid {
+		t.Errorf("scanned %+v", s)
+	}
+}
+
+func TestForEachRecord(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	err = client.ForEachRecord(context.Background(), testTableDbid, quickbase.ScanOptions{Clist: "6.7", Slist: "6", Only: []int{6}}, func(record *quickbase.Record) error {
+		if _, ok := record.Bytes(7); ok {
+			t.Errorf("field 7 was decoded despite Only")
+		}
+		names = append(names, record.Value(6))
+		return nil
+	})
+	if err != nil || fmt.Sprint(names) != "[Boise Denver]" {
+		t.Errorf("ForEachRecord read %v: %v", names, err)
+	}
+
+	stop := errors.New("stop")
+	calls := 0
+	err = client.ForEachRecord(context.Background(), testTableDbid, quickbase.ScanOptions{}, func(*quickbase.Record) error {
+		calls++
+		return stop
+	})
+	if err != stop || calls != 1 {
+		t.Errorf("ForEachRecord stopped after %d calls with %v", calls, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls = 0
+	err = client.ForEachRecord(ctx, testTableDbid, quickbase.ScanOptions{}, func(*quickbase.Record) error {
+		calls++
+		cancel()
+		return nil
+	})
+	if err != context.Canceled || calls != 1 {
+		t.Errorf("cancelled ForEachRecord stopped after %d calls with %v", calls, err)
+	}
+	if err = client.ForEachRecord(ctx, testTableDbid, quickbase.ScanOptions{}, func(*quickbase.Record) error {
+		t.Error("callback run with a cancelled context")
+		return nil
+	}); err != context.Canceled {
+		t.Errorf("ForEachRecord with a cancelled context gave %v", err)
+	}
+	if err = client.ForEachRecord(context.Background(), testTableDbid, quickbase.ScanOptions{Query: "{'99'.EX.'x'}"}, func(*quickbase.Record) error {
+		return nil
+	}); err == nil {
+		t.Error("ForEachRecord of a missing field succeeded")
+	}
+}
+
+func TestScanQueryCorpus(t *testing.T) {
+	server, ticket := fixtureTicket(t, "API_DoQuery", "structured_query.xml")
+	defer server.Close()
+	want, err := quickbase.DoStructuredQuery(ticket, "bck7gp3q2", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	scanner, err := quickbase.ScanQuery(ticket, "bck7gp3q2", "", "", "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer scanner.Close()
+	var got []map[int]string
+	for scanner.Next() {
+		got = append(got, scanner.Record().Map())
+	}
+	if err = scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("ScanQuery gave %v; DoStructuredQuery gave %v", got, want)
+	}
+}
+
+func TestScannerOnly(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	ticket, err := authenticate(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scanner, err := quickbase.ScanQuery(ticket, testTableDbid, "", "3.6.7", "6", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer scanner.Close()
+	scanner.Only(7)
+	var costs []string
+	for scanner.Next() {
+		record := scanner.Record()
+		if len(record.Fids) != 1 || record.Fids[0] != 7 {
+			t.Errorf("record holds fields %v", record.Fids)
+		}
+		costs = append(costs, record.Value(7))
+	}
+	if err = scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(costs) != "[3 12.50]" {
+		t.Errorf("scanned costs %v", costs)
+	}
+}
+
+// BenchmarkScanQuery scans a response of many records, to measure the
+// garbage made per record.
+func BenchmarkScanQuery(b *testing.B) {
+	var response strings.Builder
+	response.WriteString(`<?xml version="1.0" ?><qdbapi><action>API_DoQuery</action><errcode>0</errcode>` +
+		`<errtext>No error</errtext><table><records>`)
+	for i := 0; i < 10000; i++ {
+		fmt.Fprintf(&response, `<record rid="%d"><f id="3">%d</f><f id="6">Site %d</f><f id="7">%d.50</f></record>`, i, i, i, i)
+	}
+	response.WriteString(`</records></table></qdbapi>`)
+	server := quickbasetest.NewFixtureServer(map[string][]byte{"API_DoQuery": []byte(response.String())})
+	defer server.Close()
+	ticket, err := quickbase.Authenticate(server.BaseUrl(), "fixture", "fixture")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		scanner, err := quickbase.ScanQuery(ticket, "bck7gp3q2", "", "", "", "")
+		if err != nil {
+			b.Fatal(err)
+		}
+		var s site
+		for scanner.Next() {
+			scanner.Scan(&s)
+		}
+		scanner.Close()
+		if err = scanner.Err(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
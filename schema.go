@@ -0,0 +1,333 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Schema describes a table, as returned by API_GetSchema.  The schema
+// of an application has no fields, but lists its tables.
+type Schema struct {
+	Dbid    string
+	Name    string
+	Fields  []Field
+	Queries []Query  // the table's saved queries, i.e. its reports
+	Tables  []string // for an application, the dbids of its tables
+	// TableAliases maps the alias of each table of an application,
+	// e.g. '_dbid_sites', to its dbid.
+	TableAliases map[string]string
+	// Variables holds the application's variables (DBVars) by name.
+	Variables map[string]string
+}
+
+// Field describes a single field of a table.  Type is the field_type
+// reported by QuickBase, e.g. 'text', 'float', 'checkbox', 'date' or
+// 'timestamp'.
+type Field struct {
+	Id           int
+	Label        string
+	Type         string
+	Mode         string // '' for ordinary fields, else e.g. 'virtual', 'lookup' or 'summary'
+	ParentDbid   string // for a reference field, the dbid of the parent table
+	ReferenceFid int    // for a lookup field, the reference field it looks up through
+	Required     bool
+	Unique       bool
+	Choices      []string // the values offered for a multiple-choice field
+	Formula      string   // for a formula field, its formula
+}
+
+// FieldTag returns the XML tag QuickBase gives the values of a field
+// with the given label in the records of API_DoQuery, which are the
+// keys of the maps DoQuery returns: the label lowercased, with every
+// character but the letters a to z and digits replaced by an
+// underscore, and an underscore prefixed if it begins with a digit.
+func FieldTag(label string) string {
+	tag := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		}
+		return '_'
+	}, label)
+	if tag != "" && tag[0] >= '0' && tag[0] <= '9' {
+		tag = "_" + tag
+	}
+	return tag
+}
+
+// TagCollisions returns, by tag, the fields of the schema whose
+// labels give the same FieldTag, of which DoQuery returns the value
+// of only one.  It returns nil if there are none.
+func (s Schema) TagCollisions() (collisions map[string][]Field) {
+	byTag := make(map[string][]Field)
+	for _, field := range s.Fields {
+		tag := FieldTag(field.Label)
+		byTag[tag] = append(byTag[tag], field)
+	}
+	for tag, fields := range byTag {
+		if len(fields) > 1 {
+			if collisions == nil {
+				collisions = make(map[string][]Field)
+			}
+			collisions[tag] = fields
+		}
+	}
+	return collisions
+}
+
+// A Query is a saved query (report) of a table.  Criteria, Clist and
+// Slist are as for DoQuery.
+type Query struct {
+	Id       int
+	Name     string
+	Type     string // e.g. 'table', 'chart' or 'calendar'
+	Criteria string
+	Clist    string
+	Slist    string
+}
+
+type schemaResponse struct {
+	qdbapiResponse
+	Table *struct {
+		Name    text `xml:"name"`
+		TableId text `xml:"table_id"`
+		Fields  []struct {
+			Id              int    `xml:"id,attr"`
+			FieldType       string `xml:"field_type,attr"`
+			Mode            string `xml:"mode,attr"`
+			Label           text   `xml:"label"`
+			Mastag          text   `xml:"mastag"`
+			LookupSourceFid text   `xml:"lookup_source_fid"`
+			Required        text   `xml:"required"`
+			Unique          text   `xml:"unique"`
+			Choices         []text `xml:"choices>choice"`
+			Formula         text   `xml:"formula"`
+		} `xml:"fields>field"`
+		Queries []struct {
+			Id       int  `xml:"id,attr"`
+			Name     text `xml:"qyname"`
+			Type     text `xml:"qytype"`
+			Criteria text `xml:"qycrit"`
+			Clist    text `xml:"qyclst"`
+			Slist    text `xml:"qyslst"`
+		} `xml:"queries>query"`
+		Chdbids []struct {
+			Name string `xml:"name,attr"`
+			Dbid text   `xml:",chardata"`
+		} `xml:"chdbids>chdbid"`
+		Variables []struct {
+			Name  string `xml:"name,attr"`
+			Value text   `xml:",chardata"`
+		} `xml:"variables>var"`
+	} `xml:"table"`
+}
+
+// GetSchema returns the schema of a table, per
+// <http://www.quickbase.com/api-guide/index.html#getschema.html>.
+func GetSchema(ticket Ticket, dbid string) (schema Schema, err error) {
+	params := ticket.params()
+	var result schemaResponse
+	if err = executeApiCall(ticket, ticket.url+"db/"+dbid, "API_GetSchema", params, &result); err != nil {
+		return schema, err
+	}
+	table := result.Table
+	if table == nil {
+		return schema, fmt.Errorf("No table returned from API_GetSchema")
+	}
+	schema.Name = string(table.Name)
+	schema.Dbid = string(table.TableId)
+	for _, field := range table.Fields {
+		referenceFid, _ := strconv.Atoi(string(field.LookupSourceFid))
+		var choices []string
+		for _, choice := range field.Choices {
+			choices = append(choices, string(choice))
+		}
+		schema.Fields = append(schema.Fields, Field{
+			Id:           field.Id,
+			Label:        string(field.Label),
+			Type:         field.FieldType,
+			Mode:         field.Mode,
+			ParentDbid:   string(field.Mastag),
+			ReferenceFid: referenceFid,
+			Required:     field.Required == "1",
+			Unique:       field.Unique == "1",
+			Choices:      choices,
+			Formula:      string(field.Formula),
+		})
+	}
+	for _, query := range table.Queries {
+		schema.Queries = append(schema.Queries, Query{
+			Id:       query.Id,
+			Name:     string(query.Name),
+			Type:     string(query.Type),
+			Criteria: string(query.Criteria),
+			Clist:    string(query.Clist),
+			Slist:    string(query.Slist),
+		})
+	}
+	for _, chdbid := range table.Chdbids {
+		schema.Tables = append(schema.Tables, string(chdbid.Dbid))
+		if chdbid.Name != "" {
+			if schema.TableAliases == nil {
+				schema.TableAliases = make(map[string]string)
+			}
+			schema.TableAliases[chdbid.Name] = string(chdbid.Dbid)
+		}
+	}
+	for _, variable := range table.Variables {
+		if schema.Variables == nil {
+			schema.Variables = make(map[string]string)
+		}
+		schema.Variables[variable.Name] = string(variable.Value)
+	}
+	if schema.Dbid == "" {
+		schema.Dbid = dbid
+	}
+	return schema, nil
+}
+
+// A Relationship links a parent table to a child table through a
+// reference field in the child, whose value is the key of a record of
+// the parent.
+type Relationship struct {
+	ParentDbid   string
+	ChildDbid    string
+	ReferenceFid int   // the reference field, in the child table
+	LookupFids   []int // fields of the child looked up from the parent
+	SummaryFids  []int // fields of the parent summarizing the children, if known
+}
+
+// Relationships returns the relationships in which the schema's table
+// is the child, as far as they can be derived from API_GetSchema: the
+// summary fields of the parent table are not reported.
+func (s Schema) Relationships() (relationships []Relationship) {
+	index := make(map[int]int)
+	for _, field := range s.Fields {
+		if field.ParentDbid != "" {
+			index[field.Id] = len(relationships)
+			relationships = append(relationships, Relationship{
+				ParentDbid:   field.ParentDbid,
+				ChildDbid:    s.Dbid,
+				ReferenceFid: field.Id,
+			})
+		}
+	}
+	for _, field := range s.Fields {
+		if i, ok := index[field.ReferenceFid]; ok && field.Mode == "lookup" {
+			relationships[i].LookupFids = append(relationships[i].LookupFids, field.Id)
+		}
+	}
+	return relationships
+}
+
+// A RelationshipGraph is the graph of the relationships between a set
+// of tables, however it was introspected.
+type RelationshipGraph struct {
+	Relationships []Relationship
+}
+
+// GetRelationshipGraph builds the graph of the relationships in which
+// the given tables are children.
+func GetRelationshipGraph(ticket Ticket, dbids ...string) (graph *RelationshipGraph, err error) {
+	graph = &RelationshipGraph{}
+	for _, dbid := range dbids {
+		schema, err := GetSchema(ticket, dbid)
+		if err != nil {
+			return nil, err
+		}
+		graph.Relationships = append(graph.Relationships, schema.Relationships()...)
+	}
+	return graph, nil
+}
+
+// Parents returns the relationships in which dbid is the child.
+func (g *RelationshipGraph) Parents(dbid string) (relationships []Relationship) {
+	for _, relationship := range g.Relationships {
+		if relationship.ChildDbid == dbid {
+			relationships = append(relationships, relationship)
+		}
+	}
+	return relationships
+}
+
+// Children returns the relationships in which dbid is the parent.
+func (g *RelationshipGraph) Children(dbid string) (relationships []Relationship) {
+	for _, relationship := range g.Relationships {
+		if relationship.ParentDbid == dbid {
+			relationships = append(relationships, relationship)
+		}
+	}
+	return relationships
+}
+
+// DependencyOrder returns the tables of the graph ordered so that
+// every parent precedes its children, e.g. the order in which to load
+// data.  It is an error for the graph to contain a cycle, other than
+// a table which is its own parent.
+func (g *RelationshipGraph) DependencyOrder() (dbids []string, err error) {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int)
+	var tables []string
+	for _, relationship := range g.Relationships {
+		for _, dbid := range []string{relationship.ParentDbid, relationship.ChildDbid} {
+			if _, ok := state[dbid]; !ok {
+				state[dbid] = unvisited
+				tables = append(tables, dbid)
+			}
+		}
+	}
+	var visit func(dbid string) error
+	visit = func(dbid string) error {
+		switch state[dbid] {
+		case visited:
+			return nil
+		case visiting:
+			return fmt.Errorf("Relationship cycle through table %s", dbid)
+		}
+		state[dbid] = visiting
+		for _, relationship := range g.Parents(dbid) {
+			if relationship.ParentDbid == dbid {
+				continue
+			}
+			if err := visit(relationship.ParentDbid); err != nil {
+				return err
+			}
+		}
+		state[dbid] = visited
+		dbids = append(dbids, dbid)
+		return nil
+	}
+	for _, dbid := range tables {
+		if err := visit(dbid); err != nil {
+			return nil, err
+		}
+	}
+	return dbids, nil
+}
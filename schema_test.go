@@ -0,0 +1,86 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"github.com/WesTower/quickbase"
+	"reflect"
+	"testing"
+)
+
+func TestSchemaRelationships(t *testing.T) {
+	schema := quickbase.Schema{
+		Dbid: "visits",
+		Fields: []quickbase.Field{
+			{Id: 6, Label: "Related Crew", Type: "float", ParentDbid: "crews"},
+			{Id: 7, Label: "Crew Name", Type: "text", Mode: "lookup", ReferenceFid: 6},
+			{Id: 8, Label: "Notes", Type: "text"},
+		},
+	}
+	expected := []quickbase.Relationship{{ParentDbid: "crews", ChildDbid: "visits", ReferenceFid: 6, LookupFids: []int{7}}}
+	if relationships := schema.Relationships(); !reflect.DeepEqual(relationships, expected) {
+		t.Errorf("relationships are %+v", relationships)
+	}
+}
+
+func TestDependencyOrderCycle(t *testing.T) {
+	graph := &quickbase.RelationshipGraph{Relationships: []quickbase.Relationship{
+		{ParentDbid: "a", ChildDbid: "b"},
+		{ParentDbid: "b", ChildDbid: "a"},
+	}}
+	if _, err := graph.DependencyOrder(); err == nil {
+		t.Error("cycle not detected")
+	}
+}
+
+func TestFieldTag(t *testing.T) {
+	for label, tag := range map[string]string{
+		"Site":              "site",
+		"Cost (USD)":        "cost__usd_",
+		"foo ":              "foo_",
+		"foo*":              "foo_",
+		"2nd Phone":         "_2nd_phone",
+		"Zürich Office":     "z_rich_office",
+		"Record ID#":        "record_id_",
+		"":                  "",
+		"ALL_CAPS_and_more": "all_caps_and_more",
+	} {
+		if got := quickbase.FieldTag(label); got != tag {
+			t.Errorf("FieldTag(%q) is %q, want %q", label, got, tag)
+		}
+	}
+}
+
+func TestTagCollisions(t *testing.T) {
+	schema := quickbase.Schema{Fields: []quickbase.Field{
+		{Id: 6, Label: "foo "},
+		{Id: 7, Label: "Site"},
+		{Id: 8, Label: "foo*"},
+		{Id: 9, Label: "FOO?"},
+	}}
+	collisions := schema.TagCollisions()
+	if len(collisions) != 1 || len(collisions["foo_"]) != 3 || collisions["foo_"][2].Id != 9 {
+		t.Errorf("collisions are %+v", collisions)
+	}
+	if collisions := (quickbase.Schema{Fields: schema.Fields[:2]}).TagCollisions(); collisions != nil {
+		t.Errorf("distinct labels collide: %+v", collisions)
+	}
+}
@@ -0,0 +1,215 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Error codes with which QuickBase rejects a ticket.
+const (
+	errcodeBadTicket     = 4
+	errcodeTicketExpired = 83
+)
+
+// IsTicketError reports whether err is QuickBase's rejection of a
+// call's ticket, as bad or expired, after which authenticating again
+// may succeed.
+func IsTicketError(err error) bool {
+	qbErr, ok := err.(QuickBaseError)
+	return ok && (qbErr.Code == errcodeBadTicket || qbErr.Code == errcodeTicketExpired)
+}
+
+// A SessionPool keeps a Client for each of many QuickBase users, for
+// programs which act on their behalf, so that each call is made as
+// (and records are owned by) the real user rather than a service
+// account.  A user's Client is authenticated on first use, again
+// once its ticket reaches its Lifetime or is rejected, and dropped
+// when more than Size users have Clients, least recently used first.
+// A SessionPool is safe for concurrent use.
+type SessionPool struct {
+	Url string
+	// Credentials returns the credentials of a user.
+	Credentials func(user string) (Credentials, error)
+	// Size bounds the Clients kept; if zero or less, there is no
+	// bound.
+	Size int
+	// Lifetime is how long a ticket is used for; if zero,
+	// DefaultTicketLifetime.  Clients using user tokens do not
+	// expire.
+	Lifetime time.Duration
+	// Template, if set, is copied to make each user's Client, so
+	// sharing its HttpClient, Limiter, Concurrency, Journal and the
+	// like, and its Ticket's Apptoken.  Its Cache is not copied,
+	// since one user's records are not for another's eyes.
+	Template *Client
+
+	mu       sync.Mutex
+	sessions map[string]*list.Element // of *session, by user
+	lru      list.List                // most recently used first
+	now      func() time.Time         // if nil, time.Now
+}
+
+// session is a user's Client, which is ready once authenticated.
+type session struct {
+	user    string
+	client  *Client
+	err     error
+	expires time.Time // if zero, never
+	ready   chan struct{}
+}
+
+// NewSessionPool returns a SessionPool for the realm at url, keeping
+// at most size Clients and getting users' credentials from
+// credentials.
+func NewSessionPool(url string, size int, credentials func(user string) (Credentials, error)) *SessionPool {
+	return &SessionPool{Url: url, Size: size, Credentials: credentials}
+}
+
+// Client returns the Client making calls as user, authenticating
+// them if need be.  Concurrent calls for a user not yet authenticated
+// wait on a single authentication.
+func (p *SessionPool) Client(user string) (client *Client, err error) {
+	p.mu.Lock()
+	if p.sessions == nil {
+		p.sessions = make(map[string]*list.Element)
+	}
+	var s *session
+	if e, ok := p.sessions[user]; ok {
+		s = e.Value.(*session)
+		if p.stale(s) {
+			p.remove(e)
+			s = nil
+		} else {
+			p.lru.MoveToFront(e)
+		}
+	}
+	if s == nil {
+		s = &session{user: user, ready: make(chan struct{})}
+		p.sessions[user] = p.lru.PushFront(s)
+		for p.Size > 0 && p.lru.Len() > p.Size {
+			p.remove(p.lru.Back())
+		}
+		p.mu.Unlock()
+		p.authenticate(s)
+	} else {
+		p.mu.Unlock()
+	}
+	<-s.ready
+	if s.err != nil {
+		p.mu.Lock()
+		if e, ok := p.sessions[user]; ok && e.Value.(*session) == s {
+			p.remove(e)
+		}
+		p.mu.Unlock()
+	}
+	return s.client, s.err
+}
+
+// Do calls f with user's Client, and if QuickBase rejects the
+// Client's ticket, authenticates again and calls f once more.
+func (p *SessionPool) Do(user string, f func(client *Client) error) error {
+	client, err := p.Client(user)
+	if err != nil {
+		return err
+	}
+	if err = f(client); !IsTicketError(err) {
+		return err
+	}
+	hooks := client.Hooks
+	p.Invalidate(user)
+	if client, err = p.Client(user); err != nil {
+		hooks.authRefresh(p.Url, "", err)
+		return err
+	}
+	hooks.authRefresh(p.Url, client.Ticket.userid, nil)
+	return f(client)
+}
+
+// Invalidate drops user's Client, so that the next call for them
+// authenticates again.
+func (p *SessionPool) Invalidate(user string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if e, ok := p.sessions[user]; ok {
+		p.remove(e)
+	}
+}
+
+// Len returns the number of users with Clients.
+func (p *SessionPool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.lru.Len()
+}
+
+// authenticate makes s's Client and marks it ready.
+func (p *SessionPool) authenticate(s *session) {
+	defer close(s.ready)
+	credentials, err := p.Credentials(s.user)
+	if err != nil {
+		s.err = err
+		return
+	}
+	client := &Client{}
+	if p.Template != nil {
+		*client = *p.Template
+		client.Ticket = Ticket{Apptoken: p.Template.Ticket.Apptoken}
+		client.Cache, client.refresher, client.life = nil, nil, nil
+	}
+	provider := CredentialsFunc(func(url string) (Credentials, error) { return credentials, nil })
+	if s.err = client.AuthenticateWith(provider, p.Url); s.err != nil {
+		return
+	}
+	s.client = client
+	if credentials.Usertoken == "" {
+		lifetime := p.Lifetime
+		if lifetime == 0 {
+			lifetime = DefaultTicketLifetime
+		}
+		s.expires = p.clock().Add(lifetime)
+	}
+}
+
+// stale reports whether s is authenticated but expired or failed;
+// one still authenticating is not.
+func (p *SessionPool) stale(s *session) bool {
+	select {
+	case <-s.ready:
+		return s.err != nil || (!s.expires.IsZero() && !p.clock().Before(s.expires))
+	default:
+		return false
+	}
+}
+
+func (p *SessionPool) remove(e *list.Element) {
+	delete(p.sessions, e.Value.(*session).user)
+	p.lru.Remove(e)
+}
+
+func (p *SessionPool) clock() time.Time {
+	if p.now != nil {
+		return p.now()
+	}
+	return time.Now()
+}
@@ -0,0 +1,136 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSessionPool(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	for _, user := range []string{"alice", "bob", "carol", "dave"} {
+		server.AddUser(user, user+"-secret")
+	}
+	var mu sync.Mutex
+	logins := make(map[string]int)
+	pool := quickbase.NewSessionPool(server.BaseUrl(), 2, func(user string) (quickbase.Credentials, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		logins[user]++
+		if user == "mallory" {
+			return quickbase.Credentials{}, fmt.Errorf("no such user %s", user)
+		}
+		return quickbase.Credentials{Username: user, Password: user + "-secret"}, nil
+	})
+	pool.Template = &quickbase.Client{Ticket: quickbase.Ticket{Apptoken: "app"}}
+
+	alice, err := pool.Client("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := pool.Client("alice"); again != alice || logins["alice"] != 1 {
+		t.Errorf("second Client for alice authenticated again: %d logins", logins["alice"])
+	}
+	if alice.Ticket.Apptoken != "app" {
+		t.Errorf("Client did not take the template's Apptoken: %+v", alice.Ticket)
+	}
+	bob, err := pool.Client("bob")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, aliceId := alice.Ticket.Credentials()
+	if _, _, bobId := bob.Ticket.Credentials(); aliceId == bobId {
+		t.Errorf("alice and bob share user ID %s", aliceId)
+	}
+
+	pool.Client("carol")
+	if pool.Len() != 2 {
+		t.Errorf("pool of size 2 holds %d", pool.Len())
+	}
+	pool.Client("alice")
+	if logins["alice"] != 2 {
+		t.Errorf("evicted alice was not authenticated again: %d logins", logins["alice"])
+	}
+
+	server.ExpireTickets()
+	calls := 0
+	err = pool.Do("alice", func(client *quickbase.Client) error {
+		calls++
+		_, err := client.DoQueryCount(testTableDbid, "")
+		return err
+	})
+	if err != nil || calls != 2 || logins["alice"] != 3 {
+		t.Errorf("Do with an expired ticket gave %v after %d calls, %d logins", err, calls, logins["alice"])
+	}
+
+	if _, err = pool.Client("mallory"); err == nil {
+		t.Error("Client for a user without credentials succeeded")
+	}
+	pool.Client("mallory")
+	if logins["mallory"] != 2 {
+		t.Errorf("failed authentication was kept: %d attempts", logins["mallory"])
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := pool.Client("dave"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if logins["dave"] != 1 {
+		t.Errorf("concurrent Clients for dave authenticated %d times", logins["dave"])
+	}
+}
+
+func TestSessionPoolLifetime(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	server.AddUserToken("jdoe", "b2fr52_xyz")
+	logins := 0
+	pool := quickbase.NewSessionPool(server.BaseUrl(), 0, func(user string) (quickbase.Credentials, error) {
+		logins++
+		if user == "token" {
+			return quickbase.Credentials{Usertoken: "b2fr52_xyz"}, nil
+		}
+		return quickbase.Credentials{Username: "jdoe", Password: "secret"}, nil
+	})
+	pool.Lifetime = -time.Second
+	pool.Client("jdoe")
+	pool.Client("jdoe")
+	if logins != 2 {
+		t.Errorf("expired tickets were reused: %d logins", logins)
+	}
+	pool.Client("token")
+	pool.Client("token")
+	if logins != 3 {
+		t.Errorf("user token Client expired: %d logins", logins)
+	}
+}
@@ -0,0 +1,167 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// ErrClosed is returned by the calls of a Client which has been shut
+// down.
+var ErrClosed = fmt.Errorf("Client is closed")
+
+// lifecycle tracks the calls in flight for a Client, so that Shutdown
+// may cancel them or wait for them.
+type lifecycle struct {
+	mu       sync.Mutex
+	closed   bool
+	next     int
+	inflight map[int]*flight
+	writes   sync.WaitGroup // writes in flight
+}
+
+// flight is a call in flight: a write, which Shutdown lets finish, or
+// a read, which it cancels at once.
+type flight struct {
+	write  bool
+	cancel context.CancelFunc
+}
+
+// lifecycles guards the creation of Clients' lifecycles.
+var lifecycles sync.Mutex
+
+// lifecycle returns the Client's lifecycle, creating it if need be.
+func (c *Client) lifecycle() *lifecycle {
+	lifecycles.Lock()
+	defer lifecycles.Unlock()
+	if c.life == nil {
+		c.life = &lifecycle{inflight: make(map[int]*flight)}
+	}
+	return c.life
+}
+
+// begin registers a call about to send req, setting its context to one
+// Shutdown may cancel, and returns the function to call once the call
+// is over.
+func (l *lifecycle) begin(req *http.Request, write bool) (release func(), err error) {
+	if l == nil {
+		return func() {}, nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return nil, ErrClosed
+	}
+	ctx, cancel := context.WithCancel(req.Context())
+	*req = *req.WithContext(ctx)
+	id := l.next
+	l.next++
+	l.inflight[id] = &flight{write, cancel}
+	if write {
+		l.writes.Add(1)
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			delete(l.inflight, id)
+			l.mu.Unlock()
+			cancel()
+			if write {
+				l.writes.Done()
+			}
+		})
+	}, nil
+}
+
+// cancel cancels the calls in flight, or only the reads.
+func (l *lifecycle) cancel(writes bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, f := range l.inflight {
+		if writes || !f.write {
+			f.cancel()
+		}
+	}
+}
+
+// releasingBody is a response body which ends its call when closed.
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
+
+// Shutdown shuts the Client down: calls made after it begins fail
+// with ErrClosed; those reading records, e.g. streaming queries and
+// downloads, are cancelled; and those changing data, e.g. imports, are
+// waited for until ctx is done, whereupon they too are cancelled and
+// ctx.Err() returned.  It then stops StartTicketRefresh's goroutine
+// and closes the idle connections of the Client's HttpClient.
+//
+// A bulk.Runner whose calls fail with ErrClosed starts no more
+// batches, so Shutdown lets a bulk import finish the batches under
+// way, to be resumed from its checkpoint.
+func (c *Client) Shutdown(ctx context.Context) (err error) {
+	life := c.lifecycle()
+	life.mu.Lock()
+	life.closed = true
+	life.mu.Unlock()
+	life.cancel(false)
+	drained := make(chan struct{})
+	go func() {
+		life.writes.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		life.cancel(true)
+		err = ctx.Err()
+	}
+	c.StopTicketRefresh()
+	httpClient := c.HttpClient
+	if httpClient == nil {
+		httpClient = DefaultClient
+	}
+	httpClient.CloseIdleConnections()
+	return err
+}
+
+// Close shuts the Client down at once, cancelling all its calls in
+// flight; see Shutdown.
+func (c *Client) Close() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := c.Shutdown(ctx); err != context.Canceled {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"context"
+	"github.com/WesTower/quickbase"
+	"testing"
+	"time"
+)
+
+func TestShutdown(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.SetLatency(100*time.Millisecond, 0)
+	added := make(chan error)
+	go func() {
+		_, err := client.AddRecordByFid(testTableDbid, map[int]string{6: "Tulsa"})
+		added <- err
+	}()
+	counted := make(chan error)
+	go func() {
+		_, err := client.DoQueryCount(testTableDbid, "")
+		counted <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err = client.Shutdown(ctx); err != nil {
+		t.Errorf("Shutdown gave %v", err)
+	}
+	select {
+	case err = <-added:
+		if err != nil {
+			t.Errorf("write in flight gave %v", err)
+		}
+	default:
+		t.Error("Shutdown returned before the write in flight finished")
+	}
+	if err = <-counted; err == nil {
+		t.Error("read in flight was not cancelled")
+	}
+	if records := server.Records(testTableDbid); len(records) != 3 {
+		t.Errorf("table holds %d records", len(records))
+	}
+	if _, err = client.DoQueryCount(testTableDbid, ""); err != quickbase.ErrClosed {
+		t.Errorf("call after Shutdown gave %v", err)
+	}
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server.SetLatency(time.Second, 0)
+	added := make(chan error)
+	go func() {
+		_, err := client.AddRecordByFid(testTableDbid, map[int]string{6: "Tulsa"})
+		added <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err = client.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Shutdown gave %v", err)
+	}
+	if err = <-added; err == nil {
+		t.Error("write past the deadline was not cancelled")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Shutdown took %s", elapsed)
+	}
+	if err = client.Close(); err != nil {
+		t.Errorf("Close of a shut down Client gave %v", err)
+	}
+}
@@ -0,0 +1,477 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+// Package sqlquery translates simple SQL SELECT statements into the
+// clist, query, slist and options of API_DoQuery, for porting query
+// logic written against SQL stores.  The statements it accepts are of
+// the form
+//
+//	SELECT f1, f2 FROM table WHERE x = 'y' AND (z < 3 OR z > 9)
+//	    ORDER BY z DESC, f1 LIMIT 100 OFFSET 200
+//
+// Columns may be given as field IDs, or, given a Schema, as field
+// labels or their tags (see quickbase.FieldTag), quoted with double
+// quotes if they are not plain identifiers.  Conditions may compare a
+// column with a literal by =, <>, !=, <, <=, > or >=, match it with
+// LIKE or NOT LIKE patterns of the forms 'abc', 'abc%' and '%abc%',
+// test it with IS NULL, IS NOT NULL, IN or NOT IN, and be combined
+// with AND, OR and parentheses.  Literals may contain quotes, doubled
+// as in 'O''Brien', except where QuickBase cannot match them: before
+// a } or at the end.
+package sqlquery
+
+import (
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"github.com/WesTower/quickbase/criteria"
+	"strconv"
+	"strings"
+)
+
+// A Statement is a translated SELECT statement.
+type Statement struct {
+	Table   string // the table named by FROM, as written
+	Clist   string // 'a' for SELECT *
+	Query   string
+	Slist   string
+	Options string
+}
+
+// Translate translates a SELECT statement.  If schema is not nil,
+// columns may be named by label or tag as well as by field ID, and
+// field IDs must be those of its fields.
+func Translate(sql string, schema *quickbase.Schema) (stmt Statement, err error) {
+	tokens, err := lex(sql)
+	if err != nil {
+		return stmt, err
+	}
+	p := &parser{tokens: tokens, schema: schema}
+	return p.statement()
+}
+
+type tokenKind int
+
+const (
+	tokenEnd tokenKind = iota
+	tokenWord
+	tokenQuoted // a "quoted" identifier
+	tokenString
+	tokenNumber
+	tokenSymbol
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	offset int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokenEnd:
+		return "end of statement"
+	case tokenString:
+		return fmt.Sprintf("'%s'", strings.Replace(t.text, "'", "''", -1))
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+func lex(sql string) (tokens []token, err error) {
+	i := 0
+	for i < len(sql) {
+		c := sql[i]
+		start := i
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case c == '\'' || c == '"':
+			var text []byte
+			for i++; ; i++ {
+				if i == len(sql) {
+					return nil, fmt.Errorf("Unterminated %c at offset %d", c, start)
+				}
+				if sql[i] == c {
+					if i+1 < len(sql) && sql[i+1] == c {
+						i++
+					} else {
+						break
+					}
+				}
+				text = append(text, sql[i])
+			}
+			i++
+			kind := tokenString
+			if c == '"' {
+				kind = tokenQuoted
+			}
+			tokens = append(tokens, token{kind, string(text), start})
+			continue
+		case c >= '0' && c <= '9' || c == '-' && i+1 < len(sql) && sql[i+1] >= '0' && sql[i+1] <= '9':
+			for i++; i < len(sql) && (sql[i] >= '0' && sql[i] <= '9' || sql[i] == '.'); i++ {
+			}
+			tokens = append(tokens, token{tokenNumber, sql[start:i], start})
+			continue
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			for i++; i < len(sql) && (sql[i] == '_' || sql[i] >= 'a' && sql[i] <= 'z' || sql[i] >= 'A' && sql[i] <= 'Z' || sql[i] >= '0' && sql[i] <= '9'); i++ {
+			}
+			tokens = append(tokens, token{tokenWord, sql[start:i], start})
+			continue
+		}
+		for _, symbol := range []string{"<=", ">=", "<>", "!=", "=", "<", ">", "(", ")", ",", "*", ";"} {
+			if strings.HasPrefix(sql[i:], symbol) {
+				tokens = append(tokens, token{tokenSymbol, symbol, start})
+				i += len(symbol)
+				break
+			}
+		}
+		if i == start {
+			return nil, fmt.Errorf("Unexpected %q at offset %d", c, start)
+		}
+	}
+	return append(tokens, token{tokenEnd, "", len(sql)}), nil
+}
+
+type parser struct {
+	tokens []token
+	next   int
+	schema *quickbase.Schema
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) take() token {
+	t := p.tokens[p.next]
+	if t.kind != tokenEnd {
+		p.next++
+	}
+	return t
+}
+
+// is reports whether the next token is the given keyword or symbol.
+func (p *parser) is(text string) bool {
+	t := p.peek()
+	return (t.kind == tokenWord || t.kind == tokenSymbol) && strings.EqualFold(t.text, text)
+}
+
+// accept takes the next token if it is the given keyword or symbol.
+func (p *parser) accept(text string) bool {
+	if p.is(text) {
+		p.next++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(text string) error {
+	if !p.accept(text) {
+		return p.unexpected(text)
+	}
+	return nil
+}
+
+func (p *parser) unexpected(wanted string) error {
+	t := p.peek()
+	return fmt.Errorf("Expected %s but found %s at offset %d", wanted, t, t.offset)
+}
+
+func (p *parser) statement() (stmt Statement, err error) {
+	if err = p.expect("SELECT"); err != nil {
+		return stmt, err
+	}
+	if p.accept("*") {
+		stmt.Clist = "a"
+	} else {
+		var fids []string
+		for {
+			fid, err := p.column()
+			if err != nil {
+				return stmt, err
+			}
+			fids = append(fids, strconv.Itoa(fid))
+			if !p.accept(",") {
+				break
+			}
+		}
+		stmt.Clist = strings.Join(fids, ".")
+	}
+	if err = p.expect("FROM"); err != nil {
+		return stmt, err
+	}
+	switch t := p.take(); t.kind {
+	case tokenWord, tokenQuoted:
+		stmt.Table = t.text
+	default:
+		p.next--
+		return stmt, p.unexpected("a table")
+	}
+	if p.accept("WHERE") {
+		cond, err := p.disjunction()
+		if err != nil {
+			return stmt, err
+		}
+		if err = criteria.Select().Where(cond).Check(); err != nil {
+			return stmt, err
+		}
+		stmt.Query = cond.String()
+	}
+	var options []string
+	if p.accept("ORDER") {
+		if err = p.expect("BY"); err != nil {
+			return stmt, err
+		}
+		var fids []string
+		order, descending := "", false
+		for {
+			fid, err := p.column()
+			if err != nil {
+				return stmt, err
+			}
+			fids = append(fids, strconv.Itoa(fid))
+			if p.accept("DESC") {
+				order += "D"
+				descending = true
+			} else {
+				p.accept("ASC")
+				order += "A"
+			}
+			if !p.accept(",") {
+				break
+			}
+		}
+		stmt.Slist = strings.Join(fids, ".")
+		if descending {
+			options = append(options, "sortorder-"+order)
+		}
+	}
+	if p.accept("LIMIT") {
+		n, err := p.count()
+		if err != nil {
+			return stmt, err
+		}
+		options = append(options, "num-"+strconv.Itoa(n))
+	}
+	if p.accept("OFFSET") {
+		n, err := p.count()
+		if err != nil {
+			return stmt, err
+		}
+		options = append(options, "skp-"+strconv.Itoa(n))
+	}
+	stmt.Options = strings.Join(options, ".")
+	p.accept(";")
+	if p.peek().kind != tokenEnd {
+		return stmt, p.unexpected("end of statement")
+	}
+	return stmt, nil
+}
+
+func (p *parser) count() (n int, err error) {
+	t := p.peek()
+	if t.kind == tokenNumber {
+		if n, err = strconv.Atoi(t.text); err == nil && n >= 0 {
+			p.next++
+			return n, nil
+		}
+	}
+	return 0, p.unexpected("a count")
+}
+
+// column parses a column name, returning its field ID.
+func (p *parser) column() (fid int, err error) {
+	t := p.take()
+	switch t.kind {
+	case tokenNumber:
+		fid, err = strconv.Atoi(t.text)
+		if err != nil || fid <= 0 {
+			return 0, fmt.Errorf("Bad field ID %s at offset %d", t.text, t.offset)
+		}
+		if p.schema != nil && p.field(func(f quickbase.Field) bool { return f.Id == fid }) == nil {
+			return 0, fmt.Errorf("No field %d in %s at offset %d", fid, p.schema.Dbid, t.offset)
+		}
+		return fid, nil
+	case tokenWord, tokenQuoted:
+		if p.schema == nil {
+			return 0, fmt.Errorf("Column %s at offset %d must be a field ID, lacking a schema", t, t.offset)
+		}
+		field := p.field(func(f quickbase.Field) bool { return strings.EqualFold(f.Label, t.text) })
+		if field == nil {
+			field = p.field(func(f quickbase.Field) bool { return quickbase.FieldTag(f.Label) == quickbase.FieldTag(t.text) })
+		}
+		if field == nil {
+			return 0, fmt.Errorf("No field %s in %s at offset %d", t, p.schema.Dbid, t.offset)
+		}
+		return field.Id, nil
+	}
+	p.next--
+	return 0, p.unexpected("a column")
+}
+
+func (p *parser) field(match func(quickbase.Field) bool) *quickbase.Field {
+	for i := range p.schema.Fields {
+		if match(p.schema.Fields[i]) {
+			return &p.schema.Fields[i]
+		}
+	}
+	return nil
+}
+
+func (p *parser) disjunction() (cond criteria.Expr, err error) {
+	var conditions []criteria.Expr
+	for {
+		if cond, err = p.conjunction(); err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, cond)
+		if !p.accept("OR") {
+			return criteria.Disjoin(conditions...), nil
+		}
+	}
+}
+
+func (p *parser) conjunction() (cond criteria.Expr, err error) {
+	var conditions []criteria.Expr
+	for {
+		if cond, err = p.primary(); err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, cond)
+		if !p.accept("AND") {
+			return criteria.Conjoin(conditions...), nil
+		}
+	}
+}
+
+var comparisons = map[string]string{
+	"=":  "EX",
+	"<>": "XEX",
+	"!=": "XEX",
+	"<":  "LT",
+	"<=": "LTE",
+	">":  "GT",
+	">=": "GTE",
+}
+
+func (p *parser) primary() (cond criteria.Expr, err error) {
+	if p.accept("(") {
+		if cond, err = p.disjunction(); err != nil {
+			return nil, err
+		}
+		return cond, p.expect(")")
+	}
+	fid, err := p.column()
+	if err != nil {
+		return nil, err
+	}
+	if op, ok := comparisons[p.peek().text]; ok && p.peek().kind == tokenSymbol {
+		p.next++
+		value, err := p.literal()
+		if err != nil {
+			return nil, err
+		}
+		return criteria.Criterion{Fid: fid, Op: op, Value: value}, nil
+	}
+	negated := false
+	switch {
+	case p.accept("IS"):
+		negated = p.accept("NOT")
+		if err = p.expect("NULL"); err != nil {
+			return nil, err
+		}
+		return criteria.Criterion{Fid: fid, Op: negate("EX", negated)}, nil
+	case p.accept("NOT"):
+		negated = true
+		if !p.is("LIKE") && !p.is("IN") {
+			return nil, p.unexpected("LIKE or IN")
+		}
+	}
+	switch {
+	case p.accept("LIKE"):
+		t := p.peek()
+		pattern, err := p.literal()
+		if err != nil {
+			return nil, err
+		}
+		op, value, ok := like(pattern)
+		if !ok {
+			return nil, fmt.Errorf("Unsupported LIKE pattern %s at offset %d", t, t.offset)
+		}
+		return criteria.Criterion{Fid: fid, Op: negate(op, negated), Value: value}, nil
+	case p.accept("IN"):
+		if err = p.expect("("); err != nil {
+			return nil, err
+		}
+		var conditions []criteria.Expr
+		for {
+			value, err := p.literal()
+			if err != nil {
+				return nil, err
+			}
+			conditions = append(conditions, criteria.Criterion{Fid: fid, Op: negate("EX", negated), Value: value})
+			if !p.accept(",") {
+				break
+			}
+		}
+		if err = p.expect(")"); err != nil {
+			return nil, err
+		}
+		if negated {
+			return criteria.Conjoin(conditions...), nil
+		}
+		return criteria.Disjoin(conditions...), nil
+	}
+	return nil, p.unexpected("a comparison")
+}
+
+func negate(op string, negated bool) string {
+	if negated {
+		return "X" + op
+	}
+	return op
+}
+
+// like returns the QuickBase operator and value equivalent to a LIKE
+// pattern, if there is one.
+func like(pattern string) (op, value string, ok bool) {
+	switch {
+	case len(pattern) >= 2 && strings.HasPrefix(pattern, "%") && strings.HasSuffix(pattern, "%"):
+		op, value = "CT", pattern[1:len(pattern)-1]
+	case strings.HasSuffix(pattern, "%"):
+		op, value = "SW", pattern[:len(pattern)-1]
+	default:
+		op, value = "EX", pattern
+	}
+	return op, value, !strings.ContainsAny(value, "%_")
+}
+
+// literal parses a string or number, returning its value.
+func (p *parser) literal() (value string, err error) {
+	t := p.take()
+	switch t.kind {
+	case tokenNumber:
+		return t.text, nil
+	case tokenString:
+		return t.text, nil
+	}
+	p.next--
+	return "", p.unexpected("a value")
+}
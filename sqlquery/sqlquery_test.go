@@ -0,0 +1,105 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package sqlquery_test
+
+import (
+	"github.com/WesTower/quickbase"
+	"github.com/WesTower/quickbase/sqlquery"
+	"strings"
+	"testing"
+)
+
+var schema = &quickbase.Schema{
+	Dbid: "bck7gp3q2",
+	Fields: []quickbase.Field{
+		{Id: 3, Label: "Record ID#"},
+		{Id: 6, Label: "Site"},
+		{Id: 7, Label: "Cost"},
+		{Id: 8, Label: "Due Date"},
+	},
+}
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want sqlquery.Statement
+	}{
+		{"SELECT * FROM sites", sqlquery.Statement{Table: "sites", Clist: "a"}},
+		{
+			"SELECT 6, 7 FROM bck7gp3q2 WHERE 6 = 'Denver' ORDER BY 7 LIMIT 100",
+			sqlquery.Statement{"bck7gp3q2", "6.7", "{'6'.EX.'Denver'}", "7", "num-100"},
+		},
+		{
+			`select site, "Due Date" from sites where cost >= 10 and (site like 'Den%' or site like '%ois%') order by cost desc, site limit 10 offset 20;`,
+			sqlquery.Statement{"sites", "6.8", "{'7'.GTE.'10'}AND({'6'.SW.'Den'}OR{'6'.CT.'ois'})", "7.6", "sortorder-DA.num-10.skp-20"},
+		},
+		{
+			"SELECT due_date FROM sites WHERE site <> 'Boise' AND cost < -1.5 OR record_id_ != 3",
+			sqlquery.Statement{"sites", "8", "{'6'.XEX.'Boise'}AND{'7'.LT.'-1.5'}OR{'3'.XEX.'3'}", "", ""},
+		},
+		{
+			"SELECT * FROM sites WHERE site IN ('Denver', 'Boise') AND cost IS NOT NULL",
+			sqlquery.Statement{"sites", "a", "({'6'.EX.'Denver'}OR{'6'.EX.'Boise'})AND{'7'.XEX.''}", "", ""},
+		},
+		{
+			"SELECT * FROM sites WHERE site NOT IN ('Denver', 'Boise') OR site NOT LIKE 'x' OR cost IS NULL",
+			sqlquery.Statement{"sites", "a", "{'6'.XEX.'Denver'}AND{'6'.XEX.'Boise'}OR{'6'.XEX.'x'}OR{'7'.EX.''}", "", ""},
+		},
+		{
+			"SELECT * FROM sites WHERE site = 'O''Brien' OR site LIKE '%''s%'",
+			sqlquery.Statement{"sites", "a", "{'6'.EX.'O'Brien'}OR{'6'.CT.''s'}", "", ""},
+		},
+	}
+	for _, test := range tests {
+		stmt, err := sqlquery.Translate(test.sql, schema)
+		if err != nil {
+			t.Errorf("%s: %v", test.sql, err)
+		} else if stmt != test.want {
+			t.Errorf("%s:\ngot  %+v\nwant %+v", test.sql, stmt, test.want)
+		}
+	}
+}
+
+func TestTranslateErrors(t *testing.T) {
+	tests := []struct {
+		sql    string
+		schema *quickbase.Schema
+		want   string
+	}{
+		{"SELECT site FROM sites", nil, "must be a field ID"},
+		{"SELECT 9 FROM sites", schema, "No field 9"},
+		{"SELECT nope FROM sites", schema, `No field "nope"`},
+		{"SELECT * FROM sites WHERE site = 'O'''", schema, "Cannot match a value containing '} or ending in '"},
+		{"SELECT * FROM sites WHERE site LIKE '%x''}y%'", schema, "Cannot match a value containing '} or ending in '"},
+		{"SELECT * FROM sites WHERE site LIKE 'a%b'", schema, "Unsupported LIKE pattern"},
+		{"SELECT * FROM sites WHERE site = 'x", schema, "Unterminated"},
+		{"SELECT * FROM sites WHERE (site = 'x'", schema, `Expected ) but found end of statement`},
+		{"SELECT * FROM sites LIMIT -1", schema, "Expected a count"},
+		{"SELECT * FROM sites GROUP BY site", schema, `Expected end of statement but found "GROUP" at offset 20`},
+		{"SELECT * sites", schema, `Expected FROM`},
+	}
+	for _, test := range tests {
+		_, err := sqlquery.Translate(test.sql, test.schema)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: got error %v, want %q", test.sql, err, test.want)
+		}
+	}
+}
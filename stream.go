@@ -0,0 +1,122 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"context"
+	"fmt"
+)
+
+// A QueryStream reads the records of a query one at a time as they
+// arrive, so that results of any size may be handled in constant
+// memory:
+//
+//	stream, err := client.StreamQuery(ctx, dbid, query, clist, slist, "")
+//	if err != nil {
+//		return err
+//	}
+//	defer stream.Close()
+//	for stream.Next() {
+//		record := stream.Record()
+//		...
+//	}
+//	return stream.Err()
+//
+// A failure partway through the response, e.g. a dropped connection,
+// stops Next and is returned by Err, as is ctx.Err() once the
+// stream's context is done.  The response is closed once Next returns
+// false, or by Close, which may be called at any time.
+type QueryStream struct {
+	ctx     context.Context
+	scanner *Scanner
+	record  map[int]string
+	err     error
+}
+
+// StreamQuery starts a query as DoStructuredQuery does, returning a
+// QueryStream reading its records.  The request, and the reading of
+// its response, are abandoned once ctx is done.
+func StreamQuery(ctx context.Context, ticket Ticket, dbid, query, clist, slist, options string) (stream *QueryStream, err error) {
+	scanner, err := scanQuery(ctx, ticket, dbid, query, clist, slist, options)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	return &QueryStream{ctx: ctx, scanner: scanner}, nil
+}
+
+// Next reads the next record, returning false at the end of the
+// records or on an error, which Err then returns.
+func (s *QueryStream) Next() bool {
+	if s.scanner == nil {
+		return false
+	}
+	if s.err = s.ctx.Err(); s.err == nil && s.scanner.Next() {
+		s.record = s.scanner.Record().Map()
+		return true
+	}
+	if s.err == nil {
+		s.err = s.scanner.Err()
+	}
+	if s.err != nil && s.ctx.Err() != nil {
+		s.err = s.ctx.Err()
+	}
+	s.record = nil
+	s.Close()
+	return false
+}
+
+// Record returns the record Next read, as a map from field IDs to
+// values, which the caller may keep.
+func (s *QueryStream) Record() map[int]string {
+	return s.record
+}
+
+// Scan copies the record Next read into the struct to which dst
+// points, as Scanner.Scan does.
+func (s *QueryStream) Scan(dst interface{}) error {
+	if s.scanner == nil || s.record == nil {
+		return fmt.Errorf("No record to scan")
+	}
+	return s.scanner.Scan(dst)
+}
+
+// Err returns the error which ended the records early, if any.
+func (s *QueryStream) Err() error {
+	return s.err
+}
+
+// Close closes the response.  It is safe to call more than once.
+func (s *QueryStream) Close() error {
+	if s.scanner == nil {
+		return nil
+	}
+	err := s.scanner.Close()
+	s.scanner = nil
+	return err
+}
+
+// StreamQuery calls StreamQuery against the Client's instance.
+func (c *Client) StreamQuery(ctx context.Context, dbid, query, clist, slist, options string) (stream *QueryStream, err error) {
+	return StreamQuery(ctx, c.ticket(), dbid, query, clist, slist, options)
+}
@@ -0,0 +1,98 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"context"
+	"github.com/WesTower/quickbase"
+	"github.com/WesTower/quickbase/quickbasetest"
+	"testing"
+)
+
+func TestStreamQuery(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream, err := client.StreamQuery(context.Background(), testTableDbid, "", "3.6.7", "6", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var records []map[int]string
+	var sites []site
+	for stream.Next() {
+		records = append(records, stream.Record())
+		var s site
+		if err = stream.Scan(&s); err != nil {
+			t.Fatal(err)
+		}
+		sites = append(sites, s)
+	}
+	if err = stream.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 || records[0][6] != "Boise" || records[1][6] != "Denver" || sites[1].Cost != 12.5 {
+		t.Errorf("streamed %v, %+v", records, sites)
+	}
+	if stream.Next() || stream.Close() != nil {
+		t.Error("exhausted stream went on")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if stream, err = client.StreamQuery(ctx, testTableDbid, "", "", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	if !stream.Next() {
+		t.Fatal(stream.Err())
+	}
+	cancel()
+	if stream.Next() || stream.Err() != context.Canceled {
+		t.Errorf("cancelled stream gave %v", stream.Err())
+	}
+	if _, err = client.StreamQuery(ctx, testTableDbid, "", "", "", ""); err != context.Canceled {
+		t.Errorf("StreamQuery with a cancelled context gave %v", err)
+	}
+	if _, err = client.StreamQuery(context.Background(), testTableDbid, "{'99'.EX.'x'}", "", "", ""); err == nil {
+		t.Error("StreamQuery of a missing field succeeded")
+	}
+
+	fixture := quickbasetest.NewFixtureServer(map[string][]byte{"API_DoQuery": []byte(`<?xml version="1.0" ?>
+<qdbapi><errcode>0</errcode><errtext>No error</errtext><table><records>
+<record rid="1"><f id="6">Denver</f></record><record rid="2"><f id="6">Bo`)})
+	defer fixture.Close()
+	ticket, err := quickbase.Authenticate(fixture.BaseUrl(), "fixture", "fixture")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stream, err = quickbase.StreamQuery(context.Background(), ticket, testTableDbid, "", "", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	n := 0
+	for stream.Next() {
+		n++
+	}
+	if n != 1 || stream.Err() == nil {
+		t.Errorf("truncated response gave %d records and %v", n, stream.Err())
+	}
+}
@@ -0,0 +1,70 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+// TableOptions are the optional properties of a table created by
+// CreateTableWithOptions.
+type TableOptions struct {
+	Description string // shown beneath the table's name
+	Icon        string // the name of one of QuickBase's table icons
+}
+
+// CreateTable adds a table to the application appDbid, named name,
+// whose records are each called pnoun, e.g. 'Site', returning the dbid
+// of the new table.  A table created so has only the built-in fields.
+func CreateTable(ticket Ticket, appDbid, name, pnoun string) (dbid string, err error) {
+	return CreateTableWithOptions(ticket, appDbid, name, pnoun, TableOptions{})
+}
+
+// CreateTableWithOptions is like CreateTable, but also sets the
+// properties in options.
+func CreateTableWithOptions(ticket Ticket, appDbid, name, pnoun string, options TableOptions) (dbid string, err error) {
+	var result createTableResponse
+	if err = createTableCall(ticket, appDbid, name, pnoun, options).execute(&result); err != nil {
+		return "", err
+	}
+	return result.dbid()
+}
+
+func createTableCall(ticket Ticket, appDbid, name, pnoun string, options TableOptions) apiCall {
+	params := ticket.params()
+	params["tname"] = name
+	params["pnoun"] = pnoun
+	if options.Description != "" {
+		params["description"] = options.Description
+	}
+	if options.Icon != "" {
+		params["icon"] = options.Icon
+	}
+	return apiCall{ticket, ticket.url + "db/" + appDbid, "API_CreateTable", params}
+}
+
+func (c *Client) CreateTable(appDbid, name, pnoun string) (dbid string, err error) {
+	return c.CreateTableWithOptions(appDbid, name, pnoun, TableOptions{})
+}
+
+func (c *Client) CreateTableWithOptions(appDbid, name, pnoun string, options TableOptions) (dbid string, err error) {
+	var result createTableResponse
+	if err = c.mutate(createTableCall(c.ticket(), appDbid, name, pnoun, options), &result); err != nil || c.DryRun {
+		return "", err
+	}
+	return result.dbid()
+}
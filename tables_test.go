@@ -0,0 +1,64 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"bytes"
+	"github.com/WesTower/quickbase"
+	"strings"
+	"testing"
+)
+
+func TestCreateTable(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dbid, err := client.CreateTableWithOptions(testAppDbid, "Work Orders", "Work Order", quickbase.TableOptions{Description: "Jobs at each site", Icon: "Wrench"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema, err := client.GetSchema(dbid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if schema.Name != "Work Orders" {
+		t.Errorf("new table is named %q", schema.Name)
+	}
+	if schema, err = client.GetSchema(testAppDbid); err != nil {
+		t.Fatal(err)
+	}
+	if len(schema.Tables) != 2 || schema.Tables[1] != dbid {
+		t.Errorf("application has tables %v, not also %s", schema.Tables, dbid)
+	}
+	var state bytes.Buffer
+	if err = server.SaveState(&state); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(state.String(), `"description": "Jobs at each site"`) || !strings.Contains(state.String(), `"icon": "Wrench"`) {
+		t.Errorf("options were not sent:\n%s", state.String())
+	}
+	if _, err = client.CreateTable(testTableDbid, "Nested", "Nest"); err == nil {
+		t.Error("CreateTable succeeded in a table rather than an application")
+	}
+}
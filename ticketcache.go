@@ -0,0 +1,244 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// DefaultTicketLifetime is how long a TicketCache keeps a ticket
+// unless told otherwise: QuickBase's default of 12 hours, less a
+// margin so that a ticket is not used just as it expires.
+const DefaultTicketLifetime = 12*time.Hour - 5*time.Minute
+
+// A TicketCache keeps tickets and user tokens in a file, encrypted
+// with a key the caller supplies, so that short-lived programs (e.g.
+// those run from cron) may reuse a session rather than authenticating
+// on every run.  Entries are named; Authenticate names them by user
+// and URL.
+//
+// The file is encrypted and authenticated with AES-256-GCM under the
+// SHA-256 of Key, which should therefore be a random secret rather
+// than a memorable password.  A file which cannot be decrypted, e.g.
+// because the key changed, is an error rather than an empty cache.
+type TicketCache struct {
+	Path string
+	Key  []byte
+	// Lifetime is how long a saved ticket is used for; if zero,
+	// DefaultTicketLifetime.  User tokens do not expire.
+	Lifetime time.Duration
+
+	mu  sync.Mutex
+	now func() time.Time // if nil, time.Now
+}
+
+// NewTicketCache returns a TicketCache keeping its entries in the file
+// at path, encrypted with key.
+func NewTicketCache(path string, key []byte) (*TicketCache, error) {
+	if len(key) == 0 {
+		return nil, fmt.Errorf("No key given for ticket cache %s", path)
+	}
+	return &TicketCache{Path: path, Key: key}, nil
+}
+
+// cachedTicket is an entry of a TicketCache.
+type cachedTicket struct {
+	Url       string    `json:"url"`
+	Ticket    string    `json:"ticket,omitempty"`
+	Userid    string    `json:"userid,omitempty"`
+	Usertoken string    `json:"usertoken,omitempty"`
+	Expires   time.Time `json:"expires,omitempty"`
+}
+
+// Load returns the ticket saved under name, and whether there was one
+// which has not expired.
+func (c *TicketCache) Load(name string) (ticket Ticket, ok bool, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries, err := c.read()
+	if err != nil {
+		return ticket, false, err
+	}
+	entry, ok := entries[name]
+	if !ok {
+		return ticket, false, nil
+	}
+	return Ticket{url: entry.Url, ticket: entry.Ticket, userid: entry.Userid, usertoken: entry.Usertoken}, true, nil
+}
+
+// Save saves ticket under name, replacing any entry there, and drops
+// expired entries.  A ticket from Authenticate expires after the
+// cache's Lifetime; one from UserTokenTicket does not.  The ticket's
+// Apptoken is not saved.
+func (c *TicketCache) Save(name string, ticket Ticket) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries, err := c.read()
+	if err != nil {
+		return err
+	}
+	entry := cachedTicket{Url: ticket.url, Ticket: ticket.ticket, Userid: ticket.userid, Usertoken: ticket.usertoken}
+	if ticket.usertoken == "" {
+		lifetime := c.Lifetime
+		if lifetime == 0 {
+			lifetime = DefaultTicketLifetime
+		}
+		entry.Expires = c.clock().Add(lifetime)
+	}
+	entries[name] = entry
+	return c.write(entries)
+}
+
+// Remove removes the entry saved under name, e.g. when QuickBase has
+// rejected its ticket.
+func (c *TicketCache) Remove(name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entries, err := c.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := entries[name]; !ok {
+		return nil
+	}
+	delete(entries, name)
+	return c.write(entries)
+}
+
+// Authenticate returns the ticket cached for username at url if there
+// is one, and otherwise authenticates as the package-level
+// Authenticate does and caches the ticket.
+func (c *TicketCache) Authenticate(url, username, password string) (ticket Ticket, err error) {
+	return c.authenticate(Ticket{}, url, username, password)
+}
+
+func (c *TicketCache) authenticate(session Ticket, url, username, password string) (ticket Ticket, err error) {
+	name := ticketCacheName(url, username)
+	if ticket, ok, err := c.Load(name); err != nil || ok {
+		ticket.transportFrom(session)
+		return ticket, err
+	}
+	if ticket, err = authenticate(session, url, username, password); err != nil {
+		return ticket, err
+	}
+	return ticket, c.Save(name, ticket)
+}
+
+// ticketCacheName names the cache entry of a user's ticket.
+func ticketCacheName(url, username string) string {
+	return username + "@" + url
+}
+
+func (c *TicketCache) clock() time.Time {
+	if c.now != nil {
+		return c.now()
+	}
+	return time.Now()
+}
+
+func (c *TicketCache) aead() (cipher.AEAD, error) {
+	key := sha256.Sum256(c.Key)
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// read returns the cache's unexpired entries; a missing file holds
+// none.
+func (c *TicketCache) read() (entries map[string]cachedTicket, err error) {
+	entries = make(map[string]cachedTicket)
+	data, err := ioutil.ReadFile(c.Path)
+	if os.IsNotExist(err) {
+		return entries, nil
+	} else if err != nil {
+		return nil, err
+	}
+	aead, err := c.aead()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("Ticket cache %s is corrupt", c.Path)
+	}
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("Cannot decrypt ticket cache %s: wrong key, or corrupt", c.Path)
+	}
+	if err = json.Unmarshal(plain, &entries); err != nil {
+		return nil, fmt.Errorf("Ticket cache %s is corrupt: %s", c.Path, err)
+	}
+	now := c.clock()
+	for name, entry := range entries {
+		if !entry.Expires.IsZero() && !now.Before(entry.Expires) {
+			delete(entries, name)
+		}
+	}
+	return entries, nil
+}
+
+// write replaces the cache's file, readable only by its owner, with
+// one holding entries; it writes a new file and renames it, so that a
+// concurrent reader sees either the old entries or the new.
+func (c *TicketCache) write(entries map[string]cachedTicket) error {
+	plain, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	aead, err := c.aead()
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = io.ReadFull(rand.Reader, nonce); err != nil {
+		return err
+	}
+	data := aead.Seal(nonce, nonce, plain, nil)
+	file, err := ioutil.TempFile(filepath.Dir(c.Path), filepath.Base(c.Path)+".new")
+	if err != nil {
+		return err
+	}
+	if err = file.Chmod(0600); err == nil {
+		_, err = file.Write(data)
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), c.Path)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+	}
+	return err
+}
@@ -0,0 +1,114 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"github.com/WesTower/quickbase"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTicketCache(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "ticketcache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "tickets")
+	cache, err := quickbase.NewTicketCache(path, []byte("0123456789abcdef0123456789abcdef"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ticket, err := cache.Authenticate(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("cache file %v, %v", info, err)
+	}
+	// A cached ticket is used without checking the password again.
+	client := &quickbase.Client{}
+	if err = client.AuthenticateCached(cache, server.BaseUrl(), "jdoe", "wrong"); err != nil {
+		t.Fatal(err)
+	}
+	if _, got, _ := client.Ticket.Credentials(); got == "" {
+		t.Error("AuthenticateCached left no ticket")
+	} else if _, want, _ := ticket.Credentials(); got != want {
+		t.Errorf("cached ticket %q, want %q", got, want)
+	}
+	if count, err := client.DoQueryCount(testTableDbid, ""); err != nil || count != 2 {
+		t.Errorf("query with the cached ticket gave %d, %v", count, err)
+	}
+
+	if err = cache.Save("token", quickbase.UserTokenTicket(server.BaseUrl(), "b2fr52_xyz")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := cache.Load("token"); !ok || err != nil {
+		t.Errorf("Load of a user token gave %t, %v", ok, err)
+	}
+	if err = cache.Remove("token"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := cache.Load("token"); ok || err != nil {
+		t.Errorf("Load of a removed entry gave %t, %v", ok, err)
+	}
+
+	other, _ := quickbase.NewTicketCache(path, []byte("not the key"))
+	if _, _, err = other.Load("jdoe@" + server.BaseUrl()); err == nil {
+		t.Error("Load with the wrong key succeeded")
+	}
+	data, _ := ioutil.ReadFile(path)
+	data[len(data)-1] ^= 1
+	ioutil.WriteFile(path, data, 0600)
+	if _, _, err = cache.Load("jdoe@" + server.BaseUrl()); err == nil {
+		t.Error("Load of a tampered cache succeeded")
+	}
+	if _, err = quickbase.NewTicketCache(path, nil); err == nil {
+		t.Error("NewTicketCache without a key succeeded")
+	}
+}
+
+func TestTicketCacheExpiry(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "ticketcache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cache, _ := quickbase.NewTicketCache(filepath.Join(dir, "tickets"), []byte("key"))
+	cache.Lifetime = -time.Second
+	if _, err = cache.Authenticate(server.BaseUrl(), "jdoe", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := cache.Load("jdoe@" + server.BaseUrl()); ok || err != nil {
+		t.Errorf("Load of an expired ticket gave %t, %v", ok, err)
+	}
+	if _, err = cache.Authenticate(server.BaseUrl(), "jdoe", "wrong"); err == nil {
+		t.Error("Authenticate with an expired ticket and a bad password succeeded")
+	}
+}
@@ -0,0 +1,190 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// ticketLifetime is how long QuickBase's tickets last by default.
+const ticketLifetime = 12 * time.Hour
+
+// DefaultRefreshMargin is how long before its ticket expires a Client
+// refreshing it authenticates again, unless told otherwise.
+const DefaultRefreshMargin = 10 * time.Minute
+
+// refreshRetry is how long a Client refreshing its ticket waits after
+// failing to authenticate before trying again.
+const refreshRetry = time.Minute
+
+// ticketRefresher holds the ticket a Client calls with, which its
+// goroutine, if it has one, replaces before it expires, and which is
+// replaced when QuickBase rejects it.
+type ticketRefresher struct {
+	mu       sync.Mutex
+	ticket   Ticket
+	provider CredentialsProvider
+	url      string
+	stop     chan struct{} // nil if there is no goroutine
+	done     chan struct{}
+}
+
+func (r *ticketRefresher) current() Ticket {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.ticket
+}
+
+// StartTicketRefresh authenticates with the credentials provider gives
+// for url, as AuthenticateWith does, and starts a goroutine which
+// authenticates again margin before each ticket expires (if margin is
+// zero, DefaultRefreshMargin before) and swaps the new ticket in, so
+// that a long-running program's calls are never made with an expired
+// ticket.  A failure to authenticate is passed to the Client's
+// Hooks.OnAuthRefresh, or logged if it has none, and retried a minute
+// later while the old ticket lasts.  Until StopTicketRefresh is
+// called, the Client's calls use the refreshed ticket rather than its
+// Ticket field.  Credentials of a user token, which does not expire,
+// start no goroutine.  A call whose ticket is nonetheless rejected is
+// retried as by AuthenticateRenewing.
+func (c *Client) StartTicketRefresh(provider CredentialsProvider, url string, margin time.Duration) (err error) {
+	if c.refresher != nil {
+		return fmt.Errorf("Client is already refreshing its ticket")
+	}
+	if margin == 0 {
+		margin = DefaultRefreshMargin
+	}
+	if margin < 0 || margin >= ticketLifetime {
+		return fmt.Errorf("Bad ticket refresh margin %s", margin)
+	}
+	if err = c.AuthenticateWith(provider, url); err != nil {
+		return err
+	}
+	if c.Ticket.usertoken != "" {
+		return nil
+	}
+	r := &ticketRefresher{ticket: c.Ticket, provider: provider, url: url, stop: make(chan struct{}), done: make(chan struct{})}
+	go r.run(c.ticket(), provider, url, ticketLifetime-margin)
+	c.refresher = r
+	return nil
+}
+
+// AuthenticateRenewing authenticates with the credentials provider
+// gives for url, as AuthenticateWith does, and thereafter, when
+// QuickBase rejects the Client's ticket as expired or bad, asks
+// provider for the credentials again, authenticates with them and
+// retries the call once with the new ticket.  Concurrent calls so
+// rejected authenticate only once.  Calls which stream a request or
+// response body (Upload, ImportFromCSV, StreamQuery, ScanQuery,
+// ForEachRecord, DoQueryChan, Download and GenResultsTable) cannot be
+// replayed, and return the error, though later calls use the new
+// ticket.  Unlike StartTicketRefresh, no goroutine is started;
+// StopTicketRefresh ends the renewal.
+func (c *Client) AuthenticateRenewing(provider CredentialsProvider, url string) (err error) {
+	if c.refresher != nil {
+		return fmt.Errorf("Client is already refreshing its ticket")
+	}
+	if err = c.AuthenticateWith(provider, url); err != nil {
+		return err
+	}
+	if c.Ticket.usertoken != "" {
+		return nil
+	}
+	c.refresher = &ticketRefresher{ticket: c.Ticket, provider: provider, url: url}
+	return nil
+}
+
+// StopTicketRefresh stops the goroutine started by StartTicketRefresh,
+// or the renewal started by AuthenticateRenewing, and sets the
+// Client's Ticket to the last ticket it got.
+func (c *Client) StopTicketRefresh() {
+	r := c.refresher
+	if r == nil {
+		return
+	}
+	if r.stop != nil {
+		close(r.stop)
+		<-r.done
+	}
+	c.Ticket = r.current()
+	c.refresher = nil
+}
+
+// run authenticates every interval, making the calls as session does,
+// until stopped.
+func (r *ticketRefresher) run(session Ticket, provider CredentialsProvider, url string, interval time.Duration) {
+	defer close(r.done)
+	wait := interval
+	for {
+		timer := time.NewTimer(wait)
+		select {
+		case <-r.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		ticket, err := r.refresh(session, provider, url)
+		session.hooks.authRefresh(url, ticket.userid, err)
+		if err != nil {
+			if session.hooks == nil || session.hooks.OnAuthRefresh == nil {
+				log.Print("quickbase: ticket refresh: ", err)
+			}
+			wait = refreshRetry
+			continue
+		}
+		r.mu.Lock()
+		r.ticket = ticket
+		r.mu.Unlock()
+		wait = interval
+	}
+}
+
+func (r *ticketRefresher) refresh(session Ticket, provider CredentialsProvider, url string) (ticket Ticket, err error) {
+	credentials, err := provider.Credentials(url)
+	if err != nil {
+		return ticket, err
+	}
+	if ticket, err = authenticate(session, url, credentials.Username, credentials.Password); err != nil {
+		return ticket, err
+	}
+	ticket.Apptoken = session.Apptoken
+	return ticket, nil
+}
+
+// renew replaces the ticket QuickBase rejected with a new one, making
+// the call as session does, unless it has been replaced already.
+func (r *ticketRefresher) renew(session Ticket, rejected string) (ticket Ticket, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.ticket.ticket != rejected {
+		return r.ticket, nil
+	}
+	ticket, err = r.refresh(session, r.provider, r.url)
+	session.hooks.authRefresh(r.url, ticket.userid, err)
+	if err != nil {
+		return ticket, err
+	}
+	r.ticket = ticket
+	return ticket, nil
+}
@@ -0,0 +1,175 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"bytes"
+	"errors"
+	"github.com/WesTower/quickbase"
+	"log"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTicketRefresh(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	server.AddUserToken("jdoe", "b2fr52_xyz")
+	password := quickbase.CredentialsFunc(func(url string) (quickbase.Credentials, error) {
+		return quickbase.Credentials{Username: "jdoe", Password: "secret"}, nil
+	})
+	client := &quickbase.Client{}
+	if err := client.StartTicketRefresh(password, server.BaseUrl(), 13*time.Hour); err == nil {
+		t.Error("StartTicketRefresh with a margin longer than a ticket lasts succeeded")
+	}
+	// Refresh every 20ms.
+	if err := client.StartTicketRefresh(password, server.BaseUrl(), 12*time.Hour-20*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.StartTicketRefresh(password, server.BaseUrl(), 0); err == nil {
+		t.Error("second StartTicketRefresh succeeded")
+	}
+	_, first, _ := client.Ticket.Credentials()
+	server.ExpireTickets()
+	time.Sleep(100 * time.Millisecond)
+	if count, err := client.DoQueryCount(testTableDbid, ""); err != nil || count != 2 {
+		t.Errorf("query after tickets expired gave %d, %v", count, err)
+	}
+	client.StopTicketRefresh()
+	_, last, _ := client.Ticket.Credentials()
+	if last == first {
+		t.Error("ticket was not refreshed")
+	}
+	time.Sleep(50 * time.Millisecond)
+	if _, ticket, _ := client.Ticket.Credentials(); ticket != last {
+		t.Error("ticket refreshed after StopTicketRefresh")
+	}
+	if _, err := client.DoQueryCount(testTableDbid, ""); err != nil {
+		t.Errorf("query after StopTicketRefresh gave %v", err)
+	}
+
+	token := &quickbase.Client{}
+	err := token.StartTicketRefresh(quickbase.CredentialsFunc(func(url string) (quickbase.Credentials, error) {
+		return quickbase.Credentials{Usertoken: "b2fr52_xyz"}, nil
+	}), server.BaseUrl(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token.StopTicketRefresh()
+	if _, err := token.DoQueryCount(testTableDbid, ""); err != nil {
+		t.Errorf("query with a refreshed user token gave %v", err)
+	}
+}
+
+func TestAuthenticateRenewing(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	var mu sync.Mutex
+	var calls int
+	var fail bool
+	provider := quickbase.CredentialsFunc(func(url string) (quickbase.Credentials, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if fail {
+			return quickbase.Credentials{}, nil
+		}
+		return quickbase.Credentials{Username: "jdoe", Password: "secret"}, nil
+	})
+	var refreshes int
+	client := &quickbase.Client{Hooks: &quickbase.Hooks{OnAuthRefresh: func(e quickbase.AuthRefreshEvent) {
+		mu.Lock()
+		refreshes++
+		mu.Unlock()
+	}}}
+	if err := client.AuthenticateRenewing(provider, server.BaseUrl()); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.AuthenticateRenewing(provider, server.BaseUrl()); err == nil {
+		t.Error("second AuthenticateRenewing succeeded")
+	}
+	server.ExpireTickets()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if count, err := client.DoQueryCount(testTableDbid, ""); err != nil || count != 2 {
+				t.Errorf("query after tickets expired gave %d, %v", count, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls != 2 || refreshes != 1 {
+		t.Errorf("got %d credentials calls and %d refreshes; want 2 and 1", calls, refreshes)
+	}
+
+	fail = true
+	server.ExpireTickets()
+	if _, err := client.DoQueryCount(testTableDbid, ""); !quickbase.IsTicketError(err) {
+		t.Errorf("query failing to renew gave %v", err)
+	}
+	client.StopTicketRefresh()
+	fail = false
+	server.ExpireTickets()
+	if _, err := client.DoQueryCount(testTableDbid, ""); !quickbase.IsTicketError(err) {
+		t.Errorf("query after StopTicketRefresh gave %v", err)
+	}
+}
+
+func TestTicketRefreshFailureHook(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	var mu sync.Mutex
+	var calls int
+	var failures []error
+	provider := quickbase.CredentialsFunc(func(url string) (quickbase.Credentials, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if calls++; calls > 1 {
+			return quickbase.Credentials{}, errors.New("vault sealed")
+		}
+		return quickbase.Credentials{Username: "jdoe", Password: "secret"}, nil
+	})
+	client := &quickbase.Client{Hooks: &quickbase.Hooks{OnAuthRefresh: func(e quickbase.AuthRefreshEvent) {
+		mu.Lock()
+		failures = append(failures, e.Err)
+		mu.Unlock()
+	}}}
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+	if err := client.StartTicketRefresh(provider, server.BaseUrl(), 12*time.Hour-10*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(50 * time.Millisecond)
+	client.StopTicketRefresh()
+	mu.Lock()
+	defer mu.Unlock()
+	if len(failures) == 0 || failures[0] == nil {
+		t.Errorf("hook was given %v", failures)
+	}
+	if logged.Len() != 0 {
+		t.Errorf("failure hooked yet logged: %s", logged.String())
+	}
+}
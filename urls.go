@@ -0,0 +1,120 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// The functions below build links into QuickBase's own pages, e.g.
+// for notification emails and chat messages, from the base URL of the
+// instance (such as https://example.quickbase.com/) and the IDs of
+// the table, record or report.
+
+// RecordUrl returns the URL of the page displaying a record.
+func RecordUrl(baseUrl, dbid string, rid int) string {
+	return uiUrl(baseUrl, dbid, "dr", "rid", rid)
+}
+
+// EditRecordUrl returns the URL of the page editing a record.
+func EditRecordUrl(baseUrl, dbid string, rid int) string {
+	return uiUrl(baseUrl, dbid, "er", "rid", rid)
+}
+
+// AddRecordUrl returns the URL of the page adding a record to a table.
+func AddRecordUrl(baseUrl, dbid string) string {
+	return uiUrl(baseUrl, dbid, "nwr", "", 0)
+}
+
+// ReportUrl returns the URL of a table's report.
+func ReportUrl(baseUrl, dbid string, qid int) string {
+	return uiUrl(baseUrl, dbid, "q", "qid", qid)
+}
+
+// GridEditUrl returns the URL of the page editing the records of a
+// table's report in a grid.
+func GridEditUrl(baseUrl, dbid string, qid int) string {
+	return uiUrl(baseUrl, dbid, "GridEdit", "qid", qid)
+}
+
+// QueryUrl returns the URL of a page listing the records of a table
+// matching query, with the columns of clist in the order of slist,
+// each of which may be empty as for DoQuery.
+func QueryUrl(baseUrl, dbid, query, clist, slist string) string {
+	values := url.Values{}
+	values.Set("qid", "1") // List All, refined by the rest
+	if query != "" {
+		values.Set("query", query)
+	}
+	if clist != "" {
+		values.Set("clist", clist)
+	}
+	if slist != "" {
+		values.Set("slist", slist)
+	}
+	return uiUrl(baseUrl, dbid, "q", "", 0) + "&" + values.Encode()
+}
+
+// uiUrl returns the URL of the page given by action a, for the table
+// dbid, with the given ID parameter if its name is not empty.
+func uiUrl(baseUrl, dbid, a, idName string, id int) string {
+	if !strings.HasSuffix(baseUrl, "/") {
+		baseUrl += "/"
+	}
+	s := baseUrl + "db/" + url.PathEscape(dbid) + "?a=" + a
+	if idName != "" {
+		s += "&" + idName + "=" + strconv.Itoa(id)
+	}
+	return s
+}
+
+// RecordUrl returns the URL of the page displaying a record of the
+// Client's instance.
+func (c *Client) RecordUrl(dbid string, rid int) string {
+	return RecordUrl(c.Ticket.url, dbid, rid)
+}
+
+// EditRecordUrl is as RecordUrl, for the page editing a record.
+func (c *Client) EditRecordUrl(dbid string, rid int) string {
+	return EditRecordUrl(c.Ticket.url, dbid, rid)
+}
+
+// AddRecordUrl is as RecordUrl, for the page adding a record.
+func (c *Client) AddRecordUrl(dbid string) string {
+	return AddRecordUrl(c.Ticket.url, dbid)
+}
+
+// ReportUrl is as RecordUrl, for a report.
+func (c *Client) ReportUrl(dbid string, qid int) string {
+	return ReportUrl(c.Ticket.url, dbid, qid)
+}
+
+// GridEditUrl is as RecordUrl, for the grid editing a report.
+func (c *Client) GridEditUrl(dbid string, qid int) string {
+	return GridEditUrl(c.Ticket.url, dbid, qid)
+}
+
+// QueryUrl is as RecordUrl, for the records matching a query.
+func (c *Client) QueryUrl(dbid, query, clist, slist string) string {
+	return QueryUrl(c.Ticket.url, dbid, query, clist, slist)
+}
@@ -0,0 +1,54 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"github.com/WesTower/quickbase"
+	"testing"
+)
+
+func TestUrls(t *testing.T) {
+	const base = "https://example.quickbase.com/"
+	for _, test := range []struct{ got, want string }{
+		{quickbase.RecordUrl(base, "bck7gp3q2", 12), base + "db/bck7gp3q2?a=dr&rid=12"},
+		{quickbase.RecordUrl("https://example.quickbase.com", "bck7gp3q2", 12), base + "db/bck7gp3q2?a=dr&rid=12"},
+		{quickbase.EditRecordUrl(base, "bck7gp3q2", 12), base + "db/bck7gp3q2?a=er&rid=12"},
+		{quickbase.AddRecordUrl(base, "bck7gp3q2"), base + "db/bck7gp3q2?a=nwr"},
+		{quickbase.ReportUrl(base, "bck7gp3q2", 5), base + "db/bck7gp3q2?a=q&qid=5"},
+		{quickbase.GridEditUrl(base, "bck7gp3q2", 5), base + "db/bck7gp3q2?a=GridEdit&qid=5"},
+		{quickbase.QueryUrl(base, "bck7gp3q2", "{'6'.EX.'Denver & Boise'}", "6.7", ""),
+			base + "db/bck7gp3q2?a=q&clist=6.7&qid=1&query=%7B%276%27.EX.%27Denver+%26+Boise%27%7D"},
+	} {
+		if test.got != test.want {
+			t.Errorf("got %s, want %s", test.got, test.want)
+		}
+	}
+
+	server := newServer()
+	defer server.Close()
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := client.RecordUrl(testTableDbid, 1), server.BaseUrl()+"db/"+testTableDbid+"?a=dr&rid=1"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
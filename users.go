@@ -0,0 +1,133 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// A UserInfo describes a QuickBase user, as returned by GetUserInfo.
+type UserInfo struct {
+	Id         string
+	FirstName  string
+	LastName   string
+	Login      string
+	Email      string
+	ScreenName string
+	Verified   bool // whether the user has confirmed their email address
+}
+
+// GetUserInfo returns the user with the given email address, or the
+// ticket's own user if email is empty.
+func GetUserInfo(ticket Ticket, email string) (user UserInfo, err error) {
+	params := ticket.params()
+	if email != "" {
+		params["email"] = email
+	}
+	var result userInfoResponse
+	if err = executeApiCall(ticket, ticket.url+"db/main", "API_GetUserInfo", params, &result); err != nil {
+		return user, err
+	}
+	if result.User == nil || result.User.Id == "" {
+		return user, fmt.Errorf("No user returned from API_GetUserInfo")
+	}
+	u := result.User
+	return UserInfo{u.Id, string(u.FirstName), string(u.LastName), string(u.Login), string(u.Email), string(u.ScreenName), u.IsVerified == "1"}, nil
+}
+
+// ProvisionUser adds a user who is not yet a QuickBase user to the
+// application appDbid with the role roleid, or the application's
+// default role if roleid is zero, returning their user ID.  The user
+// cannot sign in until invited with SendInvitation.
+func ProvisionUser(ticket Ticket, appDbid, email, firstName, lastName string, roleid int) (userid string, err error) {
+	var result provisionUserResponse
+	if err = provisionUserCall(ticket, appDbid, email, firstName, lastName, roleid).execute(&result); err != nil {
+		return "", err
+	}
+	return result.userid()
+}
+
+func provisionUserCall(ticket Ticket, appDbid, email, firstName, lastName string, roleid int) apiCall {
+	params := ticket.params()
+	params["email"] = email
+	params["fname"] = firstName
+	params["lname"] = lastName
+	if roleid != 0 {
+		params["roleid"] = strconv.Itoa(roleid)
+	}
+	return apiCall{ticket, ticket.url + "db/" + appDbid, "API_ProvisionUser", params}
+}
+
+func (r *provisionUserResponse) userid() (userid string, err error) {
+	if r.Userid == "" {
+		return "", fmt.Errorf("No userid returned from API_ProvisionUser")
+	}
+	return string(r.Userid), nil
+}
+
+// SendInvitation emails the user userid an invitation to the
+// application appDbid, including message if it is not empty.
+func SendInvitation(ticket Ticket, appDbid, userid, message string) (err error) {
+	return sendInvitationCall(ticket, appDbid, userid, message).execute(nil)
+}
+
+func sendInvitationCall(ticket Ticket, appDbid, userid, message string) apiCall {
+	params := ticket.params()
+	params["userid"] = userid
+	if message != "" {
+		params["usertext"] = message
+	}
+	return apiCall{ticket, ticket.url + "db/" + appDbid, "API_SendInvitation", params}
+}
+
+// InviteUser provisions a new user with the role roleid and sends them
+// an invitation, returning their user ID.  A user who already exists
+// should instead be given a role with AddUserToRole and invited.
+func InviteUser(ticket Ticket, appDbid, email, firstName, lastName string, roleid int, message string) (userid string, err error) {
+	if userid, err = ProvisionUser(ticket, appDbid, email, firstName, lastName, roleid); err != nil {
+		return "", err
+	}
+	return userid, SendInvitation(ticket, appDbid, userid, message)
+}
+
+func (c *Client) GetUserInfo(email string) (user UserInfo, err error) {
+	return GetUserInfo(c.ticket(), email)
+}
+
+func (c *Client) ProvisionUser(appDbid, email, firstName, lastName string, roleid int) (userid string, err error) {
+	var result provisionUserResponse
+	if err = c.mutate(provisionUserCall(c.ticket(), appDbid, email, firstName, lastName, roleid), &result); err != nil || c.DryRun {
+		return "", err
+	}
+	return result.userid()
+}
+
+func (c *Client) SendInvitation(appDbid, userid, message string) (err error) {
+	return c.mutate(sendInvitationCall(c.ticket(), appDbid, userid, message), nil)
+}
+
+func (c *Client) InviteUser(appDbid, email, firstName, lastName string, roleid int, message string) (userid string, err error) {
+	if userid, err = c.ProvisionUser(appDbid, email, firstName, lastName, roleid); err != nil || c.DryRun {
+		return userid, err
+	}
+	return userid, c.SendInvitation(appDbid, userid, message)
+}
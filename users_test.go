@@ -0,0 +1,74 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"github.com/WesTower/quickbase"
+	"testing"
+)
+
+func TestInviteUser(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	me, err := client.GetUserInfo("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, userid := client.Ticket.Credentials(); me.Id != userid || me.Login != "jdoe" {
+		t.Errorf("GetUserInfo gave %+v", me)
+	}
+	userid, err := client.InviteUser(testAppDbid, "tech@example.com", "Terry", "Tech", 11, "Welcome aboard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !server.Invited("tech@example.com") {
+		t.Error("tech@example.com was not invited")
+	}
+	tech, err := client.GetUserInfo("tech@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tech.Id != userid || tech.FirstName != "Terry" || tech.LastName != "Tech" || tech.Email != "tech@example.com" {
+		t.Errorf("GetUserInfo gave %+v", tech)
+	}
+	users, err := client.UserRoles(testAppDbid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, user := range users {
+		if user.Id == userid && (len(user.Roles) != 1 || user.Roles[0].Id != 11) {
+			t.Errorf("provisioned user has roles %v", user.Roles)
+		}
+	}
+	if _, err = client.ProvisionUser(testAppDbid, "tech@example.com", "Terry", "Tech", 0); err == nil {
+		t.Error("ProvisionUser provisioned an existing user")
+	}
+	if _, err = quickbase.Login(server.BaseUrl(), "tech@example.com", ""); err == nil {
+		t.Error("a provisioned user signed in without a password")
+	}
+	if _, err = client.GetUserInfo("nobody@example.com"); err == nil {
+		t.Error("GetUserInfo found an unknown user")
+	}
+}
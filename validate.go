@@ -0,0 +1,197 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A Violation is a way in which a record about to be written breaks
+// the rules of its table's schema.
+type Violation struct {
+	Fid     int
+	Label   string
+	Value   string
+	Rule    string // 'unknown', 'read-only', 'required', 'type', 'choice' or 'unique'
+	Message string
+}
+
+func (v Violation) Error() string {
+	return fmt.Sprintf("Field %d (%s): %s", v.Fid, v.Label, v.Message)
+}
+
+// Violations are those of a single record, in field ID order.
+type Violations []Violation
+
+func (v Violations) Error() string {
+	messages := make([]string, len(v))
+	for i, violation := range v {
+		messages[i] = violation.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// A Validator checks records against the schema of their table before
+// they are written, so that bad data may be reported in full, field by
+// field, rather than one QuickBase error at a time.
+type Validator struct {
+	Schema Schema
+	// AllowNewChoices accepts values of multiple-choice fields
+	// which are not among their choices, as QuickBase does of
+	// fields which allow users to add choices.
+	AllowNewChoices bool
+	// QB, if set, is queried to check that the values of unique
+	// fields are not those of other records.
+	QB QuickBase
+}
+
+// ValidateAdd checks a record to be added, by field ID, as for
+// AddRecordByFid: every required field must be given.  The error is
+// that of checking uniqueness, not a violation.
+func (v *Validator) ValidateAdd(fields map[int]string) (violations Violations, err error) {
+	return v.validate(0, fields)
+}
+
+// ValidateEdit checks the changes to be made to record rid, as for
+// EditRecordByFid: required fields need not be given, but may not be
+// blanked.
+func (v *Validator) ValidateEdit(rid int, fields map[int]string) (violations Violations, err error) {
+	return v.validate(rid, fields)
+}
+
+func (v *Validator) validate(rid int, fields map[int]string) (violations Violations, err error) {
+	add := func(field Field, value, rule, format string, args ...interface{}) {
+		violations = append(violations, Violation{field.Id, field.Label, value, rule, fmt.Sprintf(format, args...)})
+	}
+	known := make(map[int]bool)
+	for _, field := range v.Schema.Fields {
+		known[field.Id] = true
+		value, given := fields[field.Id]
+		switch {
+		case !given:
+			if rid == 0 && field.Required && writable(field) {
+				add(field, value, "required", "is required")
+			}
+			continue
+		case !writable(field):
+			add(field, value, "read-only", "is read-only")
+			continue
+		case value == "":
+			if field.Required {
+				add(field, value, "required", "is required")
+			}
+			continue
+		}
+		if err := checkValue(field.Type, value); err != nil {
+			add(field, value, "type", "%s", err)
+		} else if len(field.Choices) > 0 && !v.AllowNewChoices && !isChoice(field, value) {
+			add(field, value, "choice", "%q is not one of its choices", value)
+		} else if field.Unique && v.QB != nil {
+			unique, err := v.unique(field.Id, rid, value)
+			if err != nil {
+				return nil, err
+			}
+			if !unique {
+				add(field, value, "unique", "%q is the value of another record", value)
+			}
+		}
+	}
+	for fid, value := range fields {
+		if !known[fid] {
+			violations = append(violations, Violation{fid, "", value, "unknown", fmt.Sprintf("is not a field of %s", v.Schema.Dbid)})
+		}
+	}
+	sort.SliceStable(violations, func(i, j int) bool { return violations[i].Fid < violations[j].Fid })
+	return violations, nil
+}
+
+// writable reports whether field may be written with AddRecord or
+// EditRecord; Record Owner (4) may be, though ChangeRecordOwner is
+// usual.
+func writable(field Field) bool {
+	if field.Id == 1 || field.Id == 2 || field.Id == 3 || field.Id == 5 {
+		return false
+	}
+	switch field.Type {
+	case "recordid", "formula", "dblink":
+		return false
+	}
+	return field.Mode == ""
+}
+
+func isChoice(field Field, value string) bool {
+	for _, choice := range field.Choices {
+		if strings.EqualFold(choice, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkValue returns an error if value, which is not blank, is not one
+// QuickBase accepts for a field of the given type.
+func checkValue(fieldType, value string) error {
+	switch fieldType {
+	case "float", "numeric", "currency", "percent", "rating", "duration", "timeofday":
+		return CheckNumber(value)
+	case "checkbox":
+		switch strings.ToLower(value) {
+		case "0", "1", "true", "false", "yes", "no":
+			return nil
+		}
+		return fmt.Errorf("%q is not a checkbox value", value)
+	case "date", "timestamp":
+		if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return nil
+		}
+		if fieldType == "date" {
+			for _, layout := range []string{"2006-01-02", "01-02-2006", "01/02/2006"} {
+				if _, err := time.Parse(layout, value); err == nil {
+					return nil
+				}
+			}
+		}
+		return fmt.Errorf("%q is not a %s", value, fieldType)
+	case "email":
+		at := strings.Index(value, "@")
+		if at <= 0 || at == len(value)-1 || strings.ContainsAny(value, " \t\r\n") || strings.Count(value, "@") != 1 {
+			return fmt.Errorf("%q is not an email address", value)
+		}
+	}
+	return nil
+}
+
+// unique reports whether no record but rid has value in field fid.
+func (v *Validator) unique(fid, rid int, value string) (unique bool, err error) {
+	if strings.Contains(value, "'") {
+		return false, fmt.Errorf("Cannot query for a value containing a quote: %q", value)
+	}
+	query := fmt.Sprintf("{'%d'.EX.'%s'}", fid, value)
+	if rid != 0 {
+		query += fmt.Sprintf("AND{'3'.XEX.'%d'}", rid)
+	}
+	count, err := v.QB.DoQueryCount(v.Schema.Dbid, query)
+	return count == 0, err
+}
@@ -0,0 +1,86 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/WesTower/quickbase"
+)
+
+func TestValidator(t *testing.T) {
+	server := newServer()
+	defer server.Close()
+	ticket, err := authenticate(server)
+	if err != nil {
+		t.Fatal(err)
+	}
+	validator := &quickbase.Validator{
+		Schema: quickbase.Schema{Dbid: testTableDbid, Fields: []quickbase.Field{
+			{Id: 3, Label: "Record ID#", Type: "recordid"},
+			{Id: 6, Label: "Site", Type: "text", Required: true, Unique: true},
+			{Id: 7, Label: "Cost", Type: "currency"},
+			{Id: 8, Label: "Status", Type: "text", Choices: []string{"Open", "Closed"}},
+			{Id: 9, Label: "Due", Type: "date"},
+			{Id: 10, Label: "Done", Type: "checkbox"},
+			{Id: 11, Label: "Contact", Type: "email"},
+			{Id: 12, Label: "Region", Type: "text", Mode: "lookup"},
+		}},
+		QB: quickbase.NewClient(ticket),
+	}
+	rules := func(violations quickbase.Violations, err error) string {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var rules []string
+		for _, violation := range violations {
+			rules = append(rules, violation.Label+":"+violation.Rule)
+		}
+		return strings.Join(rules, " ")
+	}
+
+	if got := rules(validator.ValidateAdd(map[int]string{6: "Tulsa", 7: "1.50", 8: "open", 9: "2015-04-03", 10: "true", 11: "jdoe@example.com"})); got != "" {
+		t.Errorf("valid record gave %s", got)
+	}
+	got := rules(validator.ValidateAdd(map[int]string{3: "9", 7: "1,50", 8: "Pending", 9: "soon", 10: "maybe", 11: "jdoe", 12: "West", 99: "x"}))
+	if want := "Record ID#:read-only Site:required Cost:type Status:choice Due:type Done:type Contact:type Region:read-only :unknown"; got != want {
+		t.Errorf("invalid record gave\n%s\nwant\n%s", got, want)
+	}
+	if got := rules(validator.ValidateAdd(map[int]string{6: "denver"})); got != "Site:unique" {
+		t.Errorf("duplicate site gave %s", got)
+	}
+	if got := rules(validator.ValidateEdit(1, map[int]string{6: "Denver"})); got != "" {
+		t.Errorf("unchanged site gave %s", got)
+	}
+	if got := rules(validator.ValidateEdit(2, map[int]string{6: "Denver", 7: ""})); got != "Site:unique" {
+		t.Errorf("edit to duplicate site gave %s", got)
+	}
+	if got := rules(validator.ValidateEdit(2, map[int]string{6: ""})); got != "Site:required" {
+		t.Errorf("blanking required field gave %s", got)
+	}
+	validator.AllowNewChoices = true
+	violations, _ := validator.ValidateEdit(2, map[int]string{8: "Pending", 9: "x"})
+	if len(violations) != 1 || violations.Error() != `Field 9 (Due): "x" is not a date` {
+		t.Errorf("got %v", violations)
+	}
+}
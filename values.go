@@ -0,0 +1,281 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// The functions below encode Go values in the forms QuickBase accepts
+// when writing to fields of the corresponding types, e.g. in the maps
+// given to AddRecordByFid and EditRecordByFid.  The forms are those
+// API_DoQuery returns, so values round-trip.
+
+// CheckboxValue encodes a checkbox as "1" or "0".
+func CheckboxValue(checked bool) string {
+	if checked {
+		return "1"
+	}
+	return "0"
+}
+
+// DateValue encodes the calendar date of t, in t's location, for a
+// date field, as milliseconds since the epoch at midnight UTC of that
+// date.  Thus a date is not moved a day by the time zones of the
+// program, of t or of the QuickBase app.  The zero Time encodes as "",
+// clearing the field.
+func DateValue(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	year, month, day := t.Date()
+	return strconv.FormatInt(msecsSinceEpoch(time.Date(year, month, day, 0, 0, 0, 0, time.UTC)), 10)
+}
+
+// TimestampValue encodes t for a date/time field, as milliseconds
+// since the epoch, dropping any finer precision.  The zero Time
+// encodes as "", clearing the field.
+func TimestampValue(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return strconv.FormatInt(msecsSinceEpoch(t), 10)
+}
+
+// DurationValue encodes d for a duration field, as a whole number of
+// milliseconds, rounded half away from zero.
+func DurationValue(d time.Duration) string {
+	return strconv.FormatInt(int64(d.Round(time.Millisecond)/time.Millisecond), 10)
+}
+
+// EncodeValue encodes v by its type: a bool as a checkbox, a
+// time.Time as a timestamp (use DateValue for date fields), a
+// time.Duration as a duration, integers and floating-point numbers in
+// decimal, a string as itself, a Value as its Encode gives, a Null
+// type as its value or "" if it is not Valid, and a fmt.Stringer as
+// its String.
+func EncodeValue(v interface{}) (value string, err error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case bool:
+		return CheckboxValue(v), nil
+	case time.Time:
+		return TimestampValue(v), nil
+	case time.Duration:
+		return DurationValue(v), nil
+	case int:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int8:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int16:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int32:
+		return strconv.FormatInt(int64(v), 10), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case uint:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint8:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint16:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint32:
+		return strconv.FormatUint(uint64(v), 10), nil
+	case uint64:
+		return strconv.FormatUint(v, 10), nil
+	case float32:
+		return numberValue(float64(v), 32)
+	case float64:
+		return NumberValue(v)
+	case Value:
+		return v.Encode()
+	case nullEncoder:
+		value, _, err = v.encode()
+		return value, err
+	case fmt.Stringer:
+		return v.String(), nil
+	}
+	return "", fmt.Errorf("Cannot encode a %T as a field value", v)
+}
+
+// NumberValue encodes f for a numeric or currency field, in plain
+// decimal with a point and no grouping or exponent, which is the only
+// form QuickBase reads the same in every locale.  NaN and the
+// infinities cannot be stored, and are errors.
+func NumberValue(f float64) (value string, err error) {
+	return numberValue(f, 64)
+}
+
+func numberValue(f float64, bits int) (value string, err error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return "", fmt.Errorf("Cannot store %v in a numeric field", f)
+	}
+	return strconv.FormatFloat(f, 'f', -1, bits), nil
+}
+
+// CheckNumber returns an error unless s is empty or a number in the
+// form NumberValue gives, with an optional sign, e.g. "-1234.5".
+// Numbers otherwise written, such as "1,234.5" or "1234,5", are
+// misread by QuickBase or depend on the app's locale.
+func CheckNumber(s string) error {
+	if s == "" {
+		return nil
+	}
+	digits, point := 0, false
+	for i, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			digits++
+		case (r == '-' || r == '+') && i == 0:
+		case r == '.' && !point && digits > 0:
+			point, digits = true, 0
+		default:
+			return fmt.Errorf("Bad number %q: %q at offset %d", s, r, i)
+		}
+	}
+	if digits == 0 {
+		return fmt.Errorf("Bad number %q", s)
+	}
+	return nil
+}
+
+// A NumberFormat describes how numbers are written in a locale, for
+// reading them with Canonical.
+type NumberFormat struct {
+	Decimal  rune   // the decimal separator
+	Grouping rune   // the thousands separator, or 0 if none is used
+	Currency string // a currency symbol which may precede or follow numbers
+}
+
+// Common NumberFormats.
+var (
+	USNumbers     = NumberFormat{Decimal: '.', Grouping: ',', Currency: "$"}
+	EuroNumbers   = NumberFormat{Decimal: ',', Grouping: '.', Currency: "€"}
+	SwissNumbers  = NumberFormat{Decimal: '.', Grouping: '\'', Currency: "CHF"}
+	PlainNumbers  = NumberFormat{Decimal: '.'}
+	FrenchNumbers = NumberFormat{Decimal: ',', Grouping: ' ', Currency: "€"}
+)
+
+// Canonical reads s as a number written in format f, returning it in
+// the form CheckNumber accepts without converting it to binary, so
+// that no precision is lost.  It is strict: thousands separators must
+// separate groups of three digits, and any separator the format does
+// not use is an error, so that e.g. "1.234" is rejected rather than
+// read as 1234 or 1.234 when the format has no grouping.  A leading
+// sign or enclosing parentheses make a number negative, and the
+// format's currency symbol may precede or follow it.  An empty s, or
+// one of spaces, gives "".
+func (f NumberFormat) Canonical(s string) (value string, err error) {
+	bad := func() (string, error) {
+		return "", fmt.Errorf("Bad number %q", s)
+	}
+	t := strings.TrimSpace(s)
+	if t == "" {
+		return "", nil
+	}
+	negative := false
+	if strings.HasPrefix(t, "(") && strings.HasSuffix(t, ")") {
+		negative, t = true, strings.TrimSpace(t[1:len(t)-1])
+	}
+	if strings.HasPrefix(t, "-") || strings.HasPrefix(t, "+") {
+		if negative {
+			return bad()
+		}
+		negative, t = t[0] == '-', strings.TrimSpace(t[1:])
+	}
+	if f.Currency != "" {
+		if strings.HasPrefix(t, f.Currency) {
+			t = strings.TrimSpace(t[len(f.Currency):])
+		} else if strings.HasSuffix(t, f.Currency) {
+			t = strings.TrimSpace(t[:len(t)-len(f.Currency)])
+		}
+	}
+	whole, fraction := t, ""
+	if i := strings.IndexRune(t, f.Decimal); i >= 0 {
+		whole, fraction = t[:i], t[i+utf8.RuneLen(f.Decimal):]
+		if fraction == "" || strings.IndexFunc(fraction, notDigit) >= 0 {
+			return bad()
+		}
+	}
+	var digits strings.Builder
+	if f.Grouping != 0 && strings.ContainsRune(whole, f.Grouping) {
+		groups := strings.Split(whole, string(f.Grouping))
+		for i, group := range groups {
+			if group == "" || len(group) > 3 || i > 0 && len(group) != 3 || strings.IndexFunc(group, notDigit) >= 0 {
+				return bad()
+			}
+			digits.WriteString(group)
+		}
+	} else if whole == "" || strings.IndexFunc(whole, notDigit) >= 0 {
+		return bad()
+	} else {
+		digits.WriteString(whole)
+	}
+	value = strings.TrimLeft(digits.String(), "0")
+	if value == "" {
+		value = "0"
+	}
+	if fraction != "" {
+		value += "." + fraction
+	}
+	if negative && strings.Trim(value, "0.") != "" {
+		value = "-" + value
+	}
+	return value, nil
+}
+
+func notDigit(r rune) bool {
+	return r < '0' || r > '9'
+}
+
+// EncodeFields encodes the values of fields, keyed by field ID, with
+// EncodeValue, for AddRecordByFid and EditRecordByFid.  Fields whose
+// values are nil or Null types not Present are left out, so that the
+// write leaves them unchanged.
+func EncodeFields(fields map[int]interface{}) (encoded map[int]string, err error) {
+	encoded = make(map[int]string, len(fields))
+	for fid, v := range fields {
+		if n, ok := v.(nullEncoder); ok {
+			if _, present, _ := n.encode(); !present {
+				continue
+			}
+		}
+		if v == nil {
+			continue
+		}
+		if encoded[fid], err = EncodeValue(v); err != nil {
+			return nil, fmt.Errorf("Field %d: %s", fid, err)
+		}
+	}
+	return encoded, nil
+}
+
+// msecsSinceEpoch returns t in milliseconds since the epoch, rounding
+// down for times before it as after.
+func msecsSinceEpoch(t time.Time) int64 {
+	return t.Unix()*1000 + int64(t.Nanosecond())/int64(time.Millisecond)
+}
@@ -0,0 +1,172 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase_test
+
+import (
+	"github.com/WesTower/quickbase"
+	"math"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestValueEncoders(t *testing.T) {
+	denver := time.FixedZone("MST", -7*60*60)
+	tokyo := time.FixedZone("JST", 9*60*60)
+	for _, test := range []struct{ got, want string }{
+		{quickbase.CheckboxValue(true), "1"},
+		{quickbase.CheckboxValue(false), "0"},
+		{quickbase.DateValue(time.Date(2015, 3, 1, 23, 30, 0, 0, denver)), "1425168000000"},
+		{quickbase.DateValue(time.Date(2015, 3, 1, 0, 30, 0, 0, tokyo)), "1425168000000"},
+		{quickbase.DateValue(time.Date(1969, 12, 31, 12, 0, 0, 0, time.UTC)), "-86400000"},
+		{quickbase.DateValue(time.Time{}), ""},
+		{quickbase.TimestampValue(time.Date(2015, 3, 1, 23, 30, 0, 999999999, denver)), "1425277800999"},
+		{quickbase.TimestampValue(time.Unix(-1, 500000000)), "-500"},
+		{quickbase.TimestampValue(time.Time{}), ""},
+		{quickbase.DurationValue(90 * time.Minute), "5400000"},
+		{quickbase.DurationValue(1500 * time.Microsecond), "2"},
+		{quickbase.DurationValue(-1500 * time.Microsecond), "-2"},
+		{quickbase.DurationValue(0), "0"},
+	} {
+		if test.got != test.want {
+			t.Errorf("got %s, want %s", test.got, test.want)
+		}
+	}
+}
+
+func TestEncodeFields(t *testing.T) {
+	fields, err := quickbase.EncodeFields(map[int]interface{}{
+		6:  "Denver",
+		7:  12.5,
+		8:  true,
+		9:  uint8(7),
+		10: 2 * time.Second,
+		11: time.Unix(1, 0),
+		12: net.IPv4(10, 0, 0, 1),
+		13: float32(0.1),
+		14: 1e21,
+	})
+	want := map[int]string{6: "Denver", 7: "12.5", 8: "1", 9: "7", 10: "2000", 11: "1000", 12: "10.0.0.1", 13: "0.1",
+		14: "1000000000000000000000"}
+	if err != nil {
+		t.Fatal(err)
+	}
+	for fid, value := range want {
+		if fields[fid] != value {
+			t.Errorf("field %d is %q, want %q", fid, fields[fid], value)
+		}
+	}
+	if _, err = quickbase.EncodeFields(map[int]interface{}{6: []int{1}}); err == nil {
+		t.Error("a slice was encoded")
+	}
+}
+
+func TestNumbers(t *testing.T) {
+	for f, want := range map[float64]string{1234.5: "1234.5", -0.25: "-0.25", 1e-7: "0.0000001", 3: "3"} {
+		if got, err := quickbase.NumberValue(f); err != nil || got != want {
+			t.Errorf("NumberValue(%v) is %q, %v", f, got, err)
+		}
+	}
+	if _, err := quickbase.NumberValue(math.Inf(1)); err == nil {
+		t.Error("NumberValue encoded infinity")
+	}
+	for _, s := range []string{"", "0", "-12.50", "+3", "1234.5"} {
+		if err := quickbase.CheckNumber(s); err != nil {
+			t.Errorf("CheckNumber(%q): %v", s, err)
+		}
+	}
+	for _, s := range []string{"1,234.5", "1234,5", "1.2.3", "-", ".5", "5.", "$5", "1e6", " 5"} {
+		if err := quickbase.CheckNumber(s); err == nil {
+			t.Errorf("CheckNumber(%q) succeeded", s)
+		}
+	}
+
+	for _, test := range []struct {
+		format quickbase.NumberFormat
+		s      string
+		want   string
+	}{
+		{quickbase.USNumbers, "1,234.50", "1234.50"},
+		{quickbase.USNumbers, "$1,234,567", "1234567"},
+		{quickbase.USNumbers, "($12.00)", "-12.00"},
+		{quickbase.USNumbers, "-$0.5", "-0.5"},
+		{quickbase.USNumbers, "007", "7"},
+		{quickbase.USNumbers, "-0", "0"},
+		{quickbase.USNumbers, "  ", ""},
+		{quickbase.EuroNumbers, "1.234,5 €", "1234.5"},
+		{quickbase.EuroNumbers, "-1234,5", "-1234.5"},
+		{quickbase.SwissNumbers, "CHF 1'234.05", "1234.05"},
+		{quickbase.FrenchNumbers, "1 234 567,8", "1234567.8"},
+		{quickbase.PlainNumbers, "1234.5", "1234.5"},
+	} {
+		if got, err := test.format.Canonical(test.s); err != nil || got != test.want {
+			t.Errorf("%+v: Canonical(%q) is %q, %v; want %q", test.format, test.s, got, err, test.want)
+		}
+	}
+	for _, test := range []struct {
+		format quickbase.NumberFormat
+		s      string
+	}{
+		{quickbase.USNumbers, "1,23"},
+		{quickbase.USNumbers, "1,2345"},
+		{quickbase.USNumbers, ",123"},
+		{quickbase.USNumbers, "1.234,5"},
+		{quickbase.USNumbers, "1.5.0"},
+		{quickbase.USNumbers, "12."},
+		{quickbase.USNumbers, "-(5)"},
+		{quickbase.USNumbers, "€5"},
+		{quickbase.EuroNumbers, "1,234.5"},
+		{quickbase.PlainNumbers, "1.234,5"},
+		{quickbase.PlainNumbers, "1,234"},
+		{quickbase.PlainNumbers, "twelve"},
+	} {
+		if got, err := test.format.Canonical(test.s); err == nil {
+			t.Errorf("%+v: Canonical(%q) gave %q", test.format, test.s, got)
+		}
+	}
+}
+
+func TestEncodeNulls(t *testing.T) {
+	fields, err := quickbase.EncodeFields(map[int]interface{}{
+		6:  quickbase.NullString{String: "Denver", Valid: true, Present: true},
+		7:  quickbase.NullFloat{Present: true},
+		8:  quickbase.NullInt{Int: 0, Valid: true, Present: true},
+		9:  quickbase.NullBool{Bool: true, Valid: true},
+		10: nil,
+		11: quickbase.NullTime{Time: time.Unix(2, 0), Valid: true, Present: true},
+		12: quickbase.NullBool{Bool: false, Valid: true, Present: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[int]string{6: "Denver", 7: "", 8: "0", 11: "2000", 12: "0"}
+	if len(fields) != len(want) {
+		t.Errorf("encoded %v", fields)
+	}
+	for fid, value := range want {
+		if got, ok := fields[fid]; !ok || got != value {
+			t.Errorf("field %d is %q, want %q", fid, got, value)
+		}
+	}
+	if _, err = quickbase.EncodeFields(map[int]interface{}{7: quickbase.NullFloat{Float: math.NaN(), Valid: true, Present: true}}); err == nil {
+		t.Error("NaN was encoded")
+	}
+}
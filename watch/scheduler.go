@@ -0,0 +1,243 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package watch
+
+import (
+	"context"
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"log"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+)
+
+// A Scheduler polls API_GetAppDTMInfo on behalf of many tables, making
+// one call for all those of an app, to learn which tables' records
+// have changed since it last looked.  It never calls for an app before
+// the RequestNextAllowedTime QuickBase last gave it, converted from
+// QuickBase's clock to the local one by way of RequestTime, and delays
+// each app's polls by up to Jitter so that many schedulers started
+// together do not poll in step.  Each table's changes are signalled on
+// its Changed channel, e.g. to prompt a Watcher's Poll.
+//
+// As with a Watcher, the first poll of a table records its
+// modification time without reporting a change.
+type Scheduler struct {
+	QB       quickbase.QuickBase
+	Interval time.Duration // least time between an app's polls; if zero, DefaultInterval
+	Jitter   time.Duration // most time added at random to Interval
+	// OnError, if set, is called by Run with each failed poll of an
+	// app, which is polled again after the interval; otherwise such
+	// failures are logged.
+	OnError func(appDbid string, err error)
+
+	mu     sync.Mutex
+	apps   map[string]*appSchedule   // by dbid
+	tables map[string]*tableSchedule // by dbid
+	random *rand.Rand
+}
+
+// appSchedule is when an app is next polled, and for which tables.
+type appSchedule struct {
+	dbid   string
+	tables []string
+	next   time.Time
+}
+
+// tableSchedule is what a Scheduler knows of a table.
+type tableSchedule struct {
+	modified time.Time // the table's last record modification
+	seen     bool      // whether modified has been polled
+	changed  chan struct{}
+}
+
+// NewScheduler returns a Scheduler polling with qb every interval.
+func NewScheduler(qb quickbase.QuickBase, interval time.Duration) *Scheduler {
+	return &Scheduler{QB: qb, Interval: interval}
+}
+
+// Add adds tables of the app appDbid to those polled; they are polled
+// with the app's others at its next poll, or at once if the app is
+// new.
+func (s *Scheduler) Add(appDbid string, tableDbids ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.apps == nil {
+		s.apps = make(map[string]*appSchedule)
+		s.tables = make(map[string]*tableSchedule)
+	}
+	app, ok := s.apps[appDbid]
+	if !ok {
+		app = &appSchedule{dbid: appDbid}
+		s.apps[appDbid] = app
+	}
+	for _, dbid := range tableDbids {
+		if _, ok := s.tables[dbid]; !ok {
+			app.tables = append(app.tables, dbid)
+			s.tables[dbid] = &tableSchedule{changed: make(chan struct{}, 1)}
+		}
+	}
+}
+
+// Changed returns a channel which receives a value when a poll finds
+// the table's records changed.  Changes found before the last value is
+// received are signalled once.  It returns nil for a table not added.
+func (s *Scheduler) Changed(tableDbid string) <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if table, ok := s.tables[tableDbid]; ok {
+		return table.changed
+	}
+	return nil
+}
+
+// Next returns when the next app is due to be polled.
+func (s *Scheduler) Next() (next time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, app := range s.apps {
+		if next.IsZero() || app.next.Before(next) {
+			next = app.next
+		}
+	}
+	return next
+}
+
+// Poll polls the apps which are due, and returns the tables found
+// changed, sorted.  An app which fails is polled again after the
+// interval, as one which succeeds is; the first error is returned.
+func (s *Scheduler) Poll() (changed []string, err error) {
+	changed = s.pollDue(func(appDbid string, pollErr error) {
+		if err == nil {
+			err = pollErr
+		}
+	})
+	return changed, err
+}
+
+// pollDue polls the apps which are due, passing each failure to
+// report, and returns the tables found changed, sorted.
+func (s *Scheduler) pollDue(report func(appDbid string, err error)) (changed []string) {
+	now := time.Now()
+	s.mu.Lock()
+	var due []*appSchedule
+	for _, app := range s.apps {
+		if !app.next.After(now) {
+			due = append(due, app)
+		}
+	}
+	s.mu.Unlock()
+	sort.Slice(due, func(i, j int) bool { return due[i].dbid < due[j].dbid })
+	for _, app := range due {
+		tables, err := s.poll(app)
+		if err != nil {
+			report(app.dbid, err)
+		}
+		changed = append(changed, tables...)
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// poll polls app and schedules its next poll.
+func (s *Scheduler) poll(app *appSchedule) (changed []string, err error) {
+	received, nextAllowed, _, tables, err := s.QB.GetAppDTMInfo(app.dbid)
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	app.next = now.Add(s.interval())
+	if err != nil {
+		return nil, err
+	}
+	if allowed := now.Add(nextAllowed.Sub(received)); allowed.After(app.next) {
+		app.next = allowed
+	}
+	modified := make(map[string]time.Time, len(tables))
+	for _, table := range tables {
+		modified[table.Dbid] = table.RecordModified
+	}
+	for _, dbid := range app.tables {
+		t, ok := modified[dbid]
+		if !ok {
+			if err == nil {
+				err = fmt.Errorf("Table %s is not in app %s", dbid, app.dbid)
+			}
+			continue
+		}
+		table := s.tables[dbid]
+		if table.seen && !t.Equal(table.modified) {
+			changed = append(changed, dbid)
+			select {
+			case table.changed <- struct{}{}:
+			default:
+			}
+		}
+		table.modified, table.seen = t, true
+	}
+	return changed, err
+}
+
+// reportError passes a failed poll of an app to OnError, or logs it.
+func (s *Scheduler) reportError(appDbid string, err error) {
+	if s.OnError != nil {
+		s.OnError(appDbid, err)
+		return
+	}
+	log.Printf("watch: polling app %s: %s", appDbid, err)
+}
+
+// interval returns the time until an app's next poll, jittered.
+func (s *Scheduler) interval() time.Duration {
+	interval := s.Interval
+	if interval == 0 {
+		interval = DefaultInterval
+	}
+	if s.Jitter > 0 {
+		if s.random == nil {
+			s.random = rand.New(rand.NewSource(time.Now().UnixNano()))
+		}
+		interval += time.Duration(s.random.Int63n(int64(s.Jitter)))
+	}
+	return interval
+}
+
+// Run polls the apps as they fall due until ctx is done, and returns
+// ctx.Err().  A failed poll of an app, which may be passing, does not
+// stop it; the failure is passed to OnError.
+func (s *Scheduler) Run(ctx context.Context) error {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+		s.pollDue(s.reportError)
+		wait := DefaultInterval
+		if next := s.Next(); !next.IsZero() {
+			wait = time.Until(next)
+		}
+		timer.Reset(wait)
+	}
+}
@@ -0,0 +1,150 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package watch_test
+
+import (
+	"context"
+	"github.com/WesTower/quickbase"
+	"github.com/WesTower/quickbase/watch"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// skewedDTM answers API_GetAppDTMInfo with a clock an hour behind the
+// local one, allowing the next request after wait.
+type skewedDTM struct {
+	quickbase.QuickBase
+	wait     time.Duration
+	calls    int
+	modified time.Time
+}
+
+func (m *skewedDTM) GetAppDTMInfo(dbid string) (received, nextAllowed time.Time, app quickbase.SchemaModification, tables []quickbase.SchemaModification, err error) {
+	m.calls++
+	received = time.Now().Add(-time.Hour)
+	tables = []quickbase.SchemaModification{{Dbid: tableDbid, RecordModified: m.modified}}
+	return received, received.Add(m.wait), app, tables, nil
+}
+
+func TestSchedulerNextAllowed(t *testing.T) {
+	mock := &skewedDTM{wait: 50 * time.Millisecond}
+	scheduler := watch.NewScheduler(mock, time.Millisecond)
+	scheduler.Add(appDbid, tableDbid)
+	if _, err := scheduler.Poll(); err != nil {
+		t.Fatal(err)
+	}
+	if wait := time.Until(scheduler.Next()); wait < 40*time.Millisecond || wait > 50*time.Millisecond {
+		t.Errorf("next poll in %s, want about 50ms", wait)
+	}
+	scheduler.Poll()
+	if mock.calls != 1 {
+		t.Errorf("polled %d times before the next allowed time", mock.calls)
+	}
+	time.Sleep(60 * time.Millisecond)
+	mock.modified = time.Now()
+	if changed, err := scheduler.Poll(); err != nil || mock.calls != 2 || !reflect.DeepEqual(changed, []string{tableDbid}) {
+		t.Errorf("second poll gave %v, %v after %d calls", changed, err, mock.calls)
+	}
+}
+
+func TestSchedulerRun(t *testing.T) {
+	server, client := newServer(t)
+	defer server.Close()
+	server.AddTable("bck7gp3q3", map[int]string{6: "Name"})
+	server.AddApp(appDbid, tableDbid, "bck7gp3q3")
+	scheduler := watch.NewScheduler(client, 5*time.Millisecond)
+	scheduler.Jitter = 5 * time.Millisecond
+	scheduler.Add(appDbid, tableDbid, "bck7gp3q3")
+	if scheduler.Changed("bck7gp3q9") != nil {
+		t.Error("Changed of a table not added is not nil")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- scheduler.Run(ctx) }()
+
+	time.Sleep(20 * time.Millisecond)
+	select {
+	case <-scheduler.Changed(tableDbid):
+		t.Error("first poll signalled a change")
+	default:
+	}
+	if _, err := client.AddRecordByFid(tableDbid, map[int]string{6: "Tulsa"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-scheduler.Changed(tableDbid):
+	case <-time.After(time.Second):
+		t.Error("change not signalled")
+	}
+	select {
+	case <-scheduler.Changed("bck7gp3q3"):
+		t.Error("change to the other table signalled")
+	default:
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Run gave %v", err)
+	}
+
+	missing := watch.NewScheduler(client, 0)
+	missing.Add(appDbid, "bck7gp3q9")
+	if _, err := missing.Poll(); err == nil {
+		t.Error("poll of a table not in the app succeeded")
+	}
+}
+
+func TestSchedulerRunFailures(t *testing.T) {
+	server, client := newServer(t)
+	defer server.Close()
+	scheduler := watch.NewScheduler(client, 5*time.Millisecond)
+	scheduler.Add(appDbid, tableDbid, "bck7gp3q9")
+	failures := make(chan string, 100)
+	scheduler.OnError = func(appDbid string, err error) { failures <- appDbid }
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- scheduler.Run(ctx) }()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case app := <-failures:
+			if app != appDbid {
+				t.Errorf("failure reported for %s", app)
+			}
+		case err := <-done:
+			t.Fatalf("Run stopped after %d failures: %v", i, err)
+		case <-time.After(time.Second):
+			t.Fatal("failure not reported")
+		}
+	}
+	if _, err := client.AddRecordByFid(tableDbid, map[int]string{6: "Tulsa"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-scheduler.Changed(tableDbid):
+	case <-time.After(time.Second):
+		t.Error("change not signalled despite the failures")
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Run gave %v", err)
+	}
+}
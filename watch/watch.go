@@ -0,0 +1,289 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+// Package watch follows the changes made to a QuickBase table by
+// polling it, for apps where webhooks are unavailable.
+//
+// A Watcher first asks API_GetAppDTMInfo, which is cheap and needs no
+// credentials, whether the table's records have changed since it last
+// looked.  If they have, it queries those whose Date Modified (field
+// 2) is no earlier than the latest it has seen, reporting each as
+// Created or Updated, and compares the table's record count with the
+// number of records it knows of, listing the Record IDs to find which
+// have been Deleted only when some have gone.  Its state is saved to
+// a file after each poll, so that a restarted watcher reports just
+// the changes made since its predecessor last polled.
+//
+// A Scheduler asks API_GetAppDTMInfo on behalf of many tables at once,
+// honoring the times QuickBase allows it to ask again, and signals
+// which tables have changed.
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// A Kind is the kind of change an Event reports.
+type Kind int
+
+const (
+	Created Kind = iota + 1
+	Updated
+	Deleted
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Created:
+		return "Created"
+	case Updated:
+		return "Updated"
+	case Deleted:
+		return "Deleted"
+	}
+	return "Kind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// An Event reports a change to a record.
+type Event struct {
+	Kind     Kind
+	Rid      int
+	Modified time.Time      // the record's Date Modified; for a deletion, when it was noticed
+	Record   map[int]string // the record's fields in the Watcher's Clist; nil for a deletion
+}
+
+// DefaultInterval is used when a Watcher's Interval is zero.
+const DefaultInterval = time.Minute
+
+// A Watcher polls a table for changes.  Its first poll, with no saved
+// state, records the table's records without reporting them.
+type Watcher struct {
+	QB       quickbase.QuickBase
+	AppDbid  string        // app holding the table, for API_GetAppDTMInfo
+	Dbid     string        // table watched
+	Clist    string        // fields reported with each event, besides 2 and 3
+	Interval time.Duration // time between polls; if zero, DefaultInterval
+	State    string        // path of the state file; if empty, state is not saved
+
+	state *state
+}
+
+// state is what a Watcher knows of its table, in milliseconds since
+// the epoch as QuickBase gives them.
+type state struct {
+	TableModified int64         `json:"table_modified"` // the table's last record modification
+	Since         int64         `json:"since"`          // the latest Date Modified seen
+	Rids          map[int]int64 `json:"rids"`           // the Date Modified of each record
+}
+
+// copy returns a copy of st, which a poll may change without touching
+// st should it fail.
+func (st *state) copy() *state {
+	c := *st
+	c.Rids = make(map[int]int64, len(st.Rids))
+	for rid, modified := range st.Rids {
+		c.Rids[rid] = modified
+	}
+	return &c
+}
+
+// Run polls the table until ctx is done, sending the changes found to
+// events, and returns ctx.Err() or the first error in polling.
+func (w *Watcher) Run(ctx context.Context, events chan<- Event) error {
+	interval := w.Interval
+	if interval == 0 {
+		interval = DefaultInterval
+	}
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+		}
+		changes, err := w.Poll()
+		if err != nil {
+			return err
+		}
+		for _, event := range changes {
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		timer.Reset(interval)
+	}
+}
+
+// Poll looks once for changes to the table, returning them in the
+// order they were made, deletions last.
+func (w *Watcher) Poll() (events []Event, err error) {
+	if w.state == nil && w.State != "" {
+		if err = w.load(); err != nil {
+			return nil, err
+		}
+	}
+	_, _, _, tables, err := w.QB.GetAppDTMInfo(w.AppDbid)
+	if err != nil {
+		return nil, err
+	}
+	modified := int64(-1)
+	for _, table := range tables {
+		if table.Dbid == w.Dbid {
+			modified = msecs(table.RecordModified)
+		}
+	}
+	if modified < 0 {
+		return nil, fmt.Errorf("Table %s is not in app %s", w.Dbid, w.AppDbid)
+	}
+	if w.state != nil && modified == w.state.TableModified {
+		return nil, nil
+	}
+
+	baseline := w.state == nil
+	var st *state
+	query := ""
+	if baseline {
+		st = &state{Rids: make(map[int]int64)}
+	} else {
+		st = w.state.copy()
+		query = fmt.Sprintf("{'2'.OAF.'%d'}", st.Since)
+	}
+	clist := "2.3"
+	if w.Clist != "" {
+		clist += "." + w.Clist
+	}
+	records, err := w.QB.DoStructuredQuery(w.Dbid, query, clist, "2", "")
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		rid, err := strconv.Atoi(record[3])
+		if err != nil {
+			return nil, fmt.Errorf("Bad Record ID# %q", record[3])
+		}
+		changed, err := strconv.ParseInt(record[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Bad Date Modified %q of record %d", record[2], rid)
+		}
+		last, known := st.Rids[rid]
+		if known && last >= changed {
+			continue // seen by the last poll
+		}
+		st.Rids[rid] = changed
+		if changed > st.Since {
+			st.Since = changed
+		}
+		kind := Created
+		if known {
+			kind = Updated
+		}
+		events = append(events, Event{kind, rid, time.Unix(0, changed*int64(time.Millisecond)), record})
+	}
+	if baseline {
+		events = nil
+	} else if deleted, err := w.deleted(st); err != nil {
+		return nil, err
+	} else {
+		events = append(events, deleted...)
+	}
+	st.TableModified = modified
+	w.state = st
+	if w.State != "" {
+		return events, w.save()
+	}
+	return events, nil
+}
+
+// deleted finds the records of st which are no longer in the table,
+// forgetting them.
+func (w *Watcher) deleted(st *state) (events []Event, err error) {
+	count, err := w.QB.DoQueryCount(w.Dbid, "")
+	if err != nil || count >= int64(len(st.Rids)) {
+		return nil, err
+	}
+	records, err := w.QB.DoStructuredQuery(w.Dbid, "", "3", "", "")
+	if err != nil {
+		return nil, err
+	}
+	present := make(map[string]bool, len(records))
+	for _, record := range records {
+		present[record[3]] = true
+	}
+	var rids []int
+	for rid := range st.Rids {
+		if !present[strconv.Itoa(rid)] {
+			rids = append(rids, rid)
+		}
+	}
+	sort.Ints(rids)
+	now := time.Now()
+	for _, rid := range rids {
+		delete(st.Rids, rid)
+		events = append(events, Event{Kind: Deleted, Rid: rid, Modified: now})
+	}
+	return events, nil
+}
+
+func (w *Watcher) load() error {
+	data, err := ioutil.ReadFile(w.State)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	st := &state{}
+	if err = json.Unmarshal(data, st); err != nil {
+		return fmt.Errorf("Bad watch state %s: %s", w.State, err)
+	}
+	if st.Rids == nil {
+		st.Rids = make(map[int]int64)
+	}
+	w.state = st
+	return nil
+}
+
+// save writes the state to a new file, then renames it over the old,
+// so that a watcher killed mid-write leaves the old state intact.
+func (w *Watcher) save() error {
+	data, err := json.Marshal(w.state)
+	if err != nil {
+		return err
+	}
+	if err = ioutil.WriteFile(w.State+".new", data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(w.State+".new", w.State)
+}
+
+func msecs(t time.Time) int64 {
+	return t.UnixNano() / int64(time.Millisecond)
+}
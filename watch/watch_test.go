@@ -0,0 +1,183 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package watch_test
+
+import (
+	"context"
+	"fmt"
+	"github.com/WesTower/quickbase"
+	"github.com/WesTower/quickbase/quickbasetest"
+	"github.com/WesTower/quickbase/watch"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const (
+	appDbid   = "bck7gp3q1"
+	tableDbid = "bck7gp3q2"
+)
+
+func newServer(t *testing.T) (server *quickbasetest.Server, client *quickbase.Client) {
+	server = quickbasetest.NewServer()
+	server.AddUser("jdoe", "secret")
+	server.AddTable(tableDbid, map[int]string{6: "Site", 7: "Cost"})
+	server.AddApp(appDbid, tableDbid)
+	server.Seed(tableDbid, map[int]string{6: "Denver", 7: "12.50"})
+	server.Seed(tableDbid, map[int]string{6: "Boise", 7: "3"})
+	client, err := quickbase.Login(server.BaseUrl(), "jdoe", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server, client
+}
+
+// summary describes events briefly, for comparison.
+func summary(events []watch.Event) string {
+	s := ""
+	for _, event := range events {
+		s += fmt.Sprintf("%s %d %s;", event.Kind, event.Rid, event.Record[6])
+	}
+	return s
+}
+
+// pause lets the clock move on, so that changes made after it have
+// later modification times than those before.
+func pause() {
+	time.Sleep(5 * time.Millisecond)
+}
+
+func TestPoll(t *testing.T) {
+	server, client := newServer(t)
+	defer server.Close()
+	dir, err := ioutil.TempDir("", "watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	state := filepath.Join(dir, "state.json")
+	watcher := &watch.Watcher{QB: client, AppDbid: appDbid, Dbid: tableDbid, Clist: "6", State: state}
+
+	if events, err := watcher.Poll(); err != nil || len(events) != 0 {
+		t.Errorf("first poll gave %v, %v", events, err)
+	}
+	if events, err := watcher.Poll(); err != nil || len(events) != 0 {
+		t.Errorf("poll of an unchanged table gave %v, %v", events, err)
+	}
+	pause()
+	if _, err = client.AddRecordByFid(tableDbid, map[int]string{6: "Tulsa"}); err != nil {
+		t.Fatal(err)
+	}
+	pause()
+	if err = client.EditRecordByFid(tableDbid, 1, map[int]string{6: "Denver West"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = client.DeleteRecord(tableDbid, 2); err != nil {
+		t.Fatal(err)
+	}
+	events, err := watcher.Poll()
+	if got := summary(events); err != nil || got != "Created 3 Tulsa;Updated 1 Denver West;Deleted 2 ;" {
+		t.Errorf("poll gave %s, %v", got, err)
+	}
+
+	// a new watcher resumes from the saved state
+	pause()
+	if err = client.EditRecordByFid(tableDbid, 3, map[int]string{6: "Tulsa North"}); err != nil {
+		t.Fatal(err)
+	}
+	watcher = &watch.Watcher{QB: client, AppDbid: appDbid, Dbid: tableDbid, Clist: "6", State: state}
+	events, err = watcher.Poll()
+	if got := summary(events); err != nil || got != "Updated 3 Tulsa North;" {
+		t.Errorf("resumed poll gave %s, %v", got, err)
+	}
+
+	watcher = &watch.Watcher{QB: client, AppDbid: appDbid, Dbid: "bck7gp3q9"}
+	if _, err = watcher.Poll(); err == nil {
+		t.Error("poll of a table outside the app succeeded")
+	}
+}
+
+// failingCount fails DoQueryCount while fail is set.
+type failingCount struct {
+	quickbase.QuickBase
+	fail bool
+}
+
+func (f *failingCount) DoQueryCount(dbid, query string) (int64, error) {
+	if f.fail {
+		return 0, fmt.Errorf("unavailable")
+	}
+	return f.QuickBase.DoQueryCount(dbid, query)
+}
+
+func TestPollFailure(t *testing.T) {
+	server, client := newServer(t)
+	defer server.Close()
+	qb := &failingCount{QuickBase: client}
+	watcher := &watch.Watcher{QB: qb, AppDbid: appDbid, Dbid: tableDbid, Clist: "6"}
+	if _, err := watcher.Poll(); err != nil {
+		t.Fatal(err)
+	}
+	pause()
+	if _, err := client.AddRecordByFid(tableDbid, map[int]string{6: "Tulsa"}); err != nil {
+		t.Fatal(err)
+	}
+
+	qb.fail = true
+	if _, err := watcher.Poll(); err == nil {
+		t.Error("poll succeeded without DoQueryCount")
+	}
+	qb.fail = false
+	events, err := watcher.Poll()
+	if got := summary(events); err != nil || got != "Created 3 Tulsa;" {
+		t.Errorf("poll after a failure gave %s, %v", got, err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	server, client := newServer(t)
+	defer server.Close()
+	watcher := &watch.Watcher{QB: client, AppDbid: appDbid, Dbid: tableDbid, Clist: "6", Interval: 5 * time.Millisecond}
+	ctx, cancel := context.WithCancel(context.Background())
+	events := make(chan watch.Event)
+	done := make(chan error)
+	go func() {
+		done <- watcher.Run(ctx, events)
+	}()
+	time.Sleep(20 * time.Millisecond) // for the first poll
+	if _, err := client.AddRecordByFid(tableDbid, map[int]string{6: "Tulsa"}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case event := <-events:
+		if event.Kind != watch.Created || event.Record[6] != "Tulsa" {
+			t.Errorf("Run sent %+v", event)
+		}
+	case <-time.After(time.Second):
+		t.Error("Run sent no event")
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Run returned %v", err)
+	}
+}
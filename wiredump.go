@@ -0,0 +1,92 @@
+// go-quickbase - Go bindings for Intuit's QuickBase
+// Copyright (C) 2012-2014 WesTower Communications
+// Copyright (C) 2014-2015 MasTec
+//
+// This file is part of go-quickbase.
+//
+// go-quickbase is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful, but
+// WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the GNU
+// Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program.  If not, see
+// <http://www.gnu.org/licenses/>.
+
+package quickbase
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"regexp"
+	"sync"
+)
+
+// A wireDump writes the HTTP exchanges of selected calls, for
+// debugging.
+type wireDump struct {
+	w       io.Writer
+	actions []string // if empty, every call is dumped
+}
+
+// dumpMutex keeps each dump whole when calls are made concurrently.
+var dumpMutex sync.Mutex
+
+var (
+	credentialElements = regexp.MustCompile(`<(ticket|usertoken|apptoken|password)>[^<]*</`)
+	credentialParams   = regexp.MustCompile(`\b(ticket|usertoken|apptoken)=[^&\s]*`)
+)
+
+// redact removes credentials from a request or response.
+func redact(s string) string {
+	s = credentialElements.ReplaceAllString(s, "<$1>REDACTED</")
+	return credentialParams.ReplaceAllString(s, "$1=REDACTED")
+}
+
+// selects reports whether req is to be dumped.
+func (d *wireDump) selects(req *http.Request) bool {
+	if d == nil {
+		return false
+	}
+	if len(d.actions) == 0 {
+		return true
+	}
+	action := req.Header.Get("QUICKBASE-ACTION")
+	for _, selected := range d.actions {
+		if selected == action {
+			return true
+		}
+	}
+	return false
+}
+
+// request dumps a request.  Its body, if any, is read into memory
+// and replaced.
+func (d *wireDump) request(req *http.Request) {
+	dump, err := httputil.DumpRequestOut(req, true)
+	d.write(">>> ", dump, err)
+}
+
+// response dumps a response.  Its body is read into memory and
+// replaced.
+func (d *wireDump) response(resp *http.Response) {
+	dump, err := httputil.DumpResponse(resp, true)
+	d.write("<<< ", dump, err)
+}
+
+func (d *wireDump) write(prefix string, dump []byte, err error) {
+	dumpMutex.Lock()
+	defer dumpMutex.Unlock()
+	if err != nil {
+		fmt.Fprintf(d.w, "%sdump failed: %s\n\n", prefix, err)
+		return
+	}
+	fmt.Fprintf(d.w, "%s%s\n\n", prefix, redact(string(dump)))
+}